@@ -0,0 +1,33 @@
+{{/*
+Whether the controller serves admission webhooks. The webhook server needs a serving
+certificate, from cert-manager or an existing Secret, and a webhook configuration.
+*/}}
+{{- define "gpu-autoscaler.webhooksEnabled" -}}
+{{- if and .Values.controller.webhook.enabled .Values.admissionWebhook.enabled -}}
+true
+{{- end -}}
+{{- end -}}
+
+{{/*
+The Secret holding the webhook serving certificate
+*/}}
+{{- define "gpu-autoscaler.webhookCertSecret" -}}
+{{- if .Values.controller.webhook.certManager.enabled -}}
+webhook-server-cert
+{{- else -}}
+{{- required "controller.webhook.certSecret is required when controller.webhook.certManager.enabled is false" .Values.controller.webhook.certSecret -}}
+{{- end -}}
+{{- end -}}
+
+{{/*
+The clientConfig of a webhook served by the controller at the given path
+*/}}
+{{- define "gpu-autoscaler.webhookClientConfig" -}}
+service:
+  name: gpu-autoscaler-webhook-service
+  namespace: {{ .root.Values.namespace }}
+  path: {{ .path }}
+{{- if not .root.Values.controller.webhook.certManager.enabled }}
+caBundle: {{ required "controller.webhook.caBundle is required when controller.webhook.certManager.enabled is false" .root.Values.controller.webhook.caBundle }}
+{{- end }}
+{{- end -}}
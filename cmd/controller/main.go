@@ -1,21 +1,36 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
+	"fmt"
 	"os"
+	"strings"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	"k8s.io/client-go/kubernetes"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	ctrlmetrics "sigs.k8s.io/controller-runtime/pkg/metrics"
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/autoscaler"
 	"github.com/gpuautoscaler/gpuautoscaler/pkg/controller"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/cost"
 	"github.com/gpuautoscaler/gpuautoscaler/pkg/metrics"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/sharing"
+	gpuwebhook "github.com/gpuautoscaler/gpuautoscaler/pkg/webhook"
 )
 
 var (
@@ -25,14 +40,48 @@ var (
 
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(v1alpha1.AddToScheme(scheme))
 }
 
 func main() {
+	if err := run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// run sets up and starts the manager. Startup errors are logged where they occur and returned,
+// so deferred cleanup such as closing the TimescaleDB connection runs before the process exits.
+func run() error {
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
 	var prometheusURL string
 	var webhookPort int
+	var enableAutoscaler bool
+	var enableSpotOrchestrator bool
+	var enableWebhook bool
+	var enableMIG bool
+	var enableMPS bool
+	var enableTimeSlicing bool
+	var enableNodeConfig bool
+	var enableCostTracking bool
+	var enableBudgets bool
+	var enableAttribution bool
+	var cloudProvider string
+	var cloudRegion string
+	var gcpProject string
+	var azureSubscriptionID string
+	var azureResourceGroup string
+	var simulatedProvisioningLatency time.Duration
+	var clusterAPINamespace string
+	var karpenterAPIVersion string
+	karpenterNodePools := map[string]string{}
+	var autoscalerDryRun bool
+	var drainTimeout time.Duration
+	var historyNamespace string
+	var redfishInventoryPath string
+	var costTrackingInterval time.Duration
+	var timescaleDBDSN string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
@@ -43,6 +92,60 @@ func main() {
 		"The URL of the Prometheus server for querying GPU metrics.")
 	flag.IntVar(&webhookPort, "webhook-port", 9443, "The port for the admission webhook server.")
 
+	// Subsystems
+	flag.BoolVar(&enableAutoscaler, "enable-autoscaler", false, "Reconcile AutoscalingPolicy objects and scale GPU node pools.")
+	flag.BoolVar(&autoscalerDryRun, "autoscaler-dry-run", false,
+		"Run every AutoscalingPolicy in shadow mode: record scaling decisions without adding or removing nodes.")
+	flag.DurationVar(&drainTimeout, "drain-timeout", autoscaler.DefaultDrainTimeout,
+		"How long a scale-down waits for pods to be evicted when the AutoscalingPolicy does not set drainTimeoutSeconds.")
+	flag.StringVar(&historyNamespace, "scaling-history-namespace", autoscaler.DefaultHistoryNamespace,
+		"The namespace of the ConfigMap that persists the autoscaler's scaling history.")
+	flag.BoolVar(&enableSpotOrchestrator, "enable-spot-orchestrator", false,
+		"Watch spot nodes for termination notices and evict their pods. Requires --enable-autoscaler.")
+	flag.BoolVar(&enableWebhook, "enable-webhook", false, "Serve the GPU optimization mutating webhook.")
+	flag.BoolVar(&enableMIG, "enable-mig", false, "Allow the webhook to convert eligible pods to MIG slices.")
+	flag.BoolVar(&enableMPS, "enable-mps", false, "Allow the webhook to convert eligible pods to MPS.")
+	flag.BoolVar(&enableTimeSlicing, "enable-timeslicing", false, "Allow the webhook to convert eligible pods to time-sliced GPUs.")
+	flag.BoolVar(&enableNodeConfig, "enable-node-config", false,
+		"Reconcile GPUNodeConfig objects and configure MIG, MPS and time-slicing on their nodes.")
+	flag.BoolVar(&enableCostTracking, "enable-cost-tracking", false, "Track per-pod GPU cost.")
+	flag.BoolVar(&enableBudgets, "enable-budgets", false, "Reconcile CostBudget objects. Requires --enable-cost-tracking.")
+	flag.BoolVar(&enableAttribution, "enable-attribution", false, "Reconcile CostAttribution objects. Requires --enable-cost-tracking.")
+
+	// Cloud provider
+	flag.StringVar(&cloudProvider, "cloud-provider", autoscaler.ProviderAWS,
+		"The cloud provider used for scaling and pricing (aws, gcp, azure, karpenter, clusterapi, redfish or simulated).")
+	flag.StringVar(&cloudRegion, "cloud-region", "", "The cloud region the autoscaler manages node pools in.")
+	flag.StringVar(&gcpProject, "gcp-project", "", "The GCP project holding the Managed Instance Groups when --cloud-provider=gcp.")
+	flag.StringVar(&azureSubscriptionID, "azure-subscription-id", "",
+		"The Azure subscription holding the VM Scale Sets when --cloud-provider=azure.")
+	flag.StringVar(&azureResourceGroup, "azure-resource-group", "",
+		"The Azure resource group holding the VM Scale Sets when --cloud-provider=azure.")
+	flag.DurationVar(&simulatedProvisioningLatency, "simulated-provisioning-latency", autoscaler.DefaultSimulatedProvisioningLatency,
+		"How long simulated nodes take to register when --cloud-provider=simulated.")
+	flag.StringVar(&karpenterAPIVersion, "karpenter-api-version", autoscaler.DefaultKarpenterAPIVersion,
+		"The karpenter.sh API version of the NodePools and NodeClaims when --cloud-provider=karpenter.")
+	flag.Func("karpenter-node-pool",
+		"Maps a node pool to the Karpenter NodePool, and through its nodeClassRef the NodeClass, it is provisioned from, "+
+			"as pool=nodepool, when --cloud-provider=karpenter. Repeatable; pools not listed use the Karpenter NodePool with the same name.",
+		func(value string) error {
+			pool, karpenterPool, ok := strings.Cut(value, "=")
+			if !ok || pool == "" || karpenterPool == "" {
+				return fmt.Errorf("expected pool=nodepool, got %q", value)
+			}
+			karpenterNodePools[pool] = karpenterPool
+			return nil
+		})
+	flag.StringVar(&clusterAPINamespace, "cluster-api-namespace", autoscaler.DefaultClusterAPINamespace,
+		"The namespace holding the cluster's MachineDeployments when --cloud-provider=clusterapi.")
+	flag.StringVar(&redfishInventoryPath, "redfish-inventory", "",
+		"Path to the YAML inventory of BMC endpoints per node pool when --cloud-provider=redfish.")
+
+	// Cost management
+	flag.DurationVar(&costTrackingInterval, "cost-tracking-interval", time.Minute, "How often pod costs are recalculated.")
+	flag.StringVar(&timescaleDBDSN, "timescaledb-dsn", os.Getenv("TIMESCALEDB_DSN"),
+		"PostgreSQL connection string for TimescaleDB cost history. Defaults to $TIMESCALEDB_DSN; cost history is kept in memory only when empty.")
+
 	opts := zap.Options{
 		Development: true,
 	}
@@ -51,6 +154,17 @@ func main() {
 
 	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
 
+	if (enableBudgets || enableAttribution) && !enableCostTracking {
+		err := errors.New("--enable-budgets and --enable-attribution require --enable-cost-tracking")
+		setupLog.Error(err, "invalid flags")
+		return err
+	}
+	if enableSpotOrchestrator && !enableAutoscaler {
+		err := errors.New("--enable-spot-orchestrator requires --enable-autoscaler")
+		setupLog.Error(err, "invalid flags")
+		return err
+	}
+
 	mgr, err := ctrl.NewManager(ctrl.GetConfigOrDie(), ctrl.Options{
 		Scheme: scheme,
 		Metrics: metricsserver.Options{
@@ -65,14 +179,14 @@ func main() {
 	})
 	if err != nil {
 		setupLog.Error(err, "unable to start manager")
-		os.Exit(1)
+		return err
 	}
 
 	// Initialize the metrics collector
 	metricsCollector := metrics.NewCollector(prometheusURL)
 	if err := metricsCollector.Start(mgr.GetClient()); err != nil {
 		setupLog.Error(err, "unable to start metrics collector")
-		os.Exit(1)
+		return err
 	}
 
 	// Setup the GPU controller
@@ -82,22 +196,224 @@ func main() {
 		metricsCollector,
 	).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "GPU")
-		os.Exit(1)
+		return err
+	}
+
+	// Index pods by node so node drains can list the pods scheduled on a node
+	if err := mgr.GetFieldIndexer().IndexField(context.Background(), &corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+		return []string{obj.(*corev1.Pod).Spec.NodeName}
+	}); err != nil {
+		setupLog.Error(err, "unable to index pods by node")
+		return err
+	}
+
+	// Setup the GPUNodeConfig controller
+	if enableNodeConfig {
+		if err = sharing.NewNodeConfigController(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			mgr.GetEventRecorderFor("gpunodeconfig-controller"),
+		).SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "GPUNodeConfig")
+			return err
+		}
+	}
+
+	// Setup the autoscaler controller
+	if enableAutoscaler {
+		var redfishInventory map[string][]autoscaler.RedfishHost
+		if redfishInventoryPath != "" {
+			if redfishInventory, err = autoscaler.LoadRedfishInventory(redfishInventoryPath); err != nil {
+				setupLog.Error(err, "unable to load Redfish inventory")
+				return err
+			}
+		}
+
+		providerOptions := autoscaler.ProviderOptions{
+			Region:         cloudRegion,
+			ProjectID:      gcpProject,
+			SubscriptionID: azureSubscriptionID,
+			ResourceGroup:  azureResourceGroup,
+			Client:         mgr.GetClient(),
+			Karpenter: autoscaler.KarpenterProviderConfig{
+				APIVersion: karpenterAPIVersion,
+				NodePools:  karpenterNodePools,
+			},
+			ClusterAPI: autoscaler.ClusterAPIProviderConfig{
+				Namespace: clusterAPINamespace,
+			},
+			Redfish: autoscaler.RedfishProviderConfig{
+				Inventory: redfishInventory,
+			},
+			Simulated: autoscaler.SimulatedProviderConfig{
+				ProvisioningLatency: simulatedProvisioningLatency,
+			},
+		}
+		switch cloudProvider {
+		case autoscaler.ProviderAWS:
+			awsClient, err := autoscaler.NewAWSAPIClient(cloudRegion, nil)
+			if err != nil {
+				setupLog.Error(err, "unable to create AWS API client")
+				return err
+			}
+			providerOptions.AWSAutoScaling = awsClient
+			providerOptions.AWSEC2 = awsClient
+		case autoscaler.ProviderGCP:
+			gcpClient, err := autoscaler.NewGCPComputeClient(gcpProject, nil)
+			if err != nil {
+				setupLog.Error(err, "unable to create GCP Compute Engine client")
+				return err
+			}
+			providerOptions.GCPCompute = gcpClient
+		case autoscaler.ProviderAzure:
+			azureClient, err := autoscaler.NewAzureVMSSClient(azureSubscriptionID, nil)
+			if err != nil {
+				setupLog.Error(err, "unable to create Azure VM Scale Set client")
+				return err
+			}
+			providerOptions.AzureVMSS = azureClient
+		}
+
+		provider, err := autoscaler.NewCloudProvider(cloudProvider, providerOptions)
+		if err != nil {
+			setupLog.Error(err, "unable to create cloud provider", "provider", cloudProvider)
+			return err
+		}
+
+		// The simulated provider registers pending nodes and reclaims spot nodes in the background
+		if simulated, ok := provider.(*autoscaler.SimulatedProvider); ok {
+			if err := mgr.Add(simulated); err != nil {
+				setupLog.Error(err, "unable to add simulated provider")
+				return err
+			}
+		}
+
+		autoscalerController := autoscaler.NewAutoscalerController(
+			mgr.GetClient(),
+			mgr.GetScheme(),
+			mgr.GetEventRecorderFor("autoscaler"),
+			metricsCollector,
+			provider,
+			autoscaler.AutoscalerConfig{
+				ReconcileInterval: autoscaler.DefaultReconcileInterval,
+				DrainTimeout:      drainTimeout,
+				DryRun:            autoscalerDryRun,
+			},
+		)
+
+		// The history ConfigMap is read directly rather than through the cache, so the manager
+		// does not watch every ConfigMap in the cluster
+		historyClient, err := client.New(mgr.GetConfig(), client.Options{Scheme: mgr.GetScheme()})
+		if err != nil {
+			setupLog.Error(err, "unable to create scaling history client")
+			return err
+		}
+		autoscalerController.History = autoscaler.NewConfigMapHistoryStore(historyClient, historyNamespace)
+
+		if err = autoscalerController.SetupWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create controller", "controller", "Autoscaler")
+			return err
+		}
+
+		if enableSpotOrchestrator {
+			spotOrchestrator := autoscaler.NewSpotOrchestrator(
+				mgr.GetClient(),
+				provider,
+				ctrl.Log.WithName("controllers").WithName("Spot"),
+			)
+			if err := mgr.Add(manager.RunnableFunc(spotOrchestrator.MonitorSpotInstances)); err != nil {
+				setupLog.Error(err, "unable to add spot orchestrator")
+				return err
+			}
+		}
+	}
+
+	// Setup cost tracking and the cost controllers
+	pricingClient := cost.NewPricingClient(cloudProvider, cloudRegion)
+	if enableCostTracking {
+		var db *cost.TimescaleDBClient
+		if timescaleDBDSN != "" {
+			db, err = cost.NewTimescaleDBClient(timescaleDBDSN)
+			if err != nil {
+				setupLog.Error(err, "unable to connect to TimescaleDB")
+				return err
+			}
+			defer db.Close()
+		}
+
+		clientset, err := kubernetes.NewForConfig(mgr.GetConfig())
+		if err != nil {
+			setupLog.Error(err, "unable to create clientset")
+			return err
+		}
+
+		costTracker := cost.NewCostTracker(clientset, pricingClient, db)
+		costTracker.RegisterMetrics(ctrlmetrics.Registry)
+		if err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			costTracker.Start(ctx, costTrackingInterval)
+			return nil
+		})); err != nil {
+			setupLog.Error(err, "unable to add cost tracker")
+			return err
+		}
+
+		if enableBudgets {
+			if err = (&cost.BudgetController{
+				Client:      mgr.GetClient(),
+				Scheme:      mgr.GetScheme(),
+				CostTracker: costTracker,
+				DB:          db,
+				Alerter:     cost.NewAlertManager(),
+				Recorder:    mgr.GetEventRecorderFor("budget-controller"),
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "Budget")
+				return err
+			}
+		}
+
+		if enableAttribution {
+			if err = (&cost.AttributionController{
+				Client:      mgr.GetClient(),
+				Scheme:      mgr.GetScheme(),
+				CostTracker: costTracker,
+				DB:          db,
+			}).SetupWithManager(mgr); err != nil {
+				setupLog.Error(err, "unable to create controller", "controller", "Attribution")
+				return err
+			}
+		}
+	}
+
+	// Setup the admission webhook
+	if enableWebhook {
+		if err := gpuwebhook.SetupWebhookServer(mgr, enableMIG, enableMPS, enableTimeSlicing); err != nil {
+			setupLog.Error(err, "unable to set up webhook server")
+			return err
+		}
+
+		// Budgets with the block action are enforced at admission time
+		if enableBudgets {
+			if err := gpuwebhook.SetupBudgetWebhook(mgr, pricingClient); err != nil {
+				setupLog.Error(err, "unable to set up budget webhook")
+				return err
+			}
+		}
 	}
 
 	// Setup health checks
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up health check")
-		os.Exit(1)
+		return err
 	}
 	if err := mgr.AddReadyzCheck("readyz", healthz.Ping); err != nil {
 		setupLog.Error(err, "unable to set up ready check")
-		os.Exit(1)
+		return err
 	}
 
 	setupLog.Info("starting manager")
 	if err := mgr.Start(ctrl.SetupSignalHandler()); err != nil {
 		setupLog.Error(err, "problem running manager")
-		os.Exit(1)
+		return err
 	}
+	return nil
 }
@@ -0,0 +1,23 @@
+package main
+
+import (
+	"os"
+
+	"k8s.io/component-base/cli"
+	_ "k8s.io/component-base/metrics/prometheus/clientgo"
+	_ "k8s.io/component-base/metrics/prometheus/version"
+	"k8s.io/kubernetes/cmd/kube-scheduler/app"
+
+	"github.com/gpuautoscaler/gpuautoscaler/cmd/scheduler/plugin"
+)
+
+// main runs kube-scheduler with the GPUBinPacking plugin registered. Pods opt in by
+// setting spec.schedulerName to the profile's schedulerName.
+func main() {
+	command := app.NewSchedulerCommand(
+		app.WithPlugin(plugin.Name, plugin.New),
+	)
+
+	code := cli.Run(command)
+	os.Exit(code)
+}
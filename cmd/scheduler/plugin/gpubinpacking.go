@@ -0,0 +1,177 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/klog/v2"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/scheduler"
+)
+
+const (
+	// Name is the name the plugin is registered under in the scheduler profile
+	Name = "GPUBinPacking"
+
+	// preScoreStateKey stores the node scores computed in PreScore
+	preScoreStateKey framework.StateKey = "PreScore" + Name
+)
+
+// GPUBinPackingArgs configures the plugin from the scheduler profile's pluginConfig
+type GPUBinPackingArgs struct {
+	// PackStrategy is one of bestfit, firstfit or worstfit. Defaults to bestfit.
+	PackStrategy string `json:"packStrategy,omitempty"`
+}
+
+// GPUBinPacking places GPU pods using the autoscaler's bin-packing strategies.
+// Filter rejects nodes without enough free GPUs, Score ranks the remaining nodes with
+// the configured pack strategy, and Reserve holds GPUs for the pod until it is bound, so
+// pods placed in the same scheduling round are not packed onto GPUs already promised.
+type GPUBinPacking struct {
+	handle       framework.Handle
+	packer       *scheduler.BinPackingScheduler
+	reservations *scheduler.GPUReservations
+}
+
+var (
+	_ framework.FilterPlugin   = &GPUBinPacking{}
+	_ framework.PreScorePlugin = &GPUBinPacking{}
+	_ framework.ScorePlugin    = &GPUBinPacking{}
+	_ framework.ReservePlugin  = &GPUBinPacking{}
+	_ framework.PostBindPlugin = &GPUBinPacking{}
+)
+
+// preScoreState holds the scores of every node that passed filtering
+type preScoreState struct {
+	scores map[string]int64
+}
+
+// Clone implements framework.StateData
+func (s *preScoreState) Clone() framework.StateData {
+	return s
+}
+
+// New creates the plugin from its args and the scheduler framework handle
+func New(_ context.Context, obj runtime.Object, handle framework.Handle) (framework.Plugin, error) {
+	args := &GPUBinPackingArgs{}
+	if obj != nil {
+		if err := frameworkruntime.DecodeInto(obj, args); err != nil {
+			return nil, fmt.Errorf("failed to decode %s args: %w", Name, err)
+		}
+	}
+
+	strategy := scheduler.PackStrategy(args.PackStrategy)
+	switch strategy {
+	case "":
+		strategy = scheduler.BestFit
+	case scheduler.BestFit, scheduler.FirstFit, scheduler.WorstFit:
+	default:
+		return nil, fmt.Errorf("unknown pack strategy %q", args.PackStrategy)
+	}
+
+	return &GPUBinPacking{
+		handle:       handle,
+		packer:       scheduler.NewBinPackingScheduler(nil, strategy),
+		reservations: scheduler.NewGPUReservations(),
+	}, nil
+}
+
+// Name returns the plugin name
+func (p *GPUBinPacking) Name() string {
+	return Name
+}
+
+// Filter rejects nodes that do not have enough free GPUs for the pod
+func (p *GPUBinPacking) Filter(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeInfo *framework.NodeInfo) *framework.Status {
+	workload := scheduler.NewGPUWorkload(pod)
+	if workload.GPURequest == 0 {
+		return nil
+	}
+	if nodeInfo.Node() == nil {
+		return framework.NewStatus(framework.Error, "node not found")
+	}
+
+	if fits, reason := scheduler.Fits(p.gpuNode(nodeInfo), workload); !fits {
+		return framework.NewStatus(framework.Unschedulable, reason)
+	}
+	return nil
+}
+
+// PreScore ranks all filtered nodes at once, since bin-packing scores are relative to each other
+func (p *GPUBinPacking) PreScore(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodes []*corev1.Node) *framework.Status {
+	workload := scheduler.NewGPUWorkload(pod)
+	if workload.GPURequest == 0 {
+		return framework.NewStatus(framework.Skip)
+	}
+
+	gpuNodes := make([]*scheduler.GPUNode, 0, len(nodes))
+	for _, node := range nodes {
+		nodeInfo, err := p.handle.SnapshotSharedLister().NodeInfos().Get(node.Name)
+		if err != nil {
+			return framework.AsStatus(fmt.Errorf("failed to get node %s from snapshot: %w", node.Name, err))
+		}
+		gpuNodes = append(gpuNodes, p.gpuNode(nodeInfo))
+	}
+
+	state.Write(preScoreStateKey, &preScoreState{
+		scores: p.packer.ScoreNodes(gpuNodes, workload, framework.MaxNodeScore),
+	})
+	return nil
+}
+
+// Score returns the node's bin-packing score computed in PreScore
+func (p *GPUBinPacking) Score(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) (int64, *framework.Status) {
+	data, err := state.Read(preScoreStateKey)
+	if err != nil {
+		return 0, framework.AsStatus(fmt.Errorf("failed to read %s state: %w", Name, err))
+	}
+	s, ok := data.(*preScoreState)
+	if !ok {
+		return 0, framework.AsStatus(fmt.Errorf("unexpected %s state type %T", Name, data))
+	}
+	return s.scores[nodeName], nil
+}
+
+// ScoreExtensions returns nil because scores are already normalized by PreScore
+func (p *GPUBinPacking) ScoreExtensions() framework.ScoreExtensions {
+	return nil
+}
+
+// Reserve holds the pod's GPUs on the chosen node until the pod is bound or shows up in the node's pod list
+func (p *GPUBinPacking) Reserve(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) *framework.Status {
+	gpus := scheduler.GetGPURequestFromPod(pod)
+	if gpus == 0 {
+		return nil
+	}
+
+	p.reservations.Reserve(nodeName, pod, gpus)
+	klog.FromContext(ctx).V(4).Info("Reserved GPUs", "pod", klog.KObj(pod), "node", nodeName, "gpus", gpus)
+	return nil
+}
+
+// Unreserve releases the pod's GPUs when a later scheduling phase fails
+func (p *GPUBinPacking) Unreserve(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) {
+	p.reservations.Unreserve(nodeName, pod)
+}
+
+// PostBind releases the pod's GPUs once it is bound, since the scheduler cache now counts the pod
+// on its node. Without it, a pod deleted right after binding would hold its reservation forever.
+func (p *GPUBinPacking) PostBind(ctx context.Context, state *framework.CycleState, pod *corev1.Pod, nodeName string) {
+	p.reservations.Unreserve(nodeName, pod)
+}
+
+// gpuNode builds GPU accounting for a node from the scheduler snapshot, less outstanding reservations
+func (p *GPUBinPacking) gpuNode(nodeInfo *framework.NodeInfo) *scheduler.GPUNode {
+	pods := make([]*corev1.Pod, 0, len(nodeInfo.Pods))
+	for _, podInfo := range nodeInfo.Pods {
+		pods = append(pods, podInfo.Pod)
+	}
+
+	gpuNode := scheduler.NewGPUNode(nodeInfo.Node(), pods)
+	p.reservations.Apply(gpuNode)
+	return gpuNode
+}
@@ -0,0 +1,210 @@
+package plugin
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/kubernetes/pkg/scheduler/framework"
+	frameworkruntime "k8s.io/kubernetes/pkg/scheduler/framework/runtime"
+)
+
+// fakeSharedLister serves node infos to the plugin in place of the scheduler snapshot
+type fakeSharedLister struct {
+	nodeInfos []*framework.NodeInfo
+}
+
+func (l *fakeSharedLister) NodeInfos() framework.NodeInfoLister       { return l }
+func (l *fakeSharedLister) StorageInfos() framework.StorageInfoLister { return l }
+func (l *fakeSharedLister) IsPVCUsedByPods(key string) bool           { return false }
+func (l *fakeSharedLister) List() ([]*framework.NodeInfo, error)      { return l.nodeInfos, nil }
+func (l *fakeSharedLister) HavePodsWithAffinityList() ([]*framework.NodeInfo, error) {
+	return nil, nil
+}
+func (l *fakeSharedLister) HavePodsWithRequiredAntiAffinityList() ([]*framework.NodeInfo, error) {
+	return nil, nil
+}
+func (l *fakeSharedLister) Get(nodeName string) (*framework.NodeInfo, error) {
+	for _, nodeInfo := range l.nodeInfos {
+		if nodeInfo.Node().Name == nodeName {
+			return nodeInfo, nil
+		}
+	}
+	return nil, fmt.Errorf("node %s not found", nodeName)
+}
+
+func newGPUPod(name, gpus string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ml", UID: types.UID(name)},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "main",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse(gpus)},
+				},
+			}},
+		},
+	}
+}
+
+func newNodeInfo(name string, gpus string, pods ...*corev1.Pod) *framework.NodeInfo {
+	nodeInfo := framework.NewNodeInfo(pods...)
+	nodeInfo.SetNode(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse(gpus)},
+		},
+	})
+	return nodeInfo
+}
+
+func newTestPlugin(t *testing.T, args runtime.Object, nodeInfos ...*framework.NodeInfo) *GPUBinPacking {
+	handle, err := frameworkruntime.NewFramework(context.Background(), nil, nil,
+		frameworkruntime.WithSnapshotSharedLister(&fakeSharedLister{nodeInfos: nodeInfos}))
+	if err != nil {
+		t.Fatalf("failed to create framework handle: %v", err)
+	}
+	p, err := New(context.Background(), args, handle)
+	if err != nil {
+		t.Fatalf("New() error = %v", err)
+	}
+	return p.(*GPUBinPacking)
+}
+
+func TestGPUBinPackingFilter(t *testing.T) {
+	tests := []struct {
+		name       string
+		pod        *corev1.Pod
+		nodeInfo   *framework.NodeInfo
+		expectCode framework.Code
+	}{
+		{
+			name:       "Node with enough free GPUs",
+			pod:        newGPUPod("trainer", "2"),
+			nodeInfo:   newNodeInfo("node1", "4", newGPUPod("existing", "2")),
+			expectCode: framework.Success,
+		},
+		{
+			name:       "Node without enough free GPUs",
+			pod:        newGPUPod("trainer", "3"),
+			nodeInfo:   newNodeInfo("node1", "4", newGPUPod("existing", "2")),
+			expectCode: framework.Unschedulable,
+		},
+		{
+			name:       "Pod without GPUs is ignored",
+			pod:        &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web"}},
+			nodeInfo:   newNodeInfo("node1", "0"),
+			expectCode: framework.Success,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlugin(t, nil, tt.nodeInfo)
+			status := p.Filter(context.Background(), framework.NewCycleState(), tt.pod, tt.nodeInfo)
+			if status.Code() != tt.expectCode {
+				t.Errorf("Expected code %v, got %v (%s)", tt.expectCode, status.Code(), status.Message())
+			}
+		})
+	}
+}
+
+func TestGPUBinPackingScore(t *testing.T) {
+	nodeInfos := []*framework.NodeInfo{
+		newNodeInfo("node1", "8", newGPUPod("a", "2")),
+		newNodeInfo("node2", "8", newGPUPod("b", "5")),
+		newNodeInfo("node3", "8"),
+	}
+	nodes := make([]*corev1.Node, 0, len(nodeInfos))
+	for _, nodeInfo := range nodeInfos {
+		nodes = append(nodes, nodeInfo.Node())
+	}
+
+	tests := []struct {
+		name        string
+		args        runtime.Object
+		expectBest  string
+		expectWorst string
+	}{
+		{
+			name:        "Best fit prefers the fullest node",
+			expectBest:  "node2",
+			expectWorst: "node3",
+		},
+		{
+			name:        "Worst fit prefers the emptiest node",
+			args:        &runtime.Unknown{Raw: []byte(`{"packStrategy":"worstfit"}`), ContentType: runtime.ContentTypeJSON},
+			expectBest:  "node3",
+			expectWorst: "node2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := newTestPlugin(t, tt.args, nodeInfos...)
+			pod := newGPUPod("trainer", "2")
+			state := framework.NewCycleState()
+
+			if status := p.PreScore(context.Background(), state, pod, nodes); !status.IsSuccess() {
+				t.Fatalf("PreScore() status = %v", status)
+			}
+
+			scores := map[string]int64{}
+			for _, node := range nodes {
+				score, status := p.Score(context.Background(), state, pod, node.Name)
+				if !status.IsSuccess() {
+					t.Fatalf("Score() status = %v", status)
+				}
+				scores[node.Name] = score
+			}
+
+			if scores[tt.expectBest] != framework.MaxNodeScore {
+				t.Errorf("Expected %s to score %d, got %v", tt.expectBest, framework.MaxNodeScore, scores)
+			}
+			if scores[tt.expectWorst] != framework.MinNodeScore {
+				t.Errorf("Expected %s to score %d, got %v", tt.expectWorst, framework.MinNodeScore, scores)
+			}
+		})
+	}
+}
+
+func TestGPUBinPackingReserve(t *testing.T) {
+	nodeInfo := newNodeInfo("node1", "4")
+	p := newTestPlugin(t, nil, nodeInfo)
+	first := newGPUPod("first", "3")
+	second := newGPUPod("second", "2")
+	state := framework.NewCycleState()
+
+	if status := p.Reserve(context.Background(), state, first, "node1"); !status.IsSuccess() {
+		t.Fatalf("Reserve() status = %v", status)
+	}
+	if status := p.Filter(context.Background(), state, second, nodeInfo); status.Code() != framework.Unschedulable {
+		t.Errorf("Expected reserved GPUs to make the node unschedulable, got %v", status.Code())
+	}
+
+	p.Unreserve(context.Background(), state, first, "node1")
+	if status := p.Filter(context.Background(), state, second, nodeInfo); !status.IsSuccess() {
+		t.Errorf("Expected node to fit after unreserve, got %v", status.Code())
+	}
+
+	// A bound pod is counted by the scheduler cache, so its reservation is dropped
+	if status := p.Reserve(context.Background(), state, first, "node1"); !status.IsSuccess() {
+		t.Fatalf("Reserve() status = %v", status)
+	}
+	p.PostBind(context.Background(), state, first, "node1")
+	if status := p.Filter(context.Background(), state, second, nodeInfo); !status.IsSuccess() {
+		t.Errorf("Expected node to fit after the reserved pod was bound, got %v", status.Code())
+	}
+}
+
+func TestNewRejectsUnknownStrategy(t *testing.T) {
+	args := &runtime.Unknown{Raw: []byte(`{"packStrategy":"random"}`), ContentType: runtime.ContentTypeJSON}
+	if _, err := New(context.Background(), args, nil); err == nil {
+		t.Error("Expected error for unknown pack strategy, got nil")
+	}
+}
@@ -48,6 +48,27 @@ type AutoscalingPolicySpec struct {
 	// +kubebuilder:validation:Minimum=0
 	PendingPodTimeoutSeconds int32 `json:"pendingPodTimeoutSeconds,omitempty"`
 
+	// DrainTimeoutSeconds is how long a scale-down waits for pods to be evicted from a node
+	// before it is aborted and the node uncordoned
+	// +optional
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=0
+	DrainTimeoutSeconds int32 `json:"drainTimeoutSeconds,omitempty"`
+
+	// ProvisioningTimeoutSeconds is how long requested nodes may take to register before the
+	// request is given up and reported as failed
+	// +optional
+	// +kubebuilder:default=900
+	// +kubebuilder:validation:Minimum=0
+	ProvisioningTimeoutSeconds int32 `json:"provisioningTimeoutSeconds,omitempty"`
+
+	// CapacityBackoffSeconds is how long an instance type, zone or node pool the cloud provider
+	// could not supply is skipped by later scale-ups
+	// +optional
+	// +kubebuilder:default=300
+	// +kubebuilder:validation:Minimum=0
+	CapacityBackoffSeconds int32 `json:"capacityBackoffSeconds,omitempty"`
+
 	// MinNodes is the minimum number of GPU nodes
 	// +optional
 	// +kubebuilder:default=0
@@ -86,9 +107,20 @@ type AutoscalingPolicySpec struct {
 	// +optional
 	NodePools []NodePoolSpec `json:"nodePools,omitempty"`
 
+	// Schedules override the node limits during recurring capacity windows, such as working hours
+	// or nightly batch runs. When windows overlap, the first one listed applies.
+	// +optional
+	Schedules []CapacitySchedule `json:"schedules,omitempty"`
+
 	// NodeSelector specifies which nodes this policy applies to
 	// +optional
 	NodeSelector map[string]string `json:"nodeSelector,omitempty"`
+
+	// DryRun runs the policy in shadow mode: scaling decisions are recorded in events,
+	// status and metrics, but no nodes are added, drained or removed
+	// +optional
+	// +kubebuilder:default=false
+	DryRun bool `json:"dryRun,omitempty"`
 }
 
 // NodePoolSpec defines a GPU node pool
@@ -106,6 +138,13 @@ type NodePoolSpec struct {
 	// +kubebuilder:default=100
 	MaxSize int32 `json:"maxSize,omitempty"`
 
+	// IdleTimeoutSeconds drains the pool down to MinSize, usually zero, once none of its nodes
+	// has run a GPU pod for this long. A pool at zero nodes is scaled up as soon as a pending
+	// pod it can host appears. Zero keeps idle nodes.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	IdleTimeoutSeconds int32 `json:"idleTimeoutSeconds,omitempty"`
+
 	// GPUType specifies the GPU type (e.g., "nvidia-tesla-v100", "nvidia-tesla-t4", "nvidia-a100")
 	// +optional
 	GPUType string `json:"gpuType,omitempty"`
@@ -114,6 +153,12 @@ type NodePoolSpec struct {
 	// +optional
 	InstanceTypes []string `json:"instanceTypes,omitempty"`
 
+	// GPUsPerNode is the number of GPUs on each node of the pool, used to size scale-ups.
+	// When unset it is read from the pool's nodes or from the cloud provider.
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	GPUsPerNode int32 `json:"gpusPerNode,omitempty"`
+
 	// CapacityType specifies the capacity type
 	// +kubebuilder:validation:Enum=spot;on-demand;reserved
 	CapacityType string `json:"capacityType"`
@@ -172,6 +217,15 @@ type AutoscalingPolicyStatus struct {
 	// +optional
 	LastScaleDownTime *metav1.Time `json:"lastScaleDownTime,omitempty"`
 
+	// ProvisioningRequests are the scale-ups whose nodes have not all registered yet.
+	// Their outstanding nodes count toward the policy's capacity.
+	// +optional
+	ProvisioningRequests []ProvisioningRequest `json:"provisioningRequests,omitempty"`
+
+	// NodePools reports the nodes of each node pool and how long they have been idle
+	// +optional
+	NodePools []NodePoolStatus `json:"nodePools,omitempty"`
+
 	// LastScalingAction is the most recent scaling action
 	// +optional
 	LastScalingAction string `json:"lastScalingAction,omitempty"`
@@ -191,15 +245,133 @@ type AutoscalingPolicyStatus struct {
 	// +optional
 	EstimatedMonthlySavings float64 `json:"estimatedMonthlySavings,omitempty"`
 
+	// Schedule reports the capacity window in effect and when the scheduled capacity next changes
+	// +optional
+	Schedule *ScheduleStatus `json:"schedule,omitempty"`
+
 	// PredictiveScaling contains predictive scaling information
 	// +optional
 	PredictiveScaling *PredictiveScalingStatus `json:"predictiveScaling,omitempty"`
 
+	// Shadow contains the scaling decisions made in dry-run mode
+	// +optional
+	Shadow *ShadowStatus `json:"shadow,omitempty"`
+
 	// Conditions represent the latest available observations of the policy's state
 	// +optional
 	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+// ProvisioningRequest records nodes requested from a node pool that are still being provisioned
+type ProvisioningRequest struct {
+	// NodePool is the node pool the nodes were requested from
+	NodePool string `json:"nodePool"`
+
+	// CapacityType is the capacity type of the requested nodes
+	// +optional
+	CapacityType string `json:"capacityType,omitempty"`
+
+	// InstanceType is the single instance type a fallback narrowed the request to
+	// +optional
+	InstanceType string `json:"instanceType,omitempty"`
+
+	// Zone is the single availability zone a fallback narrowed the request to
+	// +optional
+	Zone string `json:"zone,omitempty"`
+
+	// Count is the number of nodes requested
+	Count int32 `json:"count"`
+
+	// RegisteredNodes is the number of requested nodes that have joined the cluster
+	RegisteredNodes int32 `json:"registeredNodes"`
+
+	// RequestTime is when the nodes were requested
+	RequestTime metav1.Time `json:"requestTime"`
+}
+
+// CapacitySchedule overrides the policy's node limits while a recurring window is open
+type CapacitySchedule struct {
+	// Name identifies the window in status
+	Name string `json:"name"`
+
+	// Start is a cron expression (minute hour day-of-month month day-of-week) for when
+	// the window opens, e.g. "0 8 * * MON-FRI"
+	Start string `json:"start"`
+
+	// End is a cron expression for when the window closes, e.g. "0 19 * * MON-FRI"
+	End string `json:"end"`
+
+	// Timezone is the IANA time zone the cron expressions are evaluated in, e.g. "Europe/Berlin"
+	// +optional
+	// +kubebuilder:default=UTC
+	Timezone string `json:"timezone,omitempty"`
+
+	// MinNodes overrides the policy's MinNodes while the window is open
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinNodes *int32 `json:"minNodes,omitempty"`
+
+	// MaxNodes overrides the policy's MaxNodes while the window is open
+	// +optional
+	// +kubebuilder:validation:Minimum=1
+	MaxNodes *int32 `json:"maxNodes,omitempty"`
+
+	// NodePools overrides the sizes of node pools while the window is open
+	// +optional
+	NodePools []NodePoolSizeOverride `json:"nodePools,omitempty"`
+}
+
+// NodePoolSizeOverride overrides the size limits of a node pool
+type NodePoolSizeOverride struct {
+	// Name is the node pool name
+	Name string `json:"name"`
+
+	// MinSize overrides the pool's MinSize
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MinSize *int32 `json:"minSize,omitempty"`
+
+	// MaxSize overrides the pool's MaxSize
+	// +optional
+	// +kubebuilder:validation:Minimum=0
+	MaxSize *int32 `json:"maxSize,omitempty"`
+}
+
+// ScheduleStatus reports the scheduled capacity in effect
+type ScheduleStatus struct {
+	// ActiveWindow is the name of the capacity window in effect; empty outside all windows
+	// +optional
+	ActiveWindow string `json:"activeWindow,omitempty"`
+
+	// MinNodes is the minimum number of GPU nodes in effect
+	MinNodes int32 `json:"minNodes"`
+
+	// MaxNodes is the maximum number of GPU nodes in effect
+	MaxNodes int32 `json:"maxNodes"`
+
+	// NextTransitionTime is when a capacity window next opens or closes
+	// +optional
+	NextTransitionTime *metav1.Time `json:"nextTransitionTime,omitempty"`
+
+	// NextTransition describes the change at NextTransitionTime, e.g. "nightly-batch opens"
+	// +optional
+	NextTransition string `json:"nextTransition,omitempty"`
+}
+
+// NodePoolStatus reports the state of a node pool
+type NodePoolStatus struct {
+	// Name is the node pool name
+	Name string `json:"name"`
+
+	// Nodes is the number of the pool's nodes in the cluster
+	Nodes int32 `json:"nodes"`
+
+	// IdleSince is when the pool's nodes stopped running GPU pods.
+	// It is only tracked for pools with an idle timeout.
+	// +optional
+	IdleSince *metav1.Time `json:"idleSince,omitempty"`
+}
+
 // PredictiveScalingStatus contains predictive scaling information
 type PredictiveScalingStatus struct {
 	// Enabled indicates if predictive scaling is active
@@ -219,6 +391,46 @@ type PredictiveScalingStatus struct {
 	NextBusyPeriod *metav1.Time `json:"nextBusyPeriod,omitempty"`
 }
 
+// ShadowStatus contains the scaling decisions a dry-run policy would have carried out
+type ShadowStatus struct {
+	// LastAction is the most recent scaling action that would have been taken
+	// +optional
+	LastAction string `json:"lastAction,omitempty"`
+
+	// LastReason is the reason for the last shadow scaling action
+	// +optional
+	LastReason string `json:"lastReason,omitempty"`
+
+	// NodePool is the node pool the last shadow scale-up would have added nodes to
+	// +optional
+	NodePool string `json:"nodePool,omitempty"`
+
+	// CapacityType is the capacity type of the last shadow scaling action
+	// +optional
+	CapacityType string `json:"capacityType,omitempty"`
+
+	// NodeDelta is the number of nodes the last shadow scaling action would have added (positive) or removed (negative)
+	NodeDelta int32 `json:"nodeDelta"`
+
+	// NodesToRemove lists the nodes the last shadow scale-down would have drained and removed
+	// +optional
+	NodesToRemove []string `json:"nodesToRemove,omitempty"`
+
+	// LastScaleUpTime is the timestamp of the last shadow scale-up
+	// +optional
+	LastScaleUpTime *metav1.Time `json:"lastScaleUpTime,omitempty"`
+
+	// LastScaleDownTime is the timestamp of the last shadow scale-down
+	// +optional
+	LastScaleDownTime *metav1.Time `json:"lastScaleDownTime,omitempty"`
+
+	// ScaleUps is the number of shadow scale-ups since dry-run mode was enabled
+	ScaleUps int32 `json:"scaleUps"`
+
+	// ScaleDowns is the number of shadow scale-downs since dry-run mode was enabled
+	ScaleDowns int32 `json:"scaleDowns"`
+}
+
 // +kubebuilder:object:root=true
 // +kubebuilder:subresource:status
 // +kubebuilder:resource:scope=Cluster,shortName=asp
@@ -227,6 +439,7 @@ type PredictiveScalingStatus struct {
 // +kubebuilder:printcolumn:name="Desired",type=integer,JSONPath=`.status.desiredNodes`
 // +kubebuilder:printcolumn:name="Min",type=integer,JSONPath=`.spec.minNodes`
 // +kubebuilder:printcolumn:name="Max",type=integer,JSONPath=`.spec.maxNodes`
+// +kubebuilder:printcolumn:name="Window",type=string,JSONPath=`.status.schedule.activeWindow`,priority=1
 // +kubebuilder:printcolumn:name="Utilization",type=string,JSONPath=`.status.averageGPUUtilization`
 // +kubebuilder:printcolumn:name="Spot %",type=string,JSONPath=`.spec.spotInstancePercentage`
 // +kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
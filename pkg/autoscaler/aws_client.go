@@ -0,0 +1,620 @@
+package autoscaler
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	awsAutoScalingAPIVersion = "2011-01-01"
+	awsEC2APIVersion         = "2016-11-15"
+	awsSTSAPIVersion         = "2011-06-15"
+
+	// awsIMDSEndpoint is the EC2 instance metadata service
+	awsIMDSEndpoint = "http://169.254.169.254"
+
+	// Credentials are refreshed this long before they expire
+	awsCredentialsRefreshWindow = 5 * time.Minute
+)
+
+// AWSAPIClient implements AutoScalingAPI and EC2API on top of the AWS Query APIs.
+// Requests are signed with Signature Version 4 using credentials from the environment
+// (AWS_ACCESS_KEY_ID/AWS_SECRET_ACCESS_KEY), an IRSA web identity token, or the instance role.
+type AWSAPIClient struct {
+	region     string
+	httpClient *http.Client
+
+	// Endpoints, overridden in tests
+	autoScalingEndpoint string
+	ec2Endpoint         string
+	stsEndpoint         string
+	imdsEndpoint        string
+
+	mu          sync.Mutex
+	credentials awsCredentials
+}
+
+// awsCredentials are the keys requests are signed with
+type awsCredentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Expires         time.Time
+}
+
+// NewAWSAPIClient creates an AWS API client for a region; httpClient is http.DefaultClient when nil
+func NewAWSAPIClient(region string, httpClient *http.Client) (*AWSAPIClient, error) {
+	if region == "" {
+		return nil, fmt.Errorf("AWS region is required")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &AWSAPIClient{
+		region:              region,
+		httpClient:          httpClient,
+		autoScalingEndpoint: fmt.Sprintf("https://autoscaling.%s.amazonaws.com/", region),
+		ec2Endpoint:         fmt.Sprintf("https://ec2.%s.amazonaws.com/", region),
+		stsEndpoint:         fmt.Sprintf("https://sts.%s.amazonaws.com/", region),
+		imdsEndpoint:        awsIMDSEndpoint,
+	}, nil
+}
+
+// awsAPIError is an error returned by an AWS Query API
+type awsAPIError struct {
+	Code    string
+	Message string
+}
+
+func (e *awsAPIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the Query API error code
+func (e *awsAPIError) ErrorCode() string {
+	return e.Code
+}
+
+type awsASGInstanceXML struct {
+	InstanceID       string `xml:"InstanceId"`
+	InstanceType     string `xml:"InstanceType"`
+	AvailabilityZone string `xml:"AvailabilityZone"`
+	LifecycleState   string `xml:"LifecycleState"`
+}
+
+type awsASGXML struct {
+	Name            string              `xml:"AutoScalingGroupName"`
+	MinSize         int32               `xml:"MinSize"`
+	MaxSize         int32               `xml:"MaxSize"`
+	DesiredCapacity int32               `xml:"DesiredCapacity"`
+	Instances       []awsASGInstanceXML `xml:"Instances>member"`
+	Overrides       []struct {
+		InstanceType string `xml:"InstanceType"`
+	} `xml:"MixedInstancesPolicy>LaunchTemplate>Overrides>member"`
+}
+
+// DescribeAutoScalingGroup returns an Auto Scaling Group, marking its spot instances from EC2
+func (c *AWSAPIClient) DescribeAutoScalingGroup(ctx context.Context, name string) (*AutoScalingGroup, error) {
+	var out struct {
+		Groups []awsASGXML `xml:"DescribeAutoScalingGroupsResult>AutoScalingGroups>member"`
+	}
+	params := url.Values{"AutoScalingGroupNames.member.1": {name}}
+	if err := c.call(ctx, c.autoScalingEndpoint, "autoscaling", "DescribeAutoScalingGroups", awsAutoScalingAPIVersion, params, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Groups) == 0 {
+		return nil, fmt.Errorf("auto scaling group %s not found", name)
+	}
+
+	group := out.Groups[0]
+	asg := &AutoScalingGroup{
+		Name:            group.Name,
+		MinSize:         group.MinSize,
+		MaxSize:         group.MaxSize,
+		DesiredCapacity: group.DesiredCapacity,
+	}
+	for _, override := range group.Overrides {
+		asg.InstanceTypes = append(asg.InstanceTypes, override.InstanceType)
+	}
+
+	instanceIDs := make([]string, 0, len(group.Instances))
+	for _, instance := range group.Instances {
+		asg.Instances = append(asg.Instances, AutoScalingInstance{
+			InstanceID:       instance.InstanceID,
+			InstanceType:     instance.InstanceType,
+			AvailabilityZone: instance.AvailabilityZone,
+			LifecycleState:   instance.LifecycleState,
+		})
+		instanceIDs = append(instanceIDs, instance.InstanceID)
+	}
+
+	// The Auto Scaling API doesn't report instance lifecycles
+	if len(instanceIDs) > 0 {
+		instances, err := c.describeInstances(ctx, instanceIDParams(instanceIDs))
+		if err != nil {
+			return nil, err
+		}
+		spot := make(map[string]bool, len(instances))
+		for _, instance := range instances {
+			spot[instance.InstanceID] = instance.InstanceLifecycle == awsInstanceLifecycleSpot
+		}
+		for i := range asg.Instances {
+			asg.Instances[i].Spot = spot[asg.Instances[i].InstanceID]
+		}
+	}
+
+	return asg, nil
+}
+
+// SetDesiredCapacity sets the desired capacity of an Auto Scaling Group, ignoring its cooldown
+func (c *AWSAPIClient) SetDesiredCapacity(ctx context.Context, name string, desiredCapacity int32) error {
+	params := url.Values{
+		"AutoScalingGroupName": {name},
+		"DesiredCapacity":      {strconv.Itoa(int(desiredCapacity))},
+		"HonorCooldown":        {"false"},
+	}
+	return c.call(ctx, c.autoScalingEndpoint, "autoscaling", "SetDesiredCapacity", awsAutoScalingAPIVersion, params, nil)
+}
+
+// SetInstanceTypes replaces the instance type overrides of an Auto Scaling Group's mixed instances policy
+func (c *AWSAPIClient) SetInstanceTypes(ctx context.Context, name string, instanceTypes []string) error {
+	params := url.Values{"AutoScalingGroupName": {name}}
+	for i, instanceType := range instanceTypes {
+		params.Set(fmt.Sprintf("MixedInstancesPolicy.LaunchTemplate.Overrides.member.%d.InstanceType", i+1), instanceType)
+	}
+	return c.call(ctx, c.autoScalingEndpoint, "autoscaling", "UpdateAutoScalingGroup", awsAutoScalingAPIVersion, params, nil)
+}
+
+// DescribeScalingActivities returns the most recent scaling activities of an Auto Scaling Group
+func (c *AWSAPIClient) DescribeScalingActivities(ctx context.Context, name string) ([]ScalingActivity, error) {
+	var out struct {
+		Activities []struct {
+			Description   string    `xml:"Description"`
+			StatusCode    string    `xml:"StatusCode"`
+			StatusMessage string    `xml:"StatusMessage"`
+			StartTime     time.Time `xml:"StartTime"`
+		} `xml:"DescribeScalingActivitiesResult>Activities>member"`
+	}
+	params := url.Values{"AutoScalingGroupName": {name}, "MaxRecords": {"20"}}
+	if err := c.call(ctx, c.autoScalingEndpoint, "autoscaling", "DescribeScalingActivities", awsAutoScalingAPIVersion, params, &out); err != nil {
+		return nil, err
+	}
+
+	activities := make([]ScalingActivity, 0, len(out.Activities))
+	for _, activity := range out.Activities {
+		activities = append(activities, ScalingActivity{
+			Description:   activity.Description,
+			StatusCode:    activity.StatusCode,
+			StatusMessage: activity.StatusMessage,
+			StartTime:     activity.StartTime,
+		})
+	}
+	return activities, nil
+}
+
+// TerminateInstanceInAutoScalingGroup terminates an instance of an Auto Scaling Group
+func (c *AWSAPIClient) TerminateInstanceInAutoScalingGroup(ctx context.Context, instanceID string, decrementDesiredCapacity bool) error {
+	params := url.Values{
+		"InstanceId":                     {instanceID},
+		"ShouldDecrementDesiredCapacity": {strconv.FormatBool(decrementDesiredCapacity)},
+	}
+	return c.call(ctx, c.autoScalingEndpoint, "autoscaling", "TerminateInstanceInAutoScalingGroup", awsAutoScalingAPIVersion, params, nil)
+}
+
+// DescribeInstanceByPrivateDNSName returns the instance registered under a private DNS name
+func (c *AWSAPIClient) DescribeInstanceByPrivateDNSName(ctx context.Context, privateDNSName string) (*EC2Instance, error) {
+	instances, err := c.describeInstances(ctx, url.Values{
+		"Filter.1.Name":    {"private-dns-name"},
+		"Filter.1.Value.1": {privateDNSName},
+	})
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("no instance with private DNS name %s", privateDNSName)
+	}
+	return &instances[0], nil
+}
+
+// DescribeInstance returns an instance by ID
+func (c *AWSAPIClient) DescribeInstance(ctx context.Context, instanceID string) (*EC2Instance, error) {
+	instances, err := c.describeInstances(ctx, instanceIDParams([]string{instanceID}))
+	if err != nil {
+		return nil, err
+	}
+	if len(instances) == 0 {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+	return &instances[0], nil
+}
+
+// DescribeSpotInstanceRequest returns a spot instance request by ID
+func (c *AWSAPIClient) DescribeSpotInstanceRequest(ctx context.Context, requestID string) (*SpotInstanceRequest, error) {
+	var out struct {
+		Requests []struct {
+			RequestID string `xml:"spotInstanceRequestId"`
+			State     string `xml:"state"`
+			Status    struct {
+				Code       string    `xml:"code"`
+				UpdateTime time.Time `xml:"updateTime"`
+			} `xml:"status"`
+		} `xml:"spotInstanceRequestSet>item"`
+	}
+	params := url.Values{"SpotInstanceRequestId.1": {requestID}}
+	if err := c.call(ctx, c.ec2Endpoint, "ec2", "DescribeSpotInstanceRequests", awsEC2APIVersion, params, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Requests) == 0 {
+		return nil, fmt.Errorf("spot request %s not found", requestID)
+	}
+
+	request := out.Requests[0]
+	return &SpotInstanceRequest{
+		RequestID:        request.RequestID,
+		State:            request.State,
+		StatusCode:       request.Status.Code,
+		StatusUpdateTime: request.Status.UpdateTime,
+	}, nil
+}
+
+// describeInstances returns the EC2 instances matching the DescribeInstances parameters
+func (c *AWSAPIClient) describeInstances(ctx context.Context, params url.Values) ([]EC2Instance, error) {
+	var out struct {
+		Instances []struct {
+			InstanceID            string `xml:"instanceId"`
+			InstanceType          string `xml:"instanceType"`
+			PrivateDNSName        string `xml:"privateDnsName"`
+			AvailabilityZone      string `xml:"placement>availabilityZone"`
+			InstanceLifecycle     string `xml:"instanceLifecycle"`
+			SpotInstanceRequestID string `xml:"spotInstanceRequestId"`
+			State                 string `xml:"instanceState>name"`
+			Tags                  []struct {
+				Key   string `xml:"key"`
+				Value string `xml:"value"`
+			} `xml:"tagSet>item"`
+		} `xml:"reservationSet>item>instancesSet>item"`
+	}
+	if err := c.call(ctx, c.ec2Endpoint, "ec2", "DescribeInstances", awsEC2APIVersion, params, &out); err != nil {
+		return nil, err
+	}
+
+	instances := make([]EC2Instance, 0, len(out.Instances))
+	for _, instance := range out.Instances {
+		ec2Instance := EC2Instance{
+			InstanceID:            instance.InstanceID,
+			InstanceType:          instance.InstanceType,
+			PrivateDNSName:        instance.PrivateDNSName,
+			AvailabilityZone:      instance.AvailabilityZone,
+			InstanceLifecycle:     instance.InstanceLifecycle,
+			SpotInstanceRequestID: instance.SpotInstanceRequestID,
+			State:                 instance.State,
+		}
+		for _, tag := range instance.Tags {
+			if tag.Key == awsAutoScalingGroupTag {
+				ec2Instance.AutoScalingGroupName = tag.Value
+			}
+		}
+		instances = append(instances, ec2Instance)
+	}
+	return instances, nil
+}
+
+func instanceIDParams(instanceIDs []string) url.Values {
+	params := url.Values{}
+	for i, id := range instanceIDs {
+		params.Set(fmt.Sprintf("InstanceId.%d", i+1), id)
+	}
+	return params
+}
+
+// call sends a signed Query API request and decodes the XML response into out
+func (c *AWSAPIClient) call(ctx context.Context, endpoint, service, action, version string, params url.Values, out interface{}) error {
+	credentials, err := c.getCredentials(ctx)
+	if err != nil {
+		return err
+	}
+
+	form := url.Values{}
+	for key, values := range params {
+		form[key] = values
+	}
+	form.Set("Action", action)
+	form.Set("Version", version)
+	body := form.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, strings.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+	signAWSRequest(req, []byte(body), credentials, c.region, service, time.Now())
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", service, action, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned %s: %w", service, action, resp.Status, parseAWSError(data))
+	}
+
+	if out != nil {
+		if err := xml.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode %s %s response: %w", service, action, err)
+		}
+	}
+	return nil
+}
+
+// parseAWSError extracts the error code and message of a Query API error response.
+// Auto Scaling wraps errors in ErrorResponse and EC2 in Response>Errors.
+// Capacity error codes are returned as a ProviderError.
+func parseAWSError(data []byte) error {
+	var out struct {
+		Errors []struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Error"`
+		EC2Errors []struct {
+			Code    string `xml:"Code"`
+			Message string `xml:"Message"`
+		} `xml:"Errors>Error"`
+	}
+	if err := xml.Unmarshal(data, &out); err == nil {
+		for _, e := range append(out.Errors, out.EC2Errors...) {
+			return providerErrorFromCode(&awsAPIError{Code: e.Code, Message: e.Message})
+		}
+	}
+	return fmt.Errorf("%s", strings.TrimSpace(string(data)))
+}
+
+// getCredentials returns cached credentials, resolving them again once they are about to expire
+func (c *AWSAPIClient) getCredentials(ctx context.Context) (awsCredentials, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.credentials.AccessKeyID != "" &&
+		(c.credentials.Expires.IsZero() || time.Until(c.credentials.Expires) > awsCredentialsRefreshWindow) {
+		return c.credentials, nil
+	}
+
+	credentials, err := c.resolveCredentials(ctx)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to get AWS credentials: %w", err)
+	}
+	c.credentials = credentials
+	return credentials, nil
+}
+
+// resolveCredentials looks up credentials in the environment, then exchanges an IRSA web identity
+// token with STS, then falls back to the instance role from the instance metadata service
+func (c *AWSAPIClient) resolveCredentials(ctx context.Context) (awsCredentials, error) {
+	if accessKeyID := os.Getenv("AWS_ACCESS_KEY_ID"); accessKeyID != "" {
+		return awsCredentials{
+			AccessKeyID:     accessKeyID,
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+		}, nil
+	}
+
+	if roleARN, tokenFile := os.Getenv("AWS_ROLE_ARN"), os.Getenv("AWS_WEB_IDENTITY_TOKEN_FILE"); roleARN != "" && tokenFile != "" {
+		return c.assumeRoleWithWebIdentity(ctx, roleARN, tokenFile)
+	}
+
+	return c.instanceRoleCredentials(ctx)
+}
+
+// assumeRoleWithWebIdentity exchanges the service account token projected by IRSA for role credentials
+func (c *AWSAPIClient) assumeRoleWithWebIdentity(ctx context.Context, roleARN, tokenFile string) (awsCredentials, error) {
+	token, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to read web identity token: %w", err)
+	}
+
+	sessionName := os.Getenv("AWS_ROLE_SESSION_NAME")
+	if sessionName == "" {
+		sessionName = "gpu-autoscaler"
+	}
+	form := url.Values{
+		"Action":           {"AssumeRoleWithWebIdentity"},
+		"Version":          {awsSTSAPIVersion},
+		"RoleArn":          {roleARN},
+		"RoleSessionName":  {sessionName},
+		"WebIdentityToken": {strings.TrimSpace(string(token))},
+	}
+
+	// AssumeRoleWithWebIdentity is authenticated by the token and not signed
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.stsEndpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded; charset=utf-8")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return awsCredentials{}, fmt.Errorf("AssumeRoleWithWebIdentity returned %s: %w", resp.Status, parseAWSError(data))
+	}
+
+	var out struct {
+		Credentials struct {
+			AccessKeyID     string    `xml:"AccessKeyId"`
+			SecretAccessKey string    `xml:"SecretAccessKey"`
+			SessionToken    string    `xml:"SessionToken"`
+			Expiration      time.Time `xml:"Expiration"`
+		} `xml:"AssumeRoleWithWebIdentityResult>Credentials"`
+	}
+	if err := xml.Unmarshal(data, &out); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to decode AssumeRoleWithWebIdentity response: %w", err)
+	}
+	return awsCredentials{
+		AccessKeyID:     out.Credentials.AccessKeyID,
+		SecretAccessKey: out.Credentials.SecretAccessKey,
+		SessionToken:    out.Credentials.SessionToken,
+		Expires:         out.Credentials.Expiration,
+	}, nil
+}
+
+// instanceRoleCredentials returns the instance role's credentials from IMDSv2
+func (c *AWSAPIClient) instanceRoleCredentials(ctx context.Context) (awsCredentials, error) {
+	tokenReq, err := http.NewRequestWithContext(ctx, http.MethodPut, c.imdsEndpoint+"/latest/api/token", nil)
+	if err != nil {
+		return awsCredentials{}, err
+	}
+	tokenReq.Header.Set("X-aws-ec2-metadata-token-ttl-seconds", "21600")
+	token, err := c.imdsGet(tokenReq)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to get instance metadata token: %w", err)
+	}
+
+	get := func(path string) (string, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.imdsEndpoint+path, nil)
+		if err != nil {
+			return "", err
+		}
+		req.Header.Set("X-aws-ec2-metadata-token", token)
+		return c.imdsGet(req)
+	}
+
+	role, err := get("/latest/meta-data/iam/security-credentials/")
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to get instance role: %w", err)
+	}
+	role = strings.TrimSpace(strings.SplitN(role, "\n", 2)[0])
+	if role == "" {
+		return awsCredentials{}, fmt.Errorf("instance has no IAM role")
+	}
+
+	data, err := get("/latest/meta-data/iam/security-credentials/" + role)
+	if err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to get credentials of instance role %s: %w", role, err)
+	}
+	var out struct {
+		AccessKeyID     string    `json:"AccessKeyId"`
+		SecretAccessKey string    `json:"SecretAccessKey"`
+		Token           string    `json:"Token"`
+		Expiration      time.Time `json:"Expiration"`
+	}
+	if err := json.Unmarshal([]byte(data), &out); err != nil {
+		return awsCredentials{}, fmt.Errorf("failed to decode credentials of instance role %s: %w", role, err)
+	}
+	return awsCredentials{
+		AccessKeyID:     out.AccessKeyID,
+		SecretAccessKey: out.SecretAccessKey,
+		SessionToken:    out.Token,
+		Expires:         out.Expiration,
+	}, nil
+}
+
+// imdsGet sends an instance metadata request and returns the response body
+func (c *AWSAPIClient) imdsGet(req *http.Request) (string, error) {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s %s returned %s", req.Method, req.URL.Path, resp.Status)
+	}
+	return string(data), nil
+}
+
+// signAWSRequest signs a request with AWS Signature Version 4
+func signAWSRequest(req *http.Request, body []byte, credentials awsCredentials, region, service string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for key, values := range req.Header {
+		headers[strings.ToLower(key)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var canonicalHeaders strings.Builder
+	for _, name := range names {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	path := req.URL.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	// Query values are sorted by key, and spaces encoded as %20 rather than +
+	query := strings.ReplaceAll(req.URL.Query().Encode(), "+", "%20")
+
+	bodyHash := sha256.Sum256(body)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		path,
+		query,
+		canonicalHeaders.String(),
+		signedHeaders,
+		hex.EncodeToString(bodyHash[:]),
+	}, "\n")
+
+	credentialScope := strings.Join([]string{date, region, service, "aws4_request"}, "/")
+	requestHash := sha256.Sum256([]byte(canonicalRequest))
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		credentialScope,
+		hex.EncodeToString(requestHash[:]),
+	}, "\n")
+
+	key := []byte("AWS4" + credentials.SecretAccessKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, credentialScope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
@@ -0,0 +1,177 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAWSRequest(t *testing.T) {
+	// Requests and signatures from the AWS Signature Version 4 test suite
+	credentials := awsCredentials{AccessKeyID: "AKIDEXAMPLE", SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY"}
+	now := time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC)
+
+	tests := []struct {
+		name              string
+		method            string
+		url               string
+		body              string
+		contentType       string
+		expectedSigned    string
+		expectedSignature string
+	}{
+		{
+			name:              "get-vanilla",
+			method:            http.MethodGet,
+			url:               "https://example.amazonaws.com/",
+			expectedSigned:    "host;x-amz-date",
+			expectedSignature: "5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31",
+		},
+		{
+			name:              "get-vanilla-query-order-key-case",
+			method:            http.MethodGet,
+			url:               "https://example.amazonaws.com/?Param2=value2&Param1=value1",
+			expectedSigned:    "host;x-amz-date",
+			expectedSignature: "b97d918cfa904a5beff61c982a1b6f458b799221646efd99d3219ec94cdf2500",
+		},
+		{
+			name:              "post-x-www-form-urlencoded",
+			method:            http.MethodPost,
+			url:               "https://example.amazonaws.com/",
+			body:              "Param1=value1",
+			contentType:       "application/x-www-form-urlencoded",
+			expectedSigned:    "content-type;host;x-amz-date",
+			expectedSignature: "ff11897932ad3f4e8b18135d722051e5ac45fc38421b1da7b9d196a0fe09473a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+			if err != nil {
+				t.Fatalf("failed to create request: %v", err)
+			}
+			if tt.contentType != "" {
+				req.Header.Set("Content-Type", tt.contentType)
+			}
+
+			signAWSRequest(req, []byte(tt.body), credentials, "us-east-1", "service", now)
+
+			expected := fmt.Sprintf("AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, SignedHeaders=%s, Signature=%s",
+				tt.expectedSigned, tt.expectedSignature)
+			if got := req.Header.Get("Authorization"); got != expected {
+				t.Errorf("Expected %s, got %s", expected, got)
+			}
+		})
+	}
+}
+
+func TestAWSAPIClient(t *testing.T) {
+	t.Setenv("AWS_ACCESS_KEY_ID", "AKIDEXAMPLE")
+	t.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	t.Setenv("AWS_SESSION_TOKEN", "")
+
+	var actions []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			t.Errorf("failed to parse form: %v", err)
+		}
+		if !strings.HasPrefix(r.Header.Get("Authorization"), "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/") {
+			t.Errorf("Expected a signed request, got Authorization %q", r.Header.Get("Authorization"))
+		}
+		action := r.PostForm.Get("Action")
+		actions = append(actions, action)
+
+		switch {
+		case action == "DescribeAutoScalingGroups" && r.PostForm.Get("AutoScalingGroupNames.member.1") == "gpu-spot":
+			fmt.Fprint(w, `<DescribeAutoScalingGroupsResponse><DescribeAutoScalingGroupsResult><AutoScalingGroups><member>
+<AutoScalingGroupName>gpu-spot</AutoScalingGroupName><MinSize>0</MinSize><MaxSize>4</MaxSize><DesiredCapacity>1</DesiredCapacity>
+<Instances><member><InstanceId>i-0000000000000001</InstanceId><InstanceType>p3.2xlarge</InstanceType>
+<AvailabilityZone>us-west-2a</AvailabilityZone><LifecycleState>InService</LifecycleState></member></Instances>
+<MixedInstancesPolicy><LaunchTemplate><Overrides><member><InstanceType>p3.2xlarge</InstanceType></member>
+<member><InstanceType>p3.8xlarge</InstanceType></member></Overrides></LaunchTemplate></MixedInstancesPolicy>
+</member></AutoScalingGroups></DescribeAutoScalingGroupsResult></DescribeAutoScalingGroupsResponse>`)
+		case action == "DescribeInstances" && r.PostForm.Get("InstanceId.1") == "i-0000000000000001":
+			fmt.Fprint(w, `<DescribeInstancesResponse><reservationSet><item><instancesSet><item>
+<instanceId>i-0000000000000001</instanceId><instanceType>p3.2xlarge</instanceType>
+<privateDnsName>ip-10-0-1-1.us-west-2.compute.internal</privateDnsName><placement><availabilityZone>us-west-2a</availabilityZone></placement>
+<instanceLifecycle>spot</instanceLifecycle><spotInstanceRequestId>sir-1</spotInstanceRequestId><instanceState><name>running</name></instanceState>
+<tagSet><item><key>Name</key><value>gpu-worker</value></item><item><key>aws:autoscaling:groupName</key><value>gpu-spot</value></item></tagSet>
+</item></instancesSet></item></reservationSet></DescribeInstancesResponse>`)
+		case action == "UpdateAutoScalingGroup" && r.PostForm.Get("MixedInstancesPolicy.LaunchTemplate.Overrides.member.1.InstanceType") == "p3.8xlarge":
+			fmt.Fprint(w, `<UpdateAutoScalingGroupResponse></UpdateAutoScalingGroupResponse>`)
+		case action == "DescribeScalingActivities" && r.PostForm.Get("AutoScalingGroupName") == "gpu-spot":
+			fmt.Fprint(w, `<DescribeScalingActivitiesResponse><DescribeScalingActivitiesResult><Activities><member>
+<Description>Launching a new EC2 instance.  Status Reason: Could not launch Spot Instances. InsufficientInstanceCapacity</Description>
+<StatusCode>Failed</StatusCode><StatusMessage>Could not launch Spot Instances. InsufficientInstanceCapacity - There is no Spot capacity available that matches your request. Launching EC2 instance failed.</StatusMessage>
+<StartTime>2024-01-01T12:00:30.123Z</StartTime></member></Activities></DescribeScalingActivitiesResult></DescribeScalingActivitiesResponse>`)
+		case action == "SetDesiredCapacity":
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprint(w, `<ErrorResponse><Error><Type>Sender</Type><Code>InsufficientInstanceCapacity</Code>
+<Message>We currently do not have sufficient p3.2xlarge capacity</Message></Error></ErrorResponse>`)
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+			fmt.Fprintf(w, `<Response><Errors><Error><Code>InvalidAction</Code><Message>unexpected %s</Message></Error></Errors></Response>`, action)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewAWSAPIClient("us-west-2", server.Client())
+	if err != nil {
+		t.Fatalf("NewAWSAPIClient() error = %v", err)
+	}
+	client.autoScalingEndpoint = server.URL + "/"
+	client.ec2Endpoint = server.URL + "/"
+
+	asg, err := client.DescribeAutoScalingGroup(context.Background(), "gpu-spot")
+	if err != nil {
+		t.Fatalf("DescribeAutoScalingGroup() error = %v", err)
+	}
+	if asg.MaxSize != 4 || asg.DesiredCapacity != 1 || len(asg.InstanceTypes) != 2 {
+		t.Errorf("Unexpected auto scaling group: %+v", asg)
+	}
+	if len(asg.Instances) != 1 || !asg.Instances[0].Spot || asg.Instances[0].AvailabilityZone != "us-west-2a" {
+		t.Errorf("Unexpected instances: %+v", asg.Instances)
+	}
+
+	// Query API error codes are classified as capacity errors by the provider
+	provider := NewAWSProviderWithClients("us-west-2", client, client)
+	err = provider.ScaleUp(context.Background(), &NodePoolConfig{Name: "gpu-spot", CapacityType: CapacityTypeSpot}, 1)
+	if !IsSpotUnavailable(err) {
+		t.Errorf("Expected a spot unavailable error, got %v", err)
+	}
+
+	instance, err := client.DescribeInstance(context.Background(), "i-0000000000000001")
+	if err != nil {
+		t.Fatalf("DescribeInstance() error = %v", err)
+	}
+	if instance.AutoScalingGroupName != "gpu-spot" {
+		t.Errorf("Expected the instance's ASG from its tags, got %q", instance.AutoScalingGroupName)
+	}
+
+	if _, err := client.DescribeInstance(context.Background(), "i-missing"); err == nil || !strings.Contains(err.Error(), "InvalidAction") {
+		t.Errorf("Expected the EC2 error code in the error, got %v", err)
+	}
+
+	if err := client.SetInstanceTypes(context.Background(), "gpu-spot", []string{"p3.8xlarge"}); err != nil {
+		t.Errorf("SetInstanceTypes() error = %v", err)
+	}
+
+	activities, err := client.DescribeScalingActivities(context.Background(), "gpu-spot")
+	if err != nil {
+		t.Fatalf("DescribeScalingActivities() error = %v", err)
+	}
+	if len(activities) != 1 || activities[0].StatusCode != "Failed" || !activities[0].StartTime.Equal(time.Date(2024, 1, 1, 12, 0, 30, 123000000, time.UTC)) {
+		t.Errorf("Unexpected scaling activities: %+v", activities)
+	}
+
+	expected := "DescribeAutoScalingGroups,DescribeInstances,DescribeAutoScalingGroups,DescribeInstances,SetDesiredCapacity,DescribeInstances,DescribeInstances," +
+		"UpdateAutoScalingGroup,DescribeScalingActivities"
+	if got := strings.Join(actions, ","); got != expected {
+		t.Errorf("Expected actions %s, got %s", expected, got)
+	}
+}
@@ -3,118 +3,278 @@ package autoscaler
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"sort"
+	"strings"
 	"time"
 )
 
-// AWSProvider implements CloudProvider for AWS
+const (
+	// AWS gives a 2-minute warning before reclaiming a spot instance
+	awsSpotInterruptionWarning = 2 * time.Minute
+
+	// Spot instance request status codes that indicate an upcoming interruption
+	awsSpotStatusMarkedForTermination = "marked-for-termination"
+	awsSpotStatusMarkedForStop        = "marked-for-stop"
+	awsSpotStatusMarkedForHibernation = "marked-for-hibernation"
+
+	awsInstanceLifecycleSpot = "spot"
+
+	// awsAutoScalingGroupTag is set by EC2 Auto Scaling on the instances it launches
+	awsAutoScalingGroupTag = "aws:autoscaling:groupName"
+
+	// awsActivityStatusFailed is the status code of a scaling activity that failed
+	awsActivityStatusFailed = "Failed"
+)
+
+// awsCapacityMessage matches the zone and instance type in the message of a launch that found no capacity
+var awsCapacityMessage = regexp.MustCompile(`sufficient (\S+) capacity in the Availability Zone you requested \(([a-z0-9-]+)\)`)
+
+// AutoScalingAPI is the subset of the EC2 Auto Scaling API used by AWSProvider.
+// Production deployments back it with AWSAPIClient; tests use an in-memory fake.
+type AutoScalingAPI interface {
+	// DescribeAutoScalingGroup returns a single Auto Scaling Group by name
+	DescribeAutoScalingGroup(ctx context.Context, name string) (*AutoScalingGroup, error)
+
+	// SetDesiredCapacity sets the desired capacity of an Auto Scaling Group
+	SetDesiredCapacity(ctx context.Context, name string, desiredCapacity int32) error
+
+	// SetInstanceTypes replaces the instance type overrides of an Auto Scaling Group's mixed instances policy
+	SetInstanceTypes(ctx context.Context, name string, instanceTypes []string) error
+
+	// DescribeScalingActivities returns the recent scaling activities of an Auto Scaling Group
+	DescribeScalingActivities(ctx context.Context, name string) ([]ScalingActivity, error)
+
+	// TerminateInstanceInAutoScalingGroup terminates an instance, optionally decrementing desired capacity
+	TerminateInstanceInAutoScalingGroup(ctx context.Context, instanceID string, decrementDesiredCapacity bool) error
+}
+
+// EC2API is the subset of the EC2 API used by AWSProvider
+type EC2API interface {
+	// DescribeInstanceByPrivateDNSName returns the instance registered under a private DNS name
+	DescribeInstanceByPrivateDNSName(ctx context.Context, privateDNSName string) (*EC2Instance, error)
+
+	// DescribeInstance returns an instance by ID
+	DescribeInstance(ctx context.Context, instanceID string) (*EC2Instance, error)
+
+	// DescribeSpotInstanceRequest returns a spot instance request by ID
+	DescribeSpotInstanceRequest(ctx context.Context, requestID string) (*SpotInstanceRequest, error)
+}
+
+// AutoScalingGroup describes an AWS Auto Scaling Group
+type AutoScalingGroup struct {
+	Name            string
+	MinSize         int32
+	MaxSize         int32
+	DesiredCapacity int32
+	InstanceTypes   []string
+	Instances       []AutoScalingInstance
+}
+
+// ScalingActivity describes a scaling activity of an Auto Scaling Group, such as an instance launch
+type ScalingActivity struct {
+	Description   string
+	StatusCode    string
+	StatusMessage string
+	StartTime     time.Time
+}
+
+// AutoScalingInstance describes an instance that belongs to an Auto Scaling Group
+type AutoScalingInstance struct {
+	InstanceID       string
+	InstanceType     string
+	AvailabilityZone string
+	LifecycleState   string
+	Spot             bool
+}
+
+// EC2Instance describes an EC2 instance
+type EC2Instance struct {
+	InstanceID            string
+	InstanceType          string
+	PrivateDNSName        string
+	AvailabilityZone      string
+	InstanceLifecycle     string
+	SpotInstanceRequestID string
+	State                 string
+	AutoScalingGroupName  string // from the aws:autoscaling:groupName tag
+}
+
+// SpotInstanceRequest describes an EC2 spot instance request
+type SpotInstanceRequest struct {
+	RequestID        string
+	State            string
+	StatusCode       string
+	StatusUpdateTime time.Time
+}
+
+// AWSProvider implements CloudProvider for AWS.
+// Node pools map one-to-one to Auto Scaling Groups with the same name.
 type AWSProvider struct {
-	region string
-	// AWS SDK clients would be initialized here
-	// ec2Client    *ec2.Client
-	// asgClient    *autoscaling.Client
-	// pricingClient *pricing.Client
+	region    string
+	asgClient AutoScalingAPI
+	ec2Client EC2API
 }
 
-// NewAWSProvider creates a new AWS provider
+// NewAWSProvider creates a new AWS provider without API clients.
+// Scaling calls fail until clients are supplied via NewAWSProviderWithClients.
 func NewAWSProvider(region string) *AWSProvider {
 	return &AWSProvider{
 		region: region,
 	}
 }
 
-// ScaleUp adds nodes to an AWS Auto Scaling Group
+// NewAWSProviderWithClients creates a new AWS provider backed by the given ASG and EC2 clients
+func NewAWSProviderWithClients(region string, asgClient AutoScalingAPI, ec2Client EC2API) *AWSProvider {
+	return &AWSProvider{
+		region:    region,
+		asgClient: asgClient,
+		ec2Client: ec2Client,
+	}
+}
+
+// ScaleUp adds nodes to an AWS Auto Scaling Group by raising its desired capacity
 func (p *AWSProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
-	// In production, this would use AWS Auto Scaling API:
-	// 1. Get current ASG desired capacity
-	// 2. Increase desired capacity by count
-	// 3. Ensure new capacity doesn't exceed max size
-	// 4. Wait for instances to be healthy
+	if p.asgClient == nil {
+		return fmt.Errorf("AWS Auto Scaling client not configured")
+	}
 
-	fmt.Printf("AWS: Scaling up node pool %s by %d nodes\n", nodePool.Name, count)
+	asg, err := p.asgClient.DescribeAutoScalingGroup(ctx, nodePool.Name)
+	if err != nil {
+		return fmt.Errorf("failed to describe ASG %s: %w", nodePool.Name, err)
+	}
 
-	// Example implementation:
-	// asgName := nodePool.Name
-	// describeInput := &autoscaling.DescribeAutoScalingGroupsInput{
-	//     AutoScalingGroupNames: []string{asgName},
-	// }
-	// result, err := p.asgClient.DescribeAutoScalingGroups(ctx, describeInput)
-	// if err != nil {
-	//     return fmt.Errorf("failed to describe ASG: %w", err)
-	// }
-	//
-	// currentCapacity := *result.AutoScalingGroups[0].DesiredCapacity
-	// newCapacity := currentCapacity + int32(count)
-	// maxCapacity := *result.AutoScalingGroups[0].MaxSize
-	//
-	// if newCapacity > maxCapacity {
-	//     newCapacity = maxCapacity
-	// }
-	//
-	// updateInput := &autoscaling.SetDesiredCapacityInput{
-	//     AutoScalingGroupName: aws.String(asgName),
-	//     DesiredCapacity:      aws.Int32(newCapacity),
-	// }
-	// _, err = p.asgClient.SetDesiredCapacity(ctx, updateInput)
-	// return err
+	if asg.DesiredCapacity >= asg.MaxSize {
+		return NewProviderError(ProviderErrorPoolAtMaxSize, nodePool.Name,
+			fmt.Errorf("ASG %s is already at max size %d", asg.Name, asg.MaxSize))
+	}
+
+	// Ensure new capacity doesn't exceed max size
+	newCapacity := asg.DesiredCapacity + int32(count)
+	if newCapacity > asg.MaxSize {
+		newCapacity = asg.MaxSize
+	}
+
+	// An ASG with a mixed instances policy launches the pool's instance types. Falling back narrows
+	// them to a single type, and the next scale-up of the pool as configured restores the full list.
+	if len(asg.InstanceTypes) > 0 && len(nodePool.InstanceTypes) > 0 && !sameInstanceTypes(asg.InstanceTypes, nodePool.InstanceTypes) {
+		if err := p.asgClient.SetInstanceTypes(ctx, asg.Name, nodePool.InstanceTypes); err != nil {
+			return fmt.Errorf("failed to set instance types of ASG %s: %w", asg.Name, err)
+		}
+	}
+
+	if err := p.asgClient.SetDesiredCapacity(ctx, asg.Name, newCapacity); err != nil {
+		return fmt.Errorf("failed to set desired capacity of ASG %s: %w", asg.Name, classifyProviderError(nodePool, err))
+	}
 
 	return nil
 }
 
-// ScaleDown removes a node from AWS
+// GetScaleUpOverrides narrows instance types through the ASG's mixed instances policy. An ASG's zones
+// come from its subnets and are not narrowed.
+func (p *AWSProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{InstanceType: true}
+}
+
+// CheckScaleUp returns the error of the ASG's latest instance launch since the given time, if it failed.
+// SetDesiredCapacity succeeds before any instance launches, so unavailable capacity only shows up in the
+// ASG's scaling activities. The missing nodes are taken off the desired capacity so the ASG stops retrying
+// them; instances it already has are kept.
+func (p *AWSProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	if p.asgClient == nil {
+		return nil, fmt.Errorf("AWS Auto Scaling client not configured")
+	}
+
+	activities, err := p.asgClient.DescribeScalingActivities(ctx, nodePool.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe scaling activities of ASG %s: %w", nodePool.Name, err)
+	}
+
+	var latest *ScalingActivity
+	for i := range activities {
+		activity := &activities[i]
+		if !strings.HasPrefix(activity.Description, "Launching") || activity.StartTime.Before(since) {
+			continue
+		}
+		if latest == nil || activity.StartTime.After(latest.StartTime) {
+			latest = activity
+		}
+	}
+	if latest == nil || latest.StatusCode != awsActivityStatusFailed {
+		return nil, nil
+	}
+
+	providerErr, ok := AsProviderError(classifyProviderError(nodePool, fmt.Errorf("ASG %s failed to launch an instance: %s", nodePool.Name, latest.StatusMessage)))
+	if !ok {
+		return nil, nil
+	}
+	if match := awsCapacityMessage.FindStringSubmatch(latest.StatusMessage); match != nil {
+		providerErr.InstanceType = match[1]
+		providerErr.Zone = match[2]
+	}
+
+	asg, err := p.asgClient.DescribeAutoScalingGroup(ctx, nodePool.Name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ASG %s: %w", nodePool.Name, err)
+	}
+	desiredCapacity := asg.DesiredCapacity - int32(missing)
+	if floor := max(asg.MinSize, int32(len(asg.Instances))); desiredCapacity < floor {
+		desiredCapacity = floor
+	}
+	if desiredCapacity < asg.DesiredCapacity {
+		if err := p.asgClient.SetDesiredCapacity(ctx, asg.Name, desiredCapacity); err != nil {
+			return nil, fmt.Errorf("failed to set desired capacity of ASG %s: %w", asg.Name, err)
+		}
+	}
+	return providerErr, nil
+}
+
+// ScaleDown terminates the instance backing a node and decrements its ASG's desired capacity
 func (p *AWSProvider) ScaleDown(ctx context.Context, nodeName string) error {
-	// In production, this would:
-	// 1. Get instance ID from node name
-	// 2. Terminate specific instance in ASG
-	// 3. ASG will automatically adjust desired capacity
+	if p.asgClient == nil {
+		return fmt.Errorf("AWS Auto Scaling client not configured")
+	}
 
-	fmt.Printf("AWS: Scaling down node %s\n", nodeName)
+	instanceID, err := p.getInstanceIDFromNodeName(ctx, nodeName)
+	if err != nil {
+		return err
+	}
 
-	// Example implementation:
-	// instanceID := p.getInstanceIDFromNodeName(nodeName)
-	// terminateInput := &autoscaling.TerminateInstanceInAutoScalingGroupInput{
-	//     InstanceId:                     aws.String(instanceID),
-	//     ShouldDecrementDesiredCapacity: aws.Bool(true),
-	// }
-	// _, err := p.asgClient.TerminateInstanceInAutoScalingGroup(ctx, terminateInput)
-	// return err
+	// Decrementing desired capacity stops the ASG from replacing the instance
+	if err := p.asgClient.TerminateInstanceInAutoScalingGroup(ctx, instanceID, true); err != nil {
+		return fmt.Errorf("failed to terminate instance %s: %w", instanceID, err)
+	}
 
 	return nil
 }
 
-// GetSpotTerminationNotice checks EC2 metadata for spot termination notice
+// GetSpotTerminationNotice checks the node's spot instance request for an interruption notice.
+// EC2 marks the request before reclaiming the instance, two minutes ahead of the interruption.
 func (p *AWSProvider) GetSpotTerminationNotice(ctx context.Context, nodeName string) (time.Time, bool, error) {
-	// In production, this would query EC2 metadata service from the node:
-	// http://169.254.169.254/latest/meta-data/spot/instance-action
-	//
-	// AWS gives 2-minute warning before spot termination
-	// Response format: {"action": "terminate", "time": "2023-11-01T12:00:00Z"}
+	if p.ec2Client == nil {
+		return time.Time{}, false, fmt.Errorf("AWS EC2 client not configured")
+	}
 
-	// For now, return no termination notice
-	return time.Time{}, false, nil
+	instance, err := p.describeInstanceForNode(ctx, nodeName)
+	if err != nil {
+		return time.Time{}, false, err
+	}
 
-	// Example implementation:
-	// instanceID := p.getInstanceIDFromNodeName(nodeName)
-	//
-	// // Query instance metadata
-	// describeInput := &ec2.DescribeInstancesInput{
-	//     InstanceIds: []string{instanceID},
-	// }
-	// result, err := p.ec2Client.DescribeInstances(ctx, describeInput)
-	// if err != nil {
-	//     return time.Time{}, false, err
-	// }
-	//
-	// // Check instance state for spot termination
-	// instance := result.Reservations[0].Instances[0]
-	// if instance.InstanceLifecycle != nil &&
-	//    *instance.InstanceLifecycle == "spot" &&
-	//    instance.StateTransitionReason != nil {
-	//     // Parse termination time from state transition
-	//     terminationTime, _ := time.Parse(time.RFC3339, *instance.StateTransitionReason)
-	//     return terminationTime, true, nil
-	// }
-	//
-	// return time.Time{}, false, nil
+	if instance.InstanceLifecycle != awsInstanceLifecycleSpot || instance.SpotInstanceRequestID == "" {
+		return time.Time{}, false, nil
+	}
+
+	request, err := p.ec2Client.DescribeSpotInstanceRequest(ctx, instance.SpotInstanceRequestID)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to describe spot request %s: %w", instance.SpotInstanceRequestID, err)
+	}
+
+	switch request.StatusCode {
+	case awsSpotStatusMarkedForTermination, awsSpotStatusMarkedForStop, awsSpotStatusMarkedForHibernation:
+		return request.StatusUpdateTime.Add(awsSpotInterruptionWarning), true, nil
+	default:
+		return time.Time{}, false, nil
+	}
 }
 
 // GetSpotPrice returns current spot price from AWS
@@ -257,41 +417,121 @@ func (p *AWSProvider) GetAvailabilityZones(ctx context.Context) ([]string, error
 
 // GetNodePoolInfo returns information about an AWS Auto Scaling Group
 func (p *AWSProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
-	// In production, this would query ASG details
+	if p.asgClient == nil {
+		return nil, fmt.Errorf("AWS Auto Scaling client not configured")
+	}
 
-	// Example implementation:
-	// describeInput := &autoscaling.DescribeAutoScalingGroupsInput{
-	//     AutoScalingGroupNames: []string{nodePoolName},
-	// }
-	// result, err := p.asgClient.DescribeAutoScalingGroups(ctx, describeInput)
-	// if err != nil {
-	//     return nil, err
-	// }
-	//
-	// asg := result.AutoScalingGroups[0]
-	// return &NodePoolInfo{
-	//     Name:        *asg.AutoScalingGroupName,
-	//     CurrentSize: int(*asg.DesiredCapacity),
-	//     MinSize:     int(*asg.MinSize),
-	//     MaxSize:     int(*asg.MaxSize),
-	//     // Parse instance type from launch template/config
-	// }, nil
-
-	return &NodePoolInfo{
-		Name:        nodePoolName,
-		CurrentSize: 0,
-		MinSize:     0,
-		MaxSize:     100,
-	}, nil
+	asg, err := p.asgClient.DescribeAutoScalingGroup(ctx, nodePoolName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe ASG %s: %w", nodePoolName, err)
+	}
+
+	info := &NodePoolInfo{
+		Name:         asg.Name,
+		CurrentSize:  int(asg.DesiredCapacity),
+		MinSize:      int(asg.MinSize),
+		MaxSize:      int(asg.MaxSize),
+		CapacityType: CapacityTypeOnDemand,
+	}
+
+	if len(asg.InstanceTypes) > 0 {
+		info.InstanceType = asg.InstanceTypes[0]
+	}
+
+	// Report the pool as spot when the majority of its instances are spot
+	spotInstances := 0
+	for _, instance := range asg.Instances {
+		if instance.Spot {
+			spotInstances++
+		}
+		if info.InstanceType == "" {
+			info.InstanceType = instance.InstanceType
+		}
+	}
+	if len(asg.Instances) > 0 && spotInstances*2 > len(asg.Instances) {
+		info.CapacityType = CapacityTypeSpot
+	}
+
+	// Price the ASG's desired capacity, at the spot rate when it runs mostly spot instances
+	if info.InstanceType != "" {
+		price, err := p.GetOnDemandPrice(ctx, info.InstanceType)
+		if info.CapacityType == CapacityTypeSpot {
+			price, err = p.GetSpotPrice(ctx, info.InstanceType)
+		}
+		if err == nil {
+			info.Cost = price * float64(info.CurrentSize)
+		}
+	}
+
+	return info, nil
+}
+
+// GetNodePoolForNode returns the Auto Scaling Group that launched the node's instance
+func (p *AWSProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	if p.ec2Client == nil {
+		return "", fmt.Errorf("AWS EC2 client not configured")
+	}
+
+	instance, err := p.describeInstanceForNode(ctx, nodeName)
+	if err != nil {
+		return "", err
+	}
+	return instance.AutoScalingGroupName, nil
 }
 
 // Helper methods
 
-func (p *AWSProvider) getInstanceIDFromNodeName(nodeName string) string {
-	// Parse instance ID from node name
-	// Node names typically include instance ID
-	// Example: ip-10-0-1-123.us-west-2.compute.internal → i-1234567890abcdef0
+// sameInstanceTypes reports whether two lists hold the same instance types in any order
+func sameInstanceTypes(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sorted := append([]string(nil), a...)
+	sort.Strings(sorted)
+	other := append([]string(nil), b...)
+	sort.Strings(other)
+	for i := range sorted {
+		if sorted[i] != other[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// getInstanceIDFromNodeName resolves the EC2 instance ID backing a node.
+// Nodes named after their instance ID are used directly; otherwise the name is treated as the private DNS name.
+func (p *AWSProvider) getInstanceIDFromNodeName(ctx context.Context, nodeName string) (string, error) {
+	if strings.HasPrefix(nodeName, "i-") {
+		return strings.SplitN(nodeName, ".", 2)[0], nil
+	}
+
+	if p.ec2Client == nil {
+		return "", fmt.Errorf("AWS EC2 client not configured")
+	}
+
+	instance, err := p.ec2Client.DescribeInstanceByPrivateDNSName(ctx, nodeName)
+	if err != nil {
+		return "", fmt.Errorf("failed to find instance for node %s: %w", nodeName, err)
+	}
+
+	return instance.InstanceID, nil
+}
+
+// describeInstanceForNode returns the EC2 instance backing a node
+func (p *AWSProvider) describeInstanceForNode(ctx context.Context, nodeName string) (*EC2Instance, error) {
+	if strings.HasPrefix(nodeName, "i-") {
+		instanceID := strings.SplitN(nodeName, ".", 2)[0]
+		instance, err := p.ec2Client.DescribeInstance(ctx, instanceID)
+		if err != nil {
+			return nil, fmt.Errorf("failed to describe instance %s: %w", instanceID, err)
+		}
+		return instance, nil
+	}
+
+	instance, err := p.ec2Client.DescribeInstanceByPrivateDNSName(ctx, nodeName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find instance for node %s: %w", nodeName, err)
+	}
 
-	// This would use AWS SDK to map node name to instance ID
-	return "i-1234567890abcdef0"
+	return instance, nil
 }
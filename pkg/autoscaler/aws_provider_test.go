@@ -0,0 +1,419 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeAWS is an in-memory Auto Scaling Group and EC2 backend
+type fakeAWS struct {
+	groups       map[string]*AutoScalingGroup
+	instances    map[string]*EC2Instance
+	spotRequests map[string]*SpotInstanceRequest
+	activities   map[string][]ScalingActivity
+}
+
+func newFakeAWS() *fakeAWS {
+	return &fakeAWS{
+		groups:       make(map[string]*AutoScalingGroup),
+		instances:    make(map[string]*EC2Instance),
+		spotRequests: make(map[string]*SpotInstanceRequest),
+		activities:   make(map[string][]ScalingActivity),
+	}
+}
+
+func (f *fakeAWS) addInstance(group string, instance EC2Instance) {
+	f.instances[instance.InstanceID] = &instance
+	asg := f.groups[group]
+	asg.Instances = append(asg.Instances, AutoScalingInstance{
+		InstanceID:     instance.InstanceID,
+		InstanceType:   instance.InstanceType,
+		LifecycleState: "InService",
+		Spot:           instance.InstanceLifecycle == awsInstanceLifecycleSpot,
+	})
+}
+
+func (f *fakeAWS) DescribeAutoScalingGroup(ctx context.Context, name string) (*AutoScalingGroup, error) {
+	asg, ok := f.groups[name]
+	if !ok {
+		return nil, fmt.Errorf("auto scaling group %s not found", name)
+	}
+	copied := *asg
+	return &copied, nil
+}
+
+func (f *fakeAWS) SetDesiredCapacity(ctx context.Context, name string, desiredCapacity int32) error {
+	asg, ok := f.groups[name]
+	if !ok {
+		return fmt.Errorf("auto scaling group %s not found", name)
+	}
+	if desiredCapacity < asg.MinSize || desiredCapacity > asg.MaxSize {
+		return fmt.Errorf("desired capacity %d outside [%d, %d]", desiredCapacity, asg.MinSize, asg.MaxSize)
+	}
+	asg.DesiredCapacity = desiredCapacity
+	return nil
+}
+
+func (f *fakeAWS) SetInstanceTypes(ctx context.Context, name string, instanceTypes []string) error {
+	asg, ok := f.groups[name]
+	if !ok {
+		return fmt.Errorf("auto scaling group %s not found", name)
+	}
+	asg.InstanceTypes = append([]string(nil), instanceTypes...)
+	return nil
+}
+
+func (f *fakeAWS) DescribeScalingActivities(ctx context.Context, name string) ([]ScalingActivity, error) {
+	if _, ok := f.groups[name]; !ok {
+		return nil, fmt.Errorf("auto scaling group %s not found", name)
+	}
+	return f.activities[name], nil
+}
+
+func (f *fakeAWS) TerminateInstanceInAutoScalingGroup(ctx context.Context, instanceID string, decrementDesiredCapacity bool) error {
+	for _, asg := range f.groups {
+		for i, instance := range asg.Instances {
+			if instance.InstanceID != instanceID {
+				continue
+			}
+			if decrementDesiredCapacity {
+				if asg.DesiredCapacity-1 < asg.MinSize {
+					return fmt.Errorf("cannot decrement below min size %d", asg.MinSize)
+				}
+				asg.DesiredCapacity--
+			}
+			asg.Instances = append(asg.Instances[:i], asg.Instances[i+1:]...)
+			delete(f.instances, instanceID)
+			return nil
+		}
+	}
+	return fmt.Errorf("instance %s not found in any auto scaling group", instanceID)
+}
+
+func (f *fakeAWS) DescribeInstanceByPrivateDNSName(ctx context.Context, privateDNSName string) (*EC2Instance, error) {
+	for _, instance := range f.instances {
+		if instance.PrivateDNSName == privateDNSName {
+			return instance, nil
+		}
+	}
+	return nil, fmt.Errorf("no instance with private DNS name %s", privateDNSName)
+}
+
+func (f *fakeAWS) DescribeInstance(ctx context.Context, instanceID string) (*EC2Instance, error) {
+	instance, ok := f.instances[instanceID]
+	if !ok {
+		return nil, fmt.Errorf("instance %s not found", instanceID)
+	}
+	return instance, nil
+}
+
+func (f *fakeAWS) DescribeSpotInstanceRequest(ctx context.Context, requestID string) (*SpotInstanceRequest, error) {
+	request, ok := f.spotRequests[requestID]
+	if !ok {
+		return nil, fmt.Errorf("spot request %s not found", requestID)
+	}
+	return request, nil
+}
+
+func TestAWSProviderScaleUp(t *testing.T) {
+	tests := []struct {
+		name            string
+		desired         int32
+		count           int
+		expectedDesired int32
+		expectError     bool
+		expectAtMaxSize bool
+	}{
+		{
+			name:            "Scale up within max size",
+			desired:         2,
+			count:           1,
+			expectedDesired: 3,
+		},
+		{
+			name:            "Scale up is capped at max size",
+			desired:         2,
+			count:           5,
+			expectedDesired: 4,
+		},
+		{
+			name:            "Scale up at max size",
+			desired:         4,
+			count:           1,
+			expectedDesired: 4,
+			expectError:     true,
+			expectAtMaxSize: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAWS()
+			fake.groups["gpu-spot"] = &AutoScalingGroup{Name: "gpu-spot", MaxSize: 4, DesiredCapacity: tt.desired, InstanceTypes: []string{"p3.2xlarge"}}
+			for i := 1; i <= 2; i++ {
+				requestID := fmt.Sprintf("sir-%d", i)
+				fake.addInstance("gpu-spot", EC2Instance{
+					InstanceID:            fmt.Sprintf("i-000000000000000%d", i),
+					InstanceType:          "p3.2xlarge",
+					PrivateDNSName:        fmt.Sprintf("ip-10-0-1-%d.us-west-2.compute.internal", i),
+					InstanceLifecycle:     awsInstanceLifecycleSpot,
+					SpotInstanceRequestID: requestID,
+				})
+				fake.spotRequests[requestID] = &SpotInstanceRequest{RequestID: requestID, State: "active", StatusCode: "fulfilled"}
+			}
+			provider := NewAWSProviderWithClients("us-west-2", fake, fake)
+
+			err := provider.ScaleUp(context.Background(), &NodePoolConfig{Name: "gpu-spot"}, tt.count)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("ScaleUp() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectAtMaxSize && !IsPoolAtMaxSize(err) {
+				t.Errorf("Expected a pool-at-max-size error, got %v", err)
+			}
+			if got := fake.groups["gpu-spot"].DesiredCapacity; got != tt.expectedDesired {
+				t.Errorf("Expected desired capacity %d, got %d", tt.expectedDesired, got)
+			}
+		})
+	}
+}
+
+func TestAWSProviderScaleUpNarrowsInstanceTypes(t *testing.T) {
+	fake := newFakeAWS()
+	fake.groups["gpu-a100"] = &AutoScalingGroup{Name: "gpu-a100", MaxSize: 8, InstanceTypes: []string{"p4d.24xlarge", "p4de.24xlarge"}}
+	provider := NewAWSProviderWithClients("us-east-1", fake, fake)
+	pool := &NodePoolConfig{Name: "gpu-a100", InstanceTypes: []string{"p4d.24xlarge", "p4de.24xlarge"}}
+
+	if !provider.GetScaleUpOverrides(pool).InstanceType || provider.GetScaleUpOverrides(pool).Zone {
+		t.Errorf("Expected ASGs to be narrowed by instance type only, got %+v", provider.GetScaleUpOverrides(pool))
+	}
+
+	// A fallback narrowed to one instance type narrows the mixed instances policy
+	if err := provider.ScaleUp(context.Background(), &NodePoolConfig{Name: "gpu-a100", InstanceTypes: []string{"p4de.24xlarge"}}, 1); err != nil {
+		t.Fatalf("ScaleUp() error = %v", err)
+	}
+	if got := fake.groups["gpu-a100"].InstanceTypes; len(got) != 1 || got[0] != "p4de.24xlarge" {
+		t.Errorf("Expected the ASG narrowed to p4de.24xlarge, got %v", got)
+	}
+
+	// Scaling up the pool as configured restores its instance types
+	if err := provider.ScaleUp(context.Background(), pool, 1); err != nil {
+		t.Fatalf("ScaleUp() error = %v", err)
+	}
+	if got := fake.groups["gpu-a100"].InstanceTypes; !sameInstanceTypes(got, pool.InstanceTypes) {
+		t.Errorf("Expected the ASG's instance types restored, got %v", got)
+	}
+	if got := fake.groups["gpu-a100"].DesiredCapacity; got != 2 {
+		t.Errorf("Expected desired capacity 2, got %d", got)
+	}
+}
+
+func TestAWSProviderCheckScaleUp(t *testing.T) {
+	requested := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	failed := ScalingActivity{
+		Description: "Launching a new EC2 instance.  Status Reason: We currently do not have sufficient p4d.24xlarge capacity.",
+		StatusCode:  awsActivityStatusFailed,
+		StatusMessage: "We currently do not have sufficient p4d.24xlarge capacity in the Availability Zone you requested (us-east-1a). " +
+			"Our system will be working on provisioning additional capacity. Launching EC2 instance failed.",
+		StartTime: requested.Add(30 * time.Second),
+	}
+	succeeded := ScalingActivity{Description: "Launching a new EC2 instance: i-0000000000000002", StatusCode: "Successful", StartTime: requested.Add(time.Minute)}
+	earlier := failed
+	earlier.StartTime = requested.Add(-time.Hour)
+
+	tests := []struct {
+		name            string
+		activities      []ScalingActivity
+		expectFailure   bool
+		expectedDesired int32
+	}{
+		{name: "launch failed for capacity", activities: []ScalingActivity{failed}, expectFailure: true, expectedDesired: 1},
+		{name: "a later launch succeeded", activities: []ScalingActivity{succeeded, failed}, expectedDesired: 3},
+		{name: "failure before the request", activities: []ScalingActivity{earlier}, expectedDesired: 3},
+		{name: "no activities", expectedDesired: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAWS()
+			fake.groups["gpu-a100"] = &AutoScalingGroup{Name: "gpu-a100", MaxSize: 8, DesiredCapacity: 3}
+			fake.addInstance("gpu-a100", EC2Instance{InstanceID: "i-0000000000000001", InstanceType: "p4d.24xlarge"})
+			fake.activities["gpu-a100"] = tt.activities
+			provider := NewAWSProviderWithClients("us-east-1", fake, fake)
+
+			providerErr, err := provider.CheckScaleUp(context.Background(), &NodePoolConfig{Name: "gpu-a100"}, requested, 2)
+			if err != nil {
+				t.Fatalf("CheckScaleUp() error = %v", err)
+			}
+			if (providerErr != nil) != tt.expectFailure {
+				t.Fatalf("Expected failure %v, got %v", tt.expectFailure, providerErr)
+			}
+			if tt.expectFailure {
+				if providerErr.Reason != ProviderErrorInsufficientCapacity || providerErr.InstanceType != "p4d.24xlarge" || providerErr.Zone != "us-east-1a" {
+					t.Errorf("Expected insufficient p4d.24xlarge capacity in us-east-1a, got %s for %q in %q",
+						providerErr.Reason, providerErr.InstanceType, providerErr.Zone)
+				}
+			}
+			// The failed nodes are taken off the desired capacity; the running instance is kept
+			if got := fake.groups["gpu-a100"].DesiredCapacity; got != tt.expectedDesired {
+				t.Errorf("Expected desired capacity %d, got %d", tt.expectedDesired, got)
+			}
+		})
+	}
+}
+
+func TestAWSProviderScaleDown(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodeName string
+	}{
+		{
+			name:     "Node named by private DNS",
+			nodeName: "ip-10-0-1-1.us-west-2.compute.internal",
+		},
+		{
+			name:     "Node named by instance ID",
+			nodeName: "i-0000000000000001.us-west-2.compute.internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAWS()
+			fake.groups["gpu-spot"] = &AutoScalingGroup{Name: "gpu-spot", MaxSize: 4, DesiredCapacity: 2, InstanceTypes: []string{"p3.2xlarge"}}
+			for i := 1; i <= 2; i++ {
+				requestID := fmt.Sprintf("sir-%d", i)
+				fake.addInstance("gpu-spot", EC2Instance{
+					InstanceID:            fmt.Sprintf("i-000000000000000%d", i),
+					InstanceType:          "p3.2xlarge",
+					PrivateDNSName:        fmt.Sprintf("ip-10-0-1-%d.us-west-2.compute.internal", i),
+					InstanceLifecycle:     awsInstanceLifecycleSpot,
+					SpotInstanceRequestID: requestID,
+				})
+				fake.spotRequests[requestID] = &SpotInstanceRequest{RequestID: requestID, State: "active", StatusCode: "fulfilled"}
+			}
+			provider := NewAWSProviderWithClients("us-west-2", fake, fake)
+
+			if err := provider.ScaleDown(context.Background(), tt.nodeName); err != nil {
+				t.Fatalf("ScaleDown() error = %v", err)
+			}
+
+			asg := fake.groups["gpu-spot"]
+			if asg.DesiredCapacity != 1 {
+				t.Errorf("Expected desired capacity 1, got %d", asg.DesiredCapacity)
+			}
+			if len(asg.Instances) != 1 || asg.Instances[0].InstanceID != "i-0000000000000002" {
+				t.Errorf("Expected only i-0000000000000002 to remain, got %+v", asg.Instances)
+			}
+		})
+	}
+}
+
+func TestAWSProviderGetNodePoolInfo(t *testing.T) {
+	fake := newFakeAWS()
+	fake.groups["gpu-spot"] = &AutoScalingGroup{Name: "gpu-spot", MaxSize: 4, DesiredCapacity: 2, InstanceTypes: []string{"p3.2xlarge"}}
+	for i := 1; i <= 2; i++ {
+		requestID := fmt.Sprintf("sir-%d", i)
+		fake.addInstance("gpu-spot", EC2Instance{
+			InstanceID:            fmt.Sprintf("i-000000000000000%d", i),
+			InstanceType:          "p3.2xlarge",
+			PrivateDNSName:        fmt.Sprintf("ip-10-0-1-%d.us-west-2.compute.internal", i),
+			InstanceLifecycle:     awsInstanceLifecycleSpot,
+			SpotInstanceRequestID: requestID,
+		})
+		fake.spotRequests[requestID] = &SpotInstanceRequest{RequestID: requestID, State: "active", StatusCode: "fulfilled"}
+	}
+	provider := NewAWSProviderWithClients("us-west-2", fake, fake)
+
+	info, err := provider.GetNodePoolInfo(context.Background(), "gpu-spot")
+	if err != nil {
+		t.Fatalf("GetNodePoolInfo() error = %v", err)
+	}
+
+	if info.CurrentSize != 2 || info.MaxSize != 4 {
+		t.Errorf("Unexpected sizes: current=%d max=%d", info.CurrentSize, info.MaxSize)
+	}
+	if info.InstanceType != "p3.2xlarge" || info.CapacityType != CapacityTypeSpot {
+		t.Errorf("Unexpected pool info: %+v", info)
+	}
+	if info.Cost != 2.40 {
+		t.Errorf("Expected hourly cost 2.40, got %.2f", info.Cost)
+	}
+
+	if _, err := provider.GetNodePoolInfo(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for unknown ASG")
+	}
+}
+
+func TestAWSProviderGetNodePoolForNode(t *testing.T) {
+	fake := newFakeAWS()
+	fake.groups["gpu-spot"] = &AutoScalingGroup{Name: "gpu-spot", MaxSize: 4, DesiredCapacity: 1}
+	fake.addInstance("gpu-spot", EC2Instance{
+		InstanceID:           "i-0000000000000001",
+		PrivateDNSName:       "ip-10-0-1-1.us-west-2.compute.internal",
+		AutoScalingGroupName: "gpu-spot",
+	})
+	fake.instances["i-0000000000000002"] = &EC2Instance{
+		InstanceID:     "i-0000000000000002",
+		PrivateDNSName: "ip-10-0-1-2.us-west-2.compute.internal",
+	}
+	provider := NewAWSProviderWithClients("us-west-2", fake, fake)
+
+	tests := []struct {
+		nodeName string
+		expected string
+	}{
+		{"ip-10-0-1-1.us-west-2.compute.internal", "gpu-spot"},
+		{"ip-10-0-1-2.us-west-2.compute.internal", ""},
+	}
+
+	for _, tt := range tests {
+		got, err := provider.GetNodePoolForNode(context.Background(), tt.nodeName)
+		if err != nil {
+			t.Fatalf("GetNodePoolForNode(%s) error = %v", tt.nodeName, err)
+		}
+		if got != tt.expected {
+			t.Errorf("GetNodePoolForNode(%s) = %q, expected %q", tt.nodeName, got, tt.expected)
+		}
+	}
+}
+
+func TestAWSProviderGetSpotTerminationNotice(t *testing.T) {
+	fake := newFakeAWS()
+	fake.groups["gpu-spot"] = &AutoScalingGroup{Name: "gpu-spot", MaxSize: 4, DesiredCapacity: 2, InstanceTypes: []string{"p3.2xlarge"}}
+	for i := 1; i <= 2; i++ {
+		requestID := fmt.Sprintf("sir-%d", i)
+		fake.addInstance("gpu-spot", EC2Instance{
+			InstanceID:            fmt.Sprintf("i-000000000000000%d", i),
+			InstanceType:          "p3.2xlarge",
+			PrivateDNSName:        fmt.Sprintf("ip-10-0-1-%d.us-west-2.compute.internal", i),
+			InstanceLifecycle:     awsInstanceLifecycleSpot,
+			SpotInstanceRequestID: requestID,
+		})
+		fake.spotRequests[requestID] = &SpotInstanceRequest{RequestID: requestID, State: "active", StatusCode: "fulfilled"}
+	}
+	provider := NewAWSProviderWithClients("us-west-2", fake, fake)
+	markedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake.spotRequests["sir-1"].StatusCode = awsSpotStatusMarkedForTermination
+	fake.spotRequests["sir-1"].StatusUpdateTime = markedAt
+
+	terminationTime, hasNotice, err := provider.GetSpotTerminationNotice(context.Background(), "ip-10-0-1-1.us-west-2.compute.internal")
+	if err != nil {
+		t.Fatalf("GetSpotTerminationNotice() error = %v", err)
+	}
+	if !hasNotice {
+		t.Fatal("Expected a termination notice")
+	}
+	if !terminationTime.Equal(markedAt.Add(2 * time.Minute)) {
+		t.Errorf("Expected termination at %s, got %s", markedAt.Add(2*time.Minute), terminationTime)
+	}
+
+	_, hasNotice, err = provider.GetSpotTerminationNotice(context.Background(), "ip-10-0-1-2.us-west-2.compute.internal")
+	if err != nil {
+		t.Fatalf("GetSpotTerminationNotice() error = %v", err)
+	}
+	if hasNotice {
+		t.Error("Expected no termination notice for a fulfilled spot request")
+	}
+}
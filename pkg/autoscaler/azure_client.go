@@ -0,0 +1,352 @@
+package autoscaler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	// azureComputeAPIVersion is the Microsoft.Compute API version used for scale sets
+	azureComputeAPIVersion = "2023-09-01"
+
+	// azureARMEndpoint is the Azure Resource Manager API of the public cloud
+	azureARMEndpoint = "https://management.azure.com"
+
+	// azureIMDSEndpoint serves managed identity tokens and Scheduled Events
+	azureIMDSEndpoint = "http://169.254.169.254"
+
+	// azureAuthorityHost is the Microsoft Entra ID endpoint workload identity tokens are exchanged with
+	azureAuthorityHost = "https://login.microsoftonline.com/"
+)
+
+// AzureVMSSClient implements AzureVMSSAPI on top of the Azure Resource Manager REST API.
+// Requests carry a token from Azure Workload Identity when its federated token is projected
+// into the pod (AZURE_FEDERATED_TOKEN_FILE, AZURE_CLIENT_ID, AZURE_TENANT_ID), and otherwise
+// from the node's managed identity through IMDS.
+type AzureVMSSClient struct {
+	subscriptionID string
+	httpClient     *http.Client
+
+	// Endpoints, overridden in tests
+	armEndpoint   string
+	imdsEndpoint  string
+	authorityHost string
+
+	token oauthToken
+}
+
+// NewAzureVMSSClient creates a scale set client for a subscription; httpClient is http.DefaultClient when nil
+func NewAzureVMSSClient(subscriptionID string, httpClient *http.Client) (*AzureVMSSClient, error) {
+	if subscriptionID == "" {
+		return nil, fmt.Errorf("Azure subscription ID is required")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	authorityHost := os.Getenv("AZURE_AUTHORITY_HOST")
+	if authorityHost == "" {
+		authorityHost = azureAuthorityHost
+	}
+	return &AzureVMSSClient{
+		subscriptionID: subscriptionID,
+		httpClient:     httpClient,
+		armEndpoint:    azureARMEndpoint,
+		imdsEndpoint:   azureIMDSEndpoint,
+		authorityHost:  strings.TrimSuffix(authorityHost, "/") + "/",
+	}, nil
+}
+
+// azureAPIError is an error returned by Azure Resource Manager
+type azureAPIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *azureAPIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the ARM error code
+func (e *azureAPIError) ErrorCode() string {
+	return e.Code
+}
+
+// GetVMSS returns a VM Scale Set and its instances by name
+func (c *AzureVMSSClient) GetVMSS(ctx context.Context, resourceGroup, name string) (*VirtualMachineScaleSet, error) {
+	var out struct {
+		Name string `json:"name"`
+		SKU  struct {
+			Name     string `json:"name"`
+			Capacity int64  `json:"capacity"`
+		} `json:"sku"`
+		Zones      []string          `json:"zones"`
+		Tags       map[string]string `json:"tags"`
+		Properties struct {
+			VirtualMachineProfile struct {
+				Priority string `json:"priority"`
+			} `json:"virtualMachineProfile"`
+		} `json:"properties"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.vmssURL(resourceGroup, name, ""), nil, &out); err != nil {
+		return nil, err
+	}
+
+	vmss := &VirtualMachineScaleSet{
+		Name:     out.Name,
+		SKU:      out.SKU.Name,
+		Capacity: out.SKU.Capacity,
+		Priority: out.Properties.VirtualMachineProfile.Priority,
+		Zones:    out.Zones,
+		Tags:     out.Tags,
+	}
+
+	// Instances are listed in pages linked by nextLink
+	for next := c.vmssURL(resourceGroup, name, "/virtualMachines"); next != ""; {
+		var page struct {
+			Value []struct {
+				InstanceID string `json:"instanceId"`
+				Properties struct {
+					ProvisioningState string `json:"provisioningState"`
+					OSProfile         struct {
+						ComputerName string `json:"computerName"`
+					} `json:"osProfile"`
+				} `json:"properties"`
+			} `json:"value"`
+			NextLink string `json:"nextLink"`
+		}
+		if err := c.do(ctx, http.MethodGet, next, nil, &page); err != nil {
+			return nil, fmt.Errorf("failed to list instances of VMSS %s: %w", name, err)
+		}
+		for _, instance := range page.Value {
+			vmss.Instances = append(vmss.Instances, VMSSInstance{
+				InstanceID:        instance.InstanceID,
+				ComputerName:      instance.Properties.OSProfile.ComputerName,
+				ProvisioningState: instance.Properties.ProvisioningState,
+			})
+		}
+		next = page.NextLink
+	}
+
+	return vmss, nil
+}
+
+// SetCapacity sets the SKU capacity of a VM Scale Set. Allocation errors that ARM reports
+// when accepting the update are returned; later allocation failures surface as instances
+// that fail to provision.
+func (c *AzureVMSSClient) SetCapacity(ctx context.Context, resourceGroup, name string, capacity int64) error {
+	body := map[string]interface{}{
+		"sku": map[string]interface{}{"capacity": capacity},
+	}
+	return c.do(ctx, http.MethodPatch, c.vmssURL(resourceGroup, name, ""), body, nil)
+}
+
+// DeleteInstances deletes instances from a VM Scale Set, which lowers its capacity
+func (c *AzureVMSSClient) DeleteInstances(ctx context.Context, resourceGroup, name string, instanceIDs []string) error {
+	body := map[string]interface{}{"instanceIds": instanceIDs}
+	return c.do(ctx, http.MethodPost, c.vmssURL(resourceGroup, name, "/delete"), body, nil)
+}
+
+// GetScheduledEvents returns the Scheduled Events pending for a scale set instance.
+// Scheduled Events are served by IMDS to the VMs of a scale set, so only events of the
+// scale set the controller runs in are visible.
+func (c *AzureVMSSClient) GetScheduledEvents(ctx context.Context, resourceGroup, name, instanceID string) ([]AzureScheduledEvent, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.imdsEndpoint+"/metadata/scheduledevents?api-version=2020-07-01", nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	var out struct {
+		Events []struct {
+			EventID     string   `json:"EventId"`
+			EventType   string   `json:"EventType"`
+			EventStatus string   `json:"EventStatus"`
+			Resources   []string `json:"Resources"`
+			NotBefore   string   `json:"NotBefore"`
+		} `json:"Events"`
+	}
+	if err := c.send(req, &out); err != nil {
+		return nil, fmt.Errorf("failed to get scheduled events: %w", err)
+	}
+
+	// Events name scale set VMs as <scale set>_<instance ID>
+	resource := name + "_" + instanceID
+	var events []AzureScheduledEvent
+	for _, event := range out.Events {
+		matches := false
+		for _, r := range event.Resources {
+			matches = matches || strings.EqualFold(r, resource)
+		}
+		if !matches {
+			continue
+		}
+
+		scheduled := AzureScheduledEvent{
+			EventID:     event.EventID,
+			EventType:   event.EventType,
+			EventStatus: event.EventStatus,
+			Resources:   event.Resources,
+		}
+		if event.NotBefore != "" {
+			if scheduled.NotBefore, err = time.Parse(time.RFC1123, event.NotBefore); err != nil {
+				return nil, fmt.Errorf("failed to parse NotBefore of event %s: %w", event.EventID, err)
+			}
+		}
+		events = append(events, scheduled)
+	}
+	return events, nil
+}
+
+// vmssURL builds the ARM URL of a scale set, or of one of its child resources or actions
+func (c *AzureVMSSClient) vmssURL(resourceGroup, name, suffix string) string {
+	return fmt.Sprintf("%s/subscriptions/%s/resourceGroups/%s/providers/Microsoft.Compute/virtualMachineScaleSets/%s%s?api-version=%s",
+		c.armEndpoint, url.PathEscape(c.subscriptionID), url.PathEscape(resourceGroup), url.PathEscape(name), suffix, azureComputeAPIVersion)
+}
+
+// do sends an authenticated ARM request and decodes the JSON response into out
+func (c *AzureVMSSClient) do(ctx context.Context, method, requestURL string, body, out interface{}) error {
+	token, err := c.token.get(func() (string, time.Time, error) {
+		return c.accessToken(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get Azure access token: %w", err)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.send(req, out)
+}
+
+// send sends a request and decodes its JSON response into out, turning error responses into azureAPIError
+func (c *AzureVMSSClient) send(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", req.Method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned %s: %w", req.Method, req.URL.Path, resp.Status, parseAzureError(resp.StatusCode, data))
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode %s %s response: %w", req.Method, req.URL.Path, err)
+		}
+	}
+	return nil
+}
+
+// parseAzureError extracts the code and message of an ARM error response
+func parseAzureError(statusCode int, data []byte) error {
+	var out struct {
+		Error struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil || out.Error.Code == "" {
+		return &azureAPIError{StatusCode: statusCode, Code: http.StatusText(statusCode), Message: strings.TrimSpace(string(data))}
+	}
+	return providerErrorFromCode(&azureAPIError{StatusCode: statusCode, Code: out.Error.Code, Message: out.Error.Message})
+}
+
+// accessToken returns an ARM access token from workload identity when configured, otherwise from IMDS
+func (c *AzureVMSSClient) accessToken(ctx context.Context) (string, time.Time, error) {
+	tokenFile, clientID, tenantID := os.Getenv("AZURE_FEDERATED_TOKEN_FILE"), os.Getenv("AZURE_CLIENT_ID"), os.Getenv("AZURE_TENANT_ID")
+	if tokenFile != "" && clientID != "" && tenantID != "" {
+		return c.workloadIdentityToken(ctx, tokenFile, clientID, tenantID)
+	}
+	return c.managedIdentityToken(ctx, clientID)
+}
+
+// workloadIdentityToken exchanges the service account token projected by Azure Workload Identity for an ARM token
+func (c *AzureVMSSClient) workloadIdentityToken(ctx context.Context, tokenFile, clientID, tenantID string) (string, time.Time, error) {
+	assertion, err := os.ReadFile(tokenFile)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to read federated token: %w", err)
+	}
+
+	form := url.Values{
+		"grant_type":            {"client_credentials"},
+		"client_id":             {clientID},
+		"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+		"client_assertion":      {strings.TrimSpace(string(assertion))},
+		"scope":                 {azureARMEndpoint + "/.default"},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.authorityHost+url.PathEscape(tenantID)+"/oauth2/v2.0/token",
+		strings.NewReader(form.Encode()))
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := c.send(req, &out); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to exchange federated token: %w", err)
+	}
+	return out.AccessToken, time.Now().Add(time.Duration(out.ExpiresIn) * time.Second), nil
+}
+
+// managedIdentityToken requests an ARM token for the node's managed identity from IMDS.
+// clientID selects a user-assigned identity when the node has several.
+func (c *AzureVMSSClient) managedIdentityToken(ctx context.Context, clientID string) (string, time.Time, error) {
+	query := url.Values{
+		"api-version": {"2018-02-01"},
+		"resource":    {azureARMEndpoint + "/"},
+	}
+	if clientID != "" {
+		query.Set("client_id", clientID)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.imdsEndpoint+"/metadata/identity/oauth2/token?"+query.Encode(), nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata", "true")
+
+	// IMDS returns numbers as strings
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   string `json:"expires_in"`
+	}
+	if err := c.send(req, &out); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to get managed identity token: %w", err)
+	}
+	expiresIn, err := strconv.Atoi(out.ExpiresIn)
+	if err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to parse managed identity token expiry %q: %w", out.ExpiresIn, err)
+	}
+	return out.AccessToken, time.Now().Add(time.Duration(expiresIn) * time.Second), nil
+}
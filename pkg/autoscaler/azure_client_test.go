@@ -0,0 +1,153 @@
+package autoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestAzureVMSSClient(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "azure-identity-token")
+	if err := os.WriteFile(tokenFile, []byte("federated-token\n"), 0o600); err != nil {
+		t.Fatalf("failed to write token file: %v", err)
+	}
+
+	tests := []struct {
+		name          string
+		env           map[string]string
+		expectedToken string
+	}{
+		{
+			name:          "managed identity",
+			env:           map[string]string{"AZURE_CLIENT_ID": "identity-client"},
+			expectedToken: "imds-token",
+		},
+		{
+			name:          "workload identity",
+			env:           map[string]string{"AZURE_CLIENT_ID": "app-client", "AZURE_TENANT_ID": "tenant", "AZURE_FEDERATED_TOKEN_FILE": tokenFile},
+			expectedToken: "entra-token",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for _, key := range []string{"AZURE_CLIENT_ID", "AZURE_TENANT_ID", "AZURE_FEDERATED_TOKEN_FILE"} {
+				t.Setenv(key, tt.env[key])
+			}
+
+			var server *httptest.Server
+			var requests []string
+			server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.URL.Path {
+				case "/metadata/identity/oauth2/token":
+					if r.Header.Get("Metadata") != "true" || r.URL.Query().Get("client_id") != "identity-client" {
+						t.Errorf("Unexpected managed identity token request: %s", r.URL)
+					}
+					fmt.Fprint(w, `{"access_token":"imds-token","expires_in":"86399","token_type":"Bearer"}`)
+					return
+				case "/tenant/oauth2/v2.0/token":
+					if err := r.ParseForm(); err != nil {
+						t.Errorf("failed to parse form: %v", err)
+					}
+					if r.PostForm.Get("client_assertion") != "federated-token" || r.PostForm.Get("client_id") != "app-client" {
+						t.Errorf("Unexpected federated token exchange: %v", r.PostForm)
+					}
+					fmt.Fprint(w, `{"access_token":"entra-token","expires_in":3599,"token_type":"Bearer"}`)
+					return
+				case "/metadata/scheduledevents":
+					fmt.Fprint(w, `{"DocumentIncarnation":2,"Events":[
+{"EventId":"evt-1","EventType":"Preempt","EventStatus":"Scheduled","Resources":["gpu-spot-vmss_3"],"NotBefore":"Fri, 16 Oct 2026 09:00:30 GMT"},
+{"EventId":"evt-2","EventType":"Reboot","EventStatus":"Scheduled","Resources":["gpu-spot-vmss_4"],"NotBefore":""}]}`)
+					return
+				}
+
+				if got := r.Header.Get("Authorization"); got != "Bearer "+tt.expectedToken {
+					t.Errorf("Expected token %s, got Authorization %q", tt.expectedToken, got)
+				}
+				if r.URL.Query().Get("api-version") != azureComputeAPIVersion {
+					t.Errorf("Expected api-version %s, got %q", azureComputeAPIVersion, r.URL.Query().Get("api-version"))
+				}
+				path := strings.TrimPrefix(r.URL.Path, "/subscriptions/sub-1/resourceGroups/gpu-cluster/providers/Microsoft.Compute/virtualMachineScaleSets/")
+				requests = append(requests, r.Method+" "+path)
+
+				switch {
+				case r.Method == http.MethodGet && path == "gpu-spot-vmss":
+					fmt.Fprint(w, `{"name":"gpu-spot-vmss","sku":{"name":"Standard_NC24s_v3","capacity":2},"zones":["1","2"],
+"tags":{"min":"0","max":"8"},"properties":{"virtualMachineProfile":{"priority":"Spot"}}}`)
+				case r.Method == http.MethodGet && path == "gpu-spot-vmss/virtualMachines" && r.URL.Query().Get("page") == "":
+					fmt.Fprintf(w, `{"value":[{"instanceId":"3","properties":{"provisioningState":"Succeeded","osProfile":{"computerName":"gpu-spot-vmss000003"}}}],
+"nextLink":"%s%s&page=2"}`, server.URL, r.URL.RequestURI())
+				case r.Method == http.MethodGet && path == "gpu-spot-vmss/virtualMachines":
+					fmt.Fprint(w, `{"value":[{"instanceId":"4","properties":{"provisioningState":"Creating","osProfile":{"computerName":"gpu-spot-vmss000004"}}}]}`)
+				case r.Method == http.MethodPatch && path == "gpu-spot-vmss":
+					var body struct {
+						SKU struct {
+							Capacity int64 `json:"capacity"`
+						} `json:"sku"`
+					}
+					if err := json.NewDecoder(r.Body).Decode(&body); err != nil || body.SKU.Capacity != 3 {
+						t.Errorf("Expected capacity 3, got %+v (%v)", body, err)
+					}
+					w.WriteHeader(http.StatusConflict)
+					fmt.Fprint(w, `{"error":{"code":"SkuNotAvailable","message":"The requested size is currently not available in location eastus"}}`)
+				case r.Method == http.MethodPost && path == "gpu-spot-vmss/delete":
+					w.WriteHeader(http.StatusAccepted)
+				default:
+					w.WriteHeader(http.StatusNotFound)
+					fmt.Fprint(w, `{"error":{"code":"ResourceNotFound","message":"not found"}}`)
+				}
+			}))
+			defer server.Close()
+
+			client, err := NewAzureVMSSClient("sub-1", server.Client())
+			if err != nil {
+				t.Fatalf("NewAzureVMSSClient() error = %v", err)
+			}
+			client.armEndpoint = server.URL
+			client.imdsEndpoint = server.URL
+			client.authorityHost = server.URL + "/"
+
+			vmss, err := client.GetVMSS(context.Background(), "gpu-cluster", "gpu-spot-vmss")
+			if err != nil {
+				t.Fatalf("GetVMSS() error = %v", err)
+			}
+			if vmss.SKU != "Standard_NC24s_v3" || vmss.Capacity != 2 || vmss.Priority != azurePrioritySpot || vmss.Tags["max"] != "8" {
+				t.Errorf("Unexpected scale set: %+v", vmss)
+			}
+			if len(vmss.Instances) != 2 || vmss.Instances[1].ComputerName != "gpu-spot-vmss000004" || vmss.Instances[1].ProvisioningState != "Creating" {
+				t.Errorf("Unexpected instances: %+v", vmss.Instances)
+			}
+
+			// ARM error codes are classified by the provider
+			provider := NewAzureProviderWithClient("sub-1", "gpu-cluster", "eastus", client)
+			if err := provider.ScaleUp(context.Background(), &NodePoolConfig{Name: "gpu-spot-vmss", CapacityType: CapacityTypeSpot}, 1); !IsSpotUnavailable(err) {
+				t.Errorf("Expected a spot unavailable error, got %v", err)
+			}
+
+			if err := client.DeleteInstances(context.Background(), "gpu-cluster", "gpu-spot-vmss", []string{"3"}); err != nil {
+				t.Errorf("DeleteInstances() error = %v", err)
+			}
+
+			events, err := client.GetScheduledEvents(context.Background(), "gpu-cluster", "gpu-spot-vmss", "3")
+			if err != nil {
+				t.Fatalf("GetScheduledEvents() error = %v", err)
+			}
+			if len(events) != 1 || events[0].EventType != azureEventTypePreempt || !events[0].NotBefore.Equal(time.Date(2026, 10, 16, 9, 0, 30, 0, time.UTC)) {
+				t.Errorf("Unexpected scheduled events: %+v", events)
+			}
+
+			expected := "GET gpu-spot-vmss,GET gpu-spot-vmss/virtualMachines,GET gpu-spot-vmss/virtualMachines," +
+				"GET gpu-spot-vmss,GET gpu-spot-vmss/virtualMachines,GET gpu-spot-vmss/virtualMachines,PATCH gpu-spot-vmss,POST gpu-spot-vmss/delete"
+			if got := strings.Join(requests, ","); got != expected {
+				t.Errorf("Expected requests %s, got %s", expected, got)
+			}
+		})
+	}
+}
@@ -3,20 +3,87 @@ package autoscaler
 import (
 	"context"
 	"fmt"
+	"strconv"
+	"strings"
 	"time"
 )
 
-// AzureProvider implements CloudProvider for Microsoft Azure
+const (
+	// Azure gives at least a 30-second warning before evicting a Spot VM
+	azureSpotEvictionWarning = 30 * time.Second
+
+	// azureDefaultMaxSize bounds scale sets without a max tag
+	azureDefaultMaxSize = 100
+
+	// Scheduled Events event type for Spot evictions
+	azureEventTypePreempt = "Preempt"
+
+	// VMSS priority for Spot VMs
+	azurePrioritySpot = "Spot"
+
+	// Scale set tags holding the pool bounds, as used by cluster-autoscaler auto-discovery
+	azureMinSizeTag = "min"
+	azureMaxSizeTag = "max"
+
+	// VMSS computer names end with the instance ID as 6 base-36 digits
+	azureInstanceIDSuffixLength = 6
+)
+
+// AzureVMSSAPI is the subset of the Azure Compute API used by AzureProvider.
+// Production deployments back it with AzureVMSSClient; tests use an in-memory fake.
+type AzureVMSSAPI interface {
+	// GetVMSS returns a VM Scale Set and its instances by name
+	GetVMSS(ctx context.Context, resourceGroup, name string) (*VirtualMachineScaleSet, error)
+
+	// SetCapacity sets the SKU capacity of a VM Scale Set
+	SetCapacity(ctx context.Context, resourceGroup, name string, capacity int64) error
+
+	// DeleteInstances deletes instances from a VM Scale Set and lowers its capacity
+	DeleteInstances(ctx context.Context, resourceGroup, name string, instanceIDs []string) error
+
+	// GetScheduledEvents returns the Scheduled Events pending for a scale set instance
+	GetScheduledEvents(ctx context.Context, resourceGroup, name, instanceID string) ([]AzureScheduledEvent, error)
+}
+
+// VirtualMachineScaleSet describes an Azure VM Scale Set
+type VirtualMachineScaleSet struct {
+	Name     string
+	SKU      string
+	Capacity int64
+	// Priority is Spot or Regular
+	Priority  string
+	Zones     []string
+	Tags      map[string]string
+	Instances []VMSSInstance
+}
+
+// VMSSInstance describes a VM that belongs to a VM Scale Set
+type VMSSInstance struct {
+	InstanceID        string
+	ComputerName      string
+	ProvisioningState string
+}
+
+// AzureScheduledEvent describes an event from the Azure Scheduled Events service
+type AzureScheduledEvent struct {
+	EventID     string
+	EventType   string
+	EventStatus string
+	Resources   []string
+	NotBefore   time.Time
+}
+
+// AzureProvider implements CloudProvider for Microsoft Azure.
+// Node pools map one-to-one to VM Scale Sets with the same name in the provider's resource group.
 type AzureProvider struct {
 	subscriptionID string
 	resourceGroup  string
 	region         string
-	// Azure SDK clients would be initialized here
-	// vmssClient    *armcompute.VirtualMachineScaleSetsClient
-	// pricingClient *armpricing.PricingClient
+	vmssClient     AzureVMSSAPI
 }
 
-// NewAzureProvider creates a new Azure provider
+// NewAzureProvider creates a new Azure provider without a scale set client.
+// Scaling calls fail until a client is supplied via NewAzureProviderWithClient.
 func NewAzureProvider(subscriptionID, resourceGroup, region string) *AzureProvider {
 	return &AzureProvider{
 		subscriptionID: subscriptionID,
@@ -25,91 +92,104 @@ func NewAzureProvider(subscriptionID, resourceGroup, region string) *AzureProvid
 	}
 }
 
-// ScaleUp adds nodes to an Azure VM Scale Set
+// NewAzureProviderWithClient creates a new Azure provider backed by the given scale set client
+func NewAzureProviderWithClient(subscriptionID, resourceGroup, region string, vmssClient AzureVMSSAPI) *AzureProvider {
+	return &AzureProvider{
+		subscriptionID: subscriptionID,
+		resourceGroup:  resourceGroup,
+		region:         region,
+		vmssClient:     vmssClient,
+	}
+}
+
+// ScaleUp adds nodes to an Azure VM Scale Set by raising its capacity
 func (p *AzureProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
-	// In production, this would use Azure Compute API:
-	// 1. Get current VMSS capacity
-	// 2. Update VMSS capacity
-	// 3. Wait for VMs to be provisioned
-
-	fmt.Printf("Azure: Scaling up node pool %s by %d nodes\n", nodePool.Name, count)
-
-	// Example implementation:
-	// vmssName := nodePool.Name
-	// vmss, err := p.vmssClient.Get(ctx, p.resourceGroup, vmssName, nil)
-	// if err != nil {
-	//     return fmt.Errorf("failed to get VMSS: %w", err)
-	// }
-	//
-	// currentCapacity := *vmss.SKU.Capacity
-	// newCapacity := currentCapacity + int64(count)
-	//
-	// vmss.SKU.Capacity = &newCapacity
-	// poller, err := p.vmssClient.BeginCreateOrUpdate(ctx, p.resourceGroup, vmssName, vmss, nil)
-	// if err != nil {
-	//     return err
-	// }
-	// _, err = poller.PollUntilDone(ctx, nil)
-	// return err
+	if p.vmssClient == nil {
+		return fmt.Errorf("Azure VMSS client not configured")
+	}
+
+	vmss, err := p.vmssClient.GetVMSS(ctx, p.resourceGroup, nodePool.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get VMSS %s: %w", nodePool.Name, err)
+	}
+
+	_, maxSize := vmssSizeBounds(vmss)
+	if vmss.Capacity >= maxSize {
+		return NewProviderError(ProviderErrorPoolAtMaxSize, nodePool.Name,
+			fmt.Errorf("VMSS %s is already at max size %d", vmss.Name, maxSize))
+	}
+
+	// Ensure new capacity doesn't exceed max size
+	newCapacity := vmss.Capacity + int64(count)
+	if newCapacity > maxSize {
+		newCapacity = maxSize
+	}
+
+	if err := p.vmssClient.SetCapacity(ctx, p.resourceGroup, vmss.Name, newCapacity); err != nil {
+		return fmt.Errorf("failed to set capacity of VMSS %s: %w", vmss.Name, classifyProviderError(nodePool, err))
+	}
 
 	return nil
 }
 
-// ScaleDown removes a node from Azure
+// GetScaleUpOverrides returns no overrides; a scale set has a single VM size and set of zones
+func (p *AzureProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{}
+}
+
+// CheckScaleUp returns nil; capacity errors are returned by ScaleUp
+func (p *AzureProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	return nil, nil
+}
+
+// ScaleDown deletes the scale set instance backing a node.
+// Deleting the instance lowers the scale set's capacity so it is not recreated.
 func (p *AzureProvider) ScaleDown(ctx context.Context, nodeName string) error {
-	// In production, this would:
-	// 1. Get VM instance ID from node name
-	// 2. Delete specific VM instance from VMSS
-	// 3. VMSS will automatically adjust capacity
-
-	fmt.Printf("Azure: Scaling down node %s\n", nodeName)
-
-	// Example implementation:
-	// instanceID := p.getInstanceIDFromNodeName(nodeName)
-	// vmssName := p.getVMSSNameFromNodeName(nodeName)
-	//
-	// poller, err := p.vmssClient.BeginDeleteInstances(ctx, p.resourceGroup, vmssName,
-	//     armcompute.VirtualMachineScaleSetVMInstanceRequiredIDs{
-	//         InstanceIds: []*string{&instanceID},
-	//     }, nil)
-	// if err != nil {
-	//     return err
-	// }
-	// _, err = poller.PollUntilDone(ctx, nil)
-	// return err
+	if p.vmssClient == nil {
+		return fmt.Errorf("Azure VMSS client not configured")
+	}
+
+	vmssName, instanceID, err := p.getVMSSInstanceFromNodeName(nodeName)
+	if err != nil {
+		return err
+	}
+
+	if err := p.vmssClient.DeleteInstances(ctx, p.resourceGroup, vmssName, []string{instanceID}); err != nil {
+		return fmt.Errorf("failed to delete instance %s from VMSS %s: %w", instanceID, vmssName, err)
+	}
 
 	return nil
 }
 
-// GetSpotTerminationNotice checks Azure metadata for spot eviction notice
+// GetSpotTerminationNotice checks Scheduled Events for a Spot eviction of the node's instance.
+// Azure raises a Preempt event at least 30 seconds before evicting the VM.
 func (p *AzureProvider) GetSpotTerminationNotice(ctx context.Context, nodeName string) (time.Time, bool, error) {
-	// In production, this would query Azure Instance Metadata Service:
-	// http://169.254.169.254/metadata/scheduledevents
-	//
-	// Azure gives 30-second warning before spot eviction
-	// Response includes EventType: "Preempt" for spot evictions
+	if p.vmssClient == nil {
+		return time.Time{}, false, fmt.Errorf("Azure VMSS client not configured")
+	}
 
-	// For now, return no termination notice
-	return time.Time{}, false, nil
+	vmssName, instanceID, err := p.getVMSSInstanceFromNodeName(nodeName)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	events, err := p.vmssClient.GetScheduledEvents(ctx, p.resourceGroup, vmssName, instanceID)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get scheduled events for node %s: %w", nodeName, err)
+	}
+
+	for _, event := range events {
+		if event.EventType != azureEventTypePreempt {
+			continue
+		}
+		if event.NotBefore.IsZero() {
+			// NotBefore is empty once the eviction has started
+			return time.Now().Add(azureSpotEvictionWarning), true, nil
+		}
+		return event.NotBefore, true, nil
+	}
 
-	// Example implementation:
-	// instanceID := p.getInstanceIDFromNodeName(nodeName)
-	// vmssName := p.getVMSSNameFromNodeName(nodeName)
-	//
-	// vm, err := p.vmssClient.GetInstanceView(ctx, p.resourceGroup, vmssName, instanceID, nil)
-	// if err != nil {
-	//     return time.Time{}, false, err
-	// }
-	//
-	// // Check for scheduled events (preemption)
-	// if vm.InstanceView != nil && vm.InstanceView.PlatformUpdateDomain != nil {
-	//     // Query scheduled events from metadata service
-	//     // Parse eviction time from scheduled events
-	//     terminationTime := time.Now().Add(30 * time.Second)
-	//     return terminationTime, true, nil
-	// }
-	//
-	// return time.Time{}, false, nil
+	return time.Time{}, false, nil
 }
 
 // GetSpotPrice returns current spot price from Azure
@@ -181,39 +261,84 @@ func (p *AzureProvider) GetAvailabilityZones(ctx context.Context) ([]string, err
 
 // GetNodePoolInfo returns information about an Azure VM Scale Set
 func (p *AzureProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
-	// In production, this would query VMSS details
-
-	// Example implementation:
-	// vmss, err := p.vmssClient.Get(ctx, p.resourceGroup, nodePoolName, nil)
-	// if err != nil {
-	//     return nil, err
-	// }
-	//
-	// return &NodePoolInfo{
-	//     Name:        *vmss.Name,
-	//     CurrentSize: int(*vmss.SKU.Capacity),
-	//     MinSize:     0, // Azure doesn't have built-in min/max in VMSS
-	//     MaxSize:     100,
-	//     InstanceType: *vmss.SKU.Name,
-	// }, nil
-
-	return &NodePoolInfo{
-		Name:        nodePoolName,
-		CurrentSize: 0,
-		MinSize:     0,
-		MaxSize:     100,
-	}, nil
+	if p.vmssClient == nil {
+		return nil, fmt.Errorf("Azure VMSS client not configured")
+	}
+
+	vmss, err := p.vmssClient.GetVMSS(ctx, p.resourceGroup, nodePoolName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get VMSS %s: %w", nodePoolName, err)
+	}
+
+	minSize, maxSize := vmssSizeBounds(vmss)
+	info := &NodePoolInfo{
+		Name:         vmss.Name,
+		CurrentSize:  int(vmss.Capacity),
+		MinSize:      int(minSize),
+		MaxSize:      int(maxSize),
+		InstanceType: vmss.SKU,
+		CapacityType: CapacityTypeOnDemand,
+	}
+	if vmss.Priority == azurePrioritySpot {
+		info.CapacityType = CapacityTypeSpot
+	}
+
+	// Price the scale set's SKU capacity, using Spot rates for Spot priority scale sets
+	if info.InstanceType != "" {
+		price, err := p.GetOnDemandPrice(ctx, info.InstanceType)
+		if info.CapacityType == CapacityTypeSpot {
+			price, err = p.GetSpotPrice(ctx, info.InstanceType)
+		}
+		if err == nil {
+			info.Cost = price * float64(info.CurrentSize)
+		}
+	}
+
+	return info, nil
+}
+
+// GetNodePoolForNode returns the scale set the node's computer name places it in
+func (p *AzureProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	vmssName, _, err := p.getVMSSInstanceFromNodeName(nodeName)
+	if err != nil {
+		return "", nil
+	}
+	return vmssName, nil
 }
 
 // Helper methods
 
-func (p *AzureProvider) getInstanceIDFromNodeName(nodeName string) string {
-	// Parse instance ID from node name
-	// AKS node names include instance ID
-	return "0"
+// getVMSSInstanceFromNodeName resolves the scale set and instance ID backing a node.
+// AKS and VMSS nodes are named after the VM's computer name, which is the scale set's
+// computer name prefix followed by the instance ID as 6 base-36 digits, e.g.
+// aks-gpupool-12345678-vmss00000a is instance 10 of aks-gpupool-12345678-vmss.
+func (p *AzureProvider) getVMSSInstanceFromNodeName(nodeName string) (string, string, error) {
+	computerName := strings.SplitN(nodeName, ".", 2)[0]
+	if len(computerName) <= azureInstanceIDSuffixLength {
+		return "", "", fmt.Errorf("node %s is not named after a VMSS instance", nodeName)
+	}
+
+	split := len(computerName) - azureInstanceIDSuffixLength
+	instanceID, err := strconv.ParseInt(computerName[split:], 36, 64)
+	if err != nil {
+		return "", "", fmt.Errorf("node %s is not named after a VMSS instance: %w", nodeName, err)
+	}
+
+	return computerName[:split], strconv.FormatInt(instanceID, 10), nil
 }
 
-func (p *AzureProvider) getVMSSNameFromNodeName(nodeName string) string {
-	// Parse VMSS name from node name
-	return "aks-nodepool-vmss"
+// vmssSizeBounds returns the scale set's min and max size from its tags.
+// VM Scale Sets have no size bounds of their own, so untagged sets use 0 and the default max.
+func vmssSizeBounds(vmss *VirtualMachineScaleSet) (int64, int64) {
+	minSize := int64(0)
+	maxSize := int64(azureDefaultMaxSize)
+
+	if value, err := strconv.ParseInt(vmss.Tags[azureMinSizeTag], 10, 64); err == nil && value >= 0 {
+		minSize = value
+	}
+	if value, err := strconv.ParseInt(vmss.Tags[azureMaxSizeTag], 10, 64); err == nil && value > 0 {
+		maxSize = value
+	}
+
+	return minSize, maxSize
 }
@@ -0,0 +1,250 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeAzureVMSS is an in-memory VM Scale Set and Scheduled Events backend
+type fakeAzureVMSS struct {
+	scaleSets map[string]*VirtualMachineScaleSet // name -> scale set
+	events    map[string][]AzureScheduledEvent   // name/instanceID -> events
+}
+
+func newFakeAzureVMSS() *fakeAzureVMSS {
+	return &fakeAzureVMSS{
+		scaleSets: make(map[string]*VirtualMachineScaleSet),
+		events:    make(map[string][]AzureScheduledEvent),
+	}
+}
+
+func (f *fakeAzureVMSS) GetVMSS(ctx context.Context, resourceGroup, name string) (*VirtualMachineScaleSet, error) {
+	vmss, ok := f.scaleSets[name]
+	if !ok {
+		return nil, fmt.Errorf("VMSS %s not found in resource group %s", name, resourceGroup)
+	}
+	copied := *vmss
+	return &copied, nil
+}
+
+func (f *fakeAzureVMSS) SetCapacity(ctx context.Context, resourceGroup, name string, capacity int64) error {
+	vmss, ok := f.scaleSets[name]
+	if !ok {
+		return fmt.Errorf("VMSS %s not found in resource group %s", name, resourceGroup)
+	}
+	vmss.Capacity = capacity
+	return nil
+}
+
+func (f *fakeAzureVMSS) DeleteInstances(ctx context.Context, resourceGroup, name string, instanceIDs []string) error {
+	vmss, ok := f.scaleSets[name]
+	if !ok {
+		return fmt.Errorf("VMSS %s not found in resource group %s", name, resourceGroup)
+	}
+	for _, instanceID := range instanceIDs {
+		found := false
+		for i, instance := range vmss.Instances {
+			if instance.InstanceID == instanceID {
+				vmss.Instances = append(vmss.Instances[:i], vmss.Instances[i+1:]...)
+				vmss.Capacity--
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("instance %s not found in VMSS %s", instanceID, name)
+		}
+	}
+	return nil
+}
+
+func (f *fakeAzureVMSS) GetScheduledEvents(ctx context.Context, resourceGroup, name, instanceID string) ([]AzureScheduledEvent, error) {
+	return f.events[name+"/"+instanceID], nil
+}
+
+func TestAzureProviderScaleUp(t *testing.T) {
+	tests := []struct {
+		name             string
+		capacity         int64
+		count            int
+		tags             map[string]string
+		expectedCapacity int64
+		expectError      bool
+		expectAtMaxSize  bool
+	}{
+		{
+			name:             "Scale up within max size",
+			capacity:         2,
+			count:            1,
+			tags:             map[string]string{"min": "0", "max": "4"},
+			expectedCapacity: 3,
+		},
+		{
+			name:             "Scale up is capped at max size",
+			capacity:         2,
+			count:            5,
+			tags:             map[string]string{"min": "0", "max": "4"},
+			expectedCapacity: 4,
+		},
+		{
+			name:             "Untagged scale set uses the default max size",
+			capacity:         2,
+			count:            5,
+			tags:             map[string]string{},
+			expectedCapacity: 7,
+		},
+		{
+			name:             "Scale up at max size",
+			capacity:         4,
+			count:            1,
+			tags:             map[string]string{"min": "0", "max": "4"},
+			expectedCapacity: 4,
+			expectError:      true,
+			expectAtMaxSize:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeAzureVMSS()
+			vmss := &VirtualMachineScaleSet{
+				Name:     "aks-gpuspot-12345678-vmss",
+				SKU:      "Standard_NC6s_v3",
+				Capacity: tt.capacity,
+				Priority: azurePrioritySpot,
+				Tags:     tt.tags,
+				Instances: []VMSSInstance{
+					{InstanceID: "3", ComputerName: "aks-gpuspot-12345678-vmss000003", ProvisioningState: "Succeeded"},
+					{InstanceID: "10", ComputerName: "aks-gpuspot-12345678-vmss00000a", ProvisioningState: "Succeeded"},
+				},
+			}
+			fake.scaleSets[vmss.Name] = vmss
+			provider := NewAzureProviderWithClient("subscription", "ml-rg", "eastus", fake)
+
+			err := provider.ScaleUp(context.Background(), &NodePoolConfig{Name: vmss.Name}, tt.count)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("ScaleUp() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectAtMaxSize && !IsPoolAtMaxSize(err) {
+				t.Errorf("Expected a pool-at-max-size error, got %v", err)
+			}
+			if vmss.Capacity != tt.expectedCapacity {
+				t.Errorf("Expected capacity %d, got %d", tt.expectedCapacity, vmss.Capacity)
+			}
+		})
+	}
+}
+
+func TestAzureProviderScaleDown(t *testing.T) {
+	fake := newFakeAzureVMSS()
+	vmss := &VirtualMachineScaleSet{
+		Name:     "aks-gpuspot-12345678-vmss",
+		SKU:      "Standard_NC6s_v3",
+		Capacity: 2,
+		Priority: azurePrioritySpot,
+		Tags:     map[string]string{"min": "0", "max": "4"},
+		Instances: []VMSSInstance{
+			{InstanceID: "3", ComputerName: "aks-gpuspot-12345678-vmss000003", ProvisioningState: "Succeeded"},
+			{InstanceID: "10", ComputerName: "aks-gpuspot-12345678-vmss00000a", ProvisioningState: "Succeeded"},
+		},
+	}
+	fake.scaleSets[vmss.Name] = vmss
+	provider := NewAzureProviderWithClient("subscription", "ml-rg", "eastus", fake)
+
+	if err := provider.ScaleDown(context.Background(), "aks-gpuspot-12345678-vmss00000a"); err != nil {
+		t.Fatalf("ScaleDown() error = %v", err)
+	}
+
+	if vmss.Capacity != 1 {
+		t.Errorf("Expected capacity 1, got %d", vmss.Capacity)
+	}
+	if len(vmss.Instances) != 1 || vmss.Instances[0].InstanceID != "3" {
+		t.Errorf("Expected only instance 3 to remain, got %+v", vmss.Instances)
+	}
+
+	if err := provider.ScaleDown(context.Background(), "gpu"); err == nil {
+		t.Error("Expected error for a node not named after a VMSS instance")
+	}
+}
+
+func TestAzureProviderGetNodePoolInfo(t *testing.T) {
+	fake := newFakeAzureVMSS()
+	vmss := &VirtualMachineScaleSet{
+		Name:     "aks-gpuspot-12345678-vmss",
+		SKU:      "Standard_NC6s_v3",
+		Capacity: 2,
+		Priority: azurePrioritySpot,
+		Tags:     map[string]string{"min": "0", "max": "4"},
+		Instances: []VMSSInstance{
+			{InstanceID: "3", ComputerName: "aks-gpuspot-12345678-vmss000003", ProvisioningState: "Succeeded"},
+			{InstanceID: "10", ComputerName: "aks-gpuspot-12345678-vmss00000a", ProvisioningState: "Succeeded"},
+		},
+	}
+	fake.scaleSets[vmss.Name] = vmss
+	provider := NewAzureProviderWithClient("subscription", "ml-rg", "eastus", fake)
+
+	info, err := provider.GetNodePoolInfo(context.Background(), "aks-gpuspot-12345678-vmss")
+	if err != nil {
+		t.Fatalf("GetNodePoolInfo() error = %v", err)
+	}
+
+	if info.CurrentSize != 2 || info.MinSize != 0 || info.MaxSize != 4 {
+		t.Errorf("Unexpected sizes: current=%d min=%d max=%d", info.CurrentSize, info.MinSize, info.MaxSize)
+	}
+	if info.InstanceType != "Standard_NC6s_v3" || info.CapacityType != CapacityTypeSpot {
+		t.Errorf("Unexpected pool info: %+v", info)
+	}
+	if info.Cost != 3.00 {
+		t.Errorf("Expected hourly cost 3.00, got %.2f", info.Cost)
+	}
+
+	if _, err := provider.GetNodePoolInfo(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for unknown VMSS")
+	}
+}
+
+func TestAzureProviderGetSpotTerminationNotice(t *testing.T) {
+	fake := newFakeAzureVMSS()
+	vmss := &VirtualMachineScaleSet{
+		Name:     "aks-gpuspot-12345678-vmss",
+		SKU:      "Standard_NC6s_v3",
+		Capacity: 2,
+		Priority: azurePrioritySpot,
+		Tags:     map[string]string{"min": "0", "max": "4"},
+		Instances: []VMSSInstance{
+			{InstanceID: "3", ComputerName: "aks-gpuspot-12345678-vmss000003", ProvisioningState: "Succeeded"},
+			{InstanceID: "10", ComputerName: "aks-gpuspot-12345678-vmss00000a", ProvisioningState: "Succeeded"},
+		},
+	}
+	fake.scaleSets[vmss.Name] = vmss
+	provider := NewAzureProviderWithClient("subscription", "ml-rg", "eastus", fake)
+	evictAt := time.Date(2024, 1, 1, 12, 0, 30, 0, time.UTC)
+	fake.events["aks-gpuspot-12345678-vmss/3"] = []AzureScheduledEvent{
+		{EventID: "1", EventType: "Freeze", EventStatus: "Scheduled", NotBefore: evictAt.Add(-time.Hour)},
+		{EventID: "2", EventType: azureEventTypePreempt, EventStatus: "Scheduled", NotBefore: evictAt},
+	}
+	fake.events["aks-gpuspot-12345678-vmss/10"] = []AzureScheduledEvent{
+		{EventID: "3", EventType: "Reboot", EventStatus: "Scheduled", NotBefore: evictAt},
+	}
+
+	terminationTime, hasNotice, err := provider.GetSpotTerminationNotice(context.Background(), "aks-gpuspot-12345678-vmss000003")
+	if err != nil {
+		t.Fatalf("GetSpotTerminationNotice() error = %v", err)
+	}
+	if !hasNotice {
+		t.Fatal("Expected a termination notice")
+	}
+	if !terminationTime.Equal(evictAt) {
+		t.Errorf("Expected termination at %s, got %s", evictAt, terminationTime)
+	}
+
+	_, hasNotice, err = provider.GetSpotTerminationNotice(context.Background(), "aks-gpuspot-12345678-vmss00000a")
+	if err != nil {
+		t.Fatalf("GetSpotTerminationNotice() error = %v", err)
+	}
+	if hasNotice {
+		t.Error("Expected no termination notice without a Preempt event")
+	}
+}
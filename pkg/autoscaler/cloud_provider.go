@@ -2,7 +2,21 @@ package autoscaler
 
 import (
 	"context"
+	"fmt"
 	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// Supported cloud provider names
+const (
+	ProviderAWS        = "aws"
+	ProviderGCP        = "gcp"
+	ProviderAzure      = "azure"
+	ProviderKarpenter  = "karpenter"
+	ProviderClusterAPI = "clusterapi"
+	ProviderRedfish    = "redfish"
+	ProviderSimulated  = "simulated"
 )
 
 // CloudProvider is the interface for cloud provider integration
@@ -10,6 +24,15 @@ type CloudProvider interface {
 	// ScaleUp adds nodes to a node pool
 	ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error
 
+	// GetScaleUpOverrides reports how ScaleUp can narrow a node pool. The autoscaler only falls back
+	// between the instance types or zones of a pool the provider can narrow to a single one.
+	GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides
+
+	// CheckScaleUp reports a launch in the node pool that failed since the given time, for providers whose
+	// ScaleUp returns before nodes launch. When one failed, the provider stops retrying the missing nodes
+	// of the request and returns the error; otherwise it returns nil.
+	CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error)
+
 	// ScaleDown removes a node from the cluster
 	ScaleDown(ctx context.Context, nodeName string) error
 
@@ -30,6 +53,20 @@ type CloudProvider interface {
 
 	// GetNodePoolInfo returns information about a node pool
 	GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error)
+
+	// GetNodePoolForNode returns the node pool a node belongs to, or "" when it is in none of the
+	// provider's pools. It places nodes that do not carry the gpu-autoscaler.io/node-pool label.
+	GetNodePoolForNode(ctx context.Context, nodeName string) (string, error)
+}
+
+// ScaleUpOverrides says which parts of a node pool a provider's ScaleUp honours when the pool
+// is narrowed to a single value
+type ScaleUpOverrides struct {
+	// InstanceType is set when ScaleUp launches only the pool's instance type once InstanceTypes has one entry
+	InstanceType bool
+
+	// Zone is set when ScaleUp launches only in the pool's zone once AvailabilityZones has one entry
+	Zone bool
 }
 
 // NodePoolInfo contains information about a cloud provider node pool
@@ -41,5 +78,85 @@ type NodePoolInfo struct {
 	InstanceType  string
 	CapacityType  string
 	AvailableGPUs int
+	GPUsPerNode   int // 0 when the provider cannot tell
 	Cost          float64
 }
+
+// ProviderOptions holds the settings used to construct a CloudProvider
+type ProviderOptions struct {
+	Region         string
+	ProjectID      string
+	SubscriptionID string
+	ResourceGroup  string
+
+	// AWS API clients; the AWS provider cannot scale without them
+	AWSAutoScaling AutoScalingAPI
+	AWSEC2         EC2API
+
+	// GCP Compute Engine client; the GCP provider cannot scale without it
+	GCPCompute GCPComputeAPI
+
+	// Azure VM Scale Set client; the Azure provider cannot scale without it
+	AzureVMSS AzureVMSSAPI
+
+	// Client is used by providers that manage Kubernetes objects directly
+	Client client.Client
+
+	// Karpenter configures the Karpenter provider
+	Karpenter KarpenterProviderConfig
+
+	// ClusterAPI configures the Cluster API provider
+	ClusterAPI ClusterAPIProviderConfig
+
+	// Redfish configures the Redfish bare-metal provider
+	Redfish RedfishProviderConfig
+
+	// Simulated configures the simulated provider
+	Simulated SimulatedProviderConfig
+}
+
+// NewCloudProvider creates the CloudProvider registered under name
+func NewCloudProvider(name string, opts ProviderOptions) (CloudProvider, error) {
+	switch name {
+	case ProviderAWS:
+		if opts.AWSAutoScaling == nil || opts.AWSEC2 == nil {
+			return nil, fmt.Errorf("aws provider requires Auto Scaling and EC2 clients")
+		}
+		return NewAWSProviderWithClients(opts.Region, opts.AWSAutoScaling, opts.AWSEC2), nil
+	case ProviderGCP:
+		if opts.GCPCompute == nil {
+			return nil, fmt.Errorf("gcp provider requires a Compute Engine client")
+		}
+		return NewGCPProviderWithClient(opts.ProjectID, opts.Region, opts.GCPCompute), nil
+	case ProviderAzure:
+		if opts.AzureVMSS == nil {
+			return nil, fmt.Errorf("azure provider requires a VM Scale Set client")
+		}
+		if opts.ResourceGroup == "" {
+			return nil, fmt.Errorf("azure provider requires a resource group")
+		}
+		return NewAzureProviderWithClient(opts.SubscriptionID, opts.ResourceGroup, opts.Region, opts.AzureVMSS), nil
+	case ProviderKarpenter:
+		if opts.Client == nil {
+			return nil, fmt.Errorf("karpenter provider requires a Kubernetes client")
+		}
+		return NewKarpenterProvider(opts.Client, opts.Karpenter), nil
+	case ProviderClusterAPI:
+		if opts.Client == nil {
+			return nil, fmt.Errorf("clusterapi provider requires a Kubernetes client")
+		}
+		return NewClusterAPIProvider(opts.Client, opts.ClusterAPI), nil
+	case ProviderRedfish:
+		if opts.Client == nil {
+			return nil, fmt.Errorf("redfish provider requires a Kubernetes client")
+		}
+		return NewRedfishProvider(opts.Client, opts.Redfish), nil
+	case ProviderSimulated:
+		if opts.Client == nil {
+			return nil, fmt.Errorf("simulated provider requires a Kubernetes client")
+		}
+		return NewSimulatedProvider(opts.Client, opts.Simulated), nil
+	default:
+		return nil, fmt.Errorf("unknown cloud provider %q", name)
+	}
+}
@@ -0,0 +1,43 @@
+package autoscaler
+
+import (
+	"testing"
+
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestNewCloudProvider(t *testing.T) {
+	aws := newFakeAWS()
+	k8sClient := fake.NewClientBuilder().Build()
+
+	tests := []struct {
+		name      string
+		provider  string
+		opts      ProviderOptions
+		expectErr bool
+	}{
+		{name: "aws with clients", provider: ProviderAWS, opts: ProviderOptions{AWSAutoScaling: aws, AWSEC2: aws}},
+		{name: "aws without clients", provider: ProviderAWS, expectErr: true},
+		{name: "aws without EC2 client", provider: ProviderAWS, opts: ProviderOptions{AWSAutoScaling: aws}, expectErr: true},
+		{name: "gcp with client", provider: ProviderGCP, opts: ProviderOptions{ProjectID: "ml-project", GCPCompute: newFakeGCPCompute()}},
+		{name: "gcp without client", provider: ProviderGCP, expectErr: true},
+		{name: "azure with client", provider: ProviderAzure, opts: ProviderOptions{ResourceGroup: "gpu-cluster", AzureVMSS: newFakeAzureVMSS()}},
+		{name: "azure without client", provider: ProviderAzure, expectErr: true},
+		{name: "azure without resource group", provider: ProviderAzure, opts: ProviderOptions{AzureVMSS: newFakeAzureVMSS()}, expectErr: true},
+		{name: "karpenter", provider: ProviderKarpenter, opts: ProviderOptions{Client: k8sClient}},
+		{name: "simulated without Kubernetes client", provider: ProviderSimulated, expectErr: true},
+		{name: "unknown provider", provider: "openstack", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := NewCloudProvider(tt.provider, tt.opts)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && provider == nil {
+				t.Error("Expected a provider")
+			}
+		})
+	}
+}
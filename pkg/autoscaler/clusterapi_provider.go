@@ -0,0 +1,352 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Cluster API group and the labels and annotations it uses on Machines
+	ClusterAPIGroup               = "cluster.x-k8s.io"
+	ClusterAPIDeploymentNameLabel = "cluster.x-k8s.io/deployment-name"
+	ClusterAPIDeleteMachine       = "cluster.x-k8s.io/delete-machine"
+
+	// MachineDeployment annotations bounding the pool, shared with the cluster-autoscaler Cluster API provider
+	ClusterAPIMinSizeAnnotation  = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-min-size"
+	ClusterAPIMaxSizeAnnotation  = "cluster.x-k8s.io/cluster-api-autoscaler-node-group-max-size"
+	ClusterAPIGPUCountAnnotation = "capacity.cluster-autoscaler.kubernetes.io/gpu-count"
+
+	// DefaultClusterAPIVersion is the Cluster API version MachineDeployments and Machines are managed with
+	DefaultClusterAPIVersion = "v1beta1"
+
+	// DefaultClusterAPINamespace is where MachineDeployments are looked up when no namespace is configured
+	DefaultClusterAPINamespace = "default"
+)
+
+// ClusterAPIProviderConfig configures the ClusterAPIProvider
+type ClusterAPIProviderConfig struct {
+	// APIVersion is the cluster.x-k8s.io API version, v1beta1 by default
+	APIVersion string
+
+	// Namespace holds the cluster's MachineDeployments and Machines
+	Namespace string
+
+	// MachineDeployments maps autoscaler node pool names to MachineDeployment names.
+	// Pools that are not listed use the MachineDeployment with the same name.
+	MachineDeployments map[string]string
+
+	// Pricing supplies instance prices when the pools run on a cloud; on-prem pools have none
+	Pricing CloudProvider
+}
+
+// ClusterAPIProvider implements CloudProvider by scaling Cluster API MachineDeployments.
+// It gives vSphere, bare-metal and cloud GPU pools one code path: the infrastructure provider
+// behind each MachineDeployment creates and deletes the actual machines.
+type ClusterAPIProvider struct {
+	client client.Client
+	config ClusterAPIProviderConfig
+}
+
+// NewClusterAPIProvider creates a new Cluster API provider
+func NewClusterAPIProvider(client client.Client, config ClusterAPIProviderConfig) *ClusterAPIProvider {
+	if config.APIVersion == "" {
+		config.APIVersion = DefaultClusterAPIVersion
+	}
+	if config.Namespace == "" {
+		config.Namespace = DefaultClusterAPINamespace
+	}
+
+	return &ClusterAPIProvider{
+		client: client,
+		config: config,
+	}
+}
+
+// ScaleUp raises the replicas of the node pool's MachineDeployment
+func (p *ClusterAPIProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
+	machineDeployment, err := p.getMachineDeployment(ctx, p.machineDeploymentName(nodePool.Name))
+	if err != nil {
+		return err
+	}
+
+	replicas := machineDeploymentReplicas(machineDeployment)
+	_, maxSize := machineDeploymentSizeBounds(machineDeployment)
+	if nodePool.MaxSize > 0 && nodePool.MaxSize < maxSize {
+		maxSize = nodePool.MaxSize
+	}
+	if replicas >= maxSize {
+		return NewProviderError(ProviderErrorPoolAtMaxSize, nodePool.Name,
+			fmt.Errorf("MachineDeployment %s is already at max size %d", machineDeployment.GetName(), maxSize))
+	}
+
+	// Ensure new replicas don't exceed max size
+	newReplicas := replicas + count
+	if newReplicas > maxSize {
+		newReplicas = maxSize
+	}
+
+	return p.setReplicas(ctx, machineDeployment, newReplicas)
+}
+
+// GetScaleUpOverrides returns no overrides; the machine type and failure domain are fixed by the
+// MachineDeployment's template
+func (p *ClusterAPIProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{}
+}
+
+// CheckScaleUp returns nil; the infrastructure provider reports launch failures on the Machines
+func (p *ClusterAPIProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	return nil, nil
+}
+
+// ScaleDown removes the Machine backing a node. The Machine is marked with the delete-machine
+// annotation first, so the MachineSet deletes that Machine when replicas are decremented.
+func (p *ClusterAPIProvider) ScaleDown(ctx context.Context, nodeName string) error {
+	machine, err := p.getMachineForNode(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+
+	deploymentName := machine.GetLabels()[ClusterAPIDeploymentNameLabel]
+	if deploymentName == "" {
+		return fmt.Errorf("machine %s is not owned by a MachineDeployment", machine.GetName())
+	}
+
+	machineDeployment, err := p.getMachineDeployment(ctx, deploymentName)
+	if err != nil {
+		return err
+	}
+
+	replicas := machineDeploymentReplicas(machineDeployment)
+	minSize, _ := machineDeploymentSizeBounds(machineDeployment)
+	if replicas <= minSize {
+		return fmt.Errorf("MachineDeployment %s is already at min size %d", deploymentName, minSize)
+	}
+
+	if _, marked := machine.GetAnnotations()[ClusterAPIDeleteMachine]; !marked {
+		patch := client.MergeFrom(machine.DeepCopy())
+		annotations := machine.GetAnnotations()
+		if annotations == nil {
+			annotations = map[string]string{}
+		}
+		annotations[ClusterAPIDeleteMachine] = time.Now().UTC().Format(time.RFC3339)
+		machine.SetAnnotations(annotations)
+		if err := p.client.Patch(ctx, machine, patch); err != nil {
+			return fmt.Errorf("failed to mark machine %s for deletion: %w", machine.GetName(), err)
+		}
+	}
+
+	return p.setReplicas(ctx, machineDeployment, replicas-1)
+}
+
+// GetSpotTerminationNotice always reports no notice.
+// Cluster API has no interruption API; infrastructure providers replace interrupted machines themselves.
+func (p *ClusterAPIProvider) GetSpotTerminationNotice(ctx context.Context, nodeName string) (time.Time, bool, error) {
+	return time.Time{}, false, nil
+}
+
+// GetSpotPrice prices spot machines through the infrastructure's Pricing backend, when one is configured
+func (p *ClusterAPIProvider) GetSpotPrice(ctx context.Context, instanceType string) (float64, error) {
+	if p.config.Pricing == nil {
+		return 0, fmt.Errorf("price not found for instance type: %s", instanceType)
+	}
+	return p.config.Pricing.GetSpotPrice(ctx, instanceType)
+}
+
+// GetOnDemandPrice prices on-demand machines through the infrastructure's Pricing backend, when one is configured
+func (p *ClusterAPIProvider) GetOnDemandPrice(ctx context.Context, instanceType string) (float64, error) {
+	if p.config.Pricing == nil {
+		return 0, fmt.Errorf("price not found for instance type: %s", instanceType)
+	}
+	return p.config.Pricing.GetOnDemandPrice(ctx, instanceType)
+}
+
+// GetRecommendedSpotInstanceTypes returns nothing without a Pricing backend; the machine type is fixed by the infrastructure template
+func (p *ClusterAPIProvider) GetRecommendedSpotInstanceTypes(ctx context.Context) ([]string, error) {
+	if p.config.Pricing == nil {
+		return nil, nil
+	}
+	return p.config.Pricing.GetRecommendedSpotInstanceTypes(ctx)
+}
+
+// GetAvailabilityZones returns the failure domains the cluster's MachineDeployments are placed in
+func (p *ClusterAPIProvider) GetAvailabilityZones(ctx context.Context) ([]string, error) {
+	machineDeploymentList := &unstructured.UnstructuredList{}
+	machineDeploymentList.SetGroupVersionKind(p.gvk("MachineDeploymentList"))
+	if err := p.client.List(ctx, machineDeploymentList, client.InNamespace(p.config.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list MachineDeployments: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var zones []string
+	for i := range machineDeploymentList.Items {
+		zone, _, _ := unstructured.NestedString(machineDeploymentList.Items[i].Object, "spec", "template", "spec", "failureDomain")
+		if zone != "" && !seen[zone] {
+			seen[zone] = true
+			zones = append(zones, zone)
+		}
+	}
+	sort.Strings(zones)
+
+	return zones, nil
+}
+
+// GetNodePoolInfo returns information about a node pool's MachineDeployment
+func (p *ClusterAPIProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
+	machineDeployment, err := p.getMachineDeployment(ctx, p.machineDeploymentName(nodePoolName))
+	if err != nil {
+		return nil, err
+	}
+
+	minSize, maxSize := machineDeploymentSizeBounds(machineDeployment)
+	info := &NodePoolInfo{
+		Name:         nodePoolName,
+		CurrentSize:  machineDeploymentReplicas(machineDeployment),
+		MinSize:      minSize,
+		MaxSize:      maxSize,
+		CapacityType: CapacityTypeOnDemand,
+	}
+
+	templateLabels, _, _ := unstructured.NestedStringMap(machineDeployment.Object, "spec", "template", "metadata", "labels")
+	info.InstanceType = templateLabels[corev1.LabelInstanceTypeStable]
+	if capacityType := templateLabels[CapacityTypeLabel]; capacityType != "" {
+		info.CapacityType = capacityType
+	}
+
+	if gpuCount, err := strconv.Atoi(machineDeployment.GetAnnotations()[ClusterAPIGPUCountAnnotation]); err == nil {
+		info.AvailableGPUs = gpuCount * info.CurrentSize
+		info.GPUsPerNode = gpuCount
+	}
+
+	// Price the replicas at the instance and capacity type labeled on the machine template
+	if info.InstanceType != "" {
+		price, err := p.GetOnDemandPrice(ctx, info.InstanceType)
+		if info.CapacityType == CapacityTypeSpot {
+			price, err = p.GetSpotPrice(ctx, info.InstanceType)
+		}
+		if err == nil {
+			info.Cost = price * float64(info.CurrentSize)
+		}
+	}
+
+	return info, nil
+}
+
+// GetNodePoolForNode returns the autoscaler pool of the MachineDeployment that owns the node's Machine
+func (p *ClusterAPIProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	machine, err := p.findMachineForNode(ctx, nodeName)
+	if err != nil || machine == nil {
+		return "", err
+	}
+
+	deploymentName := machine.GetLabels()[ClusterAPIDeploymentNameLabel]
+	if deploymentName == "" {
+		return "", nil
+	}
+	return p.nodePoolName(deploymentName), nil
+}
+
+// Helper methods
+
+func (p *ClusterAPIProvider) gvk(kind string) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: ClusterAPIGroup, Version: p.config.APIVersion, Kind: kind}
+}
+
+func (p *ClusterAPIProvider) machineDeploymentName(nodePoolName string) string {
+	if name, ok := p.config.MachineDeployments[nodePoolName]; ok {
+		return name
+	}
+	return nodePoolName
+}
+
+// nodePoolName returns the autoscaler pool backed by a MachineDeployment
+func (p *ClusterAPIProvider) nodePoolName(machineDeploymentName string) string {
+	for name, deploymentName := range p.config.MachineDeployments {
+		if deploymentName == machineDeploymentName {
+			return name
+		}
+	}
+	return machineDeploymentName
+}
+
+func (p *ClusterAPIProvider) getMachineDeployment(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+	machineDeployment := &unstructured.Unstructured{}
+	machineDeployment.SetGroupVersionKind(p.gvk("MachineDeployment"))
+	if err := p.client.Get(ctx, client.ObjectKey{Namespace: p.config.Namespace, Name: name}, machineDeployment); err != nil {
+		return nil, fmt.Errorf("failed to get MachineDeployment %s/%s: %w", p.config.Namespace, name, err)
+	}
+	return machineDeployment, nil
+}
+
+// getMachineForNode returns the Machine whose status.nodeRef is the node
+func (p *ClusterAPIProvider) getMachineForNode(ctx context.Context, nodeName string) (*unstructured.Unstructured, error) {
+	machine, err := p.findMachineForNode(ctx, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	if machine == nil {
+		return nil, fmt.Errorf("no machine found for node %s", nodeName)
+	}
+	return machine, nil
+}
+
+// findMachineForNode returns the Machine whose status.nodeRef is the node, or nil if there is none
+func (p *ClusterAPIProvider) findMachineForNode(ctx context.Context, nodeName string) (*unstructured.Unstructured, error) {
+	machineList := &unstructured.UnstructuredList{}
+	machineList.SetGroupVersionKind(p.gvk("MachineList"))
+	if err := p.client.List(ctx, machineList, client.InNamespace(p.config.Namespace)); err != nil {
+		return nil, fmt.Errorf("failed to list machines: %w", err)
+	}
+
+	for i := range machineList.Items {
+		if name, _, _ := unstructured.NestedString(machineList.Items[i].Object, "status", "nodeRef", "name"); name == nodeName {
+			return &machineList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (p *ClusterAPIProvider) setReplicas(ctx context.Context, machineDeployment *unstructured.Unstructured, replicas int) error {
+	patch := client.MergeFrom(machineDeployment.DeepCopy())
+	if err := unstructured.SetNestedField(machineDeployment.Object, int64(replicas), "spec", "replicas"); err != nil {
+		return fmt.Errorf("failed to set replicas of MachineDeployment %s: %w", machineDeployment.GetName(), err)
+	}
+	if err := p.client.Patch(ctx, machineDeployment, patch); err != nil {
+		return fmt.Errorf("failed to scale MachineDeployment %s to %d replicas: %w", machineDeployment.GetName(), replicas, err)
+	}
+	return nil
+}
+
+func machineDeploymentReplicas(machineDeployment *unstructured.Unstructured) int {
+	replicas, found, _ := unstructured.NestedInt64(machineDeployment.Object, "spec", "replicas")
+	if !found {
+		// Cluster API defaults replicas to 1
+		return 1
+	}
+	return int(replicas)
+}
+
+// machineDeploymentSizeBounds returns the MachineDeployment's min and max size from its
+// autoscaler annotations, defaulting to 0 and DefaultMaxNodes
+func machineDeploymentSizeBounds(machineDeployment *unstructured.Unstructured) (int, int) {
+	annotations := machineDeployment.GetAnnotations()
+	minSize := 0
+	maxSize := DefaultMaxNodes
+
+	if value, err := strconv.Atoi(annotations[ClusterAPIMinSizeAnnotation]); err == nil && value >= 0 {
+		minSize = value
+	}
+	if value, err := strconv.Atoi(annotations[ClusterAPIMaxSizeAnnotation]); err == nil && value > 0 {
+		maxSize = value
+	}
+
+	return minSize, maxSize
+}
@@ -0,0 +1,284 @@
+package autoscaler
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func clusterAPITestGVK(kind string) schema.GroupVersionKind {
+	return NewClusterAPIProvider(nil, ClusterAPIProviderConfig{}).gvk(kind)
+}
+
+func newMachineDeployment(namespace, name string, replicas int64, annotations map[string]string) *unstructured.Unstructured {
+	machineDeployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"clusterName": "gpu-cluster",
+			"replicas":    replicas,
+			"selector": map[string]interface{}{
+				"matchLabels": map[string]interface{}{ClusterAPIDeploymentNameLabel: name},
+			},
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{
+						ClusterAPIDeploymentNameLabel:  name,
+						corev1.LabelInstanceTypeStable: "p3.8xlarge",
+					},
+				},
+				"spec": newMachineSpec(name, "us-west-2a"),
+			},
+		},
+	}}
+	machineDeployment.SetGroupVersionKind(clusterAPITestGVK("MachineDeployment"))
+	machineDeployment.SetNamespace(namespace)
+	machineDeployment.SetName(name)
+	machineDeployment.SetAnnotations(annotations)
+	return machineDeployment
+}
+
+// newMachine builds a Machine of the MachineDeployment that has joined the cluster as nodeName
+func newMachine(namespace, name, machineDeployment, nodeName string) *unstructured.Unstructured {
+	machine := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": newMachineSpec(machineDeployment, "us-west-2a"),
+		"status": map[string]interface{}{
+			"nodeRef": map[string]interface{}{"apiVersion": "v1", "kind": "Node", "name": nodeName},
+		},
+	}}
+	machine.SetGroupVersionKind(clusterAPITestGVK("Machine"))
+	machine.SetNamespace(namespace)
+	machine.SetName(name)
+	machine.SetLabels(map[string]string{ClusterAPIDeploymentNameLabel: machineDeployment})
+	return machine
+}
+
+func newMachineSpec(machineDeployment, failureDomain string) map[string]interface{} {
+	return map[string]interface{}{
+		"clusterName":   "gpu-cluster",
+		"failureDomain": failureDomain,
+		"bootstrap": map[string]interface{}{
+			"dataSecretName": machineDeployment + "-bootstrap",
+		},
+		"infrastructureRef": map[string]interface{}{
+			"apiVersion": "infrastructure.cluster.x-k8s.io/v1beta2",
+			"kind":       "AWSMachineTemplate",
+			"name":       machineDeployment,
+		},
+	}
+}
+
+// newClusterAPIEnvtestNamespace returns the envtest client and a namespace for the test's
+// MachineDeployments and Machines, deleted when the test ends
+func newClusterAPIEnvtestNamespace(t *testing.T) (client.Client, string) {
+	t.Helper()
+	k8sClient := newEnvtestClient(t)
+
+	namespace := &corev1.Namespace{}
+	namespace.GenerateName = "capi-"
+	if err := k8sClient.Create(context.Background(), namespace); err != nil {
+		t.Fatalf("failed to create namespace: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := client.IgnoreNotFound(k8sClient.Delete(context.Background(), namespace)); err != nil {
+			t.Errorf("failed to delete namespace %s: %v", namespace.Name, err)
+		}
+	})
+
+	return k8sClient, namespace.Name
+}
+
+func getTestMachineDeployment(t *testing.T, k8sClient client.Client, namespace, name string) *unstructured.Unstructured {
+	t.Helper()
+	machineDeployment := &unstructured.Unstructured{}
+	machineDeployment.SetGroupVersionKind(clusterAPITestGVK("MachineDeployment"))
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, machineDeployment); err != nil {
+		t.Fatalf("failed to get MachineDeployment: %v", err)
+	}
+	return machineDeployment
+}
+
+func TestClusterAPIProviderScaleUp(t *testing.T) {
+	tests := []struct {
+		name             string
+		replicas         int64
+		count            int
+		poolMaxSize      int
+		annotations      map[string]string
+		expectAtMaxSize  bool
+		expectedReplicas int
+	}{
+		{
+			name:             "Scale up within max size",
+			replicas:         2,
+			count:            1,
+			expectedReplicas: 3,
+		},
+		{
+			name:             "Scale up is capped at the pool's max size",
+			replicas:         2,
+			count:            5,
+			poolMaxSize:      4,
+			expectedReplicas: 4,
+		},
+		{
+			name:             "Scale up is capped at the MachineDeployment's max size annotation",
+			replicas:         2,
+			count:            5,
+			poolMaxSize:      10,
+			annotations:      map[string]string{ClusterAPIMaxSizeAnnotation: "3"},
+			expectedReplicas: 3,
+		},
+		{
+			name:             "Scale up at max size",
+			replicas:         4,
+			count:            1,
+			annotations:      map[string]string{ClusterAPIMaxSizeAnnotation: "4"},
+			expectAtMaxSize:  true,
+			expectedReplicas: 4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient, namespace := newClusterAPIEnvtestNamespace(t)
+			createWithStatus(t, k8sClient, newMachineDeployment(namespace, "gpu-md", tt.replicas, tt.annotations))
+			provider := NewClusterAPIProvider(k8sClient, ClusterAPIProviderConfig{
+				Namespace:          namespace,
+				MachineDeployments: map[string]string{"gpu": "gpu-md"},
+				Pricing:            &AWSProvider{},
+			})
+
+			err := provider.ScaleUp(context.Background(), &NodePoolConfig{Name: "gpu", MaxSize: tt.poolMaxSize}, tt.count)
+			if tt.expectAtMaxSize {
+				if !IsPoolAtMaxSize(err) {
+					t.Errorf("Expected a pool-at-max-size error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("ScaleUp() error = %v", err)
+			}
+
+			if got := machineDeploymentReplicas(getTestMachineDeployment(t, k8sClient, namespace, "gpu-md")); got != tt.expectedReplicas {
+				t.Errorf("Expected %d replicas, got %d", tt.expectedReplicas, got)
+			}
+		})
+	}
+}
+
+func TestClusterAPIProviderScaleDown(t *testing.T) {
+	tests := []struct {
+		name             string
+		replicas         int64
+		annotations      map[string]string
+		nodeName         string
+		expectErr        bool
+		expectedReplicas int
+		expectMarked     map[string]bool
+	}{
+		{
+			name:             "Scale down marks the node's machine for deletion",
+			replicas:         2,
+			nodeName:         "gpu-node-2",
+			expectedReplicas: 1,
+			expectMarked:     map[string]bool{"gpu-7d9f-abcde": false, "gpu-7d9f-fghij": true},
+		},
+		{
+			name:             "Scale down at min size",
+			replicas:         2,
+			annotations:      map[string]string{ClusterAPIMinSizeAnnotation: "2"},
+			nodeName:         "gpu-node-2",
+			expectErr:        true,
+			expectedReplicas: 2,
+			expectMarked:     map[string]bool{"gpu-7d9f-abcde": false, "gpu-7d9f-fghij": false},
+		},
+		{
+			name:             "Scale down of a node without a machine",
+			replicas:         2,
+			nodeName:         "unknown-node",
+			expectErr:        true,
+			expectedReplicas: 2,
+			expectMarked:     map[string]bool{"gpu-7d9f-abcde": false, "gpu-7d9f-fghij": false},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient, namespace := newClusterAPIEnvtestNamespace(t)
+			createWithStatus(t, k8sClient,
+				newMachineDeployment(namespace, "gpu", tt.replicas, tt.annotations),
+				newMachine(namespace, "gpu-7d9f-abcde", "gpu", "gpu-node-1"),
+				newMachine(namespace, "gpu-7d9f-fghij", "gpu", "gpu-node-2"),
+			)
+			provider := NewClusterAPIProvider(k8sClient, ClusterAPIProviderConfig{Namespace: namespace, Pricing: &AWSProvider{}})
+
+			if err := provider.ScaleDown(context.Background(), tt.nodeName); (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+
+			if got := machineDeploymentReplicas(getTestMachineDeployment(t, k8sClient, namespace, "gpu")); got != tt.expectedReplicas {
+				t.Errorf("Expected %d replicas, got %d", tt.expectedReplicas, got)
+			}
+
+			for name, expectMarked := range tt.expectMarked {
+				machine := &unstructured.Unstructured{}
+				machine.SetGroupVersionKind(clusterAPITestGVK("Machine"))
+				if err := k8sClient.Get(context.Background(), client.ObjectKey{Namespace: namespace, Name: name}, machine); err != nil {
+					t.Fatalf("failed to get machine: %v", err)
+				}
+				if _, marked := machine.GetAnnotations()[ClusterAPIDeleteMachine]; marked != expectMarked {
+					t.Errorf("Expected machine %s delete annotation %v, got %v", name, expectMarked, marked)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterAPIProviderGetNodePoolInfo(t *testing.T) {
+	k8sClient, namespace := newClusterAPIEnvtestNamespace(t)
+	createWithStatus(t, k8sClient, newMachineDeployment(namespace, "gpu", 2, map[string]string{
+		ClusterAPIMinSizeAnnotation:  "1",
+		ClusterAPIMaxSizeAnnotation:  "8",
+		ClusterAPIGPUCountAnnotation: "4",
+	}))
+	provider := NewClusterAPIProvider(k8sClient, ClusterAPIProviderConfig{Namespace: namespace, Pricing: &AWSProvider{}})
+
+	tests := []struct {
+		name      string
+		nodePool  string
+		expectErr bool
+	}{
+		{name: "MachineDeployment", nodePool: "gpu"},
+		{name: "Unknown MachineDeployment", nodePool: "missing", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := provider.GetNodePoolInfo(context.Background(), tt.nodePool)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if info.CurrentSize != 2 || info.MinSize != 1 || info.MaxSize != 8 || info.AvailableGPUs != 8 {
+				t.Errorf("Unexpected sizes: current=%d min=%d max=%d gpus=%d", info.CurrentSize, info.MinSize, info.MaxSize, info.AvailableGPUs)
+			}
+			if info.InstanceType != "p3.8xlarge" || info.CapacityType != CapacityTypeOnDemand {
+				t.Errorf("Unexpected pool info: %+v", info)
+			}
+			if info.Cost != 24.48 {
+				t.Errorf("Expected hourly cost 24.48, got %.2f", info.Cost)
+			}
+		})
+	}
+
+	zones, err := provider.GetAvailabilityZones(context.Background())
+	if err != nil {
+		t.Fatalf("GetAvailabilityZones() error = %v", err)
+	}
+	if len(zones) != 1 || zones[0] != "us-west-2a" {
+		t.Errorf("Expected zones [us-west-2a], got %v", zones)
+	}
+}
@@ -3,18 +3,31 @@ package autoscaler
 import (
 	"context"
 	"fmt"
+	"maps"
 	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
 	"github.com/gpuautoscaler/gpuautoscaler/pkg/metrics"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/scheduler"
 )
 
 const (
@@ -48,23 +61,37 @@ const (
 	EvictionPriorityLow    = "low"
 )
 
-// AutoscalerController manages GPU node autoscaling
+// AutoscalerController manages GPU node autoscaling for each AutoscalingPolicy
 type AutoscalerController struct {
 	client.Client
 	Scheme           *runtime.Scheme
 	Log              logr.Logger
-	MetricsCollector *metrics.Collector
+	Recorder         record.EventRecorder
+	Metrics          *MetricsRecorder
+	MetricsCollector GPUMetricsSource
 	CloudProvider    CloudProvider
 	PredictiveScaler *PredictiveScaler
 	SpotOrchestrator *SpotOrchestrator
 
-	// Configuration
+	// Clock returns the current time; defaults to time.Now and can be replaced with a fake clock
+	Clock func() time.Time
+
+	// History persists scaling events beyond the in-memory history; optional
+	History HistoryStore
+
+	// Configuration defaults for settings not carried by AutoscalingPolicy
 	Config AutoscalerConfig
 
-	// State tracking
-	lastScaleUpTime   time.Time
-	lastScaleDownTime time.Time
-	scalingHistory    []ScalingEvent
+	// State tracking, keyed by policy name
+	stateMu        sync.Mutex
+	policyStates   map[string]*policyState
+	scalingHistory []ScalingEvent
+}
+
+// GPUMetricsSource provides current per-GPU utilization metrics.
+// It is satisfied by *metrics.Collector and by replayed traces in the simulator.
+type GPUMetricsSource interface {
+	GetGPUMetrics(ctx context.Context) ([]metrics.GPUMetrics, error)
 }
 
 // AutoscalerConfig holds the autoscaler configuration
@@ -75,6 +102,9 @@ type AutoscalerConfig struct {
 	ScaleUpCooldown        time.Duration
 	ScaleDownCooldown      time.Duration
 	PendingPodTimeout      time.Duration
+	DrainTimeout           time.Duration
+	ProvisioningTimeout    time.Duration
+	CapacityBackoff        time.Duration
 	MaxNodes               int
 	MinNodes               int
 	SpotInstancePercentage float64
@@ -82,6 +112,9 @@ type AutoscalerConfig struct {
 	EnableSpotInstances    bool
 	EnableMultiTierScaling bool
 	NodePools              []NodePoolConfig
+
+	// DryRun records scaling decisions without acting on them
+	DryRun bool
 }
 
 // NodePoolConfig defines a GPU node pool
@@ -91,21 +124,34 @@ type NodePoolConfig struct {
 	MaxSize          int
 	GPUType          string
 	InstanceTypes    []string
+	GPUsPerNode      int // 0 reads it from the pool's nodes or the cloud provider
 	CapacityType     string
 	SpotPercentage   float64
 	Priority         int
 	Labels           map[string]string
 	Taints           []corev1.Taint
+	AvailabilityZones []string
+
+	// IdleTimeout drains the pool down to MinSize once it has run no GPU pods for this long; zero disables it
+	IdleTimeout      time.Duration
 }
 
-// ScalingEvent records a scaling action
+// ScalingEvent records a scaling action together with the inputs of the decision behind it
 type ScalingEvent struct {
-	Timestamp    time.Time
-	Action       ScalingAction
-	Reason       string
-	NodeCount    int
-	CapacityType string
-	Success      bool
+	Policy             string        `json:"policy"`
+	Timestamp          time.Time     `json:"timestamp"`
+	Action             ScalingAction `json:"action"`
+	Reason             string        `json:"reason"`
+	NodeCount          int           `json:"nodeCount"`
+	CurrentNodeCount   int           `json:"currentNodeCount"`
+	CapacityType       string        `json:"capacityType,omitempty"`
+	NodePool           string        `json:"nodePool,omitempty"`
+	GPUUtilization     float64       `json:"gpuUtilization"`
+	PendingPods        int           `json:"pendingPods"`
+	UnderutilizedNodes int           `json:"underutilizedNodes"`
+	Success            bool          `json:"success"`
+	DryRun             bool          `json:"dryRun,omitempty"`
+	Error              string        `json:"error,omitempty"`
 }
 
 // ScalingAction represents a scaling operation
@@ -121,7 +167,9 @@ const (
 type ScalingDecision struct {
 	Action           ScalingAction
 	Reason           string
+	CurrentNodeCount int
 	DesiredNodeCount int
+	ProvisioningNodes int
 	CapacityType     string
 	NodePool         string
 	Priority         int
@@ -134,7 +182,8 @@ type ScalingDecision struct {
 func NewAutoscalerController(
 	client client.Client,
 	scheme *runtime.Scheme,
-	metricsCollector *metrics.Collector,
+	recorder record.EventRecorder,
+	metricsCollector GPUMetricsSource,
 	cloudProvider CloudProvider,
 	config AutoscalerConfig,
 ) *AutoscalerController {
@@ -144,15 +193,21 @@ func NewAutoscalerController(
 		Client:           client,
 		Scheme:           scheme,
 		Log:              logger,
+		Recorder:         recorder,
+		Metrics:          NewMetricsRecorder(),
 		MetricsCollector: metricsCollector,
 		CloudProvider:    cloudProvider,
 		Config:           config,
+		Clock:            time.Now,
+		policyStates:     make(map[string]*policyState),
 		scalingHistory:   make([]ScalingEvent, 0),
 	}
 
-	// Initialize predictive scaler if enabled
-	if config.EnablePredictiveScaling {
+	// Initialize the predictive scaler whenever there are metrics to learn from, since
+	// policies can enable prediction even when the controller default does not
+	if metricsCollector != nil {
 		ac.PredictiveScaler = NewPredictiveScaler(metricsCollector)
+		ac.PredictiveScaler.Clock = ac.now
 	}
 
 	// Initialize spot orchestrator if enabled
@@ -163,21 +218,46 @@ func NewAutoscalerController(
 	return ac
 }
 
-// Reconcile implements the reconciliation loop
+// Reconcile implements the reconciliation loop for a single AutoscalingPolicy
 func (r *AutoscalerController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
-	logger := r.Log.WithValues("request", req.NamespacedName)
+	logger := r.Log.WithValues("policy", req.Name)
+
+	policy := &v1alpha1.AutoscalingPolicy{}
+	if err := r.Get(ctx, req.NamespacedName, policy); err != nil {
+		if errors.IsNotFound(err) {
+			r.forgetPolicy(req.Name)
+			return ctrl.Result{}, nil
+		}
+		return ctrl.Result{}, err
+	}
+
+	scope := r.newScalingScope(policy)
+
+	// Skip disabled policies but keep their status accurate
+	if !policy.Spec.Enabled {
+		setPolicyCondition(policy, ConditionTypeReady, metav1.ConditionFalse, ReasonDisabled, "autoscaling is disabled for this policy")
+		if err := r.Status().Update(ctx, policy); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, nil
+	}
 
 	// Analyze cluster state and make scaling decision
-	decision, err := r.analyzeClusterState(ctx)
+	decision, err := r.analyzeClusterState(ctx, scope)
 	if err != nil {
 		logger.Error(err, "failed to analyze cluster state")
-		return ctrl.Result{RequeueAfter: r.Config.ReconcileInterval}, err
+		setPolicyCondition(policy, ConditionTypeReady, metav1.ConditionFalse, ReasonReconcileFailed, err.Error())
+		if statusErr := r.Status().Update(ctx, policy); statusErr != nil {
+			logger.Error(statusErr, "failed to update policy status")
+		}
+		return ctrl.Result{RequeueAfter: scope.config.ReconcileInterval}, err
 	}
 
 	// Log decision
 	logger.Info("scaling decision",
 		"action", decision.Action,
 		"reason", decision.Reason,
+		"currentNodes", decision.CurrentNodeCount,
 		"desiredNodes", decision.DesiredNodeCount,
 		"capacityType", decision.CapacityType,
 		"gpuUtilization", decision.GPUUtilization,
@@ -185,99 +265,178 @@ func (r *AutoscalerController) Reconcile(ctx context.Context, req ctrl.Request)
 	)
 
 	// Execute scaling action
+	var scalingErr error
 	if decision.Action != NoAction {
-		if err := r.executeScalingAction(ctx, decision); err != nil {
-			logger.Error(err, "failed to execute scaling action")
-			r.recordScalingEvent(decision.Action, decision.Reason, 0, decision.CapacityType, false)
-			return ctrl.Result{RequeueAfter: r.Config.ReconcileInterval}, err
+		if scalingErr = r.executeScalingAction(ctx, scope, decision); scalingErr != nil {
+			logger.Error(scalingErr, "failed to execute scaling action")
 		}
-		r.recordScalingEvent(decision.Action, decision.Reason, decision.DesiredNodeCount, decision.CapacityType, true)
+		r.recordScalingEvent(ctx, scope, decision, scalingErr)
 	}
 
-	return ctrl.Result{RequeueAfter: r.Config.ReconcileInterval}, nil
+	if err := r.updatePolicyStatus(ctx, scope, decision, scalingErr); err != nil {
+		logger.Error(err, "failed to update policy status")
+		return ctrl.Result{RequeueAfter: scope.config.ReconcileInterval}, err
+	}
+
+	return ctrl.Result{RequeueAfter: scope.config.ReconcileInterval}, scalingErr
 }
 
-// analyzeClusterState analyzes the cluster and makes a scaling decision
-func (r *AutoscalerController) analyzeClusterState(ctx context.Context) (*ScalingDecision, error) {
+// analyzeClusterState analyzes the nodes and pods covered by a policy and makes a scaling decision
+func (r *AutoscalerController) analyzeClusterState(ctx context.Context, scope *scalingScope) (*ScalingDecision, error) {
+	// Apply the node limits of the capacity window in effect
+	r.applySchedules(scope)
+
 	// Get current GPU nodes
-	nodes, err := r.getGPUNodes(ctx)
+	nodes, err := r.getGPUNodes(ctx, scope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get GPU nodes: %w", err)
 	}
+	scope.nodes = nodes
+	r.resolveNodePools(ctx, scope)
+
+	// Nodes requested earlier that are still booting count toward capacity
+	r.trackProvisioning(ctx, scope)
+	provisioning := scope.provisioningNodeCount()
+
+	// Track how long the node pools that scale to zero have been idle
+	if err := r.trackNodePools(ctx, scope); err != nil {
+		return nil, fmt.Errorf("failed to track node pools: %w", err)
+	}
 
 	// Get pending GPU pods
-	pendingPods, err := r.getPendingGPUPods(ctx)
+	pendingPods, err := r.getPendingGPUPods(ctx, scope)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get pending GPU pods: %w", err)
 	}
+	scope.pendingPods = pendingPods
 
 	// Get GPU utilization metrics
-	avgUtilization, err := r.getAverageGPUUtilization(ctx)
+	gpuMetrics, err := r.getGPUMetrics(ctx)
 	if err != nil {
 		r.Log.Error(err, "failed to get GPU utilization, using 0")
-		avgUtilization = 0
+		gpuMetrics = nil
 	}
+	avgUtilization := averageGPUUtilization(gpuMetrics, nodes)
 
 	// Count underutilized nodes
-	underutilizedNodes := r.countUnderutilizedNodes(ctx, nodes)
+	underutilizedNodes := r.countUnderutilizedNodes(scope, gpuMetrics, nodes)
 
 	decision := &ScalingDecision{
 		Action:             NoAction,
 		Reason:             "cluster stable",
-		DesiredNodeCount:   len(nodes),
+		CurrentNodeCount:   len(nodes),
+		DesiredNodeCount:   len(nodes) + provisioning,
+		ProvisioningNodes:  provisioning,
 		GPUUtilization:     avgUtilization,
 		PendingPods:        len(pendingPods),
 		UnderutilizedNodes: underutilizedNodes,
 	}
 
-	// Check for scale-up conditions
-	if r.shouldScaleUp(ctx, nodes, pendingPods, avgUtilization) {
+	if pool, count, reason := r.planMaximumCapacity(scope); count > 0 {
+		// Bring the policy down to the MaxNodes and each pool down to the MaxSize of the capacity window
+		decision.Action = ScaleDown
+		decision.Reason = reason
+		decision.DesiredNodeCount = len(nodes) - count
+		if pool != nil {
+			decision.NodePool = pool.Name
+			decision.CapacityType = pool.CapacityType
+		} else {
+			decision.CapacityType = r.selectNodesForScaleDown(nodes)
+		}
+	} else if r.shouldScaleUp(scope, nodes, pendingPods, avgUtilization) {
+		// Check for scale-up conditions
 		decision.Action = ScaleUp
-		decision.Reason = r.getScaleUpReason(pendingPods, avgUtilization)
-		decision.DesiredNodeCount = r.calculateScaleUpNodeCount(nodes, pendingPods)
+		decision.Reason = r.getScaleUpReason(scope, pendingPods, avgUtilization)
 
 		// Determine capacity type for new nodes (multi-tier strategy)
-		decision.CapacityType, decision.NodePool = r.selectCapacityType(nodes)
+		decision.CapacityType, decision.NodePool = r.selectCapacityType(scope, nodes)
 		decision.Priority = r.calculateScalingPriority(pendingPods)
-	} else if r.shouldScaleDown(ctx, nodes, avgUtilization, underutilizedNodes) {
+
+		// Size the scale-up by packing the pending pods onto the pool's node shape
+		nodePool, nodesNeeded := r.planScaleUp(ctx, scope, decision.CapacityType, pendingPods)
+		if nodePool != nil {
+			decision.NodePool = nodePool.Name
+			if nodePool.CapacityType != "" {
+				decision.CapacityType = nodePool.CapacityType
+			}
+		}
+		switch {
+		case len(pendingPods) > 0 && nodesNeeded == 0 && len(scope.backedOffPools) > 0:
+			decision.Action = NoAction
+			decision.Reason = fmt.Sprintf("%d pending GPU pods only fit on node pools backed off after capacity errors: %s",
+				len(pendingPods), strings.Join(scope.backedOffPools, ", "))
+		case len(pendingPods) > 0 && nodesNeeded == 0:
+			decision.Action = NoAction
+			decision.Reason = fmt.Sprintf("%d pending GPU pods do not fit on any node pool", len(pendingPods))
+		case provisioning > 0 && nodesNeeded <= scope.provisioning[decision.NodePool]:
+			// The nodes already on their way cover the demand
+			decision.Action = NoAction
+			decision.Reason = fmt.Sprintf("waiting for %d provisioning nodes", provisioning)
+		default:
+			decision.DesiredNodeCount = r.calculateScaleUpNodeCount(scope, nodes, nodesNeeded-scope.provisioning[decision.NodePool])
+		}
+	} else if pool, count, reason := r.planMinimumCapacity(scope); pool != nil {
+		// Bring the policy up to the MinNodes and each pool up to the MinSize of the capacity window
+		decision.Action = ScaleUp
+		decision.Reason = reason
+		decision.NodePool = pool.Name
+		decision.CapacityType = pool.CapacityType
+		if decision.CapacityType == "" {
+			decision.CapacityType, _ = r.selectCapacityType(scope, nodes)
+		}
+		decision.DesiredNodeCount = len(nodes) + provisioning + count
+	} else if pool, count := r.selectIdleNodePool(scope); pool != nil {
+		// Drain a node pool that has run no GPU pods for its idle timeout
 		decision.Action = ScaleDown
-		decision.Reason = r.getScaleDownReason(avgUtilization, underutilizedNodes)
-		decision.DesiredNodeCount = r.calculateScaleDownNodeCount(nodes, underutilizedNodes)
+		decision.Reason = fmt.Sprintf("node pool %s has run no GPU pods for %s", pool.Name, pool.IdleTimeout)
+		decision.NodePool = pool.Name
+		decision.CapacityType = pool.CapacityType
+		decision.DesiredNodeCount = len(nodes) - count
+	} else if r.shouldScaleDown(scope, nodes, avgUtilization, underutilizedNodes) {
+		decision.Action = ScaleDown
+		decision.Reason = r.getScaleDownReason(scope, avgUtilization, underutilizedNodes)
+		decision.DesiredNodeCount = r.calculateScaleDownNodeCount(scope, nodes, underutilizedNodes)
 
 		// Select nodes to remove (prefer spot instances)
 		decision.CapacityType = r.selectNodesForScaleDown(nodes)
 	}
 
 	// Apply predictive scaling if enabled
-	if r.Config.EnablePredictiveScaling && r.PredictiveScaler != nil {
-		r.applyPredictiveScaling(ctx, decision)
+	if scope.config.EnablePredictiveScaling {
+		r.applyPredictiveScaling(ctx, scope, decision)
 	}
 
 	return decision, nil
 }
 
 // shouldScaleUp determines if the cluster should scale up
-func (r *AutoscalerController) shouldScaleUp(ctx context.Context, nodes []corev1.Node, pendingPods []corev1.Pod, avgUtilization float64) bool {
-	// Check cooldown period
-	if time.Since(r.lastScaleUpTime) < r.Config.ScaleUpCooldown {
+func (r *AutoscalerController) shouldScaleUp(scope *scalingScope, nodes []corev1.Node, pendingPods []corev1.Pod, avgUtilization float64) bool {
+	// Check max nodes limit, counting nodes that are still provisioning
+	if len(nodes)+scope.provisioningNodeCount() >= scope.config.MaxNodes {
 		return false
 	}
 
-	// Check max nodes limit
-	if len(nodes) >= r.Config.MaxNodes {
+	// Wake a node pool scaled to zero as soon as a pod it can host is unschedulable,
+	// without waiting for the cooldown or the pending pod timeout
+	if r.scaledToZeroPoolFor(scope, pendingPods) != nil {
+		return true
+	}
+
+	// Check cooldown period
+	if r.now().Sub(scope.state.lastScaleUpTime) < scope.config.ScaleUpCooldown {
 		return false
 	}
 
 	// Scale up if there are pending GPU pods waiting too long
 	if len(pendingPods) > 0 {
 		oldestPendingPod := r.getOldestPendingPod(pendingPods)
-		if time.Since(oldestPendingPod.CreationTimestamp.Time) > r.Config.PendingPodTimeout {
+		if r.now().Sub(oldestPendingPod.CreationTimestamp.Time) > scope.config.PendingPodTimeout {
 			return true
 		}
 	}
 
 	// Scale up if GPU utilization is too high
-	if avgUtilization > r.Config.ScaleUpThreshold && len(nodes) > 0 {
+	if avgUtilization > scope.config.ScaleUpThreshold && len(nodes) > 0 {
 		return true
 	}
 
@@ -285,105 +444,123 @@ func (r *AutoscalerController) shouldScaleUp(ctx context.Context, nodes []corev1
 }
 
 // shouldScaleDown determines if the cluster should scale down
-func (r *AutoscalerController) shouldScaleDown(ctx context.Context, nodes []corev1.Node, avgUtilization float64, underutilizedNodes int) bool {
+func (r *AutoscalerController) shouldScaleDown(scope *scalingScope, nodes []corev1.Node, avgUtilization float64, underutilizedNodes int) bool {
 	// Check cooldown period
-	if time.Since(r.lastScaleDownTime) < r.Config.ScaleDownCooldown {
+	if r.now().Sub(scope.state.lastScaleDownTime) < scope.config.ScaleDownCooldown {
 		return false
 	}
 
 	// Check min nodes limit
-	if len(nodes) <= r.Config.MinNodes {
+	if len(nodes) <= scope.config.MinNodes {
 		return false
 	}
 
 	// Scale down if there are underutilized nodes
-	if underutilizedNodes > 0 && avgUtilization < r.Config.ScaleDownThreshold {
+	if underutilizedNodes > 0 && avgUtilization < scope.config.ScaleDownThreshold {
 		return true
 	}
 
 	return false
 }
 
-// executeScalingAction executes the scaling decision
-func (r *AutoscalerController) executeScalingAction(ctx context.Context, decision *ScalingDecision) error {
+// executeScalingAction executes the scaling decision.
+// In dry-run mode the decision is only recorded and no nodes are touched.
+func (r *AutoscalerController) executeScalingAction(ctx context.Context, scope *scalingScope, decision *ScalingDecision) error {
+	if scope.config.DryRun {
+		r.recordShadowDecision(scope, decision)
+		return nil
+	}
+
 	switch decision.Action {
 	case ScaleUp:
-		return r.scaleUp(ctx, decision)
+		return r.scaleUp(ctx, scope, decision)
 	case ScaleDown:
-		return r.scaleDown(ctx, decision)
+		return r.scaleDown(ctx, scope, decision)
 	default:
 		return nil
 	}
 }
 
 // scaleUp adds new GPU nodes to the cluster
-func (r *AutoscalerController) scaleUp(ctx context.Context, decision *ScalingDecision) error {
+func (r *AutoscalerController) scaleUp(ctx context.Context, scope *scalingScope, decision *ScalingDecision) error {
 	r.Log.Info("scaling up cluster",
-		"currentNodes", decision.DesiredNodeCount-1,
+		"policy", scope.policy.Name,
+		"currentNodes", decision.CurrentNodeCount,
 		"targetNodes", decision.DesiredNodeCount,
 		"capacityType", decision.CapacityType,
 		"reason", decision.Reason,
 	)
 
 	// Get node pool configuration
-	nodePool := r.getNodePoolByName(decision.NodePool)
+	nodePool := r.getNodePoolByName(scope, decision.NodePool)
 	if nodePool == nil {
 		return fmt.Errorf("node pool %s not found", decision.NodePool)
 	}
 
-	// Calculate number of nodes to add
-	nodesToAdd := decision.DesiredNodeCount - (decision.DesiredNodeCount - 1)
+	// Calculate number of nodes to add, leaving out nodes that are still provisioning
+	nodesToAdd := decision.DesiredNodeCount - decision.CurrentNodeCount - decision.ProvisioningNodes
+	if nodesToAdd <= 0 {
+		return nil
+	}
 
-	// Use cloud provider to add nodes
-	if err := r.CloudProvider.ScaleUp(ctx, nodePool, nodesToAdd); err != nil {
-		return fmt.Errorf("failed to scale up: %w", err)
+	// Use cloud provider to add nodes, falling back to other capacity if it is unavailable
+	if err := r.requestNodes(ctx, scope, decision, nodePool, nodesToAdd); err != nil {
+		return err
 	}
+	r.reportColdStart(scope, decision)
 
 	// Update timestamp
-	r.lastScaleUpTime = time.Now()
+	scope.state.lastScaleUpTime = r.now()
 
 	return nil
 }
 
 // scaleDown removes GPU nodes from the cluster
-func (r *AutoscalerController) scaleDown(ctx context.Context, decision *ScalingDecision) error {
+func (r *AutoscalerController) scaleDown(ctx context.Context, scope *scalingScope, decision *ScalingDecision) error {
 	r.Log.Info("scaling down cluster",
-		"currentNodes", decision.DesiredNodeCount+1,
+		"policy", scope.policy.Name,
+		"currentNodes", decision.CurrentNodeCount,
 		"targetNodes", decision.DesiredNodeCount,
 		"capacityType", decision.CapacityType,
 		"reason", decision.Reason,
 	)
 
-	// Get GPU nodes
-	nodes, err := r.getGPUNodes(ctx)
-	if err != nil {
-		return fmt.Errorf("failed to get GPU nodes: %w", err)
-	}
-
 	// Select nodes to remove
-	nodesToRemove := r.selectNodesToRemove(nodes, decision)
+	nodesToRemove := r.selectNodesToRemove(scope, decision)
 
 	// Drain and remove nodes
 	for _, node := range nodesToRemove {
-		if err := r.drainNode(ctx, &node); err != nil {
-			r.Log.Error(err, "failed to drain node", "node", node.Name)
-			continue
+		if err := r.drainNode(ctx, scope, &node); err != nil {
+			if IsDrainBlocked(err) {
+				r.Log.Info("skipping node with pods that are not safe to evict", "node", node.Name, "reason", err.Error())
+				continue
+			}
+			// Pods that cannot move within the drain timeout abort the scale-down; the cooldown
+			// still starts so the node is not cordoned and drained again on every reconcile
+			scope.state.lastScaleDownTime = r.now()
+			return fmt.Errorf("failed to drain node %s: %w", node.Name, err)
 		}
 
 		if err := r.CloudProvider.ScaleDown(ctx, node.Name); err != nil {
-			r.Log.Error(err, "failed to remove node", "node", node.Name)
-			continue
+			// Put the drained node back in service rather than leaving it cordoned and empty
+			if uncordonErr := NewDrainer(r.Client, r.Log).Uncordon(ctx, &node); uncordonErr != nil {
+				r.Log.Error(uncordonErr, "failed to uncordon node after failed removal", "node", node.Name)
+			}
+			scope.state.lastScaleDownTime = r.now()
+			return fmt.Errorf("failed to remove node %s: %w", node.Name, err)
 		}
 	}
 
 	// Update timestamp
-	r.lastScaleDownTime = time.Now()
+	scope.state.lastScaleDownTime = r.now()
 
 	return nil
 }
 
-// getGPUNodes returns all GPU nodes in the cluster
-func (r *AutoscalerController) getGPUNodes(ctx context.Context) ([]corev1.Node, error) {
+// getGPUNodes returns the Ready GPU nodes selected by the policy's NodeSelector.
+// NotReady nodes and the nodes of powered-off Redfish hosts provide no capacity and are never
+// scale-down candidates; booting nodes are counted through provisioning tracking instead.
+func (r *AutoscalerController) getGPUNodes(ctx context.Context, scope *scalingScope) ([]corev1.Node, error) {
 	nodeList := &corev1.NodeList{}
 	if err := r.List(ctx, nodeList); err != nil {
 		return nil, err
@@ -391,8 +568,11 @@ func (r *AutoscalerController) getGPUNodes(ctx context.Context) ([]corev1.Node,
 
 	gpuNodes := make([]corev1.Node, 0)
 	for _, node := range nodeList.Items {
+		if _, poweredOff := node.Annotations[RedfishPoweredOffAnnotation]; poweredOff || !isNodeReady(&node) {
+			continue
+		}
 		// Check if node has GPU resources
-		if _, hasNvidiaGPU := node.Status.Capacity["nvidia.com/gpu"]; hasNvidiaGPU {
+		if hasGPUCapacity(&node) && scope.matchesNode(&node) {
 			gpuNodes = append(gpuNodes, node)
 		}
 	}
@@ -400,8 +580,17 @@ func (r *AutoscalerController) getGPUNodes(ctx context.Context) ([]corev1.Node,
 	return gpuNodes, nil
 }
 
-// getPendingGPUPods returns all pending pods requesting GPUs
-func (r *AutoscalerController) getPendingGPUPods(ctx context.Context) ([]corev1.Pod, error) {
+func isNodeReady(node *corev1.Node) bool {
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == corev1.NodeReady {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// getPendingGPUPods returns pending pods requesting GPUs that the policy could serve
+func (r *AutoscalerController) getPendingGPUPods(ctx context.Context, scope *scalingScope) ([]corev1.Pod, error) {
 	podList := &corev1.PodList{}
 	if err := r.List(ctx, podList); err != nil {
 		return nil, err
@@ -409,7 +598,7 @@ func (r *AutoscalerController) getPendingGPUPods(ctx context.Context) ([]corev1.
 
 	pendingPods := make([]corev1.Pod, 0)
 	for _, pod := range podList.Items {
-		if pod.Status.Phase == corev1.PodPending && r.isGPUPod(&pod) {
+		if pod.Status.Phase == corev1.PodPending && r.isGPUPod(&pod) && scope.matchesPod(&pod) {
 			pendingPods = append(pendingPods, pod)
 		}
 	}
@@ -425,7 +614,7 @@ func (r *AutoscalerController) isGPUPod(pod *corev1.Pod) bool {
 		}
 		// Check for MIG profiles
 		for resourceName := range container.Resources.Requests {
-			if len(resourceName) > 11 && string(resourceName)[:11] == "nvidia.com/mig-" {
+			if strings.HasPrefix(string(resourceName), "nvidia.com/mig-") {
 				return true
 			}
 		}
@@ -433,61 +622,48 @@ func (r *AutoscalerController) isGPUPod(pod *corev1.Pod) bool {
 	return false
 }
 
-// getAverageGPUUtilization returns the average GPU utilization across all nodes
-func (r *AutoscalerController) getAverageGPUUtilization(ctx context.Context) (float64, error) {
-	gpuMetrics, err := r.MetricsCollector.GetGPUMetrics(ctx)
-	if err != nil {
-		return 0, err
-	}
-
-	if len(gpuMetrics) == 0 {
-		return 0, nil
+// getGPUMetrics fetches current GPU metrics, returning none when no collector is configured
+func (r *AutoscalerController) getGPUMetrics(ctx context.Context) ([]metrics.GPUMetrics, error) {
+	if r.MetricsCollector == nil {
+		return nil, nil
 	}
-
-	var totalUtilization float64
-	for _, metric := range gpuMetrics {
-		totalUtilization += metric.GPUUtilization
-	}
-
-	return totalUtilization / float64(len(gpuMetrics)), nil
+	return r.MetricsCollector.GetGPUMetrics(ctx)
 }
 
-// countUnderutilizedNodes counts nodes with low GPU utilization
-func (r *AutoscalerController) countUnderutilizedNodes(ctx context.Context, nodes []corev1.Node) int {
-	count := 0
+// averageGPUUtilization returns the average GPU utilization (0-1) across the given nodes
+func averageGPUUtilization(gpuMetrics []metrics.GPUMetrics, nodes []corev1.Node) float64 {
+	nodeNames := make(map[string]bool, len(nodes))
 	for _, node := range nodes {
-		utilization, err := r.getNodeGPUUtilization(ctx, node.Name)
-		if err != nil {
-			continue
-		}
-		if utilization < r.Config.ScaleDownThreshold {
-			count++
-		}
-	}
-	return count
-}
-
-// getNodeGPUUtilization returns the average GPU utilization for a node
-func (r *AutoscalerController) getNodeGPUUtilization(ctx context.Context, nodeName string) (float64, error) {
-	gpuMetrics, err := r.MetricsCollector.GetGPUMetrics(ctx)
-	if err != nil {
-		return 0, err
+		nodeNames[node.Name] = true
 	}
 
 	var totalUtilization float64
 	var count int
 	for _, metric := range gpuMetrics {
-		if metric.NodeName == nodeName {
+		if nodeNames[metric.NodeName] {
 			totalUtilization += metric.GPUUtilization
 			count++
 		}
 	}
 
 	if count == 0 {
-		return 0, nil
+		return 0
 	}
 
-	return totalUtilization / float64(count), nil
+	// DCGM reports utilization as a percentage; thresholds are expressed as 0-1
+	return totalUtilization / float64(count) / 100
+}
+
+// countUnderutilizedNodes counts nodes with low GPU utilization
+func (r *AutoscalerController) countUnderutilizedNodes(scope *scalingScope, gpuMetrics []metrics.GPUMetrics, nodes []corev1.Node) int {
+	count := 0
+	for _, node := range nodes {
+		utilization := averageGPUUtilization(gpuMetrics, []corev1.Node{node})
+		if utilization < scope.config.ScaleDownThreshold {
+			count++
+		}
+	}
+	return count
 }
 
 // Helper methods
@@ -505,37 +681,252 @@ func (r *AutoscalerController) getOldestPendingPod(pods []corev1.Pod) corev1.Pod
 	return oldest
 }
 
-func (r *AutoscalerController) getScaleUpReason(pendingPods []corev1.Pod, utilization float64) string {
+func (r *AutoscalerController) getScaleUpReason(scope *scalingScope, pendingPods []corev1.Pod, utilization float64) string {
 	if len(pendingPods) > 0 {
 		return fmt.Sprintf("%d pending GPU pods waiting", len(pendingPods))
 	}
-	return fmt.Sprintf("GPU utilization %.1f%% exceeds threshold %.1f%%", utilization*100, r.Config.ScaleUpThreshold*100)
+	return fmt.Sprintf("GPU utilization %.1f%% exceeds threshold %.1f%%", utilization*100, scope.config.ScaleUpThreshold*100)
 }
 
-func (r *AutoscalerController) getScaleDownReason(utilization float64, underutilized int) string {
-	return fmt.Sprintf("GPU utilization %.1f%% below threshold %.1f%%, %d underutilized nodes", utilization*100, r.Config.ScaleDownThreshold*100, underutilized)
+func (r *AutoscalerController) getScaleDownReason(scope *scalingScope, utilization float64, underutilized int) string {
+	return fmt.Sprintf("GPU utilization %.1f%% below threshold %.1f%%, %d underutilized nodes", utilization*100, scope.config.ScaleDownThreshold*100, underutilized)
 }
 
-func (r *AutoscalerController) calculateScaleUpNodeCount(nodes []corev1.Node, pendingPods []corev1.Pod) int {
-	// Estimate nodes needed based on pending pods
-	// Assume each node can handle 4-8 GPU pods (conservative)
-	nodesNeeded := int(math.Ceil(float64(len(pendingPods)) / 4.0))
-	targetNodes := len(nodes) + nodesNeeded
+func (r *AutoscalerController) calculateScaleUpNodeCount(scope *scalingScope, nodes []corev1.Node, nodesNeeded int) int {
+	if nodesNeeded == 0 {
+		// Utilization-driven scale-up adds a single node
+		nodesNeeded = 1
+	}
+	targetNodes := len(nodes) + scope.provisioningNodeCount() + nodesNeeded
 
-	if targetNodes > r.Config.MaxNodes {
-		targetNodes = r.Config.MaxNodes
+	if targetNodes > scope.config.MaxNodes {
+		targetNodes = scope.config.MaxNodes
 	}
 
 	return targetNodes
 }
 
-func (r *AutoscalerController) calculateScaleDownNodeCount(nodes []corev1.Node, underutilized int) int {
-	// Remove underutilized nodes gradually (max 20% at a time)
+// planMinimumCapacity returns the node pool to scale up, and by how many nodes, to bring a pool up to
+// the MinSize or the policy up to the MinNodes the capacity window in effect sets, counting nodes that
+// are still provisioning. Outside a window, minimums only stop scale-downs. Pool minimums are skipped
+// while the pools of some nodes are unknown.
+func (r *AutoscalerController) planMinimumCapacity(scope *scalingScope) (*NodePoolConfig, int, string) {
+	window := scope.schedule
+	total := len(scope.nodes) + scope.provisioningNodeCount()
+	room := scope.config.MaxNodes - total
+	if window == nil || room <= 0 {
+		return nil, 0, ""
+	}
+
+	for _, override := range window.NodePools {
+		pool := r.getNodePoolByName(scope, override.Name)
+		if override.MinSize == nil || pool == nil || scope.unresolvedNodes > 0 {
+			continue
+		}
+		nodes := len(r.nodePoolNodes(scope, pool)) + scope.provisioning[pool.Name]
+		if nodes >= pool.MinSize || r.nodePoolBackedOff(scope, pool) {
+			continue
+		}
+		count := pool.MinSize - nodes
+		if count > room {
+			count = room
+		}
+		// Nodes for a pool's minimum size must come from that pool, so it is the only fallback
+		scope.scaleUpCandidates = []scaleUpCandidate{{pool: pool, nodes: count}}
+		return pool, count, fmt.Sprintf("node pool %s has %d nodes, below the minimum of %d in capacity window %s",
+			pool.Name, nodes, pool.MinSize, window.Name)
+	}
+
+	if window.MinNodes != nil && total < scope.config.MinNodes {
+		_, poolName := r.selectCapacityType(scope, scope.nodes)
+		pool := r.getNodePoolByName(scope, poolName)
+		if pool == nil || r.nodePoolBackedOff(scope, pool) {
+			return nil, 0, ""
+		}
+		count := scope.config.MinNodes - total
+		if count > room {
+			count = room
+		}
+		return pool, count, fmt.Sprintf("policy has %d nodes, below the minimum of %d in capacity window %s",
+			total, scope.config.MinNodes, window.Name)
+	}
+
+	return nil, 0, ""
+}
+
+// planMaximumCapacity returns how many nodes to remove when the capacity window in effect lowers the
+// policy's MaxNodes or a pool's MaxSize below its nodes, and the pool to remove them from, or nil for
+// any pool. Removals wait for the scale-down cooldown.
+func (r *AutoscalerController) planMaximumCapacity(scope *scalingScope) (*NodePoolConfig, int, string) {
+	window := scope.schedule
+	if window == nil || r.now().Sub(scope.state.lastScaleDownTime) < scope.config.ScaleDownCooldown {
+		return nil, 0, ""
+	}
+
+	if window.MaxNodes != nil && len(scope.nodes) > scope.config.MaxNodes {
+		return nil, len(scope.nodes) - scope.config.MaxNodes, fmt.Sprintf("policy has %d nodes, above the maximum of %d in capacity window %s",
+			len(scope.nodes), scope.config.MaxNodes, window.Name)
+	}
+
+	for _, override := range window.NodePools {
+		pool := r.getNodePoolByName(scope, override.Name)
+		if override.MaxSize == nil || pool == nil || pool.MaxSize == 0 || scope.unresolvedNodes > 0 {
+			continue
+		}
+		if nodes := len(r.nodePoolNodes(scope, pool)); nodes > pool.MaxSize {
+			return pool, nodes - pool.MaxSize, fmt.Sprintf("node pool %s has %d nodes, above the maximum of %d in capacity window %s",
+				pool.Name, nodes, pool.MaxSize, window.Name)
+		}
+	}
+
+	return nil, 0, ""
+}
+
+// planScaleUp runs a what-if packing of the pending pods onto new nodes of each pool and returns
+// the pool that hosts the most pods on the fewest nodes, together with the number of nodes it needs.
+// Each pool is only offered the pods whose nodeSelector, node affinity and tolerations it satisfies.
+// Pools with the selected capacity type are preferred; other pools are tried only when none of
+// those can host any of the pods. Pools whose capacity is backed off are skipped, and the
+// remaining pools are kept in the scope as scale-up fallbacks.
+func (r *AutoscalerController) planScaleUp(ctx context.Context, scope *scalingScope, capacityType string, pendingPods []corev1.Pod) (*NodePoolConfig, int) {
+	if len(pendingPods) == 0 {
+		return nil, 0
+	}
+
+	pods := make([]*corev1.Pod, len(pendingPods))
+	for i := range pendingPods {
+		pods[i] = &pendingPods[i]
+	}
+
+	packer := scheduler.NewBinPackingScheduler(r.Client, scheduler.BestFit)
+	plans := make([]*scheduler.NodePlan, len(scope.config.NodePools))
+	matched := make(map[*corev1.Pod]bool, len(pods))
+	placed := make(map[*corev1.Pod]bool, len(pods))
+	for i := range scope.config.NodePools {
+		pool := &scope.config.NodePools[i]
+
+		hostable := make([]*corev1.Pod, 0, len(pods))
+		for _, pod := range pods {
+			if podFitsNodePool(pod, pool) {
+				hostable = append(hostable, pod)
+				matched[pod] = true
+			}
+		}
+
+		plan := packer.PlanNodes(hostable, r.nodePoolGPUCount(ctx, scope, pool), nodePoolTimeSlicingReplicas(pool))
+		unplaceable := make(map[*corev1.Pod]bool, len(plan.Unplaceable))
+		for _, pod := range plan.Unplaceable {
+			unplaceable[pod] = true
+		}
+		for _, pod := range hostable {
+			if !unplaceable[pod] {
+				placed[pod] = true
+			}
+		}
+		plans[i] = plan
+	}
+
+	r.reportUnhostablePods(scope, pods, matched, placed)
+
+	scope.scaleUpCandidates, scope.backedOffPools = nil, nil
+	for i, plan := range plans {
+		pool := &scope.config.NodePools[i]
+		if plan.PlacedPods == 0 {
+			continue
+		}
+		if r.nodePoolBackedOff(scope, pool) {
+			scope.backedOffPools = append(scope.backedOffPools, pool.Name)
+			continue
+		}
+		scope.scaleUpCandidates = append(scope.scaleUpCandidates, scaleUpCandidate{pool: pool, nodes: plan.Nodes, placedPods: plan.PlacedPods})
+	}
+	sort.SliceStable(scope.scaleUpCandidates, func(i, j int) bool {
+		a, b := scope.scaleUpCandidates[i], scope.scaleUpCandidates[j]
+		if preferA, preferB := a.pool.CapacityType == capacityType, b.pool.CapacityType == capacityType; preferA != preferB {
+			return preferA
+		}
+		if a.placedPods != b.placedPods {
+			return a.placedPods > b.placedPods
+		}
+		return a.nodes < b.nodes
+	})
+
+	if len(scope.scaleUpCandidates) == 0 {
+		return nil, 0
+	}
+	return scope.scaleUpCandidates[0].pool, scope.scaleUpCandidates[0].nodes
+}
+
+// reportUnhostablePods records an event on pending pods that no node pool of the policy can host
+func (r *AutoscalerController) reportUnhostablePods(scope *scalingScope, pods []*corev1.Pod, matched, placed map[*corev1.Pod]bool) {
+	for _, pod := range pods {
+		var message string
+		switch {
+		case !matched[pod]:
+			message = fmt.Sprintf("No node pool in autoscaling policy %s matches the pod's node selector, node affinity or tolerations", scope.policy.Name)
+		case !placed[pod]:
+			message = fmt.Sprintf("The pod's GPU request does not fit on a node of any matching node pool in autoscaling policy %s", scope.policy.Name)
+		default:
+			continue
+		}
+
+		r.Log.Info("pending pod cannot be hosted by any node pool", "pod", client.ObjectKeyFromObject(pod), "policy", scope.policy.Name)
+		if r.Recorder != nil {
+			r.Recorder.Event(pod, corev1.EventTypeWarning, ReasonNoMatchingNodePool, message)
+		}
+	}
+}
+
+// nodePoolGPUCount returns the GPUs on a node of the pool: the pool's GPUsPerNode, else the GPU
+// capacity of one of its nodes, else what the cloud provider reports for the pool
+func (r *AutoscalerController) nodePoolGPUCount(ctx context.Context, scope *scalingScope, pool *NodePoolConfig) int {
+	if pool.GPUsPerNode > 0 {
+		return pool.GPUsPerNode
+	}
+
+	for _, node := range r.nodePoolNodes(scope, pool) {
+		if gpus, ok := node.Status.Capacity["nvidia.com/gpu"]; ok && gpus.Value() > 0 {
+			return int(gpus.Value())
+		}
+	}
+
+	if r.CloudProvider != nil {
+		info, err := r.CloudProvider.GetNodePoolInfo(ctx, pool.Name)
+		switch {
+		case err != nil:
+			r.Log.Error(err, "failed to get node pool info", "nodePool", pool.Name)
+		case info.GPUsPerNode > 0:
+			return info.GPUsPerNode
+		case info.AvailableGPUs > 0 && info.CurrentSize > 0:
+			return info.AvailableGPUs / info.CurrentSize
+		}
+	}
+
+	r.Log.Info("GPUs per node of node pool are unknown, assuming 1; set gpusPerNode on the pool", "nodePool", pool.Name)
+	return 1
+}
+
+// nodePoolTimeSlicingReplicas returns the time-slicing replicas configured on the pool's nodes,
+// or 0 to use the default
+func nodePoolTimeSlicingReplicas(pool *NodePoolConfig) int {
+	replicas, err := strconv.Atoi(pool.Labels["nvidia.com/time-slicing.replicas"])
+	if err != nil {
+		return 0
+	}
+	return replicas
+}
+
+func (r *AutoscalerController) calculateScaleDownNodeCount(scope *scalingScope, nodes []corev1.Node, underutilized int) int {
+	// Remove underutilized nodes gradually (max 20% at a time, but at least one node
+	// so small pools can shrink all the way to MinNodes)
 	nodesToRemove := int(math.Min(float64(underutilized), float64(len(nodes))*0.2))
+	if nodesToRemove == 0 && underutilized > 0 {
+		nodesToRemove = 1
+	}
 	targetNodes := len(nodes) - nodesToRemove
 
-	if targetNodes < r.Config.MinNodes {
-		targetNodes = r.Config.MinNodes
+	if targetNodes < scope.config.MinNodes {
+		targetNodes = scope.config.MinNodes
 	}
 
 	return targetNodes
@@ -552,7 +943,7 @@ func (r *AutoscalerController) calculateScalingPriority(pendingPods []corev1.Pod
 	return maxPriority
 }
 
-func (r *AutoscalerController) selectCapacityType(nodes []corev1.Node) (string, string) {
+func (r *AutoscalerController) selectCapacityType(scope *scalingScope, nodes []corev1.Node) (string, string) {
 	// Multi-tier strategy: prefer spot instances up to configured percentage
 	spotNodes := 0
 	totalNodes := len(nodes)
@@ -563,14 +954,17 @@ func (r *AutoscalerController) selectCapacityType(nodes []corev1.Node) (string,
 		}
 	}
 
-	spotPercentage := float64(spotNodes) / float64(totalNodes)
-	if spotPercentage < r.Config.SpotInstancePercentage {
+	spotPercentage := 0.0
+	if totalNodes > 0 {
+		spotPercentage = float64(spotNodes) / float64(totalNodes)
+	}
+	if scope.config.EnableSpotInstances && spotPercentage < scope.config.SpotInstancePercentage {
 		// Add spot instance
-		return CapacityTypeSpot, r.getPreferredNodePool(CapacityTypeSpot)
+		return CapacityTypeSpot, r.getPreferredNodePool(scope, CapacityTypeSpot)
 	}
 
 	// Add on-demand instance
-	return CapacityTypeOnDemand, r.getPreferredNodePool(CapacityTypeOnDemand)
+	return CapacityTypeOnDemand, r.getPreferredNodePool(scope, CapacityTypeOnDemand)
 }
 
 func (r *AutoscalerController) selectNodesForScaleDown(nodes []corev1.Node) string {
@@ -583,9 +977,10 @@ func (r *AutoscalerController) selectNodesForScaleDown(nodes []corev1.Node) stri
 	return CapacityTypeOnDemand
 }
 
-func (r *AutoscalerController) selectNodesToRemove(nodes []corev1.Node, decision *ScalingDecision) []corev1.Node {
+func (r *AutoscalerController) selectNodesToRemove(scope *scalingScope, decision *ScalingDecision) []corev1.Node {
 	// Sort nodes by eviction priority (spot > on-demand > reserved)
 	// and by utilization (lowest first)
+	nodes := scope.nodes
 	nodesToRemove := make([]corev1.Node, 0)
 
 	currentCount := len(nodes)
@@ -596,12 +991,45 @@ func (r *AutoscalerController) selectNodesToRemove(nodes []corev1.Node, decision
 		return nodesToRemove
 	}
 
+	// Scale-downs of a single node pool, such as draining an idle pool, only remove that pool's nodes
+	if decision.NodePool != "" {
+		for _, node := range nodes {
+			if len(nodesToRemove) >= removeCount {
+				break
+			}
+			if scope.nodeInNodePool(&node, decision.NodePool) {
+				nodesToRemove = append(nodesToRemove, node)
+			}
+		}
+		return nodesToRemove
+	}
+
+	// Keep each node pool at its MinSize
+	spare := make(map[string]int, len(scope.config.NodePools))
+	for i := range scope.config.NodePools {
+		pool := &scope.config.NodePools[i]
+		spare[pool.Name] = len(r.nodePoolNodes(scope, pool)) - pool.MinSize
+	}
+	removable := func(node *corev1.Node) bool {
+		for i := range scope.config.NodePools {
+			pool := &scope.config.NodePools[i]
+			if scope.nodeInNodePool(node, pool.Name) {
+				if spare[pool.Name] <= 0 {
+					return false
+				}
+				spare[pool.Name]--
+				return true
+			}
+		}
+		return true
+	}
+
 	// Prioritize spot instances for removal
 	for _, node := range nodes {
 		if len(nodesToRemove) >= removeCount {
 			break
 		}
-		if node.Labels[CapacityTypeLabel] == CapacityTypeSpot {
+		if node.Labels[CapacityTypeLabel] == CapacityTypeSpot && removable(&node) {
 			nodesToRemove = append(nodesToRemove, node)
 		}
 	}
@@ -612,7 +1040,7 @@ func (r *AutoscalerController) selectNodesToRemove(nodes []corev1.Node, decision
 			if len(nodesToRemove) >= removeCount {
 				break
 			}
-			if node.Labels[CapacityTypeLabel] == CapacityTypeOnDemand {
+			if node.Labels[CapacityTypeLabel] == CapacityTypeOnDemand && removable(&node) {
 				nodesToRemove = append(nodesToRemove, node)
 			}
 		}
@@ -621,114 +1049,243 @@ func (r *AutoscalerController) selectNodesToRemove(nodes []corev1.Node, decision
 	return nodesToRemove
 }
 
-func (r *AutoscalerController) drainNode(ctx context.Context, node *corev1.Node) error {
+// drainNode evicts the node's pods through the Eviction API, respecting PodDisruptionBudgets
+func (r *AutoscalerController) drainNode(ctx context.Context, scope *scalingScope, node *corev1.Node) error {
 	r.Log.Info("draining node", "node", node.Name)
 
-	// Mark node as unschedulable
-	node.Spec.Unschedulable = true
-	if err := r.Update(ctx, node); err != nil {
-		return fmt.Errorf("failed to mark node unschedulable: %w", err)
+	drainer := NewDrainer(r.Client, r.Log)
+	if scope.config.DrainTimeout > 0 {
+		drainer.Timeout = scope.config.DrainTimeout
 	}
+	return drainer.Drain(ctx, node)
+}
 
-	// Get all pods on the node
-	podList := &corev1.PodList{}
-	if err := r.List(ctx, podList, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
-		return fmt.Errorf("failed to list pods: %w", err)
+// recordShadowDecision records what a scaling decision would have done in the policy's shadow status,
+// a Kubernetes event and the shadow metrics. Cooldowns start as if the action had been taken,
+// so shadow decisions follow the cadence real scaling would have.
+func (r *AutoscalerController) recordShadowDecision(scope *scalingScope, decision *ScalingDecision) {
+	policy := scope.policy
+	if policy.Status.Shadow == nil {
+		policy.Status.Shadow = &v1alpha1.ShadowStatus{}
 	}
+	shadow := policy.Status.Shadow
+	now := r.now()
 
-	// Evict pods gracefully
-	for _, pod := range podList.Items {
-		if err := r.Delete(ctx, &pod, client.GracePeriodSeconds(30)); err != nil && !errors.IsNotFound(err) {
-			r.Log.Error(err, "failed to evict pod", "pod", pod.Name)
+	shadow.LastAction = string(decision.Action)
+	shadow.LastReason = decision.Reason
+	shadow.CapacityType = decision.CapacityType
+	shadow.NodePool = ""
+	shadow.NodesToRemove = nil
+
+	var eventReason, message string
+	var nodeCount int
+	switch decision.Action {
+	case ScaleUp:
+		nodeCount = decision.DesiredNodeCount - decision.CurrentNodeCount - decision.ProvisioningNodes
+		shadow.NodePool = decision.NodePool
+		shadow.NodeDelta = int32(nodeCount)
+		shadow.ScaleUps++
+		shadow.LastScaleUpTime = &metav1.Time{Time: now}
+		scope.state.lastScaleUpTime = now
+		eventReason = ReasonShadowScaleUp
+		message = fmt.Sprintf("dry run: would add %d %s nodes to node pool %s: %s",
+			nodeCount, decision.CapacityType, decision.NodePool, decision.Reason)
+	case ScaleDown:
+		for _, node := range r.selectNodesToRemove(scope, decision) {
+			shadow.NodesToRemove = append(shadow.NodesToRemove, node.Name)
 		}
+		nodeCount = len(shadow.NodesToRemove)
+		shadow.NodeDelta = -int32(nodeCount)
+		shadow.ScaleDowns++
+		shadow.LastScaleDownTime = &metav1.Time{Time: now}
+		scope.state.lastScaleDownTime = now
+		eventReason = ReasonShadowScaleDown
+		message = fmt.Sprintf("dry run: would drain and remove nodes [%s]: %s",
+			strings.Join(shadow.NodesToRemove, ", "), decision.Reason)
+	default:
+		return
 	}
 
-	// Wait for pods to be evicted (with timeout)
-	timeout := time.After(5 * time.Minute)
-	ticker := time.NewTicker(5 * time.Second)
-	defer ticker.Stop()
+	r.Log.Info("dry run: skipping scaling action",
+		"policy", policy.Name,
+		"action", decision.Action,
+		"nodes", nodeCount,
+		"capacityType", decision.CapacityType,
+		"reason", decision.Reason,
+	)
 
-	for {
-		select {
-		case <-timeout:
-			return fmt.Errorf("timeout waiting for pods to be evicted")
-		case <-ticker.C:
-			podList := &corev1.PodList{}
-			if err := r.List(ctx, podList, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
-				return err
-			}
-			if len(podList.Items) == 0 {
-				return nil
-			}
-		}
+	if r.Recorder != nil {
+		r.Recorder.Event(policy, corev1.EventTypeNormal, eventReason, message)
+	}
+	if r.Metrics != nil {
+		r.Metrics.RecordShadowScalingAction(policy.Name, decision.Action, decision.CapacityType, nodeCount)
 	}
 }
 
-func (r *AutoscalerController) applyPredictiveScaling(ctx context.Context, decision *ScalingDecision) {
+// applyPredictiveScaling pre-warms nodes ahead of the load predicted for the policy's nodes.
+// It only raises a decision that is not a scale-down, and never while the scale-up cooldown
+// is running, nodes are still provisioning or the target pool is backed off.
+func (r *AutoscalerController) applyPredictiveScaling(ctx context.Context, scope *scalingScope, decision *ScalingDecision) {
 	if r.PredictiveScaler == nil {
 		return
 	}
 
 	// Get predictive scaling recommendation
-	prediction := r.PredictiveScaler.PredictFutureLoad(ctx)
-	if prediction.ShouldPreWarm {
-		r.Log.Info("predictive scaling recommendation",
-			"prediction", prediction.PredictedUtilization,
-			"recommendedNodes", prediction.RecommendedNodes,
-		)
+	prediction := r.PredictiveScaler.PredictFutureLoad(ctx, scope.policy.Name, scope.nodes)
+	scope.prediction = prediction
+	if !prediction.ShouldPreWarm || decision.Action == ScaleDown {
+		return
+	}
 
-		// Adjust decision based on prediction
-		if prediction.RecommendedNodes > decision.DesiredNodeCount {
-			decision.Action = ScaleUp
-			decision.Reason = fmt.Sprintf("predictive scaling: expected load increase to %.1f%%", prediction.PredictedUtilization*100)
-			decision.DesiredNodeCount = prediction.RecommendedNodes
-		}
+	r.Log.Info("predictive scaling recommendation",
+		"policy", scope.policy.Name,
+		"prediction", prediction.PredictedUtilization,
+		"recommendedNodes", prediction.RecommendedNodes,
+	)
+
+	// Pre-warming waits like any other scale-up, and not for nodes that are already on their way
+	provisioning := scope.provisioningNodeCount()
+	if r.now().Sub(scope.state.lastScaleUpTime) < scope.config.ScaleUpCooldown || provisioning > 0 || len(scope.backedOffPools) > 0 {
+		return
+	}
+
+	nodesToAdd := min(prediction.RecommendedNodes, scope.config.MaxNodes) - len(scope.nodes) - provisioning
+	if nodesToAdd <= 0 || len(scope.nodes)+provisioning+nodesToAdd <= decision.DesiredNodeCount {
+		return
+	}
+
+	capacityType, nodePool := decision.CapacityType, decision.NodePool
+	if nodePool == "" {
+		capacityType, nodePool = r.selectCapacityType(scope, scope.nodes)
 	}
+	if r.nodePoolHasBackoff(scope, nodePool) {
+		return
+	}
+
+	// Adjust decision based on prediction
+	decision.Action = ScaleUp
+	decision.Reason = fmt.Sprintf("predictive scaling: expected load increase to %.1f%%", prediction.PredictedUtilization*100)
+	decision.CapacityType, decision.NodePool = capacityType, nodePool
+	decision.DesiredNodeCount = len(scope.nodes) + provisioning + nodesToAdd
 }
 
-func (r *AutoscalerController) getNodePoolByName(name string) *NodePoolConfig {
-	for i := range r.Config.NodePools {
-		if r.Config.NodePools[i].Name == name {
-			return &r.Config.NodePools[i]
+// now returns the current time from the controller's clock
+func (r *AutoscalerController) now() time.Time {
+	if r.Clock != nil {
+		return r.Clock()
+	}
+	return time.Now()
+}
+
+func (r *AutoscalerController) getNodePoolByName(scope *scalingScope, name string) *NodePoolConfig {
+	for i := range scope.config.NodePools {
+		if scope.config.NodePools[i].Name == name {
+			return &scope.config.NodePools[i]
 		}
 	}
 	return nil
 }
 
-func (r *AutoscalerController) getPreferredNodePool(capacityType string) string {
-	// Return the first node pool with matching capacity type
-	for _, pool := range r.Config.NodePools {
-		if pool.CapacityType == capacityType {
+func (r *AutoscalerController) getPreferredNodePool(scope *scalingScope, capacityType string) string {
+	// Return the first node pool with matching capacity type whose capacity is not backed off
+	for i := range scope.config.NodePools {
+		pool := &scope.config.NodePools[i]
+		if pool.CapacityType == capacityType && !r.nodePoolBackedOff(scope, pool) {
 			return pool.Name
 		}
 	}
 	// Default to first pool
-	if len(r.Config.NodePools) > 0 {
-		return r.Config.NodePools[0].Name
+	if len(scope.config.NodePools) > 0 {
+		return scope.config.NodePools[0].Name
 	}
 	return "default"
 }
 
-func (r *AutoscalerController) recordScalingEvent(action ScalingAction, reason string, nodeCount int, capacityType string, success bool) {
+// recordScalingEvent keeps the scaling action in the in-memory history and persists it to the history store
+func (r *AutoscalerController) recordScalingEvent(ctx context.Context, scope *scalingScope, decision *ScalingDecision, scalingErr error) {
 	event := ScalingEvent{
-		Timestamp:    time.Now(),
-		Action:       action,
-		Reason:       reason,
-		NodeCount:    nodeCount,
-		CapacityType: capacityType,
-		Success:      success,
+		Policy:             scope.policy.Name,
+		Timestamp:          r.now(),
+		Action:             decision.Action,
+		Reason:             decision.Reason,
+		NodeCount:          decision.DesiredNodeCount,
+		CurrentNodeCount:   decision.CurrentNodeCount,
+		CapacityType:       decision.CapacityType,
+		NodePool:           decision.NodePool,
+		GPUUtilization:     decision.GPUUtilization,
+		PendingPods:        decision.PendingPods,
+		UnderutilizedNodes: decision.UnderutilizedNodes,
+		Success:            scalingErr == nil,
+		DryRun:             scope.config.DryRun,
+	}
+	if scalingErr != nil {
+		event.NodeCount = 0
+		event.Error = scalingErr.Error()
 	}
+
+	r.stateMu.Lock()
 	r.scalingHistory = append(r.scalingHistory, event)
 
 	// Keep only last 100 events
 	if len(r.scalingHistory) > 100 {
 		r.scalingHistory = r.scalingHistory[len(r.scalingHistory)-100:]
 	}
+	r.stateMu.Unlock()
+
+	if r.History != nil {
+		if err := r.History.Record(ctx, event); err != nil {
+			r.Log.Error(err, "failed to persist scaling event", "policy", event.Policy)
+		}
+	}
 }
 
-// SetupWithManager sets up the controller with the Manager
+// SetupWithManager sets up the controller with the Manager.
+// Policies are reconciled on spec changes, and every policy is re-evaluated when GPU nodes or pods change.
 func (r *AutoscalerController) SetupWithManager(mgr ctrl.Manager) error {
+	gpuPods := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		pod, ok := obj.(*corev1.Pod)
+		return ok && r.isGPUPod(pod)
+	})
+
 	return ctrl.NewControllerManagedBy(mgr).
-		For(&corev1.Node{}).
+		Named("autoscaler").
+		For(&v1alpha1.AutoscalingPolicy{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.enqueueAllPolicies), builder.WithPredicates(gpuNodeChanged())).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.enqueueAllPolicies), builder.WithPredicates(gpuPods)).
 		Complete(r)
 }
+
+// gpuNodeChanged passes GPU nodes being added or removed and the node updates that change scaling
+// decisions: readiness, labels, schedulability, GPU capacity and Redfish power state. Heartbeat
+// status updates are dropped, so they do not re-reconcile every policy.
+func gpuNodeChanged() predicate.Funcs {
+	isGPUNode := func(obj client.Object) bool {
+		node, ok := obj.(*corev1.Node)
+		return ok && hasGPUCapacity(node)
+	}
+
+	return predicate.Funcs{
+		CreateFunc:  func(e event.CreateEvent) bool { return isGPUNode(e.Object) },
+		DeleteFunc:  func(e event.DeleteEvent) bool { return isGPUNode(e.Object) },
+		GenericFunc: func(e event.GenericEvent) bool { return isGPUNode(e.Object) },
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldNode, okOld := e.ObjectOld.(*corev1.Node)
+			newNode, okNew := e.ObjectNew.(*corev1.Node)
+			if !okOld || !okNew || (!hasGPUCapacity(oldNode) && !hasGPUCapacity(newNode)) {
+				return false
+			}
+			oldGPUs, newGPUs := oldNode.Status.Capacity["nvidia.com/gpu"], newNode.Status.Capacity["nvidia.com/gpu"]
+			return !oldGPUs.Equal(newGPUs) ||
+				isNodeReady(oldNode) != isNodeReady(newNode) ||
+				oldNode.Spec.Unschedulable != newNode.Spec.Unschedulable ||
+				!maps.Equal(oldNode.Labels, newNode.Labels) ||
+				oldNode.Annotations[RedfishPoweredOffAnnotation] != newNode.Annotations[RedfishPoweredOffAnnotation]
+		},
+	}
+}
+
+// hasGPUCapacity reports whether a node advertises NVIDIA GPUs
+func hasGPUCapacity(node *corev1.Node) bool {
+	_, ok := node.Status.Capacity["nvidia.com/gpu"]
+	return ok
+}
@@ -0,0 +1,634 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/metrics"
+)
+
+// recordingProvider counts scaling calls and takes its pricing from the AWS provider
+type recordingProvider struct {
+	*AWSProvider
+	scaleUps     int
+	scaleDowns   int
+	scaleDownErr error
+
+	// nodePools is the pool membership reported for unlabeled nodes
+	nodePools       map[string]string
+	nodePoolLookups int
+
+	// launchFailure is reported for every outstanding provisioning request
+	launchFailure *ProviderError
+}
+
+func (p *recordingProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
+	p.scaleUps += count
+	return nil
+}
+
+func (p *recordingProvider) ScaleDown(ctx context.Context, nodeName string) error {
+	p.scaleDowns++
+	return p.scaleDownErr
+}
+
+func (p *recordingProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	return p.launchFailure, nil
+}
+
+func (p *recordingProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	p.nodePoolLookups++
+	return p.nodePools[nodeName], nil
+}
+
+func newTestAutoscalerController(t *testing.T, objects ...client.Object) (*AutoscalerController, *recordingProvider, *record.FakeRecorder, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add core scheme: %v", err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add v1alpha1 scheme: %v", err)
+	}
+
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithStatusSubresource(&v1alpha1.AutoscalingPolicy{}).
+		WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+			return []string{obj.(*corev1.Pod).Spec.NodeName}
+		}).
+		Build()
+	provider := &recordingProvider{AWSProvider: &AWSProvider{}}
+	recorder := record.NewFakeRecorder(10)
+
+	controller := NewAutoscalerController(k8sClient, scheme, recorder, nil, provider, AutoscalerConfig{ReconcileInterval: time.Minute})
+	return controller, provider, recorder, k8sClient
+}
+
+func newTestGPUNode(name, capacityType string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   name,
+			Labels: map[string]string{CapacityTypeLabel: capacityType},
+		},
+		Status: corev1.NodeStatus{
+			Capacity:   corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+}
+
+func TestReconcileDryRun(t *testing.T) {
+	policy := &v1alpha1.AutoscalingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "training"},
+		Spec: v1alpha1.AutoscalingPolicySpec{
+			Enabled:                true,
+			DryRun:                 true,
+			ScaleUpCooldownSeconds: 180,
+			MaxNodes:               10,
+			NodePools:              []v1alpha1.NodePoolSpec{{Name: "a100", CapacityType: CapacityTypeOnDemand}},
+		},
+	}
+	pendingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              "trainer",
+			Namespace:         "ml",
+			CreationTimestamp: metav1.NewTime(time.Now().Add(-10 * time.Minute)),
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "trainer",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+
+	controller, provider, recorder, k8sClient := newTestAutoscalerController(t,
+		policy, pendingPod, newTestGPUNode("gpu-node-1", CapacityTypeOnDemand))
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+	// The second reconcile falls within the shadow scale-up's cooldown
+	for i := 0; i < 2; i++ {
+		if _, err := controller.Reconcile(context.Background(), req); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+
+	if provider.scaleUps != 0 || provider.scaleDowns != 0 {
+		t.Errorf("Expected no provider calls in dry-run mode, got %d scale-ups and %d scale-downs", provider.scaleUps, provider.scaleDowns)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, ReasonShadowScaleUp) || !strings.Contains(event, "would add 1 on-demand nodes to node pool a100") {
+			t.Errorf("Unexpected event: %s", event)
+		}
+	default:
+		t.Error("Expected a shadow scale-up event")
+	}
+	if len(recorder.Events) != 0 {
+		t.Errorf("Expected a single shadow event, got %d more", len(recorder.Events))
+	}
+
+	updated := &v1alpha1.AutoscalingPolicy{}
+	if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+
+	shadow := updated.Status.Shadow
+	if shadow == nil {
+		t.Fatal("Expected a shadow status")
+	}
+	if shadow.LastAction != string(ScaleUp) || shadow.NodePool != "a100" || shadow.NodeDelta != 1 || shadow.ScaleUps != 1 {
+		t.Errorf("Unexpected shadow status: %+v", shadow)
+	}
+	if shadow.LastScaleUpTime == nil {
+		t.Error("Expected the shadow scale-up time to be recorded")
+	}
+
+	if updated.Status.LastScaleUpTime != nil || updated.Status.LastScalingAction != "" {
+		t.Errorf("Expected no real scaling in status, got action %q", updated.Status.LastScalingAction)
+	}
+	if condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeScaling); condition == nil || condition.Reason != ReasonStable {
+		t.Errorf("Expected the cooled-down reconcile to leave the policy stable, got %+v", condition)
+	}
+}
+
+func TestGetGPUNodes(t *testing.T) {
+	notReady := newTestGPUNode("not-ready", CapacityTypeOnDemand)
+	notReady.Status.Conditions[0].Status = corev1.ConditionUnknown
+	poweredOff := newTestGPUNode("powered-off", CapacityTypeReserved)
+	poweredOff.Annotations = map[string]string{RedfishPoweredOffAnnotation: "2024-01-01T12:00:00Z"}
+	cpuOnly := newTestGPUNode("cpu-only", CapacityTypeOnDemand)
+	cpuOnly.Status.Capacity = corev1.ResourceList{}
+
+	controller, _, _, _ := newTestAutoscalerController(t, newTestGPUNode("ready", CapacityTypeOnDemand), notReady, poweredOff, cpuOnly)
+	scope := controller.newScalingScope(&v1alpha1.AutoscalingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "training"}})
+
+	nodes, err := controller.getGPUNodes(context.Background(), scope)
+	if err != nil {
+		t.Fatalf("getGPUNodes() error = %v", err)
+	}
+	if len(nodes) != 1 || nodes[0].Name != "ready" {
+		t.Errorf("Expected only the Ready GPU node, got %d nodes", len(nodes))
+	}
+}
+
+func TestRecordShadowDecisionScaleDown(t *testing.T) {
+	controller, _, recorder, _ := newTestAutoscalerController(t)
+	policy := &v1alpha1.AutoscalingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "training"}}
+	scope := &scalingScope{
+		policy: policy,
+		config: AutoscalerConfig{DryRun: true},
+		state:  &policyState{},
+		nodes: []corev1.Node{
+			*newTestGPUNode("on-demand-1", CapacityTypeOnDemand),
+			*newTestGPUNode("spot-1", CapacityTypeSpot),
+		},
+	}
+	decision := &ScalingDecision{
+		Action:           ScaleDown,
+		Reason:           "GPU utilization low",
+		CurrentNodeCount: 2,
+		DesiredNodeCount: 1,
+		CapacityType:     CapacityTypeSpot,
+	}
+
+	if err := controller.executeScalingAction(context.Background(), scope, decision); err != nil {
+		t.Fatalf("executeScalingAction() error = %v", err)
+	}
+
+	shadow := policy.Status.Shadow
+	if shadow == nil || shadow.NodeDelta != -1 || shadow.ScaleDowns != 1 {
+		t.Fatalf("Unexpected shadow status: %+v", shadow)
+	}
+	if len(shadow.NodesToRemove) != 1 || shadow.NodesToRemove[0] != "spot-1" {
+		t.Errorf("Expected spot-1 to be the shadow scale-down candidate, got %v", shadow.NodesToRemove)
+	}
+	if scope.state.lastScaleDownTime.IsZero() {
+		t.Error("Expected the scale-down cooldown to start")
+	}
+	if len(recorder.Events) != 1 {
+		t.Errorf("Expected a shadow scale-down event, got %d events", len(recorder.Events))
+	}
+}
+
+func TestScaleDownUncordonsNodeWhenRemovalFails(t *testing.T) {
+	controller, provider, _, k8sClient := newTestAutoscalerController(t, newTestGPUNode("gpu-node-1", CapacityTypeSpot))
+	provider.scaleDownErr = fmt.Errorf("instance is protected from scale in")
+
+	node := &corev1.Node{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-node-1"}, node); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	scope := &scalingScope{
+		policy: &v1alpha1.AutoscalingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "training"}},
+		state:  &policyState{},
+		nodes:  []corev1.Node{*node},
+	}
+
+	err := controller.scaleDown(context.Background(), scope, &ScalingDecision{CurrentNodeCount: 1, DesiredNodeCount: 0})
+	if err == nil {
+		t.Fatal("Expected the failed removal to be returned")
+	}
+
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-node-1"}, node); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if node.Spec.Unschedulable {
+		t.Error("Expected the node to be uncordoned after the failed removal")
+	}
+	if scope.state.lastScaleDownTime.IsZero() {
+		t.Error("Expected the scale-down cooldown to start")
+	}
+}
+
+func TestPlanScaleUp(t *testing.T) {
+	newPendingPods := func(count int, requests corev1.ResourceList) []corev1.Pod {
+		pods := make([]corev1.Pod, count)
+		for i := range pods {
+			pods[i] = corev1.Pod{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("pending-%d", i), Namespace: "ml"},
+				Spec: corev1.PodSpec{
+					Containers: []corev1.Container{{Name: "main", Resources: corev1.ResourceRequirements{Requests: requests}}},
+				},
+			}
+		}
+		return pods
+	}
+	gpus := func(count string) corev1.ResourceList {
+		return corev1.ResourceList{"nvidia.com/gpu": resource.MustParse(count)}
+	}
+
+	pools := []NodePoolConfig{
+		{Name: "v100-spot", CapacityType: CapacityTypeSpot, InstanceTypes: []string{"p3.2xlarge"}, GPUsPerNode: 1},
+		{Name: "v100-x4", CapacityType: CapacityTypeOnDemand, InstanceTypes: []string{"p3.8xlarge"}, GPUsPerNode: 4},
+		{Name: "v100-x8", CapacityType: CapacityTypeOnDemand, InstanceTypes: []string{"p3.16xlarge"}, GPUsPerNode: 8},
+	}
+
+	tests := []struct {
+		name          string
+		capacityType  string
+		pods          []corev1.Pod
+		expectedPool  string
+		expectedNodes int
+	}{
+		{name: "single-GPU pods on spot", capacityType: CapacityTypeSpot, pods: newPendingPods(3, gpus("1")), expectedPool: "v100-spot", expectedNodes: 3},
+		{name: "fewest on-demand nodes", capacityType: CapacityTypeOnDemand, pods: newPendingPods(3, gpus("2")), expectedPool: "v100-x8", expectedNodes: 1},
+		{name: "falls back when spot nodes are too small", capacityType: CapacityTypeSpot, pods: newPendingPods(3, gpus("4")), expectedPool: "v100-x8", expectedNodes: 2},
+		{name: "MIG slices pack onto one GPU", capacityType: CapacityTypeSpot, pods: newPendingPods(7, corev1.ResourceList{"nvidia.com/mig-1g.5gb": resource.MustParse("1")}), expectedPool: "v100-spot", expectedNodes: 1},
+		{name: "no pool fits", capacityType: CapacityTypeOnDemand, pods: newPendingPods(1, gpus("16")), expectedNodes: 0},
+	}
+
+	controller, _, _, _ := newTestAutoscalerController(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scope := &scalingScope{
+				policy: &v1alpha1.AutoscalingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "training"}},
+				config: AutoscalerConfig{NodePools: pools},
+				state:  &policyState{},
+			}
+			pool, nodes := controller.planScaleUp(context.Background(), scope, tt.capacityType, tt.pods)
+
+			poolName := ""
+			if pool != nil {
+				poolName = pool.Name
+			}
+			if poolName != tt.expectedPool || nodes != tt.expectedNodes {
+				t.Errorf("Expected %d nodes of pool %q, got %d nodes of pool %q", tt.expectedNodes, tt.expectedPool, nodes, poolName)
+			}
+		})
+	}
+}
+
+// poolInfoProvider reports fixed node pool info, failing for pools it does not know
+type poolInfoProvider struct {
+	*recordingProvider
+	pools map[string]*NodePoolInfo
+}
+
+func (p *poolInfoProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
+	info, ok := p.pools[nodePoolName]
+	if !ok {
+		return nil, fmt.Errorf("node pool %s not found", nodePoolName)
+	}
+	return info, nil
+}
+
+func TestNodePoolGPUCount(t *testing.T) {
+	node := newTestGPUNode("gpu-node-1", CapacityTypeOnDemand)
+	node.Labels[NodePoolLabel] = "a100"
+	node.Status.Capacity["nvidia.com/gpu"] = resource.MustParse("8")
+
+	tests := []struct {
+		name     string
+		pool     NodePoolConfig
+		expected int
+	}{
+		{name: "pool spec", pool: NodePoolConfig{Name: "a100", GPUsPerNode: 2}, expected: 2},
+		{name: "existing node", pool: NodePoolConfig{Name: "a100"}, expected: 8},
+		{name: "provider GPUs per node", pool: NodePoolConfig{Name: "gcp-a2"}, expected: 4},
+		{name: "provider GPUs of the pool's nodes", pool: NodePoolConfig{Name: "capi-md"}, expected: 2},
+		{name: "unknown", pool: NodePoolConfig{Name: "asg", InstanceTypes: []string{"p4d.24xlarge"}}, expected: 1},
+	}
+
+	controller, provider, _, _ := newTestAutoscalerController(t)
+	controller.CloudProvider = &poolInfoProvider{recordingProvider: provider, pools: map[string]*NodePoolInfo{
+		"gcp-a2":  {Name: "gcp-a2", GPUsPerNode: 4},
+		"capi-md": {Name: "capi-md", CurrentSize: 3, AvailableGPUs: 6},
+		"asg":     {Name: "asg", CurrentSize: 0},
+	}}
+	scope := &scalingScope{nodes: []corev1.Node{*node}}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := controller.nodePoolGPUCount(context.Background(), scope, &tt.pool); got != tt.expected {
+				t.Errorf("Expected %d GPUs per node, got %d", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPlanScaleUpHonoursPodConstraints(t *testing.T) {
+	newPod := func(name string, nodeSelector map[string]string, tolerations ...corev1.Toleration) corev1.Pod {
+		return corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ml"},
+			Spec: corev1.PodSpec{
+				NodeSelector: nodeSelector,
+				Tolerations:  tolerations,
+				Containers: []corev1.Container{{
+					Name:      "main",
+					Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}},
+				}},
+			},
+		}
+	}
+	dedicated := corev1.Taint{Key: "dedicated", Value: "research", Effect: corev1.TaintEffectNoSchedule}
+	pools := []NodePoolConfig{
+		{Name: "a100", CapacityType: CapacityTypeOnDemand, GPUType: "nvidia-a100", InstanceTypes: []string{"p4d.24xlarge"}},
+		{Name: "h100-research", CapacityType: CapacityTypeOnDemand, GPUType: "nvidia-h100", Taints: []corev1.Taint{dedicated}},
+	}
+
+	tests := []struct {
+		name          string
+		pod           corev1.Pod
+		expectedPool  string
+		expectedEvent bool
+	}{
+		{name: "unconstrained pod avoids the tainted pool", pod: newPod("any", nil), expectedPool: "a100"},
+		{
+			name:         "H100 pod tolerating the taint",
+			pod:          newPod("h100", map[string]string{GPUProductLabel: "NVIDIA-H100-80GB-HBM3"}, corev1.Toleration{Key: "dedicated", Operator: corev1.TolerationOpExists}),
+			expectedPool: "h100-research",
+		},
+		{name: "H100 pod without the toleration", pod: newPod("h100-untolerated", map[string]string{GPUProductLabel: "NVIDIA-H100-80GB-HBM3"}), expectedEvent: true},
+		{name: "GPU type no pool offers", pod: newPod("l4", map[string]string{GPUTypeLabel: "nvidia-l4"}), expectedEvent: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller, _, recorder, _ := newTestAutoscalerController(t)
+			scope := &scalingScope{
+				policy: &v1alpha1.AutoscalingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "training"}},
+				config: AutoscalerConfig{NodePools: pools},
+				state:  &policyState{},
+			}
+
+			pool, _ := controller.planScaleUp(context.Background(), scope, CapacityTypeOnDemand, []corev1.Pod{tt.pod})
+			poolName := ""
+			if pool != nil {
+				poolName = pool.Name
+			}
+			if poolName != tt.expectedPool {
+				t.Errorf("Expected pool %q, got %q", tt.expectedPool, poolName)
+			}
+
+			if got := len(recorder.Events) == 1; got != tt.expectedEvent {
+				t.Errorf("Expected event %v, got %d events", tt.expectedEvent, len(recorder.Events))
+			}
+			if tt.expectedEvent {
+				if event := <-recorder.Events; !strings.Contains(event, ReasonNoMatchingNodePool) {
+					t.Errorf("Unexpected event: %s", event)
+				}
+			}
+		})
+	}
+}
+
+// staticMetrics reports the same GPU metrics on every call
+type staticMetrics []metrics.GPUMetrics
+
+func (m staticMetrics) GetGPUMetrics(ctx context.Context) ([]metrics.GPUMetrics, error) {
+	return m, nil
+}
+
+func TestApplyPredictiveScaling(t *testing.T) {
+	now := time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC)
+
+	// A steady busy Tuesday morning with 24 pods on the policy's nodes predicts 4 nodes
+	var patterns []UtilizationPattern
+	for hour := 8; hour <= 10; hour++ {
+		patterns = append(patterns, UtilizationPattern{DayOfWeek: time.Tuesday, HourOfDay: hour, Duration: time.Hour, AvgUtilization: 0.9, PodsCount: 24})
+	}
+
+	tests := []struct {
+		name          string
+		setup         func(scope *scalingScope)
+		expectAction  ScalingAction
+		expectDesired int
+	}{
+		{
+			name:          "Stable policy is pre-warmed to the predicted nodes",
+			expectAction:  ScaleUp,
+			expectDesired: 4,
+		},
+		{
+			name: "Scale-up cooldown holds back pre-warming",
+			setup: func(scope *scalingScope) {
+				scope.state.lastScaleUpTime = now.Add(-time.Minute)
+			},
+			expectAction:  NoAction,
+			expectDesired: 1,
+		},
+		{
+			name: "Provisioning nodes hold back pre-warming",
+			setup: func(scope *scalingScope) {
+				scope.provisioning = map[string]int{"a100": 1}
+			},
+			expectAction:  NoAction,
+			expectDesired: 2,
+		},
+		{
+			name: "Backed-off pool is not pre-warmed",
+			setup: func(scope *scalingScope) {
+				scope.state.failedCapacity = map[capacityKey]time.Time{{nodePool: "a100"}: now.Add(time.Hour)}
+			},
+			expectAction:  NoAction,
+			expectDesired: 1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			controller, _, _, _ := newTestAutoscalerController(t)
+			controller.Clock = func() time.Time { return now }
+			controller.PredictiveScaler = NewPredictiveScaler(staticMetrics{})
+			controller.PredictiveScaler.Clock = controller.now
+			controller.PredictiveScaler.patterns["training"] = &policyPatterns{patterns: patterns, lastUpdate: now}
+
+			scope := controller.newScalingScope(&v1alpha1.AutoscalingPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "training"},
+				Spec: v1alpha1.AutoscalingPolicySpec{
+					Enabled:                 true,
+					EnablePredictiveScaling: true,
+					ScaleUpCooldownSeconds:  180,
+					MaxNodes:                10,
+					NodePools:               []v1alpha1.NodePoolSpec{{Name: "a100", CapacityType: CapacityTypeOnDemand}},
+				},
+			})
+			scope.nodes = []corev1.Node{*newTestGPUNode("gpu-node-1", CapacityTypeOnDemand)}
+			if tt.setup != nil {
+				tt.setup(scope)
+			}
+			decision := &ScalingDecision{
+				Action:            NoAction,
+				CurrentNodeCount:  1,
+				DesiredNodeCount:  1 + scope.provisioningNodeCount(),
+				ProvisioningNodes: scope.provisioningNodeCount(),
+			}
+
+			controller.applyPredictiveScaling(context.Background(), scope, decision)
+
+			if scope.prediction == nil || scope.prediction.RecommendedNodes != 4 {
+				t.Fatalf("Expected a prediction of 4 nodes, got %+v", scope.prediction)
+			}
+			if decision.Action != tt.expectAction {
+				t.Errorf("Expected action %s, got %s (%s)", tt.expectAction, decision.Action, decision.Reason)
+			}
+			if decision.DesiredNodeCount != tt.expectDesired {
+				t.Errorf("Expected %d desired nodes, got %d", tt.expectDesired, decision.DesiredNodeCount)
+			}
+			if decision.Action == ScaleUp && decision.NodePool != "a100" {
+				t.Errorf("Expected pre-warming in node pool a100, got %q", decision.NodePool)
+			}
+		})
+	}
+}
+
+func TestAnalyzePatternCountsOnlyPolicyNodes(t *testing.T) {
+	var gpuMetrics staticMetrics
+	for i := 0; i < 24; i++ {
+		gpuMetrics = append(gpuMetrics, metrics.GPUMetrics{NodeName: "gpu-node-1", PodName: fmt.Sprintf("trainer-%d", i), GPUUtilization: 0.5})
+	}
+	for i := 0; i < 80; i++ {
+		gpuMetrics = append(gpuMetrics, metrics.GPUMetrics{NodeName: "other-node", PodName: fmt.Sprintf("batch-%d", i), GPUUtilization: 0.1})
+	}
+
+	scaler := NewPredictiveScaler(gpuMetrics)
+	start := time.Date(2026, time.March, 3, 9, 0, 0, 0, time.UTC)
+	pattern := scaler.analyzePattern(context.Background(), map[string]bool{"gpu-node-1": true},
+		time.Tuesday, 9, start, start.Add(3*7*24*time.Hour))
+	if pattern == nil {
+		t.Fatal("Expected a pattern from three weeks of samples")
+	}
+	if pattern.PodsCount != 24 || pattern.AvgUtilization != 0.5 {
+		t.Errorf("Expected 24 pods at 0.50 utilization from the policy's node, got %d pods at %.2f", pattern.PodsCount, pattern.AvgUtilization)
+	}
+}
+
+func TestGPUNodeChanged(t *testing.T) {
+	gpuNode := newTestGPUNode("gpu-node-1", CapacityTypeOnDemand)
+	cpuNode := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "cpu-node"}}
+
+	tests := []struct {
+		name   string
+		old    *corev1.Node
+		update func(node *corev1.Node)
+		expect bool
+	}{
+		{
+			name: "Heartbeat is ignored",
+			old:  gpuNode,
+			update: func(node *corev1.Node) {
+				node.Status.Conditions[0].LastHeartbeatTime = metav1.Now()
+			},
+			expect: false,
+		},
+		{
+			name: "Node becoming NotReady is passed",
+			old:  gpuNode,
+			update: func(node *corev1.Node) {
+				node.Status.Conditions[0].Status = corev1.ConditionFalse
+			},
+			expect: true,
+		},
+		{
+			name: "Cordon is passed",
+			old:  gpuNode,
+			update: func(node *corev1.Node) {
+				node.Spec.Unschedulable = true
+			},
+			expect: true,
+		},
+		{
+			name: "Label change is passed",
+			old:  gpuNode,
+			update: func(node *corev1.Node) {
+				node.Labels[NodePoolLabel] = "a100"
+			},
+			expect: true,
+		},
+		{
+			name: "Node registering its GPUs is passed",
+			old:  cpuNode,
+			update: func(node *corev1.Node) {
+				node.Status.Capacity = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("8")}
+			},
+			expect: true,
+		},
+		{
+			name: "CPU node change is ignored",
+			old:  cpuNode,
+			update: func(node *corev1.Node) {
+				node.Spec.Unschedulable = true
+			},
+			expect: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated := tt.old.DeepCopy()
+			tt.update(updated)
+			if got := gpuNodeChanged().Update(event.UpdateEvent{ObjectOld: tt.old, ObjectNew: updated}); got != tt.expect {
+				t.Errorf("Expected %v, got %v", tt.expect, got)
+			}
+		})
+	}
+
+	if !gpuNodeChanged().Create(event.CreateEvent{Object: gpuNode}) {
+		t.Error("Expected a new GPU node to be passed")
+	}
+	if gpuNodeChanged().Create(event.CreateEvent{Object: cpuNode}) {
+		t.Error("Expected a new CPU node to be ignored")
+	}
+}
@@ -0,0 +1,245 @@
+package autoscaler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// SafeToEvictAnnotation set to "false" on a pod stops the autoscaler from draining its node
+	SafeToEvictAnnotation = "gpu-autoscaler.io/safe-to-evict"
+
+	// ClusterAutoscalerSafeToEvictAnnotation is the cluster-autoscaler equivalent of SafeToEvictAnnotation,
+	// honoured so existing workloads keep their protection
+	ClusterAutoscalerSafeToEvictAnnotation = "cluster-autoscaler.kubernetes.io/safe-to-evict"
+
+	// Drain defaults
+	DefaultDrainTimeout      = 5 * time.Minute
+	DefaultDrainPollInterval = 5 * time.Second
+)
+
+// ErrDrainBlocked is returned when a node runs a pod that must not be evicted
+var ErrDrainBlocked = errors.New("node has pods that are not safe to evict")
+
+// IsDrainBlocked reports whether a drain was refused because of a pod that must not be evicted
+func IsDrainBlocked(err error) bool {
+	return errors.Is(err, ErrDrainBlocked)
+}
+
+// Drainer moves pods off nodes through the policy/v1 Eviction API, so PodDisruptionBudgets are respected.
+// DaemonSet pods, mirror pods and finished pods are left in place.
+type Drainer struct {
+	client client.Client
+	logger logr.Logger
+
+	// Timeout bounds how long Drain waits for pods to leave a node
+	Timeout time.Duration
+
+	// PollInterval is how often evictions refused by a disruption budget are retried
+	PollInterval time.Duration
+
+	// GracePeriodSeconds overrides the termination grace period of evicted pods; nil keeps each pod's own
+	GracePeriodSeconds *int64
+}
+
+// NewDrainer creates a new drainer with the default timeout and poll interval
+func NewDrainer(client client.Client, logger logr.Logger) *Drainer {
+	return &Drainer{
+		client:       client,
+		logger:       logger,
+		Timeout:      DefaultDrainTimeout,
+		PollInterval: DefaultDrainPollInterval,
+	}
+}
+
+// Drain cordons a node and evicts its pods, waiting until they are gone.
+// Nodes running a pod that must not be evicted are left untouched and ErrDrainBlocked is returned.
+// If the pods cannot be moved within the timeout the node is uncordoned again and an error is returned.
+func (d *Drainer) Drain(ctx context.Context, node *corev1.Node) error {
+	if err := d.CheckDrainable(ctx, node.Name); err != nil {
+		return err
+	}
+
+	// Mark node as unschedulable with a patch, so a stale cached node neither conflicts
+	// nor overwrites label and taint changes made since it was read
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = true
+	if err := d.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to mark node unschedulable: %w", err)
+	}
+
+	if err := d.EvictAndWait(ctx, node.Name); err != nil {
+		if uncordonErr := d.Uncordon(ctx, node); uncordonErr != nil {
+			d.logger.Error(uncordonErr, "failed to uncordon node after aborted drain", "node", node.Name)
+		}
+		return err
+	}
+	return nil
+}
+
+// CheckDrainable returns ErrDrainBlocked when the node runs a pod that must not be evicted: one
+// annotated safe-to-evict=false, or one without a controller that would recreate it elsewhere
+func (d *Drainer) CheckDrainable(ctx context.Context, nodeName string) error {
+	pods, err := d.PodsToEvict(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+	for i := range pods {
+		pod := &pods[i]
+		switch {
+		case !isSafeToEvict(pod):
+			return fmt.Errorf("pod %s/%s on node %s: %w", pod.Namespace, pod.Name, nodeName, ErrDrainBlocked)
+		case metav1.GetControllerOf(pod) == nil:
+			return fmt.Errorf("pod %s/%s on node %s has no controller to recreate it: %w", pod.Namespace, pod.Name, nodeName, ErrDrainBlocked)
+		}
+	}
+	return nil
+}
+
+// Uncordon marks a node schedulable again
+func (d *Drainer) Uncordon(ctx context.Context, node *corev1.Node) error {
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = false
+	if err := d.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to uncordon node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// EvictAndWait evicts the pods on a node and waits until they are gone, retrying evictions
+// that a PodDisruptionBudget refuses until the timeout
+func (d *Drainer) EvictAndWait(ctx context.Context, nodeName string) error {
+	timeout := time.After(d.Timeout)
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	evicted := make(map[client.ObjectKey]bool)
+	for {
+		pods, err := d.PodsToEvict(ctx, nodeName)
+		if err != nil {
+			return err
+		}
+		if len(pods) == 0 {
+			return nil
+		}
+
+		progress := false
+		for i := range pods {
+			pod := &pods[i]
+			if evicted[client.ObjectKeyFromObject(pod)] || pod.DeletionTimestamp != nil {
+				continue
+			}
+
+			err := d.evict(ctx, pod, d.GracePeriodSeconds)
+			switch {
+			case err == nil || apierrors.IsNotFound(err):
+				evicted[client.ObjectKeyFromObject(pod)] = true
+				progress = true
+			case apierrors.IsTooManyRequests(err):
+				d.logger.V(1).Info("eviction refused by disruption budget, retrying", "pod", client.ObjectKeyFromObject(pod))
+			default:
+				return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+		}
+
+		// Check again straight away after new evictions, since pods without a grace period are already gone
+		if progress {
+			continue
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-timeout:
+			return fmt.Errorf("timeout after %s waiting for %d pods to be evicted from node %s", d.Timeout, len(pods), nodeName)
+		case <-ticker.C:
+		}
+	}
+}
+
+// EvictPod evicts a single pod, retrying while a PodDisruptionBudget refuses the eviction
+// until the context is done
+func (d *Drainer) EvictPod(ctx context.Context, pod *corev1.Pod, gracePeriodSeconds int64) error {
+	ticker := time.NewTicker(d.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		err := d.evict(ctx, pod, &gracePeriodSeconds)
+		if err == nil || apierrors.IsNotFound(err) {
+			return nil
+		}
+		if !apierrors.IsTooManyRequests(err) {
+			return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("eviction of pod %s/%s refused by disruption budget: %w", pod.Namespace, pod.Name, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// PodsToEvict returns the node's pods that a drain must move, excluding DaemonSet, mirror and finished pods
+func (d *Drainer) PodsToEvict(ctx context.Context, nodeName string) ([]corev1.Pod, error) {
+	podList := &corev1.PodList{}
+	if err := d.client.List(ctx, podList, client.MatchingFields{"spec.nodeName": nodeName}); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	var pods []corev1.Pod
+	for _, pod := range podList.Items {
+		if !needsEviction(&pod) {
+			continue
+		}
+		pods = append(pods, pod)
+	}
+
+	return pods, nil
+}
+
+// evict creates an Eviction for the pod; a nil grace period keeps the pod's own
+func (d *Drainer) evict(ctx context.Context, pod *corev1.Pod, gracePeriodSeconds *int64) error {
+	eviction := &policyv1.Eviction{
+		ObjectMeta: metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+		DeleteOptions: &metav1.DeleteOptions{
+			GracePeriodSeconds: gracePeriodSeconds,
+		},
+	}
+	return d.client.SubResource("eviction").Create(ctx, pod, eviction)
+}
+
+// needsEviction reports whether a drain must move the pod off its node
+func needsEviction(pod *corev1.Pod) bool {
+	if _, mirror := pod.Annotations[corev1.MirrorPodAnnotationKey]; mirror {
+		return false
+	}
+	if ownedByDaemonSet(pod) {
+		return false
+	}
+	return pod.Status.Phase != corev1.PodSucceeded && pod.Status.Phase != corev1.PodFailed
+}
+
+// isSafeToEvict reports whether the pod allows its node to be drained
+func isSafeToEvict(pod *corev1.Pod) bool {
+	return pod.Annotations[SafeToEvictAnnotation] != "false" &&
+		pod.Annotations[ClusterAutoscalerSafeToEvictAnnotation] != "false"
+}
+
+func ownedByDaemonSet(pod *corev1.Pod) bool {
+	for _, owner := range pod.OwnerReferences {
+		if owner.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
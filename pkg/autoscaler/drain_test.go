@@ -0,0 +1,209 @@
+package autoscaler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+// newTestDrainer returns a drainer whose evictions of the named pod are refused by a disruption budget
+// the given number of times (-1 refuses them forever)
+func newTestDrainer(t *testing.T, blockedPod string, refusals int, objects ...client.Object) (*Drainer, client.Client) {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add core scheme: %v", err)
+	}
+
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(objects...).
+		WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+			return []string{obj.(*corev1.Pod).Spec.NodeName}
+		}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceCreate: func(ctx context.Context, c client.Client, subResource string, obj client.Object, subResourceObj client.Object, opts ...client.SubResourceCreateOption) error {
+				if subResource == "eviction" && obj.GetName() == blockedPod && refusals != 0 {
+					refusals--
+					return apierrors.NewTooManyRequests("Cannot evict pod as it would violate the pod's disruption budget.", 0)
+				}
+				return c.SubResource(subResource).Create(ctx, obj, subResourceObj, opts...)
+			},
+		}).
+		Build()
+
+	drainer := NewDrainer(k8sClient, logr.Discard())
+	drainer.Timeout = 200 * time.Millisecond
+	drainer.PollInterval = 10 * time.Millisecond
+	return drainer, k8sClient
+}
+
+func newDrainTestPod(name string, mutate func(pod *corev1.Pod)) *corev1.Pod {
+	controller := true
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            name,
+			Namespace:       "ml",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: name, UID: "2", Controller: &controller}},
+		},
+		Spec:   corev1.PodSpec{NodeName: "gpu-node-1"},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+	if mutate != nil {
+		mutate(pod)
+	}
+	return pod
+}
+
+func TestDrainerDrain(t *testing.T) {
+	daemonSetPod := newDrainTestPod("dcgm-exporter", func(pod *corev1.Pod) {
+		pod.OwnerReferences = []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "dcgm-exporter", UID: "1"}}
+	})
+	mirrorPod := newDrainTestPod("static-monitor", func(pod *corev1.Pod) {
+		pod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "abc"}
+	})
+	finishedPod := newDrainTestPod("batch-done", func(pod *corev1.Pod) { pod.Status.Phase = corev1.PodSucceeded })
+
+	tests := []struct {
+		name          string
+		pods          []client.Object
+		refusals      int
+		expectErr     bool
+		expectBlocked bool
+		expectCordon  bool
+		expectedPods  int
+	}{
+		{
+			name:         "evicts workloads and leaves DaemonSet, mirror and finished pods",
+			pods:         []client.Object{newDrainTestPod("trainer", nil), daemonSetPod, mirrorPod, finishedPod},
+			expectCordon: true,
+			expectedPods: 3,
+		},
+		{
+			name:         "retries evictions refused by a disruption budget",
+			pods:         []client.Object{newDrainTestPod("trainer", nil), newDrainTestPod("inference", nil)},
+			refusals:     3,
+			expectCordon: true,
+		},
+		{
+			name:         "aborts and uncordons when the budget never allows the eviction",
+			pods:         []client.Object{newDrainTestPod("trainer", nil), newDrainTestPod("inference", nil)},
+			refusals:     -1,
+			expectErr:    true,
+			expectedPods: 1,
+		},
+		{
+			name: "leaves nodes with pods that are not safe to evict",
+			pods: []client.Object{newDrainTestPod("trainer", nil), newDrainTestPod("notebook", func(pod *corev1.Pod) {
+				pod.Annotations = map[string]string{ClusterAutoscalerSafeToEvictAnnotation: "false"}
+			})},
+			expectErr:     true,
+			expectBlocked: true,
+			expectedPods:  2,
+		},
+		{
+			name: "leaves nodes with pods no controller would recreate",
+			pods: []client.Object{newDrainTestPod("trainer", nil), newDrainTestPod("debug-shell", func(pod *corev1.Pod) {
+				pod.OwnerReferences = nil
+			})},
+			expectErr:     true,
+			expectBlocked: true,
+			expectedPods:  2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := append([]client.Object{newTestGPUNode("gpu-node-1", CapacityTypeOnDemand)}, tt.pods...)
+			drainer, k8sClient := newTestDrainer(t, "trainer", tt.refusals, objects...)
+
+			node := &corev1.Node{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-node-1"}, node); err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+
+			err := drainer.Drain(context.Background(), node)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if IsDrainBlocked(err) != tt.expectBlocked {
+				t.Errorf("Expected blocked drain %v, got %v", tt.expectBlocked, err)
+			}
+
+			if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-node-1"}, node); err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+			if node.Spec.Unschedulable != tt.expectCordon {
+				t.Errorf("Expected unschedulable %v, got %v", tt.expectCordon, node.Spec.Unschedulable)
+			}
+
+			podList := &corev1.PodList{}
+			if err := k8sClient.List(context.Background(), podList); err != nil {
+				t.Fatalf("failed to list pods: %v", err)
+			}
+			if len(podList.Items) != tt.expectedPods {
+				t.Errorf("Expected %d pods to remain, got %d", tt.expectedPods, len(podList.Items))
+			}
+		})
+	}
+}
+
+func TestDrainerCordonsStaleNode(t *testing.T) {
+	drainer, k8sClient := newTestDrainer(t, "trainer", 0, newTestGPUNode("gpu-node-1", CapacityTypeOnDemand))
+
+	stale := &corev1.Node{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-node-1"}, stale); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+
+	// Another controller labels the node after it was read
+	current := stale.DeepCopy()
+	current.Labels[NodePoolLabel] = "a100"
+	if err := k8sClient.Update(context.Background(), current); err != nil {
+		t.Fatalf("failed to label node: %v", err)
+	}
+
+	if err := drainer.Drain(context.Background(), stale); err != nil {
+		t.Fatalf("Drain() error = %v", err)
+	}
+
+	node := &corev1.Node{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "gpu-node-1"}, node); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if !node.Spec.Unschedulable {
+		t.Error("Expected the node to be cordoned")
+	}
+	if node.Labels[NodePoolLabel] != "a100" {
+		t.Errorf("Expected the concurrent label to be kept, got labels %v", node.Labels)
+	}
+}
+
+func TestDrainerEvictPod(t *testing.T) {
+	pod := newDrainTestPod("trainer", nil)
+	drainer, k8sClient := newTestDrainer(t, "trainer", 2, pod)
+
+	if err := drainer.EvictPod(context.Background(), pod, 0); err != nil {
+		t.Fatalf("EvictPod() error = %v", err)
+	}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(pod), &corev1.Pod{}); !apierrors.IsNotFound(err) {
+		t.Errorf("Expected the pod to be evicted, got %v", err)
+	}
+
+	blocked := newDrainTestPod("trainer", nil)
+	drainer, _ = newTestDrainer(t, "trainer", -1, blocked)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	if err := drainer.EvictPod(ctx, blocked, 0); err == nil {
+		t.Error("Expected error when the disruption budget never allows the eviction")
+	}
+}
@@ -0,0 +1,283 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// DefaultCapacityBackoff is how long capacity a cloud provider could not supply is skipped
+const DefaultCapacityBackoff = 5 * time.Minute
+
+// capacityKey identifies capacity a scale-up failed to get. An empty instance type or zone
+// covers every instance type or zone of the pool.
+type capacityKey struct {
+	nodePool     string
+	instanceType string
+	zone         string
+}
+
+func (k capacityKey) String() string {
+	parts := make([]string, 0, 2)
+	if k.instanceType != "" {
+		parts = append(parts, k.instanceType)
+	}
+	if k.zone != "" {
+		parts = append(parts, k.zone)
+	}
+	if len(parts) == 0 {
+		return "node pool " + k.nodePool
+	}
+	return fmt.Sprintf("node pool %s (%s)", k.nodePool, strings.Join(parts, " in "))
+}
+
+// scaleUpCandidate is a node pool a scale-up can use, with the nodes it needs
+type scaleUpCandidate struct {
+	pool       *NodePoolConfig
+	nodes      int
+	placedPods int
+}
+
+// scaleUpAttempt is one request for nodes from a pool. Fallback attempts narrow the pool
+// to a single instance type and zone, as far as the cloud provider can narrow it.
+type scaleUpAttempt struct {
+	pool         NodePoolConfig
+	instanceType string
+	zone         string
+}
+
+// requestNodes asks the cloud provider for nodes. When the provider reports that the capacity is not
+// available, the request falls back to the pool's next instance type, then its next zone, for the parts
+// of the pool the provider can narrow, and then to the next node pool by priority. Capacity that failed
+// is backed off so later scale-ups skip it.
+// The decision is updated with the node pool that was used.
+func (r *AutoscalerController) requestNodes(ctx context.Context, scope *scalingScope, decision *ScalingDecision, nodePool *NodePoolConfig, count int) error {
+	maxToAdd := scope.config.MaxNodes - decision.CurrentNodeCount - decision.ProvisioningNodes
+
+	var lastErr error
+	for _, candidate := range r.scaleUpFallbacks(scope, nodePool, count, maxToAdd) {
+		capacityType := decision.CapacityType
+		if candidate.pool.CapacityType != "" {
+			capacityType = candidate.pool.CapacityType
+		}
+
+		for _, attempt := range r.scaleUpAttempts(candidate.pool) {
+			if r.attemptBackedOff(scope, attempt) {
+				continue
+			}
+
+			if err := r.startProvisioning(ctx, scope, attempt, capacityType, candidate.nodes); err != nil {
+				return err
+			}
+
+			err := r.CloudProvider.ScaleUp(ctx, &attempt.pool, candidate.nodes)
+			if err == nil {
+				if candidate.pool.Name != decision.NodePool {
+					decision.Reason = fmt.Sprintf("%s; fell back to node pool %s", decision.Reason, candidate.pool.Name)
+				}
+				decision.NodePool = candidate.pool.Name
+				decision.CapacityType = capacityType
+				decision.DesiredNodeCount = decision.CurrentNodeCount + decision.ProvisioningNodes + candidate.nodes
+				return nil
+			}
+			r.cancelProvisioning(scope)
+
+			providerErr, ok := AsProviderError(err)
+			if !ok {
+				return fmt.Errorf("failed to scale up: %w", err)
+			}
+			r.backOffCapacity(scope, attempt, providerErr)
+			lastErr = err
+		}
+	}
+
+	if lastErr == nil {
+		return fmt.Errorf("failed to scale up: the capacity of node pool %s is backed off after earlier failures", nodePool.Name)
+	}
+	return fmt.Errorf("failed to scale up: no node pool has capacity: %w", lastErr)
+}
+
+// scaleUpFallbacks returns the node pools a scale-up tries in order: the chosen pool, then the other pools
+// that can host the pending pods (every other pool for utilization-driven scale-ups) by descending priority
+func (r *AutoscalerController) scaleUpFallbacks(scope *scalingScope, nodePool *NodePoolConfig, count, maxToAdd int) []scaleUpCandidate {
+	var others []scaleUpCandidate
+	if len(scope.scaleUpCandidates) > 0 {
+		for _, candidate := range scope.scaleUpCandidates {
+			nodes := candidate.nodes - scope.provisioning[candidate.pool.Name]
+			if candidate.pool.Name == nodePool.Name || nodes <= 0 {
+				continue
+			}
+			if nodes > maxToAdd {
+				nodes = maxToAdd
+			}
+			others = append(others, scaleUpCandidate{pool: candidate.pool, nodes: nodes})
+		}
+	} else {
+		for i := range scope.config.NodePools {
+			pool := &scope.config.NodePools[i]
+			if pool.Name != nodePool.Name {
+				others = append(others, scaleUpCandidate{pool: pool, nodes: count})
+			}
+		}
+	}
+
+	sort.SliceStable(others, func(i, j int) bool {
+		return others[i].pool.Priority > others[j].pool.Priority
+	})
+
+	return append([]scaleUpCandidate{{pool: nodePool, nodes: count}}, others...)
+}
+
+// scaleUpAttempts lists the requests a scale-up of a pool tries in order: the pool as configured,
+// then, for pools with several instance types or zones the cloud provider can narrow, each instance
+// type in turn in each zone
+func (r *AutoscalerController) scaleUpAttempts(pool *NodePoolConfig) []scaleUpAttempt {
+	attempts := []scaleUpAttempt{{pool: *pool}}
+
+	overrides := r.CloudProvider.GetScaleUpOverrides(pool)
+	instanceTypes := []string{""}
+	if overrides.InstanceType && len(pool.InstanceTypes) > 1 {
+		instanceTypes = pool.InstanceTypes
+	}
+	zones := []string{""}
+	if overrides.Zone && len(pool.AvailabilityZones) > 1 {
+		zones = pool.AvailabilityZones
+	}
+	if len(instanceTypes) == 1 && len(zones) == 1 {
+		return attempts
+	}
+
+	for _, zone := range zones {
+		for _, instanceType := range instanceTypes {
+			attempts = append(attempts, narrowAttempt(pool, instanceType, zone))
+		}
+	}
+
+	return attempts
+}
+
+// narrowAttempt returns the attempt that requests nodes of a pool in a single instance type and zone.
+// An empty instance type or zone leaves that part of the pool as configured.
+func narrowAttempt(pool *NodePoolConfig, instanceType, zone string) scaleUpAttempt {
+	narrowed := *pool
+	if instanceType != "" {
+		narrowed.InstanceTypes = []string{instanceType}
+	}
+	if zone != "" {
+		narrowed.AvailabilityZones = []string{zone}
+	}
+	return scaleUpAttempt{pool: narrowed, instanceType: instanceType, zone: zone}
+}
+
+// attemptBackedOff reports whether an attempt would ask for capacity that is backed off.
+// A pool as configured is skipped once any of its capacity is backed off, since the provider
+// could pick the failed instance type or zone again.
+func (r *AutoscalerController) attemptBackedOff(scope *scalingScope, attempt scaleUpAttempt) bool {
+	if attempt.instanceType == "" && attempt.zone == "" {
+		return r.nodePoolHasBackoff(scope, attempt.pool.Name)
+	}
+	return r.capacityBackedOff(scope, attempt.pool.Name, attempt.instanceType, attempt.zone)
+}
+
+// backOffCapacity records the capacity an attempt failed to get. Pools at their max size are backed off
+// as a whole, quotas for the instance type in every zone, and unavailable capacity for the instance type
+// in the zone. Capacity the provider did not name is taken to be the pool's first instance type and zone.
+// Instance types and zones the provider cannot narrow the pool to cover the whole pool, since no later
+// attempt could avoid them.
+func (r *AutoscalerController) backOffCapacity(scope *scalingScope, attempt scaleUpAttempt, providerErr *ProviderError) {
+	key := capacityKey{
+		nodePool:     attempt.pool.Name,
+		instanceType: firstNonEmpty(providerErr.InstanceType, attempt.instanceType, firstOrEmpty(attempt.pool.InstanceTypes)),
+		zone:         firstNonEmpty(providerErr.Zone, attempt.zone, firstOrEmpty(attempt.pool.AvailabilityZones)),
+	}
+	switch providerErr.Reason {
+	case ProviderErrorPoolAtMaxSize:
+		key.instanceType, key.zone = "", ""
+	case ProviderErrorQuotaExceeded:
+		key.zone = ""
+	}
+	overrides := r.CloudProvider.GetScaleUpOverrides(&attempt.pool)
+	if !overrides.InstanceType {
+		key.instanceType = ""
+	}
+	if !overrides.Zone {
+		key.zone = ""
+	}
+
+	if scope.state.failedCapacity == nil {
+		scope.state.failedCapacity = make(map[capacityKey]time.Time)
+	}
+	scope.state.failedCapacity[key] = r.now().Add(scope.config.CapacityBackoff)
+
+	message := fmt.Sprintf("%s: %s, backing off for %s: %v", key, providerErr.Reason, scope.config.CapacityBackoff, providerErr)
+	r.Log.Info("cloud provider could not supply capacity", "policy", scope.policy.Name, "reason", message)
+	if r.Recorder != nil {
+		r.Recorder.Event(scope.policy, corev1.EventTypeWarning, ReasonCapacityUnavailable, message)
+	}
+}
+
+// capacityBackedOff reports whether an instance type in a zone of the pool is backed off
+func (r *AutoscalerController) capacityBackedOff(scope *scalingScope, nodePool, instanceType, zone string) bool {
+	now := r.now()
+	for _, key := range []capacityKey{
+		{nodePool: nodePool},
+		{nodePool: nodePool, instanceType: instanceType},
+		{nodePool: nodePool, instanceType: instanceType, zone: zone},
+	} {
+		if until, ok := scope.state.failedCapacity[key]; ok && now.Before(until) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodePoolHasBackoff reports whether any capacity of the pool is backed off, dropping expired backoffs
+func (r *AutoscalerController) nodePoolHasBackoff(scope *scalingScope, nodePool string) bool {
+	now := r.now()
+	backedOff := false
+	for key, until := range scope.state.failedCapacity {
+		if !now.Before(until) {
+			delete(scope.state.failedCapacity, key)
+			continue
+		}
+		if key.nodePool == nodePool {
+			backedOff = true
+		}
+	}
+	return backedOff
+}
+
+// nodePoolBackedOff reports whether every instance type in every zone of the pool is backed off
+func (r *AutoscalerController) nodePoolBackedOff(scope *scalingScope, pool *NodePoolConfig) bool {
+	if !r.nodePoolHasBackoff(scope, pool.Name) {
+		return false
+	}
+	// The first attempt is the pool as configured; a pool with a single instance type and zone
+	// has nothing else to fall back to
+	for _, attempt := range r.scaleUpAttempts(pool)[1:] {
+		if !r.capacityBackedOff(scope, pool.Name, attempt.instanceType, attempt.zone) {
+			return false
+		}
+	}
+	return true
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if value != "" {
+			return value
+		}
+	}
+	return ""
+}
+
+func firstOrEmpty(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,233 @@
+package autoscaler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+// stockoutProvider refuses scale-ups of the capacity listed in unavailable, keyed by
+// "pool/instance-type/zone", and records the capacity of the scale-ups it accepts.
+// Pools that are not narrowed launch their first instance type in their first zone.
+// Unless fixed is set, it narrows pools by both instance type and zone.
+type stockoutProvider struct {
+	*recordingProvider
+	unavailable map[string]error
+	fixed       bool
+	attempts    []string
+	launched    []string
+}
+
+func (p *stockoutProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{InstanceType: !p.fixed, Zone: !p.fixed}
+}
+
+func (p *stockoutProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
+	capacity := fmt.Sprintf("%s/%s/%s", nodePool.Name, firstOrEmpty(nodePool.InstanceTypes), firstOrEmpty(nodePool.AvailabilityZones))
+	p.attempts = append(p.attempts, capacity)
+	for _, key := range []string{capacity, nodePool.Name + "/" + firstOrEmpty(nodePool.InstanceTypes) + "/", nodePool.Name + "//"} {
+		if err, ok := p.unavailable[key]; ok {
+			return err
+		}
+	}
+	p.launched = append(p.launched, capacity)
+	return p.recordingProvider.ScaleUp(ctx, nodePool, count)
+}
+
+func TestClassifyProviderError(t *testing.T) {
+	onDemand := &NodePoolConfig{Name: "a100", CapacityType: CapacityTypeOnDemand, InstanceTypes: []string{"p4d.24xlarge"}, AvailabilityZones: []string{"us-east-1a"}}
+	spot := &NodePoolConfig{Name: "a100-spot", CapacityType: CapacityTypeSpot, InstanceTypes: []string{"p4d.24xlarge", "p4de.24xlarge"}}
+
+	tests := []struct {
+		name                 string
+		pool                 *NodePoolConfig
+		err                  error
+		expectedReason       ProviderErrorReason
+		expectedInstanceType string
+		expectedZone         string
+	}{
+		{
+			name:                 "AWS insufficient capacity",
+			pool:                 onDemand,
+			err:                  errors.New("InsufficientInstanceCapacity: We currently do not have sufficient p4d.24xlarge capacity"),
+			expectedReason:       ProviderErrorInsufficientCapacity,
+			expectedInstanceType: "p4d.24xlarge",
+			expectedZone:         "us-east-1a",
+		},
+		{name: "GCP stockout of a spot pool", pool: spot, err: errors.New("ZONE_RESOURCE_POOL_EXHAUSTED"), expectedReason: ProviderErrorSpotUnavailable},
+		{name: "GCP quota", pool: onDemand, err: errors.New("QUOTA_EXCEEDED: NVIDIA_A100_GPUS"), expectedReason: ProviderErrorQuotaExceeded, expectedInstanceType: "p4d.24xlarge", expectedZone: "us-east-1a"},
+		{name: "Azure allocation failure", pool: onDemand, err: errors.New("ZonalAllocationFailed"), expectedReason: ProviderErrorInsufficientCapacity, expectedInstanceType: "p4d.24xlarge", expectedZone: "us-east-1a"},
+		{
+			name:                 "AWS API error code",
+			pool:                 onDemand,
+			err:                  fmt.Errorf("ec2 RunInstances returned 500: %w", parseAWSError([]byte(`<Response><Errors><Error><Code>InsufficientInstanceCapacity</Code><Message>Try another zone</Message></Error></Errors></Response>`))),
+			expectedReason:       ProviderErrorInsufficientCapacity,
+			expectedInstanceType: "p4d.24xlarge",
+			expectedZone:         "us-east-1a",
+		},
+		{name: "Azure API error code of a spot pool", pool: spot, err: parseAzureError(409, []byte(`{"error":{"code":"ZonalAllocationFailed","message":"Allocation failed"}}`)), expectedReason: ProviderErrorSpotUnavailable},
+		{name: "API error mentioning a capacity code in its message", pool: onDemand, err: parseAzureError(400, []byte(`{"error":{"code":"InvalidParameter","message":"Tag QuotaExceeded is reserved"}}`))},
+		{name: "AWS activity message without a code", pool: spot, err: errors.New("We currently do not have sufficient p4d.24xlarge capacity in the Availability Zone you requested"), expectedReason: ProviderErrorSpotUnavailable},
+		{name: "other errors are left alone", pool: onDemand, err: errors.New("connection refused")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("failed to scale: %w", classifyProviderError(tt.pool, tt.err))
+
+			providerErr, ok := AsProviderError(err)
+			if tt.expectedReason == "" {
+				if ok {
+					t.Errorf("Expected no provider error, got %+v", providerErr)
+				}
+				return
+			}
+			if !ok {
+				t.Fatalf("Expected a provider error, got %v", err)
+			}
+			if providerErr.Reason != tt.expectedReason || providerErr.InstanceType != tt.expectedInstanceType || providerErr.Zone != tt.expectedZone {
+				t.Errorf("Expected %s for %q in %q, got %s for %q in %q", tt.expectedReason, tt.expectedInstanceType, tt.expectedZone,
+					providerErr.Reason, providerErr.InstanceType, providerErr.Zone)
+			}
+			if !errors.Is(err, tt.err) {
+				t.Error("Expected the provider error to wrap the cloud error")
+			}
+		})
+	}
+}
+
+func TestRequestNodesFallback(t *testing.T) {
+	insufficient := NewProviderError(ProviderErrorInsufficientCapacity, "a100", errors.New("InsufficientInstanceCapacity"))
+	quota := NewProviderError(ProviderErrorQuotaExceeded, "a100", errors.New("VcpuLimitExceeded"))
+	atMax := NewProviderError(ProviderErrorPoolAtMaxSize, "a100", errors.New("ASG a100 is already at max size 4"))
+
+	pools := []v1alpha1.NodePoolSpec{
+		{Name: "a100", CapacityType: CapacityTypeOnDemand, InstanceTypes: []string{"p4d.24xlarge", "p4de.24xlarge"}, AvailabilityZones: []string{"us-east-1a", "us-east-1b"}},
+		{Name: "l4", CapacityType: CapacityTypeOnDemand, InstanceTypes: []string{"g6.xlarge"}},
+		{Name: "h100", CapacityType: CapacityTypeOnDemand, InstanceTypes: []string{"p5.48xlarge"}, Priority: 10},
+	}
+
+	tests := []struct {
+		name             string
+		unavailable      map[string]error
+		fixed            bool
+		expectErr        bool
+		expectedLaunch   string
+		expectedAttempts int
+	}{
+		{name: "capacity available", expectedLaunch: "a100/p4d.24xlarge/us-east-1a", expectedAttempts: 1},
+		{
+			name:             "next instance type",
+			unavailable:      map[string]error{"a100/p4d.24xlarge/us-east-1a": insufficient},
+			expectedLaunch:   "a100/p4de.24xlarge/us-east-1a",
+			expectedAttempts: 2,
+		},
+		{
+			name:             "next zone",
+			unavailable:      map[string]error{"a100/p4d.24xlarge/us-east-1a": insufficient, "a100/p4de.24xlarge/us-east-1a": insufficient},
+			expectedLaunch:   "a100/p4d.24xlarge/us-east-1b",
+			expectedAttempts: 3,
+		},
+		{
+			name:             "quota covers the instance type in every zone",
+			unavailable:      map[string]error{"a100/p4d.24xlarge/": quota, "a100/p4de.24xlarge/us-east-1a": insufficient},
+			expectedLaunch:   "a100/p4de.24xlarge/us-east-1b",
+			expectedAttempts: 3,
+		},
+		{
+			name:             "pools the provider cannot narrow fall back to the next pool",
+			unavailable:      map[string]error{"a100/p4d.24xlarge/us-east-1a": insufficient},
+			fixed:            true,
+			expectedLaunch:   "h100/p5.48xlarge/",
+			expectedAttempts: 2,
+		},
+		{
+			name:             "next pool by priority",
+			unavailable:      map[string]error{"a100//": atMax},
+			expectedLaunch:   "h100/p5.48xlarge/",
+			expectedAttempts: 2,
+		},
+		{
+			name:             "other errors stop the scale-up",
+			unavailable:      map[string]error{"a100//": errors.New("connection refused")},
+			expectErr:        true,
+			expectedAttempts: 1,
+		},
+		{
+			name:             "no capacity anywhere",
+			unavailable:      map[string]error{"a100//": atMax, "h100//": atMax, "l4//": atMax},
+			expectErr:        true,
+			expectedAttempts: 3,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now()
+			policy := &v1alpha1.AutoscalingPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "training"},
+				Spec:       v1alpha1.AutoscalingPolicySpec{Enabled: true, MaxNodes: 10, NodePools: pools},
+			}
+			controller, recording, recorder, _ := newTestAutoscalerController(t, policy)
+			provider := &stockoutProvider{recordingProvider: recording, unavailable: tt.unavailable, fixed: tt.fixed}
+			controller.CloudProvider = provider
+			controller.Clock = func() time.Time { return now }
+
+			scope := controller.newScalingScope(policy)
+			decision := &ScalingDecision{Action: ScaleUp, Reason: "GPU utilization high", NodePool: "a100", CapacityType: CapacityTypeOnDemand}
+
+			err := controller.requestNodes(context.Background(), scope, decision, controller.getNodePoolByName(scope, "a100"), 1)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if len(provider.attempts) != tt.expectedAttempts {
+				t.Errorf("Expected %d attempts, got %v", tt.expectedAttempts, provider.attempts)
+			}
+			if tt.expectErr {
+				if len(policy.Status.ProvisioningRequests) != 0 {
+					t.Errorf("Expected no provisioning request, got %+v", policy.Status.ProvisioningRequests)
+				}
+				return
+			}
+
+			if len(provider.launched) != 1 || provider.launched[0] != tt.expectedLaunch {
+				t.Fatalf("Expected %s to be launched, got %v", tt.expectedLaunch, provider.launched)
+			}
+			pool := strings.SplitN(tt.expectedLaunch, "/", 2)[0]
+			if decision.NodePool != pool {
+				t.Errorf("Expected the decision to use node pool %s, got %s", pool, decision.NodePool)
+			}
+			if requests := policy.Status.ProvisioningRequests; len(requests) != 1 || requests[0].NodePool != pool {
+				t.Errorf("Expected one provisioning request for %s, got %+v", pool, requests)
+			}
+			if len(recorder.Events) != tt.expectedAttempts-1 {
+				t.Errorf("Expected an event per unavailable capacity, got %d events", len(recorder.Events))
+			}
+
+			// The next scale-up skips the failed capacity until the backoff expires
+			provider.attempts, provider.launched = nil, nil
+			if err := controller.requestNodes(context.Background(), scope, decision, controller.getNodePoolByName(scope, "a100"), 1); err != nil {
+				t.Fatalf("requestNodes() error = %v", err)
+			}
+			if len(provider.attempts) != 1 || provider.launched[0] != tt.expectedLaunch {
+				t.Errorf("Expected the backed-off capacity to be skipped, got %v", provider.attempts)
+			}
+
+			now = now.Add(DefaultCapacityBackoff)
+			provider.attempts = nil
+			if err := controller.requestNodes(context.Background(), scope, decision, controller.getNodePoolByName(scope, "a100"), 1); err != nil {
+				t.Fatalf("requestNodes() error = %v", err)
+			}
+			if provider.attempts[0] != "a100/p4d.24xlarge/us-east-1a" {
+				t.Errorf("Expected the pool as configured to be tried after the backoff, got %v", provider.attempts)
+			}
+		})
+	}
+}
@@ -0,0 +1,439 @@
+package autoscaler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// gcpComputeEndpoint is the Compute Engine v1 REST API
+	gcpComputeEndpoint = "https://compute.googleapis.com/compute/v1/"
+
+	// gcpMetadataEndpoint is the GCE metadata server, which also serves GKE Workload Identity tokens
+	gcpMetadataEndpoint = "http://metadata.google.internal"
+
+	// gcpPreemptedOperationType is the operation GCE records when it preempts a VM
+	gcpPreemptedOperationType = "compute.instances.preempted"
+
+	// oauthTokenRefreshWindow is how long before expiry cached access tokens are replaced
+	oauthTokenRefreshWindow = 5 * time.Minute
+)
+
+// gcpGPUMachineType matches accelerator-optimized machine types, whose GPU count is their suffix (a2-highgpu-4g)
+var gcpGPUMachineType = regexp.MustCompile(`^[a-z][0-9]-[a-z]+gpu-([0-9]+)g$`)
+
+// GCPComputeClient implements GCPComputeAPI on top of the Compute Engine REST API.
+// Requests carry an access token of the VM's (or the GKE Workload Identity) service account
+// from the metadata server.
+type GCPComputeClient struct {
+	projectID  string
+	httpClient *http.Client
+
+	// Endpoints, overridden in tests
+	computeEndpoint  string
+	metadataEndpoint string
+
+	token oauthToken
+}
+
+// oauthToken caches an OAuth 2.0 access token until shortly before it expires
+type oauthToken struct {
+	mu      sync.Mutex
+	value   string
+	expires time.Time
+}
+
+// get returns the cached token, fetching a new one when there is none or it is about to expire
+func (t *oauthToken) get(fetch func() (string, time.Time, error)) (string, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.value != "" && time.Until(t.expires) > oauthTokenRefreshWindow {
+		return t.value, nil
+	}
+
+	value, expires, err := fetch()
+	if err != nil {
+		return "", err
+	}
+	t.value, t.expires = value, expires
+	return value, nil
+}
+
+// NewGCPComputeClient creates a Compute Engine client for a project; httpClient is http.DefaultClient when nil
+func NewGCPComputeClient(projectID string, httpClient *http.Client) (*GCPComputeClient, error) {
+	if projectID == "" {
+		return nil, fmt.Errorf("GCP project ID is required")
+	}
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	return &GCPComputeClient{
+		projectID:        projectID,
+		httpClient:       httpClient,
+		computeEndpoint:  gcpComputeEndpoint,
+		metadataEndpoint: gcpMetadataEndpoint,
+	}, nil
+}
+
+// gcpAPIError is an error returned by the Compute Engine API or recorded on a failed operation
+type gcpAPIError struct {
+	StatusCode int
+	Code       string
+	Message    string
+}
+
+func (e *gcpAPIError) Error() string {
+	return fmt.Sprintf("%s: %s", e.Code, e.Message)
+}
+
+// ErrorCode returns the error reason or status of a response, or the code recorded on an operation
+func (e *gcpAPIError) ErrorCode() string {
+	return e.Code
+}
+
+// Is makes 404 responses match ErrGCPNotFound
+func (e *gcpAPIError) Is(target error) bool {
+	return target == ErrGCPNotFound && e.StatusCode == http.StatusNotFound
+}
+
+type gcpOperationJSON struct {
+	Name          string `json:"name"`
+	OperationType string `json:"operationType"`
+	TargetLink    string `json:"targetLink"`
+	Status        string `json:"status"`
+	InsertTime    string `json:"insertTime"`
+	Error         *struct {
+		Errors []struct {
+			Code    string `json:"code"`
+			Message string `json:"message"`
+		} `json:"errors"`
+	} `json:"error"`
+}
+
+// err returns the first error recorded on a finished operation
+func (o *gcpOperationJSON) err() error {
+	if o.Error == nil || len(o.Error.Errors) == 0 {
+		return nil
+	}
+	return providerErrorFromCode(&gcpAPIError{Code: o.Error.Errors[0].Code, Message: o.Error.Errors[0].Message})
+}
+
+// GetInstanceGroupManager returns a zonal MIG with its autoscaler bounds, instance template and instances
+func (c *GCPComputeClient) GetInstanceGroupManager(ctx context.Context, zone, name string) (*ManagedInstanceGroup, error) {
+	var manager struct {
+		Name             string `json:"name"`
+		Zone             string `json:"zone"`
+		TargetSize       int32  `json:"targetSize"`
+		InstanceTemplate string `json:"instanceTemplate"`
+		Status           struct {
+			Autoscaler string `json:"autoscaler"`
+		} `json:"status"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.zonalURL(zone, "instanceGroupManagers", name), nil, &manager); err != nil {
+		return nil, err
+	}
+
+	mig := &ManagedInstanceGroup{
+		Name:       manager.Name,
+		Zone:       lastPathSegment(manager.Zone),
+		TargetSize: manager.TargetSize,
+	}
+
+	if manager.Status.Autoscaler != "" {
+		var autoscaler struct {
+			AutoscalingPolicy struct {
+				MinNumReplicas int32 `json:"minNumReplicas"`
+				MaxNumReplicas int32 `json:"maxNumReplicas"`
+			} `json:"autoscalingPolicy"`
+		}
+		if err := c.do(ctx, http.MethodGet, manager.Status.Autoscaler, nil, &autoscaler); err != nil {
+			return nil, fmt.Errorf("failed to get autoscaler of MIG %s: %w", name, err)
+		}
+		mig.MinSize = autoscaler.AutoscalingPolicy.MinNumReplicas
+		mig.MaxSize = autoscaler.AutoscalingPolicy.MaxNumReplicas
+	}
+
+	if manager.InstanceTemplate != "" {
+		var template struct {
+			Properties struct {
+				MachineType string `json:"machineType"`
+				Scheduling  struct {
+					Preemptible       bool   `json:"preemptible"`
+					ProvisioningModel string `json:"provisioningModel"`
+				} `json:"scheduling"`
+				GuestAccelerators []struct {
+					AcceleratorCount int `json:"acceleratorCount"`
+				} `json:"guestAccelerators"`
+			} `json:"properties"`
+		}
+		if err := c.do(ctx, http.MethodGet, manager.InstanceTemplate, nil, &template); err != nil {
+			return nil, fmt.Errorf("failed to get instance template of MIG %s: %w", name, err)
+		}
+		properties := template.Properties
+		mig.MachineType = lastPathSegment(properties.MachineType)
+		mig.Preemptible = properties.Scheduling.Preemptible || properties.Scheduling.ProvisioningModel == "SPOT"
+		for _, accelerator := range properties.GuestAccelerators {
+			mig.GPUCount += accelerator.AcceleratorCount
+		}
+		if mig.GPUCount == 0 {
+			mig.GPUCount = gcpMachineTypeGPUCount(mig.MachineType)
+		}
+	}
+
+	var instances struct {
+		ManagedInstances []struct {
+			Instance       string `json:"instance"`
+			InstanceStatus string `json:"instanceStatus"`
+			CurrentAction  string `json:"currentAction"`
+		} `json:"managedInstances"`
+	}
+	if err := c.do(ctx, http.MethodPost, c.zonalURL(zone, "instanceGroupManagers", name, "listManagedInstances"), nil, &instances); err != nil {
+		return nil, fmt.Errorf("failed to list instances of MIG %s: %w", name, err)
+	}
+	for _, instance := range instances.ManagedInstances {
+		mig.Instances = append(mig.Instances, ManagedInstance{
+			Name:          lastPathSegment(instance.Instance),
+			Status:        instance.InstanceStatus,
+			CurrentAction: instance.CurrentAction,
+		})
+	}
+
+	return mig, nil
+}
+
+// ResizeInstanceGroupManager sets a MIG's target size and waits for the resize operation,
+// so errors such as ZONE_RESOURCE_POOL_EXHAUSTED reach the provider
+func (c *GCPComputeClient) ResizeInstanceGroupManager(ctx context.Context, zone, name string, size int32) error {
+	resizeURL := c.zonalURL(zone, "instanceGroupManagers", name, "resize") + "?size=" + strconv.Itoa(int(size))
+	var operation gcpOperationJSON
+	if err := c.do(ctx, http.MethodPost, resizeURL, nil, &operation); err != nil {
+		return err
+	}
+	return c.waitForOperation(ctx, zone, &operation)
+}
+
+// DeleteInstances deletes instances from a MIG, which lowers its target size
+func (c *GCPComputeClient) DeleteInstances(ctx context.Context, zone, name string, instances []string) error {
+	body := struct {
+		Instances []string `json:"instances"`
+	}{}
+	for _, instance := range instances {
+		body.Instances = append(body.Instances, fmt.Sprintf("zones/%s/instances/%s", zone, instance))
+	}
+
+	var operation gcpOperationJSON
+	if err := c.do(ctx, http.MethodPost, c.zonalURL(zone, "instanceGroupManagers", name, "deleteInstances"), body, &operation); err != nil {
+		return err
+	}
+	return operation.err()
+}
+
+// GetInstance returns a VM instance by name
+func (c *GCPComputeClient) GetInstance(ctx context.Context, zone, name string) (*GCEInstance, error) {
+	var out struct {
+		Name        string `json:"name"`
+		Zone        string `json:"zone"`
+		MachineType string `json:"machineType"`
+		Status      string `json:"status"`
+		Scheduling  struct {
+			Preemptible       bool   `json:"preemptible"`
+			ProvisioningModel string `json:"provisioningModel"`
+		} `json:"scheduling"`
+		Metadata struct {
+			Items []struct {
+				Key   string `json:"key"`
+				Value string `json:"value"`
+			} `json:"items"`
+		} `json:"metadata"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.zonalURL(zone, "instances", name), nil, &out); err != nil {
+		return nil, err
+	}
+
+	instance := &GCEInstance{
+		Name:        out.Name,
+		Zone:        lastPathSegment(out.Zone),
+		MachineType: lastPathSegment(out.MachineType),
+		Status:      out.Status,
+		Preemptible: out.Scheduling.Preemptible || out.Scheduling.ProvisioningModel == "SPOT",
+	}
+	for _, item := range out.Metadata.Items {
+		if item.Key == "created-by" {
+			instance.CreatedBy = item.Value
+		}
+	}
+	return instance, nil
+}
+
+// GetPreemptionOperation returns the most recent compute.instances.preempted operation for an instance, or nil
+func (c *GCPComputeClient) GetPreemptionOperation(ctx context.Context, zone, instance string) (*GCEOperation, error) {
+	filter := fmt.Sprintf(`operationType="%s"`, gcpPreemptedOperationType)
+	var out struct {
+		Items []gcpOperationJSON `json:"items"`
+	}
+	if err := c.do(ctx, http.MethodGet, c.zonalURL(zone, "operations")+"?filter="+url.QueryEscape(filter), nil, &out); err != nil {
+		return nil, err
+	}
+
+	var latest *GCEOperation
+	for _, item := range out.Items {
+		if lastPathSegment(item.TargetLink) != instance {
+			continue
+		}
+		insertTime, err := time.Parse(time.RFC3339, item.InsertTime)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse insert time of operation %s: %w", item.Name, err)
+		}
+		if latest == nil || insertTime.After(latest.InsertTime) {
+			latest = &GCEOperation{Name: item.Name, OperationType: item.OperationType, InsertTime: insertTime}
+		}
+	}
+	return latest, nil
+}
+
+// waitForOperation waits for a zonal operation to finish and returns its error.
+// The wait call returns after at most two minutes, so a still-running operation is not an error.
+func (c *GCPComputeClient) waitForOperation(ctx context.Context, zone string, operation *gcpOperationJSON) error {
+	if operation.Status == "DONE" || operation.Name == "" {
+		return operation.err()
+	}
+	if err := c.do(ctx, http.MethodPost, c.zonalURL(zone, "operations", operation.Name, "wait"), nil, operation); err != nil {
+		return fmt.Errorf("failed to wait for operation %s: %w", operation.Name, err)
+	}
+	return operation.err()
+}
+
+// zonalURL builds the URL of a zonal resource in the client's project
+func (c *GCPComputeClient) zonalURL(zone string, path ...string) string {
+	escaped := make([]string, 0, len(path)+4)
+	for _, segment := range append([]string{"projects", c.projectID, "zones", zone}, path...) {
+		escaped = append(escaped, url.PathEscape(segment))
+	}
+	return c.computeEndpoint + strings.Join(escaped, "/")
+}
+
+// do sends an authenticated Compute Engine request and decodes the JSON response into out.
+// Resource URLs returned by the API, such as instance templates, are requested as-is.
+func (c *GCPComputeClient) do(ctx context.Context, method, requestURL string, body, out interface{}) error {
+	token, err := c.token.get(func() (string, time.Time, error) {
+		return c.metadataToken(ctx)
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get GCP access token: %w", err)
+	}
+
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, requestURL, reader)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("%s %s failed: %w", method, req.URL.Path, err)
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned %s: %w", method, req.URL.Path, resp.Status, parseGCPError(resp.StatusCode, data))
+	}
+
+	if out != nil {
+		if err := json.Unmarshal(data, out); err != nil {
+			return fmt.Errorf("failed to decode %s %s response: %w", method, req.URL.Path, err)
+		}
+	}
+	return nil
+}
+
+// parseGCPError extracts the reason and message of a Compute Engine error response
+func parseGCPError(statusCode int, data []byte) error {
+	var out struct {
+		Error struct {
+			Message string `json:"message"`
+			Status  string `json:"status"`
+			Errors  []struct {
+				Reason  string `json:"reason"`
+				Message string `json:"message"`
+			} `json:"errors"`
+		} `json:"error"`
+	}
+	if err := json.Unmarshal(data, &out); err != nil || out.Error.Message == "" {
+		return &gcpAPIError{StatusCode: statusCode, Code: http.StatusText(statusCode), Message: strings.TrimSpace(string(data))}
+	}
+
+	apiErr := &gcpAPIError{StatusCode: statusCode, Code: out.Error.Status, Message: out.Error.Message}
+	if len(out.Error.Errors) > 0 && out.Error.Errors[0].Reason != "" {
+		apiErr.Code = out.Error.Errors[0].Reason
+	}
+	return providerErrorFromCode(apiErr)
+}
+
+// metadataToken fetches an access token for the default service account from the metadata server
+func (c *GCPComputeClient) metadataToken(ctx context.Context) (string, time.Time, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet,
+		c.metadataEndpoint+"/computeMetadata/v1/instance/service-accounts/default/token", nil)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	req.Header.Set("Metadata-Flavor", "Google")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", time.Time{}, fmt.Errorf("metadata server returned %s", resp.Status)
+	}
+	var out struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int    `json:"expires_in"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return "", time.Time{}, fmt.Errorf("failed to decode metadata token: %w", err)
+	}
+	return out.AccessToken, time.Now().Add(time.Duration(out.ExpiresIn) * time.Second), nil
+}
+
+// gcpMachineTypeGPUCount returns the GPUs built into accelerator-optimized machine types, or 0
+func gcpMachineTypeGPUCount(machineType string) int {
+	match := gcpGPUMachineType.FindStringSubmatch(machineType)
+	if match == nil {
+		return 0
+	}
+	count, _ := strconv.Atoi(match[1])
+	return count
+}
+
+// lastPathSegment returns the resource name at the end of a Compute Engine resource URL
+func lastPathSegment(resourceURL string) string {
+	return resourceURL[strings.LastIndex(resourceURL, "/")+1:]
+}
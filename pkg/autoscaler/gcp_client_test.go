@@ -0,0 +1,119 @@
+package autoscaler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGCPComputeClient(t *testing.T) {
+	var server *httptest.Server
+	var requests []string
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/computeMetadata/v1/instance/service-accounts/default/token" {
+			if r.Header.Get("Metadata-Flavor") != "Google" {
+				t.Errorf("Expected the Metadata-Flavor header, got %q", r.Header.Get("Metadata-Flavor"))
+			}
+			fmt.Fprint(w, `{"access_token":"ya29.token","expires_in":3599,"token_type":"Bearer"}`)
+			return
+		}
+		if got := r.Header.Get("Authorization"); got != "Bearer ya29.token" {
+			t.Errorf("Expected the metadata token, got Authorization %q", got)
+		}
+		requests = append(requests, r.Method+" "+strings.TrimPrefix(r.URL.Path, "/compute/v1/projects/ml-project/zones/us-central1-a/"))
+
+		base := server.URL + "/compute/v1/projects/ml-project/"
+		switch {
+		case r.URL.Path == "/compute/v1/projects/ml-project/zones/us-central1-a/instanceGroupManagers/gpu-a100":
+			fmt.Fprintf(w, `{"name":"gpu-a100","zone":"%[1]szones/us-central1-a","targetSize":2,
+"instanceTemplate":"%[1]sglobal/instanceTemplates/gpu-a100-v1","status":{"autoscaler":"%[1]szones/us-central1-a/autoscalers/gpu-a100"}}`, base)
+		case r.URL.Path == "/compute/v1/projects/ml-project/zones/us-central1-a/autoscalers/gpu-a100":
+			fmt.Fprint(w, `{"autoscalingPolicy":{"minNumReplicas":1,"maxNumReplicas":6}}`)
+		case r.URL.Path == "/compute/v1/projects/ml-project/global/instanceTemplates/gpu-a100-v1":
+			fmt.Fprint(w, `{"properties":{"machineType":"a2-highgpu-4g","scheduling":{"provisioningModel":"SPOT"}}}`)
+		case strings.HasSuffix(r.URL.Path, "/listManagedInstances"):
+			fmt.Fprintf(w, `{"managedInstances":[{"instance":"%szones/us-central1-a/instances/gpu-a100-x1","instanceStatus":"RUNNING","currentAction":"NONE"}]}`, base)
+		case strings.HasSuffix(r.URL.Path, "/resize"):
+			if r.URL.Query().Get("size") != "3" {
+				t.Errorf("Expected size 3, got %q", r.URL.Query().Get("size"))
+			}
+			fmt.Fprint(w, `{"name":"operation-resize","status":"RUNNING"}`)
+		case strings.HasSuffix(r.URL.Path, "/operations/operation-resize/wait"):
+			fmt.Fprint(w, `{"name":"operation-resize","status":"DONE","error":{"errors":[{"code":"ZONE_RESOURCE_POOL_EXHAUSTED","message":"The zone does not have enough resources"}]}}`)
+		case r.URL.Path == "/compute/v1/projects/ml-project/zones/us-central1-a/instances/gpu-a100-x1":
+			fmt.Fprintf(w, `{"name":"gpu-a100-x1","zone":"%[1]szones/us-central1-a","machineType":"%[1]szones/us-central1-a/machineTypes/a2-highgpu-4g",
+"status":"RUNNING","scheduling":{"preemptible":true},"metadata":{"items":[{"key":"created-by","value":"projects/123/zones/us-central1-a/instanceGroupManagers/gpu-a100"}]}}`, base)
+		case strings.HasSuffix(r.URL.Path, "/operations"):
+			if r.URL.Query().Get("filter") != `operationType="compute.instances.preempted"` {
+				t.Errorf("Unexpected operations filter %q", r.URL.Query().Get("filter"))
+			}
+			fmt.Fprintf(w, `{"items":[
+{"name":"op-1","operationType":"compute.instances.preempted","targetLink":"%[1]szones/us-central1-a/instances/gpu-a100-x1","insertTime":"2026-10-16T09:00:00.000-07:00"},
+{"name":"op-2","operationType":"compute.instances.preempted","targetLink":"%[1]szones/us-central1-a/instances/other","insertTime":"2026-10-16T10:00:00.000-07:00"}]}`, base)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			fmt.Fprintf(w, `{"error":{"code":404,"message":"The resource '%s' was not found","errors":[{"reason":"notFound"}]}}`, r.URL.Path)
+		}
+	}))
+	defer server.Close()
+
+	client, err := NewGCPComputeClient("ml-project", server.Client())
+	if err != nil {
+		t.Fatalf("NewGCPComputeClient() error = %v", err)
+	}
+	client.computeEndpoint = server.URL + "/compute/v1/"
+	client.metadataEndpoint = server.URL
+
+	mig, err := client.GetInstanceGroupManager(context.Background(), "us-central1-a", "gpu-a100")
+	if err != nil {
+		t.Fatalf("GetInstanceGroupManager() error = %v", err)
+	}
+	if mig.Zone != "us-central1-a" || mig.TargetSize != 2 || mig.MinSize != 1 || mig.MaxSize != 6 {
+		t.Errorf("Unexpected MIG: %+v", mig)
+	}
+	if mig.MachineType != "a2-highgpu-4g" || !mig.Preemptible || mig.GPUCount != 4 {
+		t.Errorf("Unexpected MIG template: %+v", mig)
+	}
+	if len(mig.Instances) != 1 || mig.Instances[0].Name != "gpu-a100-x1" || mig.Instances[0].Status != "RUNNING" {
+		t.Errorf("Unexpected instances: %+v", mig.Instances)
+	}
+
+	if _, err := client.GetInstanceGroupManager(context.Background(), "us-central1-a", "missing"); !errors.Is(err, ErrGCPNotFound) {
+		t.Errorf("Expected ErrGCPNotFound, got %v", err)
+	}
+
+	// Capacity errors recorded on the resize operation are classified by the provider
+	err = client.ResizeInstanceGroupManager(context.Background(), "us-central1-a", "gpu-a100", 3)
+	if classified := classifyProviderError(&NodePoolConfig{Name: "gpu-a100", CapacityType: CapacityTypeSpot}, err); !IsSpotUnavailable(classified) {
+		t.Errorf("Expected a spot unavailable error, got %v", err)
+	}
+
+	instance, err := client.GetInstance(context.Background(), "us-central1-a", "gpu-a100-x1")
+	if err != nil {
+		t.Fatalf("GetInstance() error = %v", err)
+	}
+	if instance.MachineType != "a2-highgpu-4g" || !instance.Preemptible || !strings.HasSuffix(instance.CreatedBy, "/instanceGroupManagers/gpu-a100") {
+		t.Errorf("Unexpected instance: %+v", instance)
+	}
+
+	operation, err := client.GetPreemptionOperation(context.Background(), "us-central1-a", "gpu-a100-x1")
+	if err != nil {
+		t.Fatalf("GetPreemptionOperation() error = %v", err)
+	}
+	if operation == nil || operation.Name != "op-1" || !operation.InsertTime.Equal(time.Date(2026, 10, 16, 16, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected preemption operation: %+v", operation)
+	}
+
+	expected := "GET instanceGroupManagers/gpu-a100,GET autoscalers/gpu-a100," +
+		"GET /compute/v1/projects/ml-project/global/instanceTemplates/gpu-a100-v1,POST instanceGroupManagers/gpu-a100/listManagedInstances," +
+		"GET instanceGroupManagers/missing,POST instanceGroupManagers/gpu-a100/resize,POST operations/operation-resize/wait," +
+		"GET instances/gpu-a100-x1,GET operations"
+	if got := strings.Join(requests, ","); got != expected {
+		t.Errorf("Expected requests %s, got %s", expected, got)
+	}
+}
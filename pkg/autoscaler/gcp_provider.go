@@ -2,20 +2,97 @@ package autoscaler
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"strings"
 	"time"
 )
 
-// GCPProvider implements CloudProvider for Google Cloud Platform
+const (
+	// GCP gives a 30-second warning before stopping a preemptible or Spot VM
+	gcpPreemptionWarning = 30 * time.Second
+
+	// gcpDefaultMaxSize bounds MIGs that have no autoscaler attached
+	gcpDefaultMaxSize = 100
+
+	// Instance statuses reported while a VM is being stopped
+	gcpInstanceStatusStopping   = "STOPPING"
+	gcpInstanceStatusTerminated = "TERMINATED"
+)
+
+// ErrGCPNotFound is returned by GCPComputeAPI implementations when a resource does not exist
+var ErrGCPNotFound = errors.New("GCP resource not found")
+
+// GCPComputeAPI is the subset of the Compute Engine API used by GCPProvider.
+// Production deployments back it with GCPComputeClient; tests use an in-memory fake.
+type GCPComputeAPI interface {
+	// GetInstanceGroupManager returns a zonal Managed Instance Group by name
+	GetInstanceGroupManager(ctx context.Context, zone, name string) (*ManagedInstanceGroup, error)
+
+	// ResizeInstanceGroupManager sets the target size of a Managed Instance Group
+	ResizeInstanceGroupManager(ctx context.Context, zone, name string, size int32) error
+
+	// DeleteInstances deletes instances from a Managed Instance Group and lowers its target size
+	DeleteInstances(ctx context.Context, zone, name string, instances []string) error
+
+	// GetInstance returns a VM instance by name
+	GetInstance(ctx context.Context, zone, name string) (*GCEInstance, error)
+
+	// GetPreemptionOperation returns the compute.instances.preempted operation for an instance, or nil
+	GetPreemptionOperation(ctx context.Context, zone, instance string) (*GCEOperation, error)
+}
+
+// ManagedInstanceGroup describes a GCP Managed Instance Group
+type ManagedInstanceGroup struct {
+	Name       string
+	Zone       string
+	TargetSize int32
+	// MinSize and MaxSize come from the autoscaler attached to the group; MaxSize is 0 without one
+	MinSize int32
+	MaxSize int32
+	// MachineType, Preemptible and GPUCount come from the group's instance template
+	MachineType string
+	Preemptible bool
+	GPUCount    int
+	Instances   []ManagedInstance
+}
+
+// ManagedInstance describes an instance that belongs to a Managed Instance Group
+type ManagedInstance struct {
+	Name          string
+	Status        string
+	CurrentAction string
+}
+
+// GCEInstance describes a Compute Engine VM instance
+type GCEInstance struct {
+	Name        string
+	Zone        string
+	MachineType string
+	Status      string
+	Preemptible bool
+	// CreatedBy is the instance's created-by metadata, set to the owning MIG's URL
+	CreatedBy string
+}
+
+// GCEOperation describes a Compute Engine zonal operation
+type GCEOperation struct {
+	Name          string
+	OperationType string
+	InsertTime    time.Time
+}
+
+// GCPProvider implements CloudProvider for Google Cloud Platform.
+// Node pools map one-to-one to zonal Managed Instance Groups with the same name,
+// and node names match their instance names as on GKE and standard GCE images.
 type GCPProvider struct {
-	projectID string
-	region    string
-	// GCP SDK clients would be initialized here
-	// computeClient *compute.InstanceGroupManagersClient
-	// pricingClient *billing.CloudCatalogClient
+	projectID     string
+	region        string
+	computeClient GCPComputeAPI
 }
 
-// NewGCPProvider creates a new GCP provider
+// NewGCPProvider creates a new GCP provider without a compute client.
+// Scaling calls fail until a client is supplied via NewGCPProviderWithClient.
 func NewGCPProvider(projectID, region string) *GCPProvider {
 	return &GCPProvider{
 		projectID: projectID,
@@ -23,92 +100,110 @@ func NewGCPProvider(projectID, region string) *GCPProvider {
 	}
 }
 
-// ScaleUp adds nodes to a GCP Managed Instance Group
+// NewGCPProviderWithClient creates a new GCP provider backed by the given compute client
+func NewGCPProviderWithClient(projectID, region string, computeClient GCPComputeAPI) *GCPProvider {
+	return &GCPProvider{
+		projectID:     projectID,
+		region:        region,
+		computeClient: computeClient,
+	}
+}
+
+// ScaleUp adds nodes to a GCP Managed Instance Group by raising its target size
 func (p *GCPProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
-	// In production, this would use GCP Compute Engine API:
-	// 1. Get current MIG size
-	// 2. Resize MIG with new target size
-	// 3. Wait for instances to be running
-
-	fmt.Printf("GCP: Scaling up node pool %s by %d nodes\n", nodePool.Name, count)
-
-	// Example implementation:
-	// req := &computepb.ResizeInstanceGroupManagerRequest{
-	//     Project:              p.projectID,
-	//     Zone:                 p.region + "-a", // or from nodePool config
-	//     InstanceGroupManager: nodePool.Name,
-	//     Size:                 int32(currentSize + count),
-	// }
-	// op, err := p.computeClient.Resize(ctx, req)
-	// if err != nil {
-	//     return fmt.Errorf("failed to resize MIG: %w", err)
-	// }
-	// // Wait for operation to complete
-	// return op.Wait(ctx)
+	if p.computeClient == nil {
+		return fmt.Errorf("GCP compute client not configured")
+	}
+
+	mig, err := p.findInstanceGroup(ctx, nodePool.Name, nodePool.AvailabilityZones)
+	if err != nil {
+		return err
+	}
+
+	maxSize := migMaxSize(mig)
+	if mig.TargetSize >= maxSize {
+		return NewProviderError(ProviderErrorPoolAtMaxSize, nodePool.Name,
+			fmt.Errorf("MIG %s is already at max size %d", mig.Name, maxSize))
+	}
+
+	// Ensure new size doesn't exceed max size
+	newSize := mig.TargetSize + int32(count)
+	if newSize > maxSize {
+		newSize = maxSize
+	}
+
+	if err := p.computeClient.ResizeInstanceGroupManager(ctx, mig.Zone, mig.Name, newSize); err != nil {
+		return fmt.Errorf("failed to resize MIG %s: %w", mig.Name, classifyProviderError(nodePool, err))
+	}
 
 	return nil
 }
 
-// ScaleDown removes a node from GCP
+// GetScaleUpOverrides narrows pools by zone only: each zone has its own MIG of the pool's name,
+// while the instance type is fixed by the MIG's instance template
+func (p *GCPProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{Zone: true}
+}
+
+// CheckScaleUp returns nil; resize errors are returned by ScaleUp
+func (p *GCPProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	return nil, nil
+}
+
+// ScaleDown deletes the instance backing a node from its Managed Instance Group.
+// Deleting through the MIG lowers its target size so the instance is not recreated.
 func (p *GCPProvider) ScaleDown(ctx context.Context, nodeName string) error {
-	// In production, this would:
-	// 1. Get instance name from node name
-	// 2. Delete specific instance from MIG
-	// 3. MIG will automatically adjust target size
-
-	fmt.Printf("GCP: Scaling down node %s\n", nodeName)
-
-	// Example implementation:
-	// instanceName := p.getInstanceNameFromNodeName(nodeName)
-	// req := &computepb.DeleteInstancesInstanceGroupManagerRequest{
-	//     Project:              p.projectID,
-	//     Zone:                 p.region + "-a",
-	//     InstanceGroupManager: nodePoolName,
-	//     InstanceGroupManagersDeleteInstancesRequestResource: &computepb.InstanceGroupManagersDeleteInstancesRequest{
-	//         Instances: []string{instanceName},
-	//     },
-	// }
-	// op, err := p.computeClient.DeleteInstances(ctx, req)
-	// if err != nil {
-	//     return err
-	// }
-	// return op.Wait(ctx)
+	if p.computeClient == nil {
+		return fmt.Errorf("GCP compute client not configured")
+	}
+
+	instance, err := p.getInstanceForNode(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+
+	zone, migName, err := parseCreatedBy(instance.CreatedBy)
+	if err != nil {
+		return fmt.Errorf("instance %s is not managed by a MIG: %w", instance.Name, err)
+	}
+
+	if err := p.computeClient.DeleteInstances(ctx, zone, migName, []string{instance.Name}); err != nil {
+		return fmt.Errorf("failed to delete instance %s from MIG %s: %w", instance.Name, migName, err)
+	}
 
 	return nil
 }
 
-// GetSpotTerminationNotice checks GCP metadata for preemptible termination notice
+// GetSpotTerminationNotice checks whether a preemptible or Spot VM is being preempted.
+// GCP records a compute.instances.preempted operation and stops the VM 30 seconds later.
 func (p *GCPProvider) GetSpotTerminationNotice(ctx context.Context, nodeName string) (time.Time, bool, error) {
-	// In production, this would query GCP metadata service from the node:
-	// http://metadata.google.internal/computeMetadata/v1/instance/preempted
-	//
-	// GCP gives 30-second warning before preemptible termination
+	if p.computeClient == nil {
+		return time.Time{}, false, fmt.Errorf("GCP compute client not configured")
+	}
 
-	// For now, return no termination notice
-	return time.Time{}, false, nil
+	instance, err := p.getInstanceForNode(ctx, nodeName)
+	if err != nil {
+		return time.Time{}, false, err
+	}
 
-	// Example implementation:
-	// instanceName := p.getInstanceNameFromNodeName(nodeName)
-	//
-	// req := &computepb.GetInstanceRequest{
-	//     Project:  p.projectID,
-	//     Zone:     p.region + "-a",
-	//     Instance: instanceName,
-	// }
-	// instance, err := p.computeClient.Get(ctx, req)
-	// if err != nil {
-	//     return time.Time{}, false, err
-	// }
-	//
-	// // Check if instance is preemptible and has termination scheduled
-	// if instance.Scheduling.Preemptible != nil && *instance.Scheduling.Preemptible {
-	//     // Check metadata for termination time
-	//     // GCP doesn't provide exact termination time, just 30s warning
-	//     terminationTime := time.Now().Add(30 * time.Second)
-	//     return terminationTime, true, nil
-	// }
-	//
-	// return time.Time{}, false, nil
+	if !instance.Preemptible {
+		return time.Time{}, false, nil
+	}
+
+	operation, err := p.computeClient.GetPreemptionOperation(ctx, instance.Zone, instance.Name)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("failed to get preemption operation for instance %s: %w", instance.Name, err)
+	}
+	if operation != nil {
+		return operation.InsertTime.Add(gcpPreemptionWarning), true, nil
+	}
+
+	// The operation may not be listed yet while the VM is already shutting down
+	if instance.Status == gcpInstanceStatusStopping || instance.Status == gcpInstanceStatusTerminated {
+		return time.Now(), true, nil
+	}
+
+	return time.Time{}, false, nil
 }
 
 // GetSpotPrice returns current preemptible price from GCP
@@ -180,20 +275,126 @@ func (p *GCPProvider) GetAvailabilityZones(ctx context.Context) ([]string, error
 
 // GetNodePoolInfo returns information about a GCP Managed Instance Group
 func (p *GCPProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
-	// In production, this would query MIG details
+	if p.computeClient == nil {
+		return nil, fmt.Errorf("GCP compute client not configured")
+	}
 
-	return &NodePoolInfo{
-		Name:        nodePoolName,
-		CurrentSize: 0,
-		MinSize:     0,
-		MaxSize:     100,
-	}, nil
+	mig, err := p.findInstanceGroup(ctx, nodePoolName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	info := &NodePoolInfo{
+		Name:          mig.Name,
+		CurrentSize:   int(mig.TargetSize),
+		MinSize:       int(mig.MinSize),
+		MaxSize:       int(migMaxSize(mig)),
+		InstanceType:  mig.MachineType,
+		CapacityType:  CapacityTypeOnDemand,
+		AvailableGPUs: mig.GPUCount * int(mig.TargetSize),
+		GPUsPerNode:   mig.GPUCount,
+	}
+	if mig.Preemptible {
+		info.CapacityType = CapacityTypeSpot
+	}
+
+	// Price the MIG's target size, using preemptible rates when its template is preemptible or Spot
+	if info.InstanceType != "" {
+		price, err := p.GetOnDemandPrice(ctx, info.InstanceType)
+		if info.CapacityType == CapacityTypeSpot {
+			price, err = p.GetSpotPrice(ctx, info.InstanceType)
+		}
+		if err == nil {
+			info.Cost = price * float64(info.CurrentSize)
+		}
+	}
+
+	return info, nil
+}
+
+// GetNodePoolForNode returns the Managed Instance Group that created the node's instance
+func (p *GCPProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	if p.computeClient == nil {
+		return "", fmt.Errorf("GCP compute client not configured")
+	}
+
+	instance, err := p.getInstanceForNode(ctx, nodeName)
+	if err != nil {
+		return "", err
+	}
+
+	// Instances created outside a MIG belong to no pool
+	_, migName, err := parseCreatedBy(instance.CreatedBy)
+	if err != nil {
+		return "", nil
+	}
+	return migName, nil
 }
 
 // Helper methods
 
-func (p *GCPProvider) getInstanceNameFromNodeName(nodeName string) string {
-	// Parse instance name from node name
-	// GKE node names typically include instance name
-	return "gke-cluster-default-pool-abc123"
+// findInstanceGroup looks up a MIG in the given zones, or in all of the region's zones when none are given
+func (p *GCPProvider) findInstanceGroup(ctx context.Context, name string, zones []string) (*ManagedInstanceGroup, error) {
+	if len(zones) == 0 {
+		zones, _ = p.GetAvailabilityZones(ctx)
+	}
+
+	for _, zone := range zones {
+		mig, err := p.computeClient.GetInstanceGroupManager(ctx, zone, name)
+		if errors.Is(err, ErrGCPNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get MIG %s in zone %s: %w", name, zone, err)
+		}
+		if mig.Zone == "" {
+			mig.Zone = zone
+		}
+		return mig, nil
+	}
+
+	return nil, fmt.Errorf("MIG %s not found in zones %s", name, strings.Join(zones, ", "))
+}
+
+// getInstanceForNode returns the VM instance backing a node.
+// The node name is the instance name, optionally followed by the internal DNS suffix.
+func (p *GCPProvider) getInstanceForNode(ctx context.Context, nodeName string) (*GCEInstance, error) {
+	instanceName := strings.SplitN(nodeName, ".", 2)[0]
+	zones, _ := p.GetAvailabilityZones(ctx)
+
+	for _, zone := range zones {
+		instance, err := p.computeClient.GetInstance(ctx, zone, instanceName)
+		if errors.Is(err, ErrGCPNotFound) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to get instance %s in zone %s: %w", instanceName, zone, err)
+		}
+		if instance.Zone == "" {
+			instance.Zone = zone
+		}
+		return instance, nil
+	}
+
+	return nil, fmt.Errorf("failed to find instance for node %s", nodeName)
+}
+
+// parseCreatedBy extracts the zone and MIG name from an instance's created-by metadata,
+// e.g. projects/123/zones/us-central1-a/instanceGroupManagers/gpu-pool
+func parseCreatedBy(createdBy string) (string, string, error) {
+	parts := strings.Split(createdBy, "/")
+	for i := 0; i+3 < len(parts); i++ {
+		if parts[i] == "zones" && parts[i+2] == "instanceGroupManagers" {
+			return parts[i+1], parts[i+3], nil
+		}
+	}
+	return "", "", fmt.Errorf("unrecognized created-by %q", createdBy)
+}
+
+// migMaxSize returns the MIG's autoscaler max size, or the default bound when it has none
+func migMaxSize(mig *ManagedInstanceGroup) int32 {
+	if mig.MaxSize > 0 {
+		return mig.MaxSize
+	}
+	return gcpDefaultMaxSize
 }
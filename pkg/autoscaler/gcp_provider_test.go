@@ -0,0 +1,270 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+)
+
+// fakeGCPCompute is an in-memory Managed Instance Group and Compute Engine backend
+type fakeGCPCompute struct {
+	groups      map[string]*ManagedInstanceGroup // zone/name -> MIG
+	instances   map[string]*GCEInstance          // zone/name -> instance
+	preemptions map[string]*GCEOperation         // zone/name -> preemption operation
+}
+
+func newFakeGCPCompute() *fakeGCPCompute {
+	return &fakeGCPCompute{
+		groups:      make(map[string]*ManagedInstanceGroup),
+		instances:   make(map[string]*GCEInstance),
+		preemptions: make(map[string]*GCEOperation),
+	}
+}
+
+func (f *fakeGCPCompute) addInstance(mig *ManagedInstanceGroup, instance GCEInstance) {
+	instance.Zone = mig.Zone
+	instance.CreatedBy = fmt.Sprintf("projects/123456/zones/%s/instanceGroupManagers/%s", mig.Zone, mig.Name)
+	f.instances[mig.Zone+"/"+instance.Name] = &instance
+	mig.Instances = append(mig.Instances, ManagedInstance{Name: instance.Name, Status: "RUNNING", CurrentAction: "NONE"})
+}
+
+func (f *fakeGCPCompute) GetInstanceGroupManager(ctx context.Context, zone, name string) (*ManagedInstanceGroup, error) {
+	mig, ok := f.groups[zone+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("MIG %s/%s: %w", zone, name, ErrGCPNotFound)
+	}
+	copied := *mig
+	return &copied, nil
+}
+
+func (f *fakeGCPCompute) ResizeInstanceGroupManager(ctx context.Context, zone, name string, size int32) error {
+	mig, ok := f.groups[zone+"/"+name]
+	if !ok {
+		return fmt.Errorf("MIG %s/%s: %w", zone, name, ErrGCPNotFound)
+	}
+	mig.TargetSize = size
+	return nil
+}
+
+func (f *fakeGCPCompute) DeleteInstances(ctx context.Context, zone, name string, instances []string) error {
+	mig, ok := f.groups[zone+"/"+name]
+	if !ok {
+		return fmt.Errorf("MIG %s/%s: %w", zone, name, ErrGCPNotFound)
+	}
+	for _, instanceName := range instances {
+		found := false
+		for i, instance := range mig.Instances {
+			if instance.Name == instanceName {
+				mig.Instances = append(mig.Instances[:i], mig.Instances[i+1:]...)
+				mig.TargetSize--
+				delete(f.instances, zone+"/"+instanceName)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("instance %s is not a member of MIG %s", instanceName, name)
+		}
+	}
+	return nil
+}
+
+func (f *fakeGCPCompute) GetInstance(ctx context.Context, zone, name string) (*GCEInstance, error) {
+	instance, ok := f.instances[zone+"/"+name]
+	if !ok {
+		return nil, fmt.Errorf("instance %s/%s: %w", zone, name, ErrGCPNotFound)
+	}
+	copied := *instance
+	return &copied, nil
+}
+
+func (f *fakeGCPCompute) GetPreemptionOperation(ctx context.Context, zone, instance string) (*GCEOperation, error) {
+	return f.preemptions[zone+"/"+instance], nil
+}
+
+func TestGCPProviderScaleUp(t *testing.T) {
+	tests := []struct {
+		name            string
+		targetSize      int32
+		count           int
+		zones           []string
+		expectedSize    int32
+		expectError     bool
+		expectAtMaxSize bool
+	}{
+		{
+			name:         "Scale up within max size",
+			targetSize:   2,
+			count:        1,
+			expectedSize: 3,
+		},
+		{
+			name:         "Scale up is capped at max size",
+			targetSize:   2,
+			count:        5,
+			expectedSize: 4,
+		},
+		{
+			name:         "Scale up in the node pool's zone",
+			targetSize:   2,
+			count:        1,
+			zones:        []string{"us-central1-b"},
+			expectedSize: 3,
+		},
+		{
+			name:         "MIG missing from the node pool's zones",
+			targetSize:   2,
+			count:        1,
+			zones:        []string{"us-central1-f"},
+			expectedSize: 2,
+			expectError:  true,
+		},
+		{
+			name:            "Scale up at max size",
+			targetSize:      4,
+			count:           1,
+			expectedSize:    4,
+			expectError:     true,
+			expectAtMaxSize: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeGCPCompute()
+			mig := &ManagedInstanceGroup{Name: "gpu-spot", Zone: "us-central1-b", TargetSize: tt.targetSize, MaxSize: 4, MachineType: "a2-highgpu-1g", Preemptible: true, GPUCount: 1}
+			fake.groups["us-central1-b/gpu-spot"] = mig
+			fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-1a2b", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+			fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-3c4d", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+			provider := NewGCPProviderWithClient("ml-project", "us-central1", fake)
+
+			err := provider.ScaleUp(context.Background(), &NodePoolConfig{Name: "gpu-spot", AvailabilityZones: tt.zones}, tt.count)
+			if (err != nil) != tt.expectError {
+				t.Fatalf("ScaleUp() error = %v, expectError %v", err, tt.expectError)
+			}
+			if tt.expectAtMaxSize && !IsPoolAtMaxSize(err) {
+				t.Errorf("Expected a pool-at-max-size error, got %v", err)
+			}
+			if got := fake.groups["us-central1-b/gpu-spot"].TargetSize; got != tt.expectedSize {
+				t.Errorf("Expected target size %d, got %d", tt.expectedSize, got)
+			}
+		})
+	}
+}
+
+func TestGCPProviderScaleDown(t *testing.T) {
+	tests := []struct {
+		name     string
+		nodeName string
+	}{
+		{
+			name:     "Node named by instance",
+			nodeName: "gke-ml-gpu-spot-1a2b",
+		},
+		{
+			name:     "Node named by internal DNS",
+			nodeName: "gke-ml-gpu-spot-1a2b.us-central1-b.c.ml-project.internal",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := newFakeGCPCompute()
+			mig := &ManagedInstanceGroup{Name: "gpu-spot", Zone: "us-central1-b", TargetSize: 2, MaxSize: 4, MachineType: "a2-highgpu-1g", Preemptible: true, GPUCount: 1}
+			fake.groups["us-central1-b/gpu-spot"] = mig
+			fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-1a2b", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+			fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-3c4d", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+			provider := NewGCPProviderWithClient("ml-project", "us-central1", fake)
+
+			if err := provider.ScaleDown(context.Background(), tt.nodeName); err != nil {
+				t.Fatalf("ScaleDown() error = %v", err)
+			}
+
+			if mig.TargetSize != 1 {
+				t.Errorf("Expected target size 1, got %d", mig.TargetSize)
+			}
+			if len(mig.Instances) != 1 || mig.Instances[0].Name != "gke-ml-gpu-spot-3c4d" {
+				t.Errorf("Expected only gke-ml-gpu-spot-3c4d to remain, got %+v", mig.Instances)
+			}
+		})
+	}
+}
+
+func TestGCPProviderGetNodePoolInfo(t *testing.T) {
+	fake := newFakeGCPCompute()
+	mig := &ManagedInstanceGroup{Name: "gpu-spot", Zone: "us-central1-b", TargetSize: 2, MaxSize: 4, MachineType: "a2-highgpu-1g", Preemptible: true, GPUCount: 1}
+	fake.groups["us-central1-b/gpu-spot"] = mig
+	fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-1a2b", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+	fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-3c4d", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+	provider := NewGCPProviderWithClient("ml-project", "us-central1", fake)
+
+	info, err := provider.GetNodePoolInfo(context.Background(), "gpu-spot")
+	if err != nil {
+		t.Fatalf("GetNodePoolInfo() error = %v", err)
+	}
+
+	if info.CurrentSize != 2 || info.MaxSize != 4 || info.AvailableGPUs != 2 {
+		t.Errorf("Unexpected sizes: current=%d max=%d gpus=%d", info.CurrentSize, info.MaxSize, info.AvailableGPUs)
+	}
+	if info.InstanceType != "a2-highgpu-1g" || info.CapacityType != CapacityTypeSpot {
+		t.Errorf("Unexpected pool info: %+v", info)
+	}
+	if info.Cost != 3.60 {
+		t.Errorf("Expected hourly cost 3.60, got %.2f", info.Cost)
+	}
+
+	if _, err := provider.GetNodePoolInfo(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for unknown MIG")
+	}
+}
+
+func TestGCPProviderGetSpotTerminationNotice(t *testing.T) {
+	fake := newFakeGCPCompute()
+	mig := &ManagedInstanceGroup{Name: "gpu-spot", Zone: "us-central1-b", TargetSize: 2, MaxSize: 4, MachineType: "a2-highgpu-1g", Preemptible: true, GPUCount: 1}
+	fake.groups["us-central1-b/gpu-spot"] = mig
+	fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-1a2b", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+	fake.addInstance(mig, GCEInstance{Name: "gke-ml-gpu-spot-3c4d", MachineType: "a2-highgpu-1g", Status: "RUNNING", Preemptible: true})
+	provider := NewGCPProviderWithClient("ml-project", "us-central1", fake)
+	preemptedAt := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	fake.preemptions["us-central1-b/gke-ml-gpu-spot-1a2b"] = &GCEOperation{
+		Name:          "operation-1",
+		OperationType: "compute.instances.preempted",
+		InsertTime:    preemptedAt,
+	}
+
+	terminationTime, hasNotice, err := provider.GetSpotTerminationNotice(context.Background(), "gke-ml-gpu-spot-1a2b")
+	if err != nil {
+		t.Fatalf("GetSpotTerminationNotice() error = %v", err)
+	}
+	if !hasNotice {
+		t.Fatal("Expected a termination notice")
+	}
+	if !terminationTime.Equal(preemptedAt.Add(30 * time.Second)) {
+		t.Errorf("Expected termination at %s, got %s", preemptedAt.Add(30*time.Second), terminationTime)
+	}
+
+	_, hasNotice, err = provider.GetSpotTerminationNotice(context.Background(), "gke-ml-gpu-spot-3c4d")
+	if err != nil {
+		t.Fatalf("GetSpotTerminationNotice() error = %v", err)
+	}
+	if hasNotice {
+		t.Error("Expected no termination notice for a running instance")
+	}
+
+	fake.instances["us-central1-b/gke-ml-gpu-spot-3c4d"].Status = gcpInstanceStatusStopping
+	if _, hasNotice, _ = provider.GetSpotTerminationNotice(context.Background(), "gke-ml-gpu-spot-3c4d"); !hasNotice {
+		t.Error("Expected a termination notice for a stopping preemptible instance")
+	}
+}
+
+func TestParseCreatedBy(t *testing.T) {
+	zone, name, err := parseCreatedBy("projects/123456/zones/us-central1-a/instanceGroupManagers/gpu-pool")
+	if err != nil || zone != "us-central1-a" || name != "gpu-pool" {
+		t.Errorf("Expected us-central1-a/gpu-pool, got %s/%s (%v)", zone, name, err)
+	}
+
+	if _, _, err := parseCreatedBy("projects/123456/regions/us-central1"); err == nil {
+		t.Error("Expected error for created-by without a MIG")
+	}
+}
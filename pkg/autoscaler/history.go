@@ -0,0 +1,190 @@
+package autoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Defaults for the ConfigMap holding the scaling history
+	DefaultHistoryNamespace     = "gpu-autoscaler-system"
+	DefaultHistoryConfigMapName = "gpu-autoscaler-scaling-history"
+	DefaultHistoryCapacity      = 1000
+
+	// HistoryEventsKey is the ConfigMap data key holding the JSON-encoded events
+	HistoryEventsKey = "events.json"
+)
+
+// HistoryStore persists scaling events so they survive controller restarts
+type HistoryStore interface {
+	// Record appends an event to the history
+	Record(ctx context.Context, event ScalingEvent) error
+
+	// List returns the events matching the filter, oldest first
+	List(ctx context.Context, filter HistoryFilter) ([]ScalingEvent, error)
+}
+
+// HistoryFilter selects scaling events; zero-valued fields match every event
+type HistoryFilter struct {
+	Policy   string
+	NodePool string
+	Action   ScalingAction
+	Since    time.Time
+	Until    time.Time
+}
+
+// Matches reports whether the event passes the filter
+func (f HistoryFilter) Matches(event ScalingEvent) bool {
+	if f.Policy != "" && event.Policy != f.Policy {
+		return false
+	}
+	if f.NodePool != "" && event.NodePool != f.NodePool {
+		return false
+	}
+	if f.Action != "" && event.Action != f.Action {
+		return false
+	}
+	if !f.Since.IsZero() && event.Timestamp.Before(f.Since) {
+		return false
+	}
+	if !f.Until.IsZero() && event.Timestamp.After(f.Until) {
+		return false
+	}
+	return true
+}
+
+// ConfigMapHistoryStore keeps the most recent scaling events as a bounded ring in a ConfigMap
+type ConfigMapHistoryStore struct {
+	client    client.Client
+	Namespace string
+	Name      string
+
+	// Capacity is the number of events kept; older events are dropped
+	Capacity int
+}
+
+// NewConfigMapHistoryStore creates a history store backed by a ConfigMap in the namespace
+func NewConfigMapHistoryStore(client client.Client, namespace string) *ConfigMapHistoryStore {
+	if namespace == "" {
+		namespace = DefaultHistoryNamespace
+	}
+	return &ConfigMapHistoryStore{
+		client:    client,
+		Namespace: namespace,
+		Name:      DefaultHistoryConfigMapName,
+		Capacity:  DefaultHistoryCapacity,
+	}
+}
+
+// Record appends the event to the ConfigMap, creating it on first use
+func (s *ConfigMapHistoryStore) Record(ctx context.Context, event ScalingEvent) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		configMap := &corev1.ConfigMap{}
+		err := s.client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, configMap)
+		if errors.IsNotFound(err) {
+			configMap = &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Namespace: s.Namespace,
+					Name:      s.Name,
+					Labels:    map[string]string{"app.kubernetes.io/name": "gpu-autoscaler"},
+				},
+			}
+			if err := s.encode(configMap, []ScalingEvent{event}); err != nil {
+				return err
+			}
+			if err := s.client.Create(ctx, configMap); err != nil {
+				if errors.IsAlreadyExists(err) {
+					// Lost the race to create it; retry as an update
+					return errors.NewConflict(corev1.Resource("configmaps"), s.Name, err)
+				}
+				return fmt.Errorf("failed to create scaling history: %w", err)
+			}
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get scaling history: %w", err)
+		}
+
+		events, err := s.decode(configMap)
+		if err != nil {
+			return err
+		}
+		events = append(events, event)
+		if err := s.encode(configMap, events); err != nil {
+			return err
+		}
+		return s.client.Update(ctx, configMap)
+	})
+}
+
+// List returns the events in the ConfigMap matching the filter, oldest first
+func (s *ConfigMapHistoryStore) List(ctx context.Context, filter HistoryFilter) ([]ScalingEvent, error) {
+	configMap := &corev1.ConfigMap{}
+	if err := s.client.Get(ctx, client.ObjectKey{Namespace: s.Namespace, Name: s.Name}, configMap); err != nil {
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to get scaling history: %w", err)
+	}
+
+	events, err := s.decode(configMap)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := make([]ScalingEvent, 0, len(events))
+	for _, event := range events {
+		if filter.Matches(event) {
+			matching = append(matching, event)
+		}
+	}
+	sort.SliceStable(matching, func(i, j int) bool {
+		return matching[i].Timestamp.Before(matching[j].Timestamp)
+	})
+
+	return matching, nil
+}
+
+// decode reads the events stored in the ConfigMap
+func (s *ConfigMapHistoryStore) decode(configMap *corev1.ConfigMap) ([]ScalingEvent, error) {
+	data := configMap.Data[HistoryEventsKey]
+	if data == "" {
+		return nil, nil
+	}
+
+	var events []ScalingEvent
+	if err := json.Unmarshal([]byte(data), &events); err != nil {
+		return nil, fmt.Errorf("failed to parse scaling history: %w", err)
+	}
+	return events, nil
+}
+
+// encode writes the newest Capacity events into the ConfigMap
+func (s *ConfigMapHistoryStore) encode(configMap *corev1.ConfigMap, events []ScalingEvent) error {
+	capacity := s.Capacity
+	if capacity <= 0 {
+		capacity = DefaultHistoryCapacity
+	}
+	if len(events) > capacity {
+		events = events[len(events)-capacity:]
+	}
+
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("failed to encode scaling history: %w", err)
+	}
+	if configMap.Data == nil {
+		configMap.Data = make(map[string]string)
+	}
+	configMap.Data[HistoryEventsKey] = string(data)
+	return nil
+}
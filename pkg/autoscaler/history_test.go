@@ -0,0 +1,70 @@
+package autoscaler
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+func TestConfigMapHistoryStore(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add core scheme: %v", err)
+	}
+	store := NewConfigMapHistoryStore(fake.NewClientBuilder().WithScheme(scheme).Build(), "")
+	store.Capacity = 3
+
+	if events, err := store.List(context.Background(), HistoryFilter{}); err != nil || len(events) != 0 {
+		t.Fatalf("Expected an empty history before the first event, got %d events and error %v", len(events), err)
+	}
+
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	recorded := []ScalingEvent{
+		{Policy: "training", NodePool: "gpu-spot", Action: ScaleUp, Timestamp: start, Success: true},
+		{Policy: "training", NodePool: "gpu-on-demand", Action: ScaleUp, Timestamp: start.Add(time.Hour), Error: "insufficient capacity"},
+		{Policy: "inference", NodePool: "gpu-spot", Action: ScaleDown, Timestamp: start.Add(2 * time.Hour), Success: true},
+		{Policy: "training", NodePool: "gpu-spot", Action: ScaleDown, Timestamp: start.Add(3 * time.Hour), Success: true},
+	}
+	for _, event := range recorded {
+		if err := store.Record(context.Background(), event); err != nil {
+			t.Fatalf("Record() error = %v", err)
+		}
+	}
+
+	tests := []struct {
+		name     string
+		filter   HistoryFilter
+		expected []time.Duration // offsets from start of the matching events
+	}{
+		{name: "oldest event dropped from the ring", filter: HistoryFilter{}, expected: []time.Duration{time.Hour, 2 * time.Hour, 3 * time.Hour}},
+		{name: "by policy", filter: HistoryFilter{Policy: "training"}, expected: []time.Duration{time.Hour, 3 * time.Hour}},
+		{name: "by pool and action", filter: HistoryFilter{NodePool: "gpu-spot", Action: ScaleDown}, expected: []time.Duration{2 * time.Hour, 3 * time.Hour}},
+		{name: "by time range", filter: HistoryFilter{Since: start.Add(90 * time.Minute), Until: start.Add(2 * time.Hour)}, expected: []time.Duration{2 * time.Hour}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := store.List(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(events) != len(tt.expected) {
+				t.Fatalf("Expected %d events, got %d", len(tt.expected), len(events))
+			}
+			for i, event := range events {
+				if !event.Timestamp.Equal(start.Add(tt.expected[i])) {
+					t.Errorf("Expected event %d at %s, got %s", i, start.Add(tt.expected[i]), event.Timestamp)
+				}
+			}
+		})
+	}
+
+	events, _ := store.List(context.Background(), HistoryFilter{NodePool: "gpu-on-demand"})
+	if len(events) != 1 || events[0].Error != "insufficient capacity" || events[0].Success {
+		t.Errorf("Expected the failed scale-up to keep its provider error, got %+v", events)
+	}
+}
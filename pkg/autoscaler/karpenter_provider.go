@@ -0,0 +1,425 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+const (
+	// Karpenter API group and the labels it sets on NodeClaims and Nodes
+	KarpenterGroup             = "karpenter.sh"
+	KarpenterNodePoolLabel     = "karpenter.sh/nodepool"
+	KarpenterCapacityTypeLabel = "karpenter.sh/capacity-type"
+
+	// DefaultKarpenterAPIVersion is the Karpenter API version NodePools and NodeClaims are managed with
+	DefaultKarpenterAPIVersion = "v1"
+
+	// Karpenter receives AWS spot interruption warnings two minutes ahead
+	karpenterSpotInterruptionWarning = 2 * time.Minute
+)
+
+// KarpenterProviderConfig configures the KarpenterProvider
+type KarpenterProviderConfig struct {
+	// APIVersion is the karpenter.sh API version, v1 by default
+	APIVersion string
+
+	// NodePools maps autoscaler node pool names to Karpenter NodePool names.
+	// Pools that are not listed use the Karpenter NodePool with the same name.
+	NodePools map[string]string
+
+	// Pricing supplies instance prices; Karpenter clusters run on AWS by default
+	Pricing CloudProvider
+}
+
+// KarpenterProvider implements CloudProvider by creating and deleting Karpenter NodeClaims.
+// Each NodeClaim is built from the Karpenter NodePool's template, narrowed by the
+// NodePoolConfig's instance types, capacity type and zones, and extended with its labels and taints.
+// Karpenter then launches, registers and eventually terminates the instance.
+type KarpenterProvider struct {
+	client client.Client
+	config KarpenterProviderConfig
+}
+
+// NewKarpenterProvider creates a new Karpenter provider
+func NewKarpenterProvider(client client.Client, config KarpenterProviderConfig) *KarpenterProvider {
+	if config.APIVersion == "" {
+		config.APIVersion = DefaultKarpenterAPIVersion
+	}
+	if config.Pricing == nil {
+		config.Pricing = &AWSProvider{}
+	}
+
+	return &KarpenterProvider{
+		client: client,
+		config: config,
+	}
+}
+
+// ScaleUp creates NodeClaims for the node pool's Karpenter NodePool
+func (p *KarpenterProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
+	karpenterPool, err := p.getNodePool(ctx, p.karpenterNodePoolName(nodePool.Name))
+	if err != nil {
+		return err
+	}
+
+	nodeClaims, err := p.listNodeClaims(ctx, karpenterPool.GetName())
+	if err != nil {
+		return err
+	}
+
+	maxSize := nodePool.MaxSize
+	if maxSize <= 0 {
+		maxSize = DefaultMaxNodes
+	}
+	currentSize := len(nodeClaims)
+	if currentSize >= maxSize {
+		return NewProviderError(ProviderErrorPoolAtMaxSize, nodePool.Name,
+			fmt.Errorf("Karpenter NodePool %s is already at max size %d", karpenterPool.GetName(), maxSize))
+	}
+	if currentSize+count > maxSize {
+		count = maxSize - currentSize
+	}
+
+	for i := 0; i < count; i++ {
+		nodeClaim, err := p.buildNodeClaim(karpenterPool, nodePool)
+		if err != nil {
+			return err
+		}
+		if err := p.client.Create(ctx, nodeClaim); err != nil {
+			return fmt.Errorf("failed to create NodeClaim for NodePool %s: %w", karpenterPool.GetName(), err)
+		}
+	}
+
+	return nil
+}
+
+// GetScaleUpOverrides narrows both: ScaleUp writes the pool's instance types and zones into the
+// requirements of the NodeClaims it creates, and leaves the Karpenter NodePool unchanged
+func (p *KarpenterProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{InstanceType: true, Zone: true}
+}
+
+// CheckScaleUp returns nil; Karpenter retries launches across its allowed capacity itself
+func (p *KarpenterProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	return nil, nil
+}
+
+// ScaleDown deletes the NodeClaim backing a node. Karpenter drains the node and terminates the instance.
+func (p *KarpenterProvider) ScaleDown(ctx context.Context, nodeName string) error {
+	nodeClaim, err := p.getNodeClaimForNode(ctx, nodeName)
+	if err != nil {
+		return err
+	}
+
+	if err := p.client.Delete(ctx, nodeClaim); err != nil && !errors.IsNotFound(err) {
+		return fmt.Errorf("failed to delete NodeClaim %s: %w", nodeClaim.GetName(), err)
+	}
+
+	return nil
+}
+
+// GetSpotTerminationNotice reports spot nodes whose NodeClaim Karpenter is deleting.
+// Karpenter handles interruption warnings itself by deleting the NodeClaim, so a deleting spot
+// NodeClaim is treated as interrupted two minutes after deletion started.
+func (p *KarpenterProvider) GetSpotTerminationNotice(ctx context.Context, nodeName string) (time.Time, bool, error) {
+	nodeClaim, err := p.getNodeClaimForNode(ctx, nodeName)
+	if err != nil {
+		return time.Time{}, false, err
+	}
+
+	deletionTimestamp := nodeClaim.GetDeletionTimestamp()
+	if deletionTimestamp == nil || nodeClaim.GetLabels()[KarpenterCapacityTypeLabel] != CapacityTypeSpot {
+		return time.Time{}, false, nil
+	}
+
+	return deletionTimestamp.Add(karpenterSpotInterruptionWarning), true, nil
+}
+
+// GetSpotPrice looks up the instance type in the Pricing backend, since Karpenter publishes no prices of its own
+func (p *KarpenterProvider) GetSpotPrice(ctx context.Context, instanceType string) (float64, error) {
+	return p.config.Pricing.GetSpotPrice(ctx, instanceType)
+}
+
+// GetOnDemandPrice looks up the instance type's on-demand rate in the Pricing backend
+func (p *KarpenterProvider) GetOnDemandPrice(ctx context.Context, instanceType string) (float64, error) {
+	return p.config.Pricing.GetOnDemandPrice(ctx, instanceType)
+}
+
+// GetRecommendedSpotInstanceTypes defers to the Pricing backend; Karpenter still picks among the NodePool's allowed types
+func (p *KarpenterProvider) GetRecommendedSpotInstanceTypes(ctx context.Context) ([]string, error) {
+	return p.config.Pricing.GetRecommendedSpotInstanceTypes(ctx)
+}
+
+// GetAvailabilityZones returns the zones Karpenter NodePools are allowed to launch into
+func (p *KarpenterProvider) GetAvailabilityZones(ctx context.Context) ([]string, error) {
+	nodePoolList := &unstructured.UnstructuredList{}
+	nodePoolList.SetGroupVersionKind(p.gvk("NodePoolList"))
+	if err := p.client.List(ctx, nodePoolList); err != nil {
+		return nil, fmt.Errorf("failed to list Karpenter NodePools: %w", err)
+	}
+
+	seen := make(map[string]bool)
+	var zones []string
+	for i := range nodePoolList.Items {
+		requirements, _, _ := unstructured.NestedSlice(nodePoolList.Items[i].Object, "spec", "template", "spec", "requirements")
+		for _, zone := range requirementValues(requirements, corev1.LabelTopologyZone) {
+			if !seen[zone] {
+				seen[zone] = true
+				zones = append(zones, zone)
+			}
+		}
+	}
+	sort.Strings(zones)
+
+	return zones, nil
+}
+
+// GetNodePoolInfo returns information about a Karpenter NodePool from its NodeClaims
+func (p *KarpenterProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
+	karpenterPool, err := p.getNodePool(ctx, p.karpenterNodePoolName(nodePoolName))
+	if err != nil {
+		return nil, err
+	}
+
+	nodeClaims, err := p.listNodeClaims(ctx, karpenterPool.GetName())
+	if err != nil {
+		return nil, err
+	}
+
+	info := &NodePoolInfo{
+		Name:        nodePoolName,
+		CurrentSize: len(nodeClaims),
+		MaxSize:     DefaultMaxNodes,
+	}
+
+	for i := range nodeClaims {
+		labels := nodeClaims[i].GetLabels()
+		if instanceType := labels[corev1.LabelInstanceTypeStable]; instanceType != "" {
+			info.InstanceType = instanceType
+		}
+		if capacityType := labels[KarpenterCapacityTypeLabel]; capacityType != "" {
+			info.CapacityType = capacityType
+		}
+		if gpus, found, _ := unstructured.NestedString(nodeClaims[i].Object, "status", "allocatable", "nvidia.com/gpu"); found {
+			if quantity, err := resource.ParseQuantity(gpus); err == nil {
+				info.AvailableGPUs += int(quantity.Value())
+				info.GPUsPerNode = int(quantity.Value())
+			}
+		}
+	}
+
+	// Karpenter resolves the instance and capacity type per NodeClaim; the pool is priced at the last claim's
+	if info.InstanceType != "" {
+		price, err := p.GetOnDemandPrice(ctx, info.InstanceType)
+		if info.CapacityType == CapacityTypeSpot {
+			price, err = p.GetSpotPrice(ctx, info.InstanceType)
+		}
+		if err == nil {
+			info.Cost = price * float64(info.CurrentSize)
+		}
+	}
+
+	return info, nil
+}
+
+// GetNodePoolForNode returns the autoscaler pool of the node's NodeClaim
+func (p *KarpenterProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	nodeClaim, err := p.findNodeClaimForNode(ctx, nodeName)
+	if err != nil || nodeClaim == nil {
+		return "", err
+	}
+
+	labels := nodeClaim.GetLabels()
+	if name := labels[NodePoolLabel]; name != "" {
+		return name, nil
+	}
+	return p.autoscalerNodePoolName(labels[KarpenterNodePoolLabel]), nil
+}
+
+// Helper methods
+
+func (p *KarpenterProvider) gvk(kind string) schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: KarpenterGroup, Version: p.config.APIVersion, Kind: kind}
+}
+
+func (p *KarpenterProvider) karpenterNodePoolName(nodePoolName string) string {
+	if name, ok := p.config.NodePools[nodePoolName]; ok {
+		return name
+	}
+	return nodePoolName
+}
+
+// autoscalerNodePoolName returns the autoscaler pool backed by a Karpenter NodePool
+func (p *KarpenterProvider) autoscalerNodePoolName(karpenterNodePoolName string) string {
+	for name, karpenterName := range p.config.NodePools {
+		if karpenterName == karpenterNodePoolName {
+			return name
+		}
+	}
+	return karpenterNodePoolName
+}
+
+func (p *KarpenterProvider) getNodePool(ctx context.Context, name string) (*unstructured.Unstructured, error) {
+	nodePool := &unstructured.Unstructured{}
+	nodePool.SetGroupVersionKind(p.gvk("NodePool"))
+	if err := p.client.Get(ctx, client.ObjectKey{Name: name}, nodePool); err != nil {
+		return nil, fmt.Errorf("failed to get Karpenter NodePool %s: %w", name, err)
+	}
+	return nodePool, nil
+}
+
+// listNodeClaims returns the NodePool's NodeClaims that are not being deleted
+func (p *KarpenterProvider) listNodeClaims(ctx context.Context, nodePoolName string) ([]unstructured.Unstructured, error) {
+	nodeClaimList := &unstructured.UnstructuredList{}
+	nodeClaimList.SetGroupVersionKind(p.gvk("NodeClaimList"))
+	if err := p.client.List(ctx, nodeClaimList, client.MatchingLabels{KarpenterNodePoolLabel: nodePoolName}); err != nil {
+		return nil, fmt.Errorf("failed to list NodeClaims for NodePool %s: %w", nodePoolName, err)
+	}
+
+	nodeClaims := make([]unstructured.Unstructured, 0, len(nodeClaimList.Items))
+	for _, nodeClaim := range nodeClaimList.Items {
+		if nodeClaim.GetDeletionTimestamp() == nil {
+			nodeClaims = append(nodeClaims, nodeClaim)
+		}
+	}
+	return nodeClaims, nil
+}
+
+// getNodeClaimForNode returns the NodeClaim whose status.nodeName is the node
+func (p *KarpenterProvider) getNodeClaimForNode(ctx context.Context, nodeName string) (*unstructured.Unstructured, error) {
+	nodeClaim, err := p.findNodeClaimForNode(ctx, nodeName)
+	if err != nil {
+		return nil, err
+	}
+	if nodeClaim == nil {
+		return nil, fmt.Errorf("no NodeClaim found for node %s", nodeName)
+	}
+	return nodeClaim, nil
+}
+
+// findNodeClaimForNode returns the NodeClaim whose status.nodeName is the node, or nil if there is none
+func (p *KarpenterProvider) findNodeClaimForNode(ctx context.Context, nodeName string) (*unstructured.Unstructured, error) {
+	nodeClaimList := &unstructured.UnstructuredList{}
+	nodeClaimList.SetGroupVersionKind(p.gvk("NodeClaimList"))
+	if err := p.client.List(ctx, nodeClaimList); err != nil {
+		return nil, fmt.Errorf("failed to list NodeClaims: %w", err)
+	}
+
+	for i := range nodeClaimList.Items {
+		if name, _, _ := unstructured.NestedString(nodeClaimList.Items[i].Object, "status", "nodeName"); name == nodeName {
+			return &nodeClaimList.Items[i], nil
+		}
+	}
+	return nil, nil
+}
+
+// buildNodeClaim creates a NodeClaim from the Karpenter NodePool's template and the autoscaler's pool config
+func (p *KarpenterProvider) buildNodeClaim(karpenterPool *unstructured.Unstructured, nodePool *NodePoolConfig) (*unstructured.Unstructured, error) {
+	spec, _, err := unstructured.NestedMap(karpenterPool.Object, "spec", "template", "spec")
+	if err != nil {
+		return nil, fmt.Errorf("invalid template in Karpenter NodePool %s: %w", karpenterPool.GetName(), err)
+	}
+	if spec == nil {
+		spec = map[string]interface{}{}
+	}
+
+	// Narrow the template's requirements to the pool's instance types, capacity type and zones
+	requirements, _, _ := unstructured.NestedSlice(spec, "requirements")
+	if len(nodePool.InstanceTypes) > 0 {
+		requirements = setRequirement(requirements, corev1.LabelInstanceTypeStable, nodePool.InstanceTypes)
+	}
+	if nodePool.CapacityType != "" {
+		requirements = setRequirement(requirements, KarpenterCapacityTypeLabel, []string{nodePool.CapacityType})
+	}
+	if len(nodePool.AvailabilityZones) > 0 {
+		requirements = setRequirement(requirements, corev1.LabelTopologyZone, nodePool.AvailabilityZones)
+	}
+	spec["requirements"] = requirements
+
+	if len(nodePool.Taints) > 0 {
+		taints, _, _ := unstructured.NestedSlice(spec, "taints")
+		for _, taint := range nodePool.Taints {
+			taintMap, err := runtime.DefaultUnstructuredConverter.ToUnstructured(&taint)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert taint %s: %w", taint.Key, err)
+			}
+			taints = append(taints, taintMap)
+		}
+		spec["taints"] = taints
+	}
+
+	labels, _, _ := unstructured.NestedStringMap(karpenterPool.Object, "spec", "template", "metadata", "labels")
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	for k, v := range nodePool.Labels {
+		labels[k] = v
+	}
+	labels[KarpenterNodePoolLabel] = karpenterPool.GetName()
+	labels[NodePoolLabel] = nodePool.Name
+	if nodePool.GPUType != "" {
+		labels[GPUTypeLabel] = nodePool.GPUType
+	}
+
+	blockOwnerDeletion := true
+	nodeClaim := &unstructured.Unstructured{Object: map[string]interface{}{"spec": spec}}
+	nodeClaim.SetGroupVersionKind(p.gvk("NodeClaim"))
+	nodeClaim.SetGenerateName(karpenterPool.GetName() + "-")
+	nodeClaim.SetLabels(labels)
+	nodeClaim.SetOwnerReferences([]metav1.OwnerReference{{
+		APIVersion:         karpenterPool.GetAPIVersion(),
+		Kind:               karpenterPool.GetKind(),
+		Name:               karpenterPool.GetName(),
+		UID:                karpenterPool.GetUID(),
+		BlockOwnerDeletion: &blockOwnerDeletion,
+	}})
+
+	return nodeClaim, nil
+}
+
+// setRequirement replaces the requirement on key with an In requirement on values
+func setRequirement(requirements []interface{}, key string, values []string) []interface{} {
+	inValues := make([]interface{}, len(values))
+	for i, value := range values {
+		inValues[i] = value
+	}
+
+	updated := make([]interface{}, 0, len(requirements)+1)
+	for _, requirement := range requirements {
+		if requirementMap, ok := requirement.(map[string]interface{}); ok && requirementMap["key"] == key {
+			continue
+		}
+		updated = append(updated, requirement)
+	}
+
+	return append(updated, map[string]interface{}{
+		"key":      key,
+		"operator": string(corev1.NodeSelectorOpIn),
+		"values":   inValues,
+	})
+}
+
+// requirementValues returns the values of In requirements on key
+func requirementValues(requirements []interface{}, key string) []string {
+	var values []string
+	for _, requirement := range requirements {
+		requirementMap, ok := requirement.(map[string]interface{})
+		if !ok || requirementMap["key"] != key || requirementMap["operator"] != string(corev1.NodeSelectorOpIn) {
+			continue
+		}
+		items, _, _ := unstructured.NestedStringSlice(requirementMap, "values")
+		values = append(values, items...)
+	}
+	return values
+}
@@ -0,0 +1,360 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+func karpenterTestGVK(kind string) schema.GroupVersionKind {
+	return NewKarpenterProvider(nil, KarpenterProviderConfig{}).gvk(kind)
+}
+
+func newKarpenterNodePool(name string) *unstructured.Unstructured {
+	nodePool := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"template": map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"team": "ml"},
+				},
+				"spec": map[string]interface{}{
+					"nodeClassRef": map[string]interface{}{
+						"group": "karpenter.k8s.aws",
+						"kind":  "EC2NodeClass",
+						"name":  "gpu",
+					},
+					"requirements": []interface{}{
+						map[string]interface{}{"key": corev1.LabelInstanceTypeStable, "operator": "In", "values": []interface{}{"p3.2xlarge", "p3.8xlarge"}},
+						map[string]interface{}{"key": corev1.LabelTopologyZone, "operator": "In", "values": []interface{}{"us-west-2b", "us-west-2a"}},
+					},
+				},
+			},
+		},
+	}}
+	nodePool.SetGroupVersionKind(karpenterTestGVK("NodePool"))
+	nodePool.SetName(name)
+	return nodePool
+}
+
+// newKarpenterNodeClaim builds a NodeClaim Karpenter has launched and registered as nodeName
+func newKarpenterNodeClaim(name, nodePool, nodeName, capacityType string) *unstructured.Unstructured {
+	nodeClaim := &unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{
+			"nodeClassRef": map[string]interface{}{
+				"group": "karpenter.k8s.aws",
+				"kind":  "EC2NodeClass",
+				"name":  "gpu",
+			},
+			"requirements": []interface{}{
+				map[string]interface{}{"key": corev1.LabelInstanceTypeStable, "operator": "In", "values": []interface{}{"p3.8xlarge"}},
+			},
+		},
+		"status": map[string]interface{}{
+			"nodeName":    nodeName,
+			"allocatable": map[string]interface{}{"nvidia.com/gpu": "4"},
+		},
+	}}
+	nodeClaim.SetGroupVersionKind(karpenterTestGVK("NodeClaim"))
+	nodeClaim.SetName(name)
+	nodeClaim.SetLabels(map[string]string{
+		KarpenterNodePoolLabel:         nodePool,
+		KarpenterCapacityTypeLabel:     capacityType,
+		corev1.LabelInstanceTypeStable: "p3.8xlarge",
+	})
+	return nodeClaim
+}
+
+// newKarpenterEnvtestClient returns the envtest client, removing the test's NodePools and NodeClaims when it ends
+func newKarpenterEnvtestClient(t *testing.T) client.Client {
+	t.Helper()
+	k8sClient := newEnvtestClient(t)
+	deleteAllOnCleanup(t, k8sClient, karpenterTestGVK("NodePoolList"), "")
+	deleteAllOnCleanup(t, k8sClient, karpenterTestGVK("NodeClaimList"), "")
+	return k8sClient
+}
+
+func listKarpenterNodeClaims(t *testing.T, k8sClient client.Client, opts ...client.ListOption) []unstructured.Unstructured {
+	t.Helper()
+	nodeClaimList := &unstructured.UnstructuredList{}
+	nodeClaimList.SetGroupVersionKind(karpenterTestGVK("NodeClaimList"))
+	if err := k8sClient.List(context.Background(), nodeClaimList, opts...); err != nil {
+		t.Fatalf("failed to list NodeClaims: %v", err)
+	}
+	return nodeClaimList.Items
+}
+
+func TestKarpenterProviderScaleUp(t *testing.T) {
+	pool := &NodePoolConfig{
+		Name:              "gpu",
+		MaxSize:           3,
+		GPUType:           "nvidia-tesla-v100",
+		InstanceTypes:     []string{"p3.8xlarge"},
+		CapacityType:      CapacityTypeSpot,
+		AvailabilityZones: []string{"us-west-2a"},
+		Labels:            map[string]string{"workload": "training"},
+		Taints:            []corev1.Taint{{Key: "nvidia.com/gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule}},
+	}
+
+	tests := []struct {
+		name               string
+		existingNodeClaims int
+		count              int
+		expectedCreated    int
+		expectAtMaxSize    bool
+	}{
+		{
+			name:            "Scale up within max size",
+			count:           2,
+			expectedCreated: 2,
+		},
+		{
+			name:               "Scale up is capped at the pool's max size",
+			existingNodeClaims: 1,
+			count:              5,
+			expectedCreated:    2,
+		},
+		{
+			name:               "Scale up at max size",
+			existingNodeClaims: 3,
+			count:              1,
+			expectAtMaxSize:    true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := newKarpenterEnvtestClient(t)
+			createWithStatus(t, k8sClient, newKarpenterNodePool("gpu"))
+			for i := 0; i < tt.existingNodeClaims; i++ {
+				createWithStatus(t, k8sClient, newKarpenterNodeClaim(fmt.Sprintf("gpu-existing-%d", i), "gpu", fmt.Sprintf("node-%d", i), CapacityTypeSpot))
+			}
+			provider := NewKarpenterProvider(k8sClient, KarpenterProviderConfig{})
+
+			err := provider.ScaleUp(context.Background(), pool, tt.count)
+			if tt.expectAtMaxSize {
+				if !IsPoolAtMaxSize(err) {
+					t.Errorf("Expected a pool-at-max-size error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("ScaleUp() error = %v", err)
+			}
+
+			// NodeClaims created by the autoscaler carry its node pool label
+			created := listKarpenterNodeClaims(t, k8sClient, client.HasLabels{NodePoolLabel})
+			if len(created) != tt.expectedCreated {
+				t.Fatalf("Expected %d NodeClaims created, got %d", tt.expectedCreated, len(created))
+			}
+
+			for _, nodeClaim := range created {
+				labels := nodeClaim.GetLabels()
+				for key, expected := range map[string]string{
+					KarpenterNodePoolLabel: "gpu",
+					NodePoolLabel:          "gpu",
+					GPUTypeLabel:           "nvidia-tesla-v100",
+					"workload":             "training",
+					"team":                 "ml",
+				} {
+					if labels[key] != expected {
+						t.Errorf("Expected label %s=%s, got %q", key, expected, labels[key])
+					}
+				}
+
+				if owners := nodeClaim.GetOwnerReferences(); len(owners) != 1 || owners[0].Kind != "NodePool" || owners[0].UID == "" {
+					t.Errorf("Expected the NodePool as owner, got %v", owners)
+				}
+				if name, _, _ := unstructured.NestedString(nodeClaim.Object, "spec", "nodeClassRef", "name"); name != "gpu" {
+					t.Errorf("Expected nodeClassRef from the NodePool template, got %q", name)
+				}
+
+				requirements, _, _ := unstructured.NestedSlice(nodeClaim.Object, "spec", "requirements")
+				for key, expected := range map[string]string{
+					corev1.LabelInstanceTypeStable: "p3.8xlarge",
+					KarpenterCapacityTypeLabel:     CapacityTypeSpot,
+					corev1.LabelTopologyZone:       "us-west-2a",
+				} {
+					values := requirementValues(requirements, key)
+					if len(values) != 1 || values[0] != expected {
+						t.Errorf("Expected requirement %s In [%s], got %v", key, expected, values)
+					}
+				}
+
+				taints, _, _ := unstructured.NestedSlice(nodeClaim.Object, "spec", "taints")
+				if len(taints) != 1 || taints[0].(map[string]interface{})["key"] != "nvidia.com/gpu" {
+					t.Errorf("Expected the pool's GPU taint, got %v", taints)
+				}
+			}
+		})
+	}
+}
+
+func TestKarpenterProviderScaleDown(t *testing.T) {
+	tests := []struct {
+		name            string
+		nodeName        string
+		expectErr       bool
+		expectRemaining []string
+	}{
+		{
+			name:            "Scale down deletes the node's NodeClaim",
+			nodeName:        "ip-10-0-1-1.us-west-2.compute.internal",
+			expectRemaining: []string{"gpu-fghij"},
+		},
+		{
+			name:            "Scale down of a node without a NodeClaim",
+			nodeName:        "unknown-node",
+			expectErr:       true,
+			expectRemaining: []string{"gpu-abcde", "gpu-fghij"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := newKarpenterEnvtestClient(t)
+			createWithStatus(t, k8sClient,
+				newKarpenterNodePool("gpu"),
+				newKarpenterNodeClaim("gpu-abcde", "gpu", "ip-10-0-1-1.us-west-2.compute.internal", CapacityTypeSpot),
+				newKarpenterNodeClaim("gpu-fghij", "gpu", "ip-10-0-1-2.us-west-2.compute.internal", CapacityTypeSpot),
+			)
+			provider := NewKarpenterProvider(k8sClient, KarpenterProviderConfig{})
+
+			if err := provider.ScaleDown(context.Background(), tt.nodeName); (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+
+			var remaining []string
+			for _, nodeClaim := range listKarpenterNodeClaims(t, k8sClient) {
+				remaining = append(remaining, nodeClaim.GetName())
+			}
+			if strings.Join(remaining, ",") != strings.Join(tt.expectRemaining, ",") {
+				t.Errorf("Expected NodeClaims %v to remain, got %v", tt.expectRemaining, remaining)
+			}
+		})
+	}
+}
+
+func TestKarpenterProviderGetSpotTerminationNotice(t *testing.T) {
+	tests := []struct {
+		name         string
+		capacityType string
+		deleting     bool
+		expectNotice bool
+	}{
+		{name: "Spot NodeClaim being deleted", capacityType: CapacityTypeSpot, deleting: true, expectNotice: true},
+		{name: "Running spot NodeClaim", capacityType: CapacityTypeSpot},
+		{name: "On-demand NodeClaim being deleted", capacityType: CapacityTypeOnDemand, deleting: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			k8sClient := newKarpenterEnvtestClient(t)
+			nodeClaim := newKarpenterNodeClaim("gpu-abcde", "gpu", "gpu-node", tt.capacityType)
+			nodeClaim.SetFinalizers([]string{"karpenter.sh/termination"})
+			createWithStatus(t, k8sClient, nodeClaim)
+
+			// The termination finalizer keeps the deleted NodeClaim around as Karpenter drains it
+			if tt.deleting {
+				if err := k8sClient.Delete(context.Background(), nodeClaim); err != nil {
+					t.Fatalf("failed to delete NodeClaim: %v", err)
+				}
+				if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(nodeClaim), nodeClaim); err != nil {
+					t.Fatalf("failed to get NodeClaim: %v", err)
+				}
+			}
+
+			provider := NewKarpenterProvider(k8sClient, KarpenterProviderConfig{})
+			terminationTime, hasNotice, err := provider.GetSpotTerminationNotice(context.Background(), "gpu-node")
+			if err != nil {
+				t.Fatalf("GetSpotTerminationNotice() error = %v", err)
+			}
+			if hasNotice != tt.expectNotice {
+				t.Fatalf("Expected notice %v, got %v", tt.expectNotice, hasNotice)
+			}
+			if tt.expectNotice {
+				expected := nodeClaim.GetDeletionTimestamp().Add(2 * time.Minute)
+				if !terminationTime.Equal(expected) {
+					t.Errorf("Expected termination at %s, got %s", expected, terminationTime)
+				}
+			}
+		})
+	}
+}
+
+func TestKarpenterProviderGetNodePoolInfo(t *testing.T) {
+	k8sClient := newKarpenterEnvtestClient(t)
+	createWithStatus(t, k8sClient,
+		newKarpenterNodePool("gpu"),
+		newKarpenterNodePool("other"),
+		newKarpenterNodeClaim("gpu-abcde", "gpu", "node-1", CapacityTypeSpot),
+		newKarpenterNodeClaim("gpu-fghij", "gpu", "node-2", CapacityTypeSpot),
+		newKarpenterNodeClaim("other-klmno", "other", "node-3", CapacityTypeOnDemand),
+	)
+	provider := NewKarpenterProvider(k8sClient, KarpenterProviderConfig{})
+
+	tests := []struct {
+		name                 string
+		nodePool             string
+		expectErr            bool
+		expectedSize         int
+		expectedGPUs         int
+		expectedCapacityType string
+		expectedCost         float64
+	}{
+		{
+			name:                 "Spot NodePool",
+			nodePool:             "gpu",
+			expectedSize:         2,
+			expectedGPUs:         8,
+			expectedCapacityType: CapacityTypeSpot,
+			expectedCost:         9.60,
+		},
+		{
+			name:                 "On-demand NodePool",
+			nodePool:             "other",
+			expectedSize:         1,
+			expectedGPUs:         4,
+			expectedCapacityType: CapacityTypeOnDemand,
+			expectedCost:         12.24,
+		},
+		{
+			name:      "Unknown NodePool",
+			nodePool:  "missing",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info, err := provider.GetNodePoolInfo(context.Background(), tt.nodePool)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if info.CurrentSize != tt.expectedSize || info.AvailableGPUs != tt.expectedGPUs {
+				t.Errorf("Unexpected sizes: current=%d gpus=%d", info.CurrentSize, info.AvailableGPUs)
+			}
+			if info.InstanceType != "p3.8xlarge" || info.CapacityType != tt.expectedCapacityType {
+				t.Errorf("Unexpected pool info: %+v", info)
+			}
+			if info.Cost != tt.expectedCost {
+				t.Errorf("Expected hourly cost %.2f, got %.2f", tt.expectedCost, info.Cost)
+			}
+		})
+	}
+
+	zones, err := provider.GetAvailabilityZones(context.Background())
+	if err != nil {
+		t.Fatalf("GetAvailabilityZones() error = %v", err)
+	}
+	if len(zones) != 2 || zones[0] != "us-west-2a" || zones[1] != "us-west-2b" {
+		t.Errorf("Expected zones [us-west-2a us-west-2b], got %v", zones)
+	}
+}
@@ -15,6 +15,23 @@ var (
 		[]string{"action", "capacity_type", "success"},
 	)
 
+	// Shadow (dry-run) scaling metrics
+	shadowScalingActionsTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gpu_autoscaler_shadow_scaling_actions_total",
+			Help: "Total number of scaling actions a dry-run policy would have performed",
+		},
+		[]string{"policy", "action", "capacity_type"},
+	)
+
+	shadowScalingNodesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Name: "gpu_autoscaler_shadow_scaling_nodes_total",
+			Help: "Total number of nodes a dry-run policy would have added or removed",
+		},
+		[]string{"policy", "action"},
+	)
+
 	scalingDuration = prometheus.NewHistogramVec(
 		prometheus.HistogramOpts{
 			Name:    "gpu_autoscaler_scaling_duration_seconds",
@@ -181,6 +198,8 @@ func init() {
 	// Register metrics with controller-runtime's metrics registry
 	metrics.Registry.MustRegister(
 		scalingActionsTotal,
+		shadowScalingActionsTotal,
+		shadowScalingNodesTotal,
 		scalingDuration,
 		nodeCount,
 		desiredNodeCount,
@@ -222,6 +241,12 @@ func (m *MetricsRecorder) RecordScalingAction(action ScalingAction, capacityType
 	scalingActionsTotal.WithLabelValues(string(action), capacityType, successStr).Inc()
 }
 
+// RecordShadowScalingAction records a scaling action a dry-run policy would have performed
+func (m *MetricsRecorder) RecordShadowScalingAction(policy string, action ScalingAction, capacityType string, nodeCount int) {
+	shadowScalingActionsTotal.WithLabelValues(policy, string(action), capacityType).Inc()
+	shadowScalingNodesTotal.WithLabelValues(policy, string(action)).Add(float64(nodeCount))
+}
+
 // RecordScalingDuration records the duration of a scaling action
 func (m *MetricsRecorder) RecordScalingDuration(action ScalingAction, durationSeconds float64) {
 	scalingDuration.WithLabelValues(string(action)).Observe(durationSeconds)
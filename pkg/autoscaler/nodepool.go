@@ -0,0 +1,223 @@
+package autoscaler
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// GPUProductLabel is set by GPU feature discovery to the GPU model (e.g. "NVIDIA-H100-80GB-HBM3")
+	GPUProductLabel = "nvidia.com/gpu.product"
+
+	// GKEAcceleratorLabel is set by GKE to the attached accelerator (e.g. "nvidia-tesla-v100")
+	GKEAcceleratorLabel = "cloud.google.com/gke-accelerator"
+)
+
+// gpuModelPattern matches the model token of a GPU name, such as "v100", "a10g" or "h100"
+var gpuModelPattern = regexp.MustCompile(`^[a-z]+[0-9]+[a-z]*$`)
+
+// podFitsNodePool reports whether new nodes of the pool would satisfy the pod's nodeSelector,
+// required node affinity and tolerations. Labels the pool does not determine, such as those
+// set by the kubelet or cloud provider, are assumed to match.
+func podFitsNodePool(pod *corev1.Pod, pool *NodePoolConfig) bool {
+	for key, value := range pod.Spec.NodeSelector {
+		requirement := corev1.NodeSelectorRequirement{Key: key, Operator: corev1.NodeSelectorOpIn, Values: []string{value}}
+		if !nodePoolMatchesRequirement(pool, requirement) {
+			return false
+		}
+	}
+
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil {
+		if required := affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution; required != nil {
+			if !nodePoolMatchesSelector(pool, required) {
+				return false
+			}
+		}
+	}
+
+	for i := range pool.Taints {
+		taint := &pool.Taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule {
+			continue
+		}
+		if !toleratesTaint(pod.Spec.Tolerations, taint) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// nodePoolMatchesSelector reports whether the pool satisfies any of the selector's terms
+func nodePoolMatchesSelector(pool *NodePoolConfig, selector *corev1.NodeSelector) bool {
+	for _, term := range selector.NodeSelectorTerms {
+		// Field selectors pin existing nodes by name, which a new node can never match
+		if len(term.MatchFields) > 0 {
+			continue
+		}
+
+		matches := true
+		for _, requirement := range term.MatchExpressions {
+			if !nodePoolMatchesRequirement(pool, requirement) {
+				matches = false
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// nodePoolMatchesRequirement reports whether some node the pool can launch satisfies the requirement
+func nodePoolMatchesRequirement(pool *NodePoolConfig, requirement corev1.NodeSelectorRequirement) bool {
+	values, determined := nodePoolLabelValues(pool, requirement.Key)
+	if !determined {
+		return true
+	}
+
+	switch requirement.Operator {
+	case corev1.NodeSelectorOpIn:
+		for _, value := range values {
+			if labelValueIn(requirement.Key, value, requirement.Values) {
+				return true
+			}
+		}
+	case corev1.NodeSelectorOpNotIn:
+		for _, value := range values {
+			if !labelValueIn(requirement.Key, value, requirement.Values) {
+				return true
+			}
+		}
+	case corev1.NodeSelectorOpExists:
+		return true
+	case corev1.NodeSelectorOpGt, corev1.NodeSelectorOpLt:
+		if len(requirement.Values) != 1 {
+			return false
+		}
+		bound, err := strconv.ParseInt(requirement.Values[0], 10, 64)
+		if err != nil {
+			return false
+		}
+		for _, value := range values {
+			number, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				continue
+			}
+			if (requirement.Operator == corev1.NodeSelectorOpGt && number > bound) ||
+				(requirement.Operator == corev1.NodeSelectorOpLt && number < bound) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// nodePoolLabelValues returns the values a label can take on new nodes of the pool.
+// The second result is false for labels the pool does not determine.
+func nodePoolLabelValues(pool *NodePoolConfig, key string) ([]string, bool) {
+	if value, ok := pool.Labels[key]; ok {
+		return []string{value}, true
+	}
+
+	switch key {
+	case NodePoolLabel:
+		return []string{pool.Name}, true
+	case CapacityTypeLabel:
+		if pool.CapacityType != "" {
+			return []string{pool.CapacityType}, true
+		}
+	case InstanceTypeLabel, corev1.LabelInstanceTypeStable:
+		if len(pool.InstanceTypes) > 0 {
+			return pool.InstanceTypes, true
+		}
+	case corev1.LabelTopologyZone:
+		if len(pool.AvailabilityZones) > 0 {
+			return pool.AvailabilityZones, true
+		}
+	case GPUTypeLabel, GPUProductLabel, GKEAcceleratorLabel:
+		if pool.GPUType != "" {
+			return []string{pool.GPUType}, true
+		}
+	}
+	return nil, false
+}
+
+// labelValueIn reports whether a label value is one of the wanted values.
+// GPU labels match by GPU name, so a pool of "nvidia-h100" matches "NVIDIA-H100-80GB-HBM3".
+func labelValueIn(key, value string, wanted []string) bool {
+	isGPULabel := key == GPUTypeLabel || key == GPUProductLabel || key == GKEAcceleratorLabel
+	for _, want := range wanted {
+		if value == want {
+			return true
+		}
+		if isGPULabel && gpuNamesMatch(value, want) {
+			return true
+		}
+	}
+	return false
+}
+
+// gpuNamesMatch reports whether two GPU names can refer to the same GPU: their models are equal
+// and the variant tokens of one, such as "80gb" or "sxm4", all appear in the other. A bare model
+// name like "nvidia-a100" matches every A100, while full product names must agree, so
+// "NVIDIA-A100-SXM4-40GB" does not match "NVIDIA-A100-80GB-PCIe".
+func gpuNamesMatch(a, b string) bool {
+	modelA, variantsA := gpuNameParts(a)
+	modelB, variantsB := gpuNameParts(b)
+	if modelA == "" || modelA != modelB {
+		return false
+	}
+	return isSubset(variantsA, variantsB) || isSubset(variantsB, variantsA)
+}
+
+// gpuModel returns the lower-cased model token of a GPU name, or "" if it has none
+func gpuModel(name string) string {
+	model, _ := gpuNameParts(name)
+	return model
+}
+
+// gpuNameParts splits a lower-cased GPU name into its model token and the variant tokens after
+// the vendor and model, such as memory size, form factor or memory type
+func gpuNameParts(name string) (string, map[string]bool) {
+	tokens := strings.FieldsFunc(strings.ToLower(name), func(r rune) bool {
+		return r == '-' || r == '_' || r == ' ' || r == '.'
+	})
+
+	model := ""
+	variants := make(map[string]bool)
+	for _, token := range tokens {
+		switch {
+		case token == "nvidia" || token == "tesla":
+		case model == "" && gpuModelPattern.MatchString(token):
+			model = token
+		default:
+			variants[token] = true
+		}
+	}
+	return model, variants
+}
+
+// isSubset reports whether every key of a is also in b
+func isSubset(a, b map[string]bool) bool {
+	for key := range a {
+		if !b[key] {
+			return false
+		}
+	}
+	return true
+}
+
+// toleratesTaint reports whether any of the tolerations tolerates the taint
+func toleratesTaint(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
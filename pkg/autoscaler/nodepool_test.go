@@ -0,0 +1,161 @@
+package autoscaler
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestPodFitsNodePool(t *testing.T) {
+	pool := &NodePoolConfig{
+		Name:              "h100",
+		CapacityType:      CapacityTypeOnDemand,
+		GPUType:           "nvidia-h100",
+		InstanceTypes:     []string{"p5.48xlarge"},
+		AvailabilityZones: []string{"us-east-1a", "us-east-1b"},
+		Labels:            map[string]string{"team": "research", "gpu-memory-gb": "80"},
+		Taints: []corev1.Taint{
+			{Key: "nvidia.com/gpu", Effect: corev1.TaintEffectNoSchedule},
+			{Key: "spare", Effect: corev1.TaintEffectPreferNoSchedule},
+		},
+	}
+	gpuToleration := corev1.Toleration{Key: "nvidia.com/gpu", Operator: corev1.TolerationOpExists}
+	requiredAffinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+	expression := func(key string, operator corev1.NodeSelectorOperator, values ...string) corev1.NodeSelectorTerm {
+		return corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{{Key: key, Operator: operator, Values: values}}}
+	}
+
+	tests := []struct {
+		name     string
+		spec     corev1.PodSpec
+		expected bool
+	}{
+		{name: "tolerates the GPU taint", spec: corev1.PodSpec{Tolerations: []corev1.Toleration{gpuToleration}}, expected: true},
+		{name: "missing toleration", spec: corev1.PodSpec{}, expected: false},
+		{
+			name:     "pool label selector",
+			spec:     corev1.PodSpec{NodeSelector: map[string]string{"team": "research"}, Tolerations: []corev1.Toleration{gpuToleration}},
+			expected: true,
+		},
+		{
+			name:     "conflicting pool label",
+			spec:     corev1.PodSpec{NodeSelector: map[string]string{"team": "platform"}, Tolerations: []corev1.Toleration{gpuToleration}},
+			expected: false,
+		},
+		{
+			name:     "labels the pool does not set",
+			spec:     corev1.PodSpec{NodeSelector: map[string]string{corev1.LabelOSStable: "linux"}, Tolerations: []corev1.Toleration{gpuToleration}},
+			expected: true,
+		},
+		{
+			name:     "GPU product matches GPU type",
+			spec:     corev1.PodSpec{NodeSelector: map[string]string{GPUProductLabel: "NVIDIA-H100-80GB-HBM3"}, Tolerations: []corev1.Toleration{gpuToleration}},
+			expected: true,
+		},
+		{
+			name:     "GPU product of another model",
+			spec:     corev1.PodSpec{NodeSelector: map[string]string{GPUProductLabel: "NVIDIA-A100-SXM4-40GB"}, Tolerations: []corev1.Toleration{gpuToleration}},
+			expected: false,
+		},
+		{
+			name: "affinity on one of the pool's zones",
+			spec: corev1.PodSpec{
+				Affinity:    requiredAffinity(expression(corev1.LabelTopologyZone, corev1.NodeSelectorOpIn, "us-east-1b", "us-west-2a")),
+				Tolerations: []corev1.Toleration{gpuToleration},
+			},
+			expected: true,
+		},
+		{
+			name: "affinity excluding the pool's GPU type",
+			spec: corev1.PodSpec{
+				Affinity:    requiredAffinity(expression(GPUTypeLabel, corev1.NodeSelectorOpNotIn, "nvidia-h100")),
+				Tolerations: []corev1.Toleration{gpuToleration},
+			},
+			expected: false,
+		},
+		{
+			name: "any affinity term may match",
+			spec: corev1.PodSpec{
+				Affinity: requiredAffinity(
+					expression(CapacityTypeLabel, corev1.NodeSelectorOpIn, CapacityTypeSpot),
+					expression("gpu-memory-gb", corev1.NodeSelectorOpGt, "40"),
+				),
+				Tolerations: []corev1.Toleration{gpuToleration},
+			},
+			expected: true,
+		},
+		{
+			name: "pool label must not exist",
+			spec: corev1.PodSpec{
+				Affinity:    requiredAffinity(expression("team", corev1.NodeSelectorOpDoesNotExist)),
+				Tolerations: []corev1.Toleration{gpuToleration},
+			},
+			expected: false,
+		},
+		{
+			name: "affinity pinned to an existing node",
+			spec: corev1.PodSpec{
+				Affinity: requiredAffinity(corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+					{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu-node-1"}},
+				}}),
+				Tolerations: []corev1.Toleration{gpuToleration},
+			},
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: tt.spec}
+			if got := podFitsNodePool(pod, pool); got != tt.expected {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestGPUModel(t *testing.T) {
+	tests := []struct {
+		name     string
+		expected string
+	}{
+		{name: "nvidia-tesla-v100", expected: "v100"},
+		{name: "NVIDIA-H100-80GB-HBM3", expected: "h100"},
+		{name: "NVIDIA A10G", expected: "a10g"},
+		{name: "nvidia", expected: ""},
+	}
+
+	for _, tt := range tests {
+		if got := gpuModel(tt.name); got != tt.expected {
+			t.Errorf("Expected model %q for %s, got %q", tt.expected, tt.name, got)
+		}
+	}
+}
+
+func TestGPUNamesMatch(t *testing.T) {
+	tests := []struct {
+		a, b     string
+		expected bool
+	}{
+		{a: "nvidia-h100", b: "NVIDIA-H100-80GB-HBM3", expected: true},
+		{a: "nvidia-tesla-a100", b: "NVIDIA-A100-SXM4-40GB", expected: true},
+		{a: "nvidia-a100-80gb", b: "NVIDIA-A100-SXM4-80GB", expected: true},
+		{a: "NVIDIA-A100-SXM4-40GB", b: "NVIDIA-A100-80GB-PCIe", expected: false},
+		{a: "nvidia-a100-80gb", b: "NVIDIA-A100-SXM4-40GB", expected: false},
+		{a: "nvidia-tesla-v100", b: "nvidia-tesla-t4", expected: false},
+		{a: "nvidia", b: "nvidia", expected: false},
+	}
+
+	for _, tt := range tests {
+		if got := gpuNamesMatch(tt.a, tt.b); got != tt.expected {
+			t.Errorf("Expected match %v for %s and %s, got %v", tt.expected, tt.a, tt.b, got)
+		}
+		if got := gpuNamesMatch(tt.b, tt.a); got != tt.expected {
+			t.Errorf("Expected match %v for %s and %s, got %v", tt.expected, tt.b, tt.a, got)
+		}
+	}
+}
@@ -0,0 +1,402 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+const (
+	// AutoscalingPolicy condition types
+	ConditionTypeReady           = "Ready"
+	ConditionTypeScaling         = "Scaling"
+	ConditionTypeCapacityLimited = "CapacityLimited"
+	ConditionTypeProvisioning    = "Provisioning"
+	ConditionTypeSchedules       = "Schedules"
+
+	// AutoscalingPolicy condition reasons
+	ReasonReconciled          = "Reconciled"
+	ReasonReconcileFailed     = "ReconcileFailed"
+	ReasonDisabled            = "Disabled"
+	ReasonScaledUp            = "ScaledUp"
+	ReasonScaledDown          = "ScaledDown"
+	ReasonScalingFailed       = "ScalingFailed"
+	ReasonStable              = "Stable"
+	ReasonMaxNodesReached     = "MaxNodesReached"
+	ReasonWithinLimits        = "WithinLimits"
+	ReasonCapacityUnavailable = "CapacityUnavailable"
+	ReasonDryRun              = "DryRun"
+
+	// Provisioning condition reasons; ReasonProvisioningTimedOut is also the event reason
+	// for requests whose nodes never registered
+	ReasonNodesProvisioning    = "NodesProvisioning"
+	ReasonNodesRegistered      = "NodesRegistered"
+	ReasonProvisioningTimedOut = "ProvisioningTimedOut"
+	ReasonProvisioningFailed   = "ProvisioningFailed"
+
+	// Schedules condition reasons
+	ReasonSchedulesValid  = "SchedulesValid"
+	ReasonInvalidSchedule = "InvalidSchedule"
+
+	// Event reasons for decisions made in dry-run mode
+	ReasonShadowScaleUp   = "ShadowScaleUp"
+	ReasonShadowScaleDown = "ShadowScaleDown"
+
+	// Event reason for pending pods that no node pool can host
+	ReasonNoMatchingNodePool = "NoMatchingNodePool"
+
+	// Event reason for pending pods waiting for a node pool to start from zero nodes
+	ReasonWaitingForColdStart = "WaitingForColdStart"
+)
+
+// policyState tracks in-memory scaling state for a single AutoscalingPolicy
+type policyState struct {
+	lastScaleUpTime   time.Time
+	lastScaleDownTime time.Time
+
+	// failedCapacity holds the capacity the cloud provider could not supply, with the end of its backoff
+	failedCapacity map[capacityKey]time.Time
+
+	// nodePools caches the node pools the cloud provider placed unlabeled nodes in, keyed by node name
+	nodePools map[string]nodePoolMember
+}
+
+// scalingScope is the per-policy view of the cluster used during a single reconcile
+type scalingScope struct {
+	policy     *v1alpha1.AutoscalingPolicy
+	config     AutoscalerConfig
+	state      *policyState
+	selector   labels.Selector
+	nodes      []corev1.Node
+	prediction *ScalingPrediction
+
+	// schedule is the capacity window in effect, if any
+	schedule *v1alpha1.CapacitySchedule
+
+	// nodePools holds the node pool of each node, keyed by node name; unresolvedNodes counts
+	// the nodes the cloud provider could not be asked about
+	nodePools       map[string]string
+	unresolvedNodes int
+
+	// provisioning holds the requested nodes that have not registered yet, keyed by node pool
+	provisioning map[string]int
+
+	// provisioningFailures describes the provisioning requests that timed out or failed to launch
+	// during this reconcile; launchFailed is set when the cloud provider reported a failed launch
+	provisioningFailures []string
+	launchFailed         bool
+
+	// scaleUpCandidates are the node pools that can host the pending pods, best first
+	scaleUpCandidates []scaleUpCandidate
+
+	// backedOffPools are the node pools that could host pending pods but whose capacity is backed off
+	backedOffPools []string
+
+	// pendingPods are the pending GPU pods the policy could serve
+	pendingPods []corev1.Pod
+}
+
+// ConfigFromPolicy builds an AutoscalerConfig from an AutoscalingPolicy spec.
+// Settings the policy does not carry (such as the reconcile interval) are taken from defaults.
+func ConfigFromPolicy(policy *v1alpha1.AutoscalingPolicy, defaults AutoscalerConfig) AutoscalerConfig {
+	spec := policy.Spec
+
+	config := AutoscalerConfig{
+		ReconcileInterval:       defaults.ReconcileInterval,
+		ScaleUpThreshold:        spec.ScaleUpThreshold,
+		ScaleDownThreshold:      spec.ScaleDownThreshold,
+		ScaleUpCooldown:         time.Duration(spec.ScaleUpCooldownSeconds) * time.Second,
+		ScaleDownCooldown:       time.Duration(spec.ScaleDownCooldownSeconds) * time.Second,
+		PendingPodTimeout:       time.Duration(spec.PendingPodTimeoutSeconds) * time.Second,
+		DrainTimeout:            time.Duration(spec.DrainTimeoutSeconds) * time.Second,
+		ProvisioningTimeout:     time.Duration(spec.ProvisioningTimeoutSeconds) * time.Second,
+		CapacityBackoff:         time.Duration(spec.CapacityBackoffSeconds) * time.Second,
+		MinNodes:                int(spec.MinNodes),
+		MaxNodes:                int(spec.MaxNodes),
+		SpotInstancePercentage:  spec.SpotInstancePercentage,
+		EnablePredictiveScaling: spec.EnablePredictiveScaling,
+		EnableSpotInstances:     spec.EnableSpotInstances,
+		EnableMultiTierScaling:  spec.EnableMultiTierScaling,
+		NodePools:               make([]NodePoolConfig, 0, len(spec.NodePools)),
+		DryRun:                  spec.DryRun || defaults.DryRun,
+	}
+
+	if config.ReconcileInterval == 0 {
+		config.ReconcileInterval = DefaultReconcileInterval
+	}
+
+	if config.DrainTimeout == 0 {
+		config.DrainTimeout = defaults.DrainTimeout
+	}
+
+	if config.ProvisioningTimeout == 0 {
+		config.ProvisioningTimeout = defaults.ProvisioningTimeout
+	}
+	if config.ProvisioningTimeout == 0 {
+		config.ProvisioningTimeout = DefaultProvisioningTimeout
+	}
+
+	if config.CapacityBackoff == 0 {
+		config.CapacityBackoff = defaults.CapacityBackoff
+	}
+	if config.CapacityBackoff == 0 {
+		config.CapacityBackoff = DefaultCapacityBackoff
+	}
+
+	// MaxNodes has a minimum of 1 in the CRD, so zero means the field was never defaulted
+	if config.MaxNodes == 0 {
+		config.MaxNodes = DefaultMaxNodes
+	}
+
+	for _, pool := range spec.NodePools {
+		config.NodePools = append(config.NodePools, nodePoolConfigFromSpec(pool))
+	}
+
+	return config
+}
+
+// nodePoolConfigFromSpec converts an API node pool into the autoscaler's NodePoolConfig
+func nodePoolConfigFromSpec(spec v1alpha1.NodePoolSpec) NodePoolConfig {
+	pool := NodePoolConfig{
+		Name:              spec.Name,
+		MinSize:           int(spec.MinSize),
+		MaxSize:           int(spec.MaxSize),
+		GPUType:           spec.GPUType,
+		InstanceTypes:     append([]string(nil), spec.InstanceTypes...),
+		GPUsPerNode:       int(spec.GPUsPerNode),
+		CapacityType:      spec.CapacityType,
+		SpotPercentage:    spec.SpotPercentage,
+		Priority:          int(spec.Priority),
+		AvailabilityZones: append([]string(nil), spec.AvailabilityZones...),
+		IdleTimeout:       time.Duration(spec.IdleTimeoutSeconds) * time.Second,
+	}
+
+	if spec.Labels != nil {
+		pool.Labels = make(map[string]string, len(spec.Labels))
+		for k, v := range spec.Labels {
+			pool.Labels[k] = v
+		}
+	}
+
+	for _, taint := range spec.Taints {
+		pool.Taints = append(pool.Taints, *taint.DeepCopy())
+	}
+
+	return pool
+}
+
+// newScalingScope builds the per-policy scope, creating in-memory state on first use
+func (r *AutoscalerController) newScalingScope(policy *v1alpha1.AutoscalingPolicy) *scalingScope {
+	config := ConfigFromPolicy(policy, r.Config)
+	return &scalingScope{
+		policy:   policy,
+		config:   config,
+		state:    r.getPolicyState(policy, config.DryRun),
+		selector: labels.SelectorFromSet(policy.Spec.NodeSelector),
+	}
+}
+
+// getPolicyState returns the in-memory state for a policy.
+// Cooldown timestamps are taken from the policy status whenever it is newer, so cooldowns survive
+// controller restarts and leader failovers; dry-run policies use the timestamps of their shadow decisions.
+func (r *AutoscalerController) getPolicyState(policy *v1alpha1.AutoscalingPolicy, dryRun bool) *policyState {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+
+	if r.policyStates == nil {
+		r.policyStates = make(map[string]*policyState)
+	}
+
+	state, ok := r.policyStates[policy.Name]
+	if !ok {
+		state = &policyState{}
+		r.policyStates[policy.Name] = state
+	}
+
+	lastScaleUpTime, lastScaleDownTime := policy.Status.LastScaleUpTime, policy.Status.LastScaleDownTime
+	if dryRun && policy.Status.Shadow != nil {
+		lastScaleUpTime, lastScaleDownTime = policy.Status.Shadow.LastScaleUpTime, policy.Status.Shadow.LastScaleDownTime
+	}
+	if lastScaleUpTime != nil && lastScaleUpTime.Time.After(state.lastScaleUpTime) {
+		state.lastScaleUpTime = lastScaleUpTime.Time
+	}
+	if lastScaleDownTime != nil && lastScaleDownTime.Time.After(state.lastScaleDownTime) {
+		state.lastScaleDownTime = lastScaleDownTime.Time
+	}
+
+	return state
+}
+
+// forgetPolicy drops in-memory state for a deleted policy
+func (r *AutoscalerController) forgetPolicy(name string) {
+	r.stateMu.Lock()
+	defer r.stateMu.Unlock()
+	delete(r.policyStates, name)
+	if r.PredictiveScaler != nil {
+		r.PredictiveScaler.Forget(name)
+	}
+}
+
+// matchesNode reports whether a node falls under the policy's NodeSelector
+func (s *scalingScope) matchesNode(node *corev1.Node) bool {
+	return s.selector.Matches(labels.Set(node.Labels))
+}
+
+// matchesPod reports whether a pending pod could land on nodes managed by the policy.
+// A pod matches unless its nodeSelector pins a key to a different value than the policy's NodeSelector.
+func (s *scalingScope) matchesPod(pod *corev1.Pod) bool {
+	for key, value := range s.policy.Spec.NodeSelector {
+		if podValue, ok := pod.Spec.NodeSelector[key]; ok && podValue != value {
+			return false
+		}
+	}
+	return true
+}
+
+// setPolicyCondition sets a condition on the policy status
+func setPolicyCondition(policy *v1alpha1.AutoscalingPolicy, conditionType string, status metav1.ConditionStatus, reason, message string) {
+	meta.SetStatusCondition(&policy.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: policy.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// updatePolicyStatus writes the observed cluster state and the scaling outcome back to the policy
+func (r *AutoscalerController) updatePolicyStatus(ctx context.Context, scope *scalingScope, decision *ScalingDecision, scalingErr error) error {
+	policy := scope.policy
+	status := &policy.Status
+
+	status.CurrentNodes = int32(len(scope.nodes))
+	status.SpotNodes, status.OnDemandNodes, status.ReservedNodes = 0, 0, 0
+	for _, node := range scope.nodes {
+		switch node.Labels[CapacityTypeLabel] {
+		case CapacityTypeSpot:
+			status.SpotNodes++
+		case CapacityTypeReserved:
+			status.ReservedNodes++
+		default:
+			status.OnDemandNodes++
+		}
+	}
+
+	status.DesiredNodes = int32(decision.DesiredNodeCount)
+	status.AverageGPUUtilization = decision.GPUUtilization
+	status.PendingPods = int32(decision.PendingPods)
+
+	// Dry-run decisions are recorded in the shadow status by recordShadowDecision
+	if !scope.config.DryRun {
+		if !scope.state.lastScaleUpTime.IsZero() {
+			status.LastScaleUpTime = &metav1.Time{Time: scope.state.lastScaleUpTime}
+		}
+		if !scope.state.lastScaleDownTime.IsZero() {
+			status.LastScaleDownTime = &metav1.Time{Time: scope.state.lastScaleDownTime}
+		}
+
+		if decision.Action != NoAction {
+			status.LastScalingAction = string(decision.Action)
+			status.LastScalingReason = decision.Reason
+		}
+	}
+
+	if scope.config.EnablePredictiveScaling && scope.prediction != nil {
+		status.PredictiveScaling = &v1alpha1.PredictiveScalingStatus{
+			Enabled:              true,
+			PredictedUtilization: scope.prediction.PredictedUtilization,
+			RecommendedNodes:     int32(scope.prediction.RecommendedNodes),
+			Confidence:           scope.prediction.Confidence,
+		}
+		if scope.prediction.ShouldPreWarm {
+			nextBusy := metav1.NewTime(r.now().Add(scope.prediction.TimeUntilPeak))
+			status.PredictiveScaling.NextBusyPeriod = &nextBusy
+		}
+	} else {
+		status.PredictiveScaling = nil
+	}
+
+	// Scaling condition reflects the outcome of this reconcile
+	switch {
+	case scalingErr != nil:
+		setPolicyCondition(policy, ConditionTypeScaling, metav1.ConditionFalse, ReasonScalingFailed, scalingErr.Error())
+	case scope.config.DryRun && decision.Action != NoAction:
+		setPolicyCondition(policy, ConditionTypeScaling, metav1.ConditionFalse, ReasonDryRun,
+			fmt.Sprintf("dry run: would %s: %s", decision.Action, decision.Reason))
+	case decision.Action == ScaleUp:
+		setPolicyCondition(policy, ConditionTypeScaling, metav1.ConditionTrue, ReasonScaledUp, decision.Reason)
+	case decision.Action == ScaleDown:
+		setPolicyCondition(policy, ConditionTypeScaling, metav1.ConditionTrue, ReasonScaledDown, decision.Reason)
+	default:
+		setPolicyCondition(policy, ConditionTypeScaling, metav1.ConditionFalse, ReasonStable, decision.Reason)
+	}
+
+	// CapacityLimited is raised when pods are waiting but the policy cannot add nodes
+	switch {
+	case decision.PendingPods > 0 && len(scope.nodes) >= scope.config.MaxNodes:
+		setPolicyCondition(policy, ConditionTypeCapacityLimited, metav1.ConditionTrue, ReasonMaxNodesReached,
+			fmt.Sprintf("%d pending GPU pods but policy is at maxNodes (%d)", decision.PendingPods, scope.config.MaxNodes))
+	case decision.PendingPods > 0 && len(scope.backedOffPools) > 0 && decision.Action == NoAction:
+		setPolicyCondition(policy, ConditionTypeCapacityLimited, metav1.ConditionTrue, ReasonCapacityUnavailable,
+			fmt.Sprintf("%d pending GPU pods but the cloud provider could not supply node pools [%s]",
+				decision.PendingPods, strings.Join(scope.backedOffPools, ", ")))
+	default:
+		setPolicyCondition(policy, ConditionTypeCapacityLimited, metav1.ConditionFalse, ReasonWithinLimits, "")
+	}
+
+	// Provisioning reports nodes that are still booting; a failed request stays reported
+	// until the next scale-up
+	switch {
+	case len(scope.provisioningFailures) > 0:
+		reason := ReasonProvisioningTimedOut
+		if scope.launchFailed {
+			reason = ReasonProvisioningFailed
+		}
+		setPolicyCondition(policy, ConditionTypeProvisioning, metav1.ConditionFalse, reason,
+			strings.Join(scope.provisioningFailures, "; "))
+	case len(status.ProvisioningRequests) > 0:
+		setPolicyCondition(policy, ConditionTypeProvisioning, metav1.ConditionTrue, ReasonNodesProvisioning,
+			fmt.Sprintf("%d requested nodes have not registered yet", scope.provisioningNodeCount()))
+	default:
+		if condition := meta.FindStatusCondition(status.Conditions, ConditionTypeProvisioning); condition == nil ||
+			(condition.Reason != ReasonProvisioningTimedOut && condition.Reason != ReasonProvisioningFailed) {
+			setPolicyCondition(policy, ConditionTypeProvisioning, metav1.ConditionFalse, ReasonNodesRegistered, "")
+		}
+	}
+
+	setPolicyCondition(policy, ConditionTypeReady, metav1.ConditionTrue, ReasonReconciled, "policy reconciled")
+
+	if err := r.Status().Update(ctx, policy); err != nil {
+		return fmt.Errorf("failed to update policy status: %w", err)
+	}
+
+	return nil
+}
+
+// enqueueAllPolicies maps node and pod events to every AutoscalingPolicy
+func (r *AutoscalerController) enqueueAllPolicies(ctx context.Context, _ client.Object) []reconcile.Request {
+	policies := &v1alpha1.AutoscalingPolicyList{}
+	if err := r.List(ctx, policies); err != nil {
+		r.Log.Error(err, "failed to list autoscaling policies")
+		return nil
+	}
+
+	requests := make([]reconcile.Request, 0, len(policies.Items))
+	for _, policy := range policies.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: client.ObjectKey{Name: policy.Name},
+		})
+	}
+
+	return requests
+}
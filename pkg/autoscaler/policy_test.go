@@ -0,0 +1,138 @@
+package autoscaler
+
+import (
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+func TestConfigFromPolicy(t *testing.T) {
+	policy := &v1alpha1.AutoscalingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "training"},
+		Spec: v1alpha1.AutoscalingPolicySpec{
+			Enabled:                  true,
+			ScaleUpThreshold:         0.75,
+			ScaleDownThreshold:       0.1,
+			ScaleUpCooldownSeconds:   60,
+			ScaleDownCooldownSeconds: 300,
+			PendingPodTimeoutSeconds: 30,
+			MinNodes:                 1,
+			MaxNodes:                 10,
+			SpotInstancePercentage:   0.5,
+			EnableSpotInstances:      true,
+			NodePools: []v1alpha1.NodePoolSpec{
+				{
+					Name:              "a100-spot",
+					MaxSize:           8,
+					GPUType:           "nvidia-a100",
+					InstanceTypes:     []string{"p4d.24xlarge"},
+					CapacityType:      CapacityTypeSpot,
+					Priority:          10,
+					Labels:            map[string]string{"team": "ml"},
+					AvailabilityZones: []string{"us-east-1a"},
+				},
+			},
+		},
+	}
+
+	config := ConfigFromPolicy(policy, AutoscalerConfig{ReconcileInterval: time.Minute})
+
+	if config.ReconcileInterval != time.Minute {
+		t.Errorf("Expected reconcile interval from defaults, got %s", config.ReconcileInterval)
+	}
+	if config.ScaleUpCooldown != time.Minute || config.ScaleDownCooldown != 5*time.Minute {
+		t.Errorf("Unexpected cooldowns: up=%s down=%s", config.ScaleUpCooldown, config.ScaleDownCooldown)
+	}
+	if config.PendingPodTimeout != 30*time.Second {
+		t.Errorf("Expected pending pod timeout 30s, got %s", config.PendingPodTimeout)
+	}
+	if config.MinNodes != 1 || config.MaxNodes != 10 {
+		t.Errorf("Unexpected node limits: min=%d max=%d", config.MinNodes, config.MaxNodes)
+	}
+	if len(config.NodePools) != 1 {
+		t.Fatalf("Expected 1 node pool, got %d", len(config.NodePools))
+	}
+
+	pool := config.NodePools[0]
+	if pool.Name != "a100-spot" || pool.MaxSize != 8 || pool.Priority != 10 {
+		t.Errorf("Unexpected node pool: %+v", pool)
+	}
+	if pool.Labels["team"] != "ml" || len(pool.AvailabilityZones) != 1 {
+		t.Errorf("Expected labels and zones to be copied, got %+v", pool)
+	}
+
+	// Mutating the converted config must not touch the policy
+	pool.Labels["team"] = "changed"
+	if policy.Spec.NodePools[0].Labels["team"] != "ml" {
+		t.Error("Expected node pool labels to be deep copied")
+	}
+}
+
+func TestConfigFromPolicyDefaultsMaxNodes(t *testing.T) {
+	config := ConfigFromPolicy(&v1alpha1.AutoscalingPolicy{}, AutoscalerConfig{})
+
+	if config.MaxNodes != DefaultMaxNodes {
+		t.Errorf("Expected MaxNodes %d, got %d", DefaultMaxNodes, config.MaxNodes)
+	}
+	if config.ReconcileInterval != DefaultReconcileInterval {
+		t.Errorf("Expected reconcile interval %s, got %s", DefaultReconcileInterval, config.ReconcileInterval)
+	}
+}
+
+func TestScalingScopeMatching(t *testing.T) {
+	r := &AutoscalerController{}
+	scope := r.newScalingScope(&v1alpha1.AutoscalingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "inference"},
+		Spec: v1alpha1.AutoscalingPolicySpec{
+			NodeSelector: map[string]string{"workload": "inference"},
+		},
+	})
+
+	tests := []struct {
+		name         string
+		nodeLabels   map[string]string
+		podSelector  map[string]string
+		expectedNode bool
+		expectedPod  bool
+	}{
+		{
+			name:         "matching labels",
+			nodeLabels:   map[string]string{"workload": "inference"},
+			podSelector:  map[string]string{"workload": "inference"},
+			expectedNode: true,
+			expectedPod:  true,
+		},
+		{
+			name:         "conflicting labels",
+			nodeLabels:   map[string]string{"workload": "training"},
+			podSelector:  map[string]string{"workload": "training"},
+			expectedNode: false,
+			expectedPod:  false,
+		},
+		{
+			name:         "unconstrained pod",
+			nodeLabels:   nil,
+			podSelector:  nil,
+			expectedNode: false,
+			expectedPod:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node := &corev1.Node{ObjectMeta: metav1.ObjectMeta{Labels: tt.nodeLabels}}
+			pod := &corev1.Pod{Spec: corev1.PodSpec{NodeSelector: tt.podSelector}}
+
+			if got := scope.matchesNode(node); got != tt.expectedNode {
+				t.Errorf("matchesNode() = %v, expected %v", got, tt.expectedNode)
+			}
+			if got := scope.matchesPod(pod); got != tt.expectedPod {
+				t.Errorf("matchesPod() = %v, expected %v", got, tt.expectedPod)
+			}
+		})
+	}
+}
@@ -4,9 +4,10 @@ import (
 	"context"
 	"fmt"
 	"math"
+	"sync"
 	"time"
 
-	"github.com/gpuautoscaler/gpuautoscaler/pkg/metrics"
+	corev1 "k8s.io/api/core/v1"
 )
 
 const (
@@ -17,11 +18,22 @@ const (
 	PreWarmThreshold         = 0.7  // Utilization threshold to trigger pre-warming
 )
 
-// PredictiveScaler analyzes historical GPU utilization patterns and predicts future load
+// PredictiveScaler analyzes historical GPU utilization patterns and predicts future load.
+// Patterns are learned separately for each policy from the metrics of its own nodes.
 type PredictiveScaler struct {
-	metricsCollector *metrics.Collector
-	patterns         []UtilizationPattern
-	lastUpdate       time.Time
+	metricsCollector GPUMetricsSource
+
+	mu       sync.Mutex
+	patterns map[string]*policyPatterns
+
+	// Clock returns the current time; defaults to time.Now and can be replaced with a fake clock
+	Clock func() time.Time
+}
+
+// policyPatterns holds the utilization patterns learned for one policy
+type policyPatterns struct {
+	patterns   []UtilizationPattern
+	lastUpdate time.Time
 }
 
 // UtilizationPattern represents a historical utilization pattern
@@ -47,18 +59,36 @@ type ScalingPrediction struct {
 }
 
 // NewPredictiveScaler creates a new predictive scaler
-func NewPredictiveScaler(metricsCollector *metrics.Collector) *PredictiveScaler {
+func NewPredictiveScaler(metricsCollector GPUMetricsSource) *PredictiveScaler {
 	return &PredictiveScaler{
 		metricsCollector: metricsCollector,
-		patterns:         make([]UtilizationPattern, 0),
+		patterns:         make(map[string]*policyPatterns),
+		Clock:            time.Now,
 	}
 }
 
-// PredictFutureLoad predicts future GPU load and makes scaling recommendations
-func (p *PredictiveScaler) PredictFutureLoad(ctx context.Context) *ScalingPrediction {
+// now returns the current time from the scaler's clock
+func (p *PredictiveScaler) now() time.Time {
+	if p.Clock != nil {
+		return p.Clock()
+	}
+	return time.Now()
+}
+
+// PredictFutureLoad predicts the future GPU load on a policy's nodes and makes scaling recommendations
+func (p *PredictiveScaler) PredictFutureLoad(ctx context.Context, policy string, nodes []corev1.Node) *ScalingPrediction {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	learned := p.patterns[policy]
+	if learned == nil {
+		learned = &policyPatterns{}
+		p.patterns[policy] = learned
+	}
+
 	// Update patterns if needed
-	if time.Since(p.lastUpdate) > time.Hour {
-		if err := p.updatePatterns(ctx); err != nil {
+	if p.now().Sub(learned.lastUpdate) > time.Hour {
+		if err := p.updatePatterns(ctx, learned, nodes); err != nil {
 			return &ScalingPrediction{
 				ShouldPreWarm: false,
 				Confidence:    0,
@@ -67,14 +97,14 @@ func (p *PredictiveScaler) PredictFutureLoad(ctx context.Context) *ScalingPredic
 		}
 	}
 
-	now := time.Now()
+	now := p.now()
 	prediction := &ScalingPrediction{
 		ShouldPreWarm: false,
 		Confidence:    0,
 	}
 
 	// Find similar historical patterns
-	similarPatterns := p.findSimilarPatterns(now)
+	similarPatterns := p.findSimilarPatterns(learned.patterns, now)
 	if len(similarPatterns) == 0 {
 		prediction.Reason = "no similar historical patterns found"
 		return prediction
@@ -102,10 +132,22 @@ func (p *PredictiveScaler) PredictFutureLoad(ctx context.Context) *ScalingPredic
 	return prediction
 }
 
-// updatePatterns updates historical patterns from metrics
-func (p *PredictiveScaler) updatePatterns(ctx context.Context) error {
+// Forget drops the patterns learned for a deleted policy
+func (p *PredictiveScaler) Forget(policy string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.patterns, policy)
+}
+
+// updatePatterns updates a policy's historical patterns from the metrics of its nodes
+func (p *PredictiveScaler) updatePatterns(ctx context.Context, learned *policyPatterns, nodes []corev1.Node) error {
+	nodeNames := make(map[string]bool, len(nodes))
+	for _, node := range nodes {
+		nodeNames[node.Name] = true
+	}
+
 	// Query historical metrics
-	endTime := time.Now()
+	endTime := p.now()
 	startTime := endTime.Add(-HistoricalLookback)
 
 	patterns := make([]UtilizationPattern, 0)
@@ -113,21 +155,21 @@ func (p *PredictiveScaler) updatePatterns(ctx context.Context) error {
 	// Analyze patterns for each hour of each day of the week
 	for day := time.Sunday; day <= time.Saturday; day++ {
 		for hour := 0; hour < 24; hour++ {
-			pattern := p.analyzePattern(ctx, day, hour, startTime, endTime)
+			pattern := p.analyzePattern(ctx, nodeNames, day, hour, startTime, endTime)
 			if pattern != nil {
 				patterns = append(patterns, *pattern)
 			}
 		}
 	}
 
-	p.patterns = patterns
-	p.lastUpdate = time.Now()
+	learned.patterns = patterns
+	learned.lastUpdate = p.now()
 
 	return nil
 }
 
-// analyzePattern analyzes utilization for a specific day and hour
-func (p *PredictiveScaler) analyzePattern(ctx context.Context, dayOfWeek time.Weekday, hourOfDay int, startTime, endTime time.Time) *UtilizationPattern {
+// analyzePattern analyzes utilization of the given nodes for a specific day and hour
+func (p *PredictiveScaler) analyzePattern(ctx context.Context, nodeNames map[string]bool, dayOfWeek time.Weekday, hourOfDay int, startTime, endTime time.Time) *UtilizationPattern {
 	utilizationSamples := make([]float64, 0)
 	podCounts := make([]int, 0)
 
@@ -142,16 +184,21 @@ func (p *PredictiveScaler) analyzePattern(ctx context.Context, dayOfWeek time.We
 				continue
 			}
 
-			if len(gpuMetrics) > 0 {
-				var totalUtil float64
-				podSet := make(map[string]bool)
+			var totalUtil float64
+			var samples int
+			podSet := make(map[string]bool)
 
-				for _, metric := range gpuMetrics {
-					totalUtil += metric.GPUUtilization
-					podSet[metric.PodName] = true
+			for _, metric := range gpuMetrics {
+				if !nodeNames[metric.NodeName] {
+					continue
 				}
+				totalUtil += metric.GPUUtilization
+				samples++
+				podSet[metric.PodName] = true
+			}
 
-				avgUtil := totalUtil / float64(len(gpuMetrics))
+			if samples > 0 {
+				avgUtil := totalUtil / float64(samples)
 				utilizationSamples = append(utilizationSamples, avgUtil)
 				podCounts = append(podCounts, len(podSet))
 			}
@@ -182,13 +229,13 @@ func (p *PredictiveScaler) analyzePattern(ctx context.Context, dayOfWeek time.We
 }
 
 // findSimilarPatterns finds historical patterns similar to current time
-func (p *PredictiveScaler) findSimilarPatterns(targetTime time.Time) []UtilizationPattern {
+func (p *PredictiveScaler) findSimilarPatterns(patterns []UtilizationPattern, targetTime time.Time) []UtilizationPattern {
 	targetDay := targetTime.Weekday()
 	targetHour := targetTime.Hour()
 
 	similar := make([]UtilizationPattern, 0)
 
-	for _, pattern := range p.patterns {
+	for _, pattern := range patterns {
 		similarity := p.calculateSimilarity(pattern, targetDay, targetHour)
 		if similarity > PatternSimilarityThreshold {
 			similar = append(similar, pattern)
@@ -312,7 +359,7 @@ func (p *PredictiveScaler) estimateTimeUntilPeak(patterns []UtilizationPattern)
 	}
 
 	// Calculate time until this pattern's hour
-	now := time.Now()
+	now := p.now()
 	targetHour := peakPattern.HourOfDay
 
 	hoursUntil := targetHour - now.Hour()
@@ -351,11 +398,18 @@ func (p *PredictiveScaler) detectTrend(values []float64) string {
 	return "stable"
 }
 
-// AnalyzeBusyPeriods identifies recurring busy periods
-func (p *PredictiveScaler) AnalyzeBusyPeriods() []BusyPeriod {
+// AnalyzeBusyPeriods identifies recurring busy periods on a policy's nodes
+func (p *PredictiveScaler) AnalyzeBusyPeriods(policy string) []BusyPeriod {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
 	busyPeriods := make([]BusyPeriod, 0)
+	learned := p.patterns[policy]
+	if learned == nil {
+		return busyPeriods
+	}
 
-	for _, pattern := range p.patterns {
+	for _, pattern := range learned.patterns {
 		if pattern.AvgUtilization > 0.6 { // Consider 60%+ as busy
 			busyPeriods = append(busyPeriods, BusyPeriod{
 				DayOfWeek:  pattern.DayOfWeek,
@@ -379,9 +433,9 @@ type BusyPeriod struct {
 	Recurring   bool
 }
 
-// GetPreWarmSchedule generates a schedule for pre-warming nodes
-func (p *PredictiveScaler) GetPreWarmSchedule() []PreWarmEvent {
-	busyPeriods := p.AnalyzeBusyPeriods()
+// GetPreWarmSchedule generates a schedule for pre-warming a policy's nodes
+func (p *PredictiveScaler) GetPreWarmSchedule(policy string) []PreWarmEvent {
+	busyPeriods := p.AnalyzeBusyPeriods(policy)
 	schedule := make([]PreWarmEvent, 0)
 
 	for _, period := range busyPeriods {
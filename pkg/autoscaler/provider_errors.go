@@ -0,0 +1,194 @@
+package autoscaler
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ProviderErrorReason classifies why a cloud provider could not add nodes
+type ProviderErrorReason string
+
+const (
+	// ProviderErrorInsufficientCapacity means the cloud has no capacity left for the instance type in the zone
+	ProviderErrorInsufficientCapacity ProviderErrorReason = "InsufficientCapacity"
+
+	// ProviderErrorQuotaExceeded means the account's quota for the instance type is used up
+	ProviderErrorQuotaExceeded ProviderErrorReason = "QuotaExceeded"
+
+	// ProviderErrorSpotUnavailable means no spot capacity is available for the instance type in the zone
+	ProviderErrorSpotUnavailable ProviderErrorReason = "SpotUnavailable"
+
+	// ProviderErrorPoolAtMaxSize means the node pool cannot grow any further
+	ProviderErrorPoolAtMaxSize ProviderErrorReason = "PoolAtMaxSize"
+)
+
+// ProviderError is returned by CloudProvider.ScaleUp when the requested capacity is not available.
+// InstanceType and Zone are set when the provider knows which capacity failed.
+type ProviderError struct {
+	Reason       ProviderErrorReason
+	NodePool     string
+	InstanceType string
+	Zone         string
+	Err          error
+}
+
+// NewProviderError creates a provider error for a node pool
+func NewProviderError(reason ProviderErrorReason, nodePool string, err error) *ProviderError {
+	return &ProviderError{Reason: reason, NodePool: nodePool, Err: err}
+}
+
+func (e *ProviderError) Error() string {
+	if e.Err != nil {
+		return e.Err.Error()
+	}
+	return fmt.Sprintf("node pool %s: %s", e.NodePool, e.Reason)
+}
+
+func (e *ProviderError) Unwrap() error {
+	return e.Err
+}
+
+// AsProviderError returns the provider error in err's chain, if any
+func AsProviderError(err error) (*ProviderError, bool) {
+	var providerErr *ProviderError
+	if errors.As(err, &providerErr) {
+		return providerErr, true
+	}
+	return nil, false
+}
+
+// IsInsufficientCapacity reports whether the cloud had no capacity for the requested nodes
+func IsInsufficientCapacity(err error) bool {
+	return hasProviderErrorReason(err, ProviderErrorInsufficientCapacity)
+}
+
+// IsQuotaExceeded reports whether the requested nodes would exceed the account's quota
+func IsQuotaExceeded(err error) bool {
+	return hasProviderErrorReason(err, ProviderErrorQuotaExceeded)
+}
+
+// IsSpotUnavailable reports whether no spot capacity was available for the requested nodes
+func IsSpotUnavailable(err error) bool {
+	return hasProviderErrorReason(err, ProviderErrorSpotUnavailable)
+}
+
+// IsPoolAtMaxSize reports whether the node pool could not grow any further
+func IsPoolAtMaxSize(err error) bool {
+	return hasProviderErrorReason(err, ProviderErrorPoolAtMaxSize)
+}
+
+func hasProviderErrorReason(err error, reason ProviderErrorReason) bool {
+	providerErr, ok := AsProviderError(err)
+	return ok && providerErr.Reason == reason
+}
+
+// providerErrorCodes maps the error codes cloud APIs return for unavailable capacity to provider error reasons
+var providerErrorCodes = []struct {
+	code   string
+	reason ProviderErrorReason
+}{
+	// AWS
+	{"InsufficientInstanceCapacity", ProviderErrorInsufficientCapacity},
+	{"InstanceLimitExceeded", ProviderErrorQuotaExceeded},
+	{"VcpuLimitExceeded", ProviderErrorQuotaExceeded},
+	{"MaxSpotInstanceCountExceeded", ProviderErrorSpotUnavailable},
+	{"SpotMaxPriceTooLow", ProviderErrorSpotUnavailable},
+	{"capacity-not-available", ProviderErrorSpotUnavailable},
+	// GCP
+	{"ZONE_RESOURCE_POOL_EXHAUSTED", ProviderErrorInsufficientCapacity},
+	{"ZONE_RESOURCE_POOL_EXHAUSTED_WITH_DETAILS", ProviderErrorInsufficientCapacity},
+	{"QUOTA_EXCEEDED", ProviderErrorQuotaExceeded},
+	// Azure
+	{"AllocationFailed", ProviderErrorInsufficientCapacity},
+	{"ZonalAllocationFailed", ProviderErrorInsufficientCapacity},
+	{"OverconstrainedAllocationRequest", ProviderErrorInsufficientCapacity},
+	{"OverconstrainedZonalAllocationRequest", ProviderErrorInsufficientCapacity},
+	{"SkuNotAvailable", ProviderErrorInsufficientCapacity},
+	{"QuotaExceeded", ProviderErrorQuotaExceeded},
+}
+
+// providerErrorMessages maps phrases of capacity errors that carry no error code, such as the status
+// messages of failed AWS Auto Scaling launch activities, to provider error reasons
+var providerErrorMessages = []struct {
+	phrase string
+	reason ProviderErrorReason
+}{
+	{"do not have sufficient", ProviderErrorInsufficientCapacity},
+	{"requested more vCPU capacity", ProviderErrorQuotaExceeded},
+}
+
+// cloudAPIError is implemented by the errors the cloud clients decode from API error responses
+type cloudAPIError interface {
+	error
+	ErrorCode() string
+}
+
+// providerErrorFromCode wraps a cloud API error in a ProviderError when its error code reports unavailable capacity.
+// The node pool is filled in by classifyProviderError.
+func providerErrorFromCode(err cloudAPIError) error {
+	if reason, ok := providerErrorReasonForCode(err.ErrorCode()); ok {
+		return &ProviderError{Reason: reason, Err: err}
+	}
+	return err
+}
+
+func providerErrorReasonForCode(code string) (ProviderErrorReason, bool) {
+	for _, c := range providerErrorCodes {
+		if c.code == code {
+			return c.reason, true
+		}
+	}
+	return "", false
+}
+
+// classifyProviderError attaches the node pool to a ProviderError returned by a cloud client. Errors without
+// a ProviderError or an API error code are matched against known error codes and messages as a last resort.
+// Capacity errors of spot pools are reported as SpotUnavailable. Other errors are returned unchanged.
+func classifyProviderError(nodePool *NodePoolConfig, err error) error {
+	if err == nil {
+		return nil
+	}
+	if providerErr, ok := AsProviderError(err); ok {
+		if providerErr.NodePool != "" {
+			return err
+		}
+		return newNodePoolProviderError(nodePool, providerErr.Reason, err)
+	}
+
+	// An API error code that is not a capacity error code is authoritative, whatever its message mentions
+	var apiErr cloudAPIError
+	if errors.As(err, &apiErr) {
+		return err
+	}
+
+	message := err.Error()
+	for _, code := range providerErrorCodes {
+		if strings.Contains(message, code.code) {
+			return newNodePoolProviderError(nodePool, code.reason, err)
+		}
+	}
+	for _, m := range providerErrorMessages {
+		if strings.Contains(message, m.phrase) {
+			return newNodePoolProviderError(nodePool, m.reason, err)
+		}
+	}
+
+	return err
+}
+
+// newNodePoolProviderError creates a provider error for a node pool, converting capacity errors of spot pools
+func newNodePoolProviderError(nodePool *NodePoolConfig, reason ProviderErrorReason, err error) *ProviderError {
+	providerErr := NewProviderError(reason, nodePool.Name, err)
+	if providerErr.Reason == ProviderErrorInsufficientCapacity && nodePool.CapacityType == CapacityTypeSpot {
+		providerErr.Reason = ProviderErrorSpotUnavailable
+	}
+	// A pool narrowed to a single instance type or zone identifies the capacity that failed
+	if len(nodePool.InstanceTypes) == 1 {
+		providerErr.InstanceType = nodePool.InstanceTypes[0]
+	}
+	if len(nodePool.AvailabilityZones) == 1 {
+		providerErr.Zone = nodePool.AvailabilityZones[0]
+	}
+	return providerErr
+}
@@ -0,0 +1,252 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+// DefaultProvisioningTimeout is how long requested nodes may take to register before the request fails
+const DefaultProvisioningTimeout = 15 * time.Minute
+
+// trackProvisioning matches the nodes that joined the cluster against the policy's provisioning requests.
+// Each request claims the nodes of its pool that registered after it was made, oldest request first.
+// Fulfilled requests are dropped; requests whose nodes did not all register within the provisioning
+// timeout, or whose launch the cloud provider reports as failed, are dropped and reported as failed.
+// The nodes still outstanding are kept in the scope so they count toward the policy's capacity.
+func (r *AutoscalerController) trackProvisioning(ctx context.Context, scope *scalingScope) {
+	status := &scope.policy.Status
+	scope.provisioning = make(map[string]int)
+	if len(status.ProvisioningRequests) == 0 {
+		return
+	}
+
+	requests := append([]v1alpha1.ProvisioningRequest(nil), status.ProvisioningRequests...)
+	sort.SliceStable(requests, func(i, j int) bool {
+		return requests[i].RequestTime.Before(&requests[j].RequestTime)
+	})
+
+	nodes := make([]*corev1.Node, len(scope.nodes))
+	for i := range scope.nodes {
+		nodes[i] = &scope.nodes[i]
+	}
+	registered := make(map[string]metav1.Time, len(nodes))
+	for _, node := range nodes {
+		registered[node.Name] = nodeRegisteredAt(node)
+	}
+	sort.SliceStable(nodes, func(i, j int) bool {
+		a, b := registered[nodes[i].Name], registered[nodes[j].Name]
+		return a.Before(&b)
+	})
+
+	now := r.now()
+	claimed := make(map[string]bool)
+	outstanding := make([]v1alpha1.ProvisioningRequest, 0, len(requests))
+	for _, request := range requests {
+		request.RegisteredNodes = 0
+		for _, node := range nodes {
+			if request.RegisteredNodes >= request.Count {
+				break
+			}
+			registeredAt := registered[node.Name]
+			if claimed[node.Name] || registeredAt.Before(&request.RequestTime) || !scope.nodeInNodePool(node, request.NodePool) {
+				continue
+			}
+			claimed[node.Name] = true
+			request.RegisteredNodes++
+		}
+
+		switch {
+		case request.RegisteredNodes >= request.Count:
+			r.Log.Info("requested nodes registered", "policy", scope.policy.Name, "nodePool", request.NodePool, "count", request.Count)
+		case now.Sub(request.RequestTime.Time) > scope.config.ProvisioningTimeout:
+			message := fmt.Sprintf("%d of %d nodes requested from node pool %s at %s did not register within %s",
+				request.Count-request.RegisteredNodes, request.Count, request.NodePool,
+				request.RequestTime.UTC().Format(time.RFC3339), scope.config.ProvisioningTimeout)
+			scope.provisioningFailures = append(scope.provisioningFailures, message)
+			r.Log.Info("provisioning request timed out", "policy", scope.policy.Name, "nodePool", request.NodePool, "reason", message)
+			if r.Recorder != nil {
+				r.Recorder.Event(scope.policy, corev1.EventTypeWarning, ReasonProvisioningTimedOut, message)
+			}
+		default:
+			if r.launchFailed(ctx, scope, request) {
+				continue
+			}
+			outstanding = append(outstanding, request)
+			scope.provisioning[request.NodePool] += int(request.Count - request.RegisteredNodes)
+		}
+	}
+
+	status.ProvisioningRequests = outstanding
+}
+
+// launchFailed asks the cloud provider whether the launch of a request's missing nodes failed. A failed
+// request is reported and its capacity backed off, so the next scale-up falls back to other capacity.
+func (r *AutoscalerController) launchFailed(ctx context.Context, scope *scalingScope, request v1alpha1.ProvisioningRequest) bool {
+	pool := r.getNodePoolByName(scope, request.NodePool)
+	if pool == nil || r.CloudProvider == nil {
+		return false
+	}
+
+	attempt := narrowAttempt(pool, request.InstanceType, request.Zone)
+	missing := int(request.Count - request.RegisteredNodes)
+	providerErr, err := r.CloudProvider.CheckScaleUp(ctx, &attempt.pool, request.RequestTime.Time, missing)
+	if err != nil {
+		r.Log.Error(err, "failed to check provisioning request", "policy", scope.policy.Name, "nodePool", request.NodePool)
+		return false
+	}
+	if providerErr == nil {
+		return false
+	}
+
+	message := fmt.Sprintf("%d of %d nodes requested from node pool %s at %s failed to launch: %v",
+		missing, request.Count, request.NodePool, request.RequestTime.UTC().Format(time.RFC3339), providerErr)
+	scope.provisioningFailures = append(scope.provisioningFailures, message)
+	scope.launchFailed = true
+	r.Log.Info("provisioning request failed", "policy", scope.policy.Name, "nodePool", request.NodePool, "reason", message)
+	r.backOffCapacity(scope, attempt, providerErr)
+	return true
+}
+
+// startProvisioning records a request for nodes of a pool and persists it in the policy status
+// before the cloud provider is called, so a controller restarted while the nodes boot does not
+// request them again
+func (r *AutoscalerController) startProvisioning(ctx context.Context, scope *scalingScope, attempt scaleUpAttempt, capacityType string, count int) error {
+	status := &scope.policy.Status
+	status.ProvisioningRequests = append(status.ProvisioningRequests, v1alpha1.ProvisioningRequest{
+		NodePool:     attempt.pool.Name,
+		CapacityType: capacityType,
+		InstanceType: attempt.instanceType,
+		Zone:         attempt.zone,
+		Count:        int32(count),
+		// The API server stores timestamps with second precision
+		RequestTime: metav1.NewTime(r.now().Truncate(time.Second)),
+	})
+
+	if err := r.Status().Update(ctx, scope.policy); err != nil {
+		status.ProvisioningRequests = status.ProvisioningRequests[:len(status.ProvisioningRequests)-1]
+		return fmt.Errorf("failed to record provisioning request: %w", err)
+	}
+
+	if scope.provisioning == nil {
+		scope.provisioning = make(map[string]int)
+	}
+	scope.provisioning[attempt.pool.Name] += count
+	return nil
+}
+
+// cancelProvisioning drops the most recent provisioning request after the cloud provider refused it
+func (r *AutoscalerController) cancelProvisioning(scope *scalingScope) {
+	status := &scope.policy.Status
+	if len(status.ProvisioningRequests) == 0 {
+		return
+	}
+	request := status.ProvisioningRequests[len(status.ProvisioningRequests)-1]
+	status.ProvisioningRequests = status.ProvisioningRequests[:len(status.ProvisioningRequests)-1]
+	scope.provisioning[request.NodePool] -= int(request.Count)
+	if scope.provisioning[request.NodePool] <= 0 {
+		delete(scope.provisioning, request.NodePool)
+	}
+}
+
+// provisioningNodeCount returns the requested nodes that have not registered yet
+func (s *scalingScope) provisioningNodeCount() int {
+	count := 0
+	for _, nodes := range s.provisioning {
+		count += nodes
+	}
+	return count
+}
+
+// nodePoolMember is the node pool the cloud provider placed a node in
+type nodePoolMember struct {
+	uid      types.UID
+	nodePool string
+}
+
+// resolveNodePools places each of the policy's nodes in a node pool. Nodes are matched on the
+// node-pool label, then on the labels of the pools that set them. ASG, MIG, VMSS, MachineDeployment
+// and Redfish nodes carry neither, so the rest are looked up through the cloud provider; its answers
+// are cached until the Node object is replaced.
+func (r *AutoscalerController) resolveNodePools(ctx context.Context, scope *scalingScope) {
+	scope.nodePools = make(map[string]string, len(scope.nodes))
+	scope.unresolvedNodes = 0
+	members := make(map[string]nodePoolMember, len(scope.nodes))
+	for i := range scope.nodes {
+		node := &scope.nodes[i]
+		if name, ok := labeledNodePool(node, scope.config.NodePools); ok {
+			scope.nodePools[node.Name] = name
+			continue
+		}
+		if member, ok := scope.state.nodePools[node.Name]; ok && member.uid == node.UID {
+			members[node.Name] = member
+			scope.nodePools[node.Name] = member.nodePool
+			continue
+		}
+		if r.CloudProvider == nil {
+			continue
+		}
+
+		name, err := r.CloudProvider.GetNodePoolForNode(ctx, node.Name)
+		if err != nil {
+			r.Log.Error(err, "failed to look up node pool", "node", node.Name)
+			scope.unresolvedNodes++
+			continue
+		}
+		members[node.Name] = nodePoolMember{uid: node.UID, nodePool: name}
+		scope.nodePools[node.Name] = name
+	}
+	scope.state.nodePools = members
+}
+
+// nodeInNodePool reports whether a node belongs to the named pool. Nodes that were not resolved
+// during this reconcile are matched on their labels only.
+func (s *scalingScope) nodeInNodePool(node *corev1.Node, poolName string) bool {
+	name, ok := s.nodePools[node.Name]
+	if !ok {
+		name, _ = labeledNodePool(node, s.config.NodePools)
+	}
+	return name != "" && name == poolName
+}
+
+// labeledNodePool returns the pool a node's labels place it in: the node-pool label, or else the first
+// pool whose labels the node carries
+func labeledNodePool(node *corev1.Node, pools []NodePoolConfig) (string, bool) {
+	if name, ok := node.Labels[NodePoolLabel]; ok {
+		return name, true
+	}
+	for i := range pools {
+		if len(pools[i].Labels) > 0 && hasLabels(node, pools[i].Labels) {
+			return pools[i].Name, true
+		}
+	}
+	return "", false
+}
+
+// hasLabels reports whether a node carries all of the labels
+func hasLabels(node *corev1.Node, labels map[string]string) bool {
+	for key, value := range labels {
+		if node.Labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// nodeRegisteredAt returns when a node joined the cluster: when its Node object was created, or for
+// Redfish hosts that keep their Node object across power cycles, when the provider last powered it on.
+// Readiness is deliberately ignored, so an old node recovering from NotReady never looks new.
+func nodeRegisteredAt(node *corev1.Node) metav1.Time {
+	registered := node.CreationTimestamp
+	if poweredOn, err := time.Parse(time.RFC3339, node.Annotations[RedfishPoweredOnAnnotation]); err == nil && registered.Time.Before(poweredOn) {
+		registered = metav1.NewTime(poweredOn)
+	}
+	return registered
+}
@@ -0,0 +1,314 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+func newProvisioningTestPolicy(requests ...v1alpha1.ProvisioningRequest) *v1alpha1.AutoscalingPolicy {
+	return &v1alpha1.AutoscalingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "training"},
+		Spec: v1alpha1.AutoscalingPolicySpec{
+			Enabled:                    true,
+			MaxNodes:                   10,
+			ProvisioningTimeoutSeconds: 600,
+			NodePools:                  []v1alpha1.NodePoolSpec{{Name: "a100", CapacityType: CapacityTypeOnDemand}},
+		},
+		Status: v1alpha1.AutoscalingPolicyStatus{ProvisioningRequests: requests},
+	}
+}
+
+func newProvisioningTestPod(name string, created time.Time) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "ml", CreationTimestamp: metav1.NewTime(created)},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:      "trainer",
+				Resources: corev1.ResourceRequirements{Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+}
+
+func TestReconcileCountsProvisioningNodes(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	policy := newProvisioningTestPolicy()
+	pods := []*corev1.Pod{
+		newProvisioningTestPod("trainer-0", now.Add(-10*time.Minute)),
+		newProvisioningTestPod("trainer-1", now.Add(-10*time.Minute)),
+	}
+
+	controller, provider, _, k8sClient := newTestAutoscalerController(t, policy, pods[0], pods[1])
+	controller.Clock = func() time.Time { return now }
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+	if _, err := controller.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if provider.scaleUps != 2 {
+		t.Fatalf("Expected 2 nodes to be requested, got %d", provider.scaleUps)
+	}
+
+	// A restarted controller sees the request in the status and does not ask for the nodes again
+	restarted := NewAutoscalerController(k8sClient, controller.Scheme, nil, nil, provider, controller.Config)
+	restarted.Clock = func() time.Time { return now.Add(time.Minute) }
+	if _, err := restarted.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if provider.scaleUps != 2 {
+		t.Errorf("Expected no duplicate scale-up after a restart, got %d nodes requested", provider.scaleUps)
+	}
+
+	updated := &v1alpha1.AutoscalingPolicy{}
+	if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	requests := updated.Status.ProvisioningRequests
+	if len(requests) != 1 || requests[0].NodePool != "a100" || requests[0].Count != 2 || requests[0].RegisteredNodes != 0 {
+		t.Fatalf("Unexpected provisioning requests: %+v", requests)
+	}
+	if updated.Status.DesiredNodes != 2 {
+		t.Errorf("Expected the provisioning nodes in the desired count, got %d", updated.Status.DesiredNodes)
+	}
+	if condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeProvisioning); condition == nil || condition.Reason != ReasonNodesProvisioning {
+		t.Errorf("Expected a provisioning condition, got %+v", condition)
+	}
+
+	// The pods are scheduled once the nodes join, which fulfils the request
+	for i, pod := range pods {
+		node := newTestGPUNode(fmt.Sprintf("a100-%d", i), CapacityTypeOnDemand)
+		node.Labels[NodePoolLabel] = "a100"
+		node.CreationTimestamp = metav1.NewTime(now.Add(2 * time.Minute))
+		if err := k8sClient.Create(context.Background(), node); err != nil {
+			t.Fatalf("failed to create node: %v", err)
+		}
+		pod.Status.Phase = corev1.PodRunning
+		if err := k8sClient.Status().Update(context.Background(), pod); err != nil {
+			t.Fatalf("failed to update pod: %v", err)
+		}
+	}
+	restarted.Clock = func() time.Time { return now.Add(3 * time.Minute) }
+	if _, err := restarted.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	if len(updated.Status.ProvisioningRequests) != 0 {
+		t.Errorf("Expected the request to be fulfilled, got %+v", updated.Status.ProvisioningRequests)
+	}
+	if condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeProvisioning); condition == nil || condition.Reason != ReasonNodesRegistered {
+		t.Errorf("Expected the nodes to be reported registered, got %+v", condition)
+	}
+}
+
+func TestReconcileProvisioningTimeout(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	policy := newProvisioningTestPolicy(v1alpha1.ProvisioningRequest{
+		NodePool:    "a100",
+		Count:       2,
+		RequestTime: metav1.NewTime(now.Add(-15 * time.Minute)),
+	})
+	node := newTestGPUNode("a100-0", CapacityTypeOnDemand)
+	node.Labels[NodePoolLabel] = "a100"
+	node.CreationTimestamp = metav1.NewTime(now.Add(-10 * time.Minute))
+
+	controller, provider, recorder, k8sClient := newTestAutoscalerController(t, policy, node)
+	controller.Clock = func() time.Time { return now }
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+	if _, err := controller.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if provider.scaleUps != 0 {
+		t.Errorf("Expected no scale-up, got %d nodes requested", provider.scaleUps)
+	}
+
+	updated := &v1alpha1.AutoscalingPolicy{}
+	if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	if len(updated.Status.ProvisioningRequests) != 0 {
+		t.Errorf("Expected the timed-out request to be dropped, got %+v", updated.Status.ProvisioningRequests)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeProvisioning)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != ReasonProvisioningTimedOut {
+		t.Fatalf("Expected a timed-out provisioning condition, got %+v", condition)
+	}
+	if !strings.Contains(condition.Message, "1 of 2 nodes requested from node pool a100") {
+		t.Errorf("Unexpected condition message: %s", condition.Message)
+	}
+
+	select {
+	case event := <-recorder.Events:
+		if !strings.Contains(event, ReasonProvisioningTimedOut) {
+			t.Errorf("Unexpected event: %s", event)
+		}
+	default:
+		t.Error("Expected a provisioning timeout event")
+	}
+
+	// The failure stays reported on later reconciles
+	if _, err := controller.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	if condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeProvisioning); condition == nil || condition.Reason != ReasonProvisioningTimedOut {
+		t.Errorf("Expected the timeout to stay reported, got %+v", condition)
+	}
+}
+
+func TestTrackProvisioningMatchesUnlabeledNodes(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	requestTime := now.Add(-5 * time.Minute)
+	policy := newProvisioningTestPolicy(v1alpha1.ProvisioningRequest{
+		NodePool:    "a100",
+		Count:       2,
+		RequestTime: metav1.NewTime(requestTime),
+	})
+
+	// An instance launched by the ASG, a bare-metal host that kept its Node object and was powered back on,
+	// and an old node that just recovered from NotReady
+	launched := newTestGPUNode("ip-10-0-1-5", CapacityTypeOnDemand)
+	launched.UID = "launched"
+	launched.CreationTimestamp = metav1.NewTime(now.Add(-2 * time.Minute))
+	poweredOn := newTestGPUNode("dgx-01", CapacityTypeOnDemand)
+	poweredOn.UID = "powered-on"
+	poweredOn.CreationTimestamp = metav1.NewTime(now.Add(-30 * 24 * time.Hour))
+	poweredOn.Annotations = map[string]string{RedfishPoweredOnAnnotation: now.Add(-4 * time.Minute).UTC().Format(time.RFC3339)}
+	existing := newTestGPUNode("dgx-02", CapacityTypeOnDemand)
+	existing.UID = "existing"
+	existing.CreationTimestamp = metav1.NewTime(now.Add(-30 * 24 * time.Hour))
+	recovered := newTestGPUNode("ip-10-0-1-6", CapacityTypeOnDemand)
+	recovered.UID = "recovered"
+	recovered.CreationTimestamp = metav1.NewTime(now.Add(-30 * 24 * time.Hour))
+	recovered.Status.Conditions[0].LastTransitionTime = metav1.NewTime(now.Add(-time.Minute))
+
+	controller, provider, _, _ := newTestAutoscalerController(t)
+	controller.Clock = func() time.Time { return now }
+	provider.nodePools = map[string]string{"ip-10-0-1-5": "a100", "ip-10-0-1-6": "a100", "dgx-01": "a100", "dgx-02": "a100"}
+	scope := &scalingScope{
+		policy: policy,
+		config: ConfigFromPolicy(policy, controller.Config),
+		state:  &policyState{},
+		nodes:  []corev1.Node{*existing, *recovered, *launched, *poweredOn},
+	}
+
+	controller.resolveNodePools(context.Background(), scope)
+	controller.trackProvisioning(context.Background(), scope)
+
+	if len(policy.Status.ProvisioningRequests) != 0 {
+		t.Errorf("Expected the request to be fulfilled, got %+v", policy.Status.ProvisioningRequests)
+	}
+	if provider.nodePoolLookups != 4 {
+		t.Errorf("Expected 4 node pool lookups, got %d", provider.nodePoolLookups)
+	}
+
+	// A node that only turned Ready again after the request does not fulfil it
+	policy.Status.ProvisioningRequests = []v1alpha1.ProvisioningRequest{{NodePool: "a100", Count: 1, RequestTime: metav1.NewTime(now.Add(-90 * time.Second))}}
+	controller.trackProvisioning(context.Background(), scope)
+	if len(policy.Status.ProvisioningRequests) != 1 || scope.provisioning["a100"] != 1 {
+		t.Errorf("Expected the request to stay outstanding, got %+v", policy.Status.ProvisioningRequests)
+	}
+
+	// Provider answers are reused until the Node object is replaced
+	scope.nodes[0].UID = "replaced"
+	controller.resolveNodePools(context.Background(), scope)
+	if provider.nodePoolLookups != 5 {
+		t.Errorf("Expected only the replaced node to be looked up again, got %d lookups", provider.nodePoolLookups)
+	}
+}
+
+func TestNodeInNodePool(t *testing.T) {
+	labeled := newTestGPUNode("labeled", CapacityTypeOnDemand)
+	labeled.Labels[NodePoolLabel] = "a100"
+	matching := newTestGPUNode("matching", CapacityTypeOnDemand)
+	matching.Labels["cloud.google.com/gke-nodepool"] = "h100"
+	unlabeled := newTestGPUNode("unlabeled", CapacityTypeOnDemand)
+
+	scope := &scalingScope{
+		config: AutoscalerConfig{NodePools: []NodePoolConfig{
+			{Name: "a100"},
+			{Name: "h100", Labels: map[string]string{"cloud.google.com/gke-nodepool": "h100"}},
+		}},
+		nodePools: map[string]string{"unlabeled": "a100"},
+	}
+
+	tests := []struct {
+		node     *corev1.Node
+		poolName string
+		expected bool
+	}{
+		{labeled, "a100", true},
+		{labeled, "h100", false},
+		{matching, "h100", true},
+		{unlabeled, "a100", true},
+		{unlabeled, "h100", false},
+		{newTestGPUNode("unknown", CapacityTypeOnDemand), "a100", false},
+	}
+
+	for _, tt := range tests {
+		if got := scope.nodeInNodePool(tt.node, tt.poolName); got != tt.expected {
+			t.Errorf("nodeInNodePool(%s, %s) = %v, expected %v", tt.node.Name, tt.poolName, got, tt.expected)
+		}
+	}
+}
+
+func TestReconcileFallsBackAfterFailedLaunch(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	policy := newProvisioningTestPolicy(v1alpha1.ProvisioningRequest{
+		NodePool:    "a100",
+		Count:       1,
+		RequestTime: metav1.NewTime(now.Add(-2 * time.Minute)),
+	})
+	policy.Spec.NodePools[0].InstanceTypes = []string{"p4d.24xlarge", "p4de.24xlarge"}
+	pod := newProvisioningTestPod("trainer-0", now.Add(-10*time.Minute))
+
+	controller, provider, _, k8sClient := newTestAutoscalerController(t, policy, pod)
+	controller.Clock = func() time.Time { return now }
+	provider.launchFailure = &ProviderError{
+		Reason:       ProviderErrorInsufficientCapacity,
+		NodePool:     "a100",
+		InstanceType: "p4d.24xlarge",
+		Zone:         "us-east-1a",
+		Err:          fmt.Errorf("We currently do not have sufficient p4d.24xlarge capacity"),
+	}
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+	if _, err := controller.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// The failed request is dropped and the pending pod is requested again in the pool's other instance type
+	if provider.scaleUps != 1 {
+		t.Errorf("Expected 1 node to be requested again, got %d", provider.scaleUps)
+	}
+	updated := &v1alpha1.AutoscalingPolicy{}
+	if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	requests := updated.Status.ProvisioningRequests
+	if len(requests) != 1 || requests[0].InstanceType != "p4de.24xlarge" {
+		t.Errorf("Expected a request narrowed to p4de.24xlarge, got %+v", requests)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeProvisioning)
+	if condition == nil || condition.Reason != ReasonProvisioningFailed || !strings.Contains(condition.Message, "failed to launch") {
+		t.Errorf("Expected a failed provisioning condition, got %+v", condition)
+	}
+}
@@ -0,0 +1,474 @@
+package autoscaler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// Redfish ComputerSystem power states
+	RedfishPowerStateOn  = "On"
+	RedfishPowerStateOff = "Off"
+
+	// Redfish ComputerSystem.Reset types
+	RedfishResetOn               = "On"
+	RedfishResetGracefulShutdown = "GracefulShutdown"
+
+	// RedfishPoweredOffAnnotation marks nodes the Redfish provider cordoned before powering their host off.
+	// The autoscaler ignores these nodes until ScaleUp powers the host back on and removes the annotation.
+	RedfishPoweredOffAnnotation = "gpu-autoscaler.io/redfish-powered-off"
+
+	// RedfishPoweredOnAnnotation records when ScaleUp last powered a node's host on. Hosts keep their
+	// Node object across power cycles, so provisioning tracking counts the node as joining at that time.
+	RedfishPoweredOnAnnotation = "gpu-autoscaler.io/redfish-powered-on"
+
+	// DefaultRedfishSystemID is the ComputerSystem ID used when a host doesn't set one
+	DefaultRedfishSystemID = "1"
+
+	// Defaults for the Redfish provider's drain wait
+	DefaultRedfishDrainTimeout = 5 * time.Minute
+	DefaultRedfishPollInterval = 10 * time.Second
+)
+
+// RedfishHost is a bare-metal GPU host whose power is controlled through its BMC
+type RedfishHost struct {
+	// NodeName is the name the host registers with as a Kubernetes node
+	NodeName string `json:"nodeName"`
+
+	// Endpoint is the BMC base URL, e.g. https://10.0.10.21
+	Endpoint string `json:"endpoint"`
+
+	// SystemID is the Redfish ComputerSystem ID, "1" by default
+	SystemID string `json:"systemID,omitempty"`
+
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	InstanceType string `json:"instanceType,omitempty"`
+	GPUCount     int    `json:"gpuCount,omitempty"`
+	Zone         string `json:"zone,omitempty"`
+}
+
+// LoadRedfishInventory reads a YAML inventory mapping node pool names to their hosts
+func LoadRedfishInventory(path string) (map[string][]RedfishHost, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read Redfish inventory: %w", err)
+	}
+
+	inventory := make(map[string][]RedfishHost)
+	if err := yaml.Unmarshal(data, &inventory); err != nil {
+		return nil, fmt.Errorf("failed to parse Redfish inventory: %w", err)
+	}
+
+	for pool, hosts := range inventory {
+		for _, host := range hosts {
+			if host.NodeName == "" || host.Endpoint == "" {
+				return nil, fmt.Errorf("host in node pool %s needs a nodeName and an endpoint", pool)
+			}
+		}
+	}
+
+	return inventory, nil
+}
+
+// RedfishProviderConfig configures the RedfishProvider
+type RedfishProviderConfig struct {
+	// Inventory lists the hosts of each node pool
+	Inventory map[string][]RedfishHost
+
+	// HTTPClient talks to the BMCs; http.DefaultClient when nil
+	HTTPClient *http.Client
+
+	// DrainTimeout bounds how long ScaleDown waits for pods to leave a node before powering it off
+	DrainTimeout time.Duration
+
+	// PollInterval is how often drain progress is checked
+	PollInterval time.Duration
+
+	// Pricing supplies instance prices; owned hardware has none
+	Pricing CloudProvider
+}
+
+// RedfishProvider implements CloudProvider for on-prem GPU hosts that are powered on and off
+// through the Redfish API of their BMCs. Hosts keep their Kubernetes node object while powered off.
+type RedfishProvider struct {
+	client     client.Client
+	httpClient *http.Client
+	config     RedfishProviderConfig
+}
+
+// NewRedfishProvider creates a new Redfish provider
+func NewRedfishProvider(client client.Client, config RedfishProviderConfig) *RedfishProvider {
+	if config.DrainTimeout == 0 {
+		config.DrainTimeout = DefaultRedfishDrainTimeout
+	}
+	if config.PollInterval == 0 {
+		config.PollInterval = DefaultRedfishPollInterval
+	}
+
+	httpClient := config.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+
+	return &RedfishProvider{
+		client:     client,
+		httpClient: httpClient,
+		config:     config,
+	}
+}
+
+// ScaleUp powers on hosts of the node pool and uncordons their nodes. It doesn't wait for the hosts
+// to boot: the autoscaler tracks them as provisioning until their nodes report Ready, and the
+// node lifecycle controller keeps pods off them until then.
+func (p *RedfishProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
+	hosts := p.config.Inventory[nodePool.Name]
+	if len(hosts) == 0 {
+		return fmt.Errorf("no hosts in inventory for node pool %s", nodePool.Name)
+	}
+
+	maxSize := len(hosts)
+	if nodePool.MaxSize > 0 && nodePool.MaxSize < maxSize {
+		maxSize = nodePool.MaxSize
+	}
+
+	var poweredOff []RedfishHost
+	poweredOn := 0
+	for _, host := range hosts {
+		state, err := p.getPowerState(ctx, host)
+		if err != nil {
+			return err
+		}
+		if state == RedfishPowerStateOff {
+			poweredOff = append(poweredOff, host)
+		} else {
+			poweredOn++
+		}
+	}
+
+	if poweredOn >= maxSize || len(poweredOff) == 0 {
+		return NewProviderError(ProviderErrorPoolAtMaxSize, nodePool.Name,
+			fmt.Errorf("node pool %s is already at max size %d", nodePool.Name, poweredOn))
+	}
+
+	// Ensure new hosts don't exceed max size
+	if count > maxSize-poweredOn {
+		count = maxSize - poweredOn
+	}
+	if count > len(poweredOff) {
+		count = len(poweredOff)
+	}
+
+	for _, host := range poweredOff[:count] {
+		if err := p.reset(ctx, host, RedfishResetOn); err != nil {
+			return err
+		}
+		if err := p.markPoweredOn(ctx, host.NodeName); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetScaleUpOverrides returns no overrides; hosts are powered on from the pool's inventory in order
+func (p *RedfishProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{}
+}
+
+// CheckScaleUp returns nil; power-on errors are returned by ScaleUp
+func (p *RedfishProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	return nil, nil
+}
+
+// ScaleDown cordons and drains a node, then gracefully shuts its host down
+func (p *RedfishProvider) ScaleDown(ctx context.Context, nodeName string) error {
+	host, ok := p.getHostForNode(nodeName)
+	if !ok {
+		return fmt.Errorf("no host in inventory for node %s", nodeName)
+	}
+
+	if err := p.cordonAndDrain(ctx, nodeName); err != nil {
+		return err
+	}
+
+	if err := p.reset(ctx, host, RedfishResetGracefulShutdown); err != nil {
+		if uncordonErr := p.uncordon(ctx, nodeName); uncordonErr != nil {
+			log.FromContext(ctx).Error(uncordonErr, "failed to uncordon node after failed shutdown", "node", nodeName)
+		}
+		return err
+	}
+	return nil
+}
+
+// GetSpotTerminationNotice always reports no notice; owned hardware is never reclaimed
+func (p *RedfishProvider) GetSpotTerminationNotice(ctx context.Context, nodeName string) (time.Time, bool, error) {
+	return time.Time{}, false, nil
+}
+
+// GetSpotPrice returns the Pricing backend's spot rate, used when comparing reserved hosts with cloud capacity
+func (p *RedfishProvider) GetSpotPrice(ctx context.Context, instanceType string) (float64, error) {
+	if p.config.Pricing == nil {
+		return 0, fmt.Errorf("price not found for instance type: %s", instanceType)
+	}
+	return p.config.Pricing.GetSpotPrice(ctx, instanceType)
+}
+
+// GetOnDemandPrice returns the hourly rate charged back for a host, taken from the Pricing backend
+func (p *RedfishProvider) GetOnDemandPrice(ctx context.Context, instanceType string) (float64, error) {
+	if p.config.Pricing == nil {
+		return 0, fmt.Errorf("price not found for instance type: %s", instanceType)
+	}
+	return p.config.Pricing.GetOnDemandPrice(ctx, instanceType)
+}
+
+// GetRecommendedSpotInstanceTypes returns no instance types; bare-metal pools have no spot capacity
+func (p *RedfishProvider) GetRecommendedSpotInstanceTypes(ctx context.Context) ([]string, error) {
+	return nil, nil
+}
+
+// GetAvailabilityZones returns the zones of the inventory's hosts
+func (p *RedfishProvider) GetAvailabilityZones(ctx context.Context) ([]string, error) {
+	seen := make(map[string]bool)
+	var zones []string
+	for _, hosts := range p.config.Inventory {
+		for _, host := range hosts {
+			if host.Zone != "" && !seen[host.Zone] {
+				seen[host.Zone] = true
+				zones = append(zones, host.Zone)
+			}
+		}
+	}
+	sort.Strings(zones)
+
+	return zones, nil
+}
+
+// GetNodePoolInfo returns information about a node pool's hosts; powered-on hosts count towards its size
+func (p *RedfishProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
+	hosts := p.config.Inventory[nodePoolName]
+	if len(hosts) == 0 {
+		return nil, fmt.Errorf("no hosts in inventory for node pool %s", nodePoolName)
+	}
+
+	info := &NodePoolInfo{
+		Name:         nodePoolName,
+		MaxSize:      len(hosts),
+		InstanceType: hosts[0].InstanceType,
+		CapacityType: CapacityTypeReserved,
+		GPUsPerNode:  hosts[0].GPUCount,
+	}
+
+	for _, host := range hosts {
+		state, err := p.getPowerState(ctx, host)
+		if err != nil {
+			return nil, err
+		}
+		if state != RedfishPowerStateOff {
+			info.CurrentSize++
+			info.AvailableGPUs += host.GPUCount
+		}
+	}
+
+	// Powered-off hosts draw no power and are not charged; powered-on hosts are charged at the on-demand rate
+	if info.InstanceType != "" {
+		if price, err := p.GetOnDemandPrice(ctx, info.InstanceType); err == nil {
+			info.Cost = price * float64(info.CurrentSize)
+		}
+	}
+
+	return info, nil
+}
+
+// GetNodePoolForNode returns the pool whose inventory lists the node's host
+func (p *RedfishProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	for poolName, hosts := range p.config.Inventory {
+		for _, host := range hosts {
+			if host.NodeName == nodeName {
+				return poolName, nil
+			}
+		}
+	}
+	return "", nil
+}
+
+// Helper methods
+
+func (p *RedfishProvider) getHostForNode(nodeName string) (RedfishHost, bool) {
+	for _, hosts := range p.config.Inventory {
+		for _, host := range hosts {
+			if host.NodeName == nodeName {
+				return host, true
+			}
+		}
+	}
+	return RedfishHost{}, false
+}
+
+// redfishSystemURL returns the URL of the host's Redfish ComputerSystem resource
+func redfishSystemURL(host RedfishHost) string {
+	systemID := host.SystemID
+	if systemID == "" {
+		systemID = DefaultRedfishSystemID
+	}
+	return fmt.Sprintf("%s/redfish/v1/Systems/%s", strings.TrimSuffix(host.Endpoint, "/"), systemID)
+}
+
+func (p *RedfishProvider) getPowerState(ctx context.Context, host RedfishHost) (string, error) {
+	var system struct {
+		PowerState string `json:"PowerState"`
+	}
+	if err := p.do(ctx, host, http.MethodGet, redfishSystemURL(host), nil, &system); err != nil {
+		return "", fmt.Errorf("failed to get power state of %s: %w", host.NodeName, err)
+	}
+	return system.PowerState, nil
+}
+
+func (p *RedfishProvider) reset(ctx context.Context, host RedfishHost, resetType string) error {
+	body := map[string]string{"ResetType": resetType}
+	if err := p.do(ctx, host, http.MethodPost, redfishSystemURL(host)+"/Actions/ComputerSystem.Reset", body, nil); err != nil {
+		return fmt.Errorf("failed to reset %s with %s: %w", host.NodeName, resetType, err)
+	}
+	return nil
+}
+
+// do sends a Redfish request with the host's BMC credentials and decodes the response into out
+func (p *RedfishProvider) do(ctx context.Context, host RedfishHost, method, url string, in, out interface{}) error {
+	var body io.Reader
+	if in != nil {
+		data, err := json.Marshal(in)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if host.Username != "" {
+		req.SetBasicAuth(host.Username, host.Password)
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%s %s returned %s", method, url, resp.Status)
+	}
+
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// uncordon undoes the cordon applied when the node's host was powered off.
+// A host that has never joined the cluster has no node yet.
+func (p *RedfishProvider) uncordon(ctx context.Context, nodeName string) error {
+	node := &corev1.Node{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+	if _, cordoned := node.Annotations[RedfishPoweredOffAnnotation]; !cordoned {
+		return nil
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = false
+	delete(node.Annotations, RedfishPoweredOffAnnotation)
+	if err := p.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to uncordon node %s: %w", node.Name, err)
+	}
+	return nil
+}
+
+// markPoweredOn uncordons the node of a host that was just powered on and records the power-on time.
+// A host that has never joined the cluster has no node yet.
+func (p *RedfishProvider) markPoweredOn(ctx context.Context, nodeName string) error {
+	node := &corev1.Node{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	if _, cordoned := node.Annotations[RedfishPoweredOffAnnotation]; cordoned {
+		node.Spec.Unschedulable = false
+		delete(node.Annotations, RedfishPoweredOffAnnotation)
+	}
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[RedfishPoweredOnAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := p.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to mark node %s powered on: %w", node.Name, err)
+	}
+	return nil
+}
+
+// cordonAndDrain marks the node unschedulable and evicts its pods through the Eviction API,
+// waiting until only DaemonSet, mirror and finished pods remain. Nodes running a pod that must not
+// be evicted are left untouched, and a drain that times out uncordons the node again.
+func (p *RedfishProvider) cordonAndDrain(ctx context.Context, nodeName string) error {
+	node := &corev1.Node{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return fmt.Errorf("failed to get node %s: %w", nodeName, err)
+	}
+
+	drainer := NewDrainer(p.client, log.FromContext(ctx))
+	drainer.Timeout = p.config.DrainTimeout
+	drainer.PollInterval = p.config.PollInterval
+	if err := drainer.CheckDrainable(ctx, nodeName); err != nil {
+		return err
+	}
+
+	patch := client.MergeFrom(node.DeepCopy())
+	node.Spec.Unschedulable = true
+	if node.Annotations == nil {
+		node.Annotations = map[string]string{}
+	}
+	node.Annotations[RedfishPoweredOffAnnotation] = time.Now().UTC().Format(time.RFC3339)
+	if err := p.client.Patch(ctx, node, patch); err != nil {
+		return fmt.Errorf("failed to mark node unschedulable: %w", err)
+	}
+
+	if err := drainer.EvictAndWait(ctx, nodeName); err != nil {
+		if uncordonErr := p.uncordon(ctx, nodeName); uncordonErr != nil {
+			log.FromContext(ctx).Error(uncordonErr, "failed to uncordon node after aborted drain", "node", nodeName)
+		}
+		return err
+	}
+	return nil
+}
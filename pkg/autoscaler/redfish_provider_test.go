@@ -0,0 +1,340 @@
+package autoscaler
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+)
+
+// fakeRedfishServer is a BMC serving the Redfish ComputerSystem power endpoints
+type fakeRedfishServer struct {
+	*httptest.Server
+
+	mu         sync.Mutex
+	powerState map[string]string // system ID -> power state
+	resets     []string          // system ID/reset type
+}
+
+func newFakeRedfishServer(t *testing.T, powerState map[string]string) *fakeRedfishServer {
+	f := &fakeRedfishServer{powerState: powerState}
+	f.Server = httptest.NewServer(http.HandlerFunc(f.handle))
+	t.Cleanup(f.Close)
+	return f
+}
+
+func (f *fakeRedfishServer) handle(w http.ResponseWriter, r *http.Request) {
+	if username, password, ok := r.BasicAuth(); !ok || username != "admin" || password != "secret" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/redfish/v1/Systems/")
+	systemID, action, _ := strings.Cut(path, "/")
+
+	f.mu.Lock()
+	state, ok := f.powerState[systemID]
+	if !ok {
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch {
+	case r.Method == http.MethodGet && action == "":
+		f.mu.Unlock()
+		json.NewEncoder(w).Encode(map[string]string{"Id": systemID, "PowerState": state})
+	case r.Method == http.MethodPost && action == "Actions/ComputerSystem.Reset":
+		var body struct{ ResetType string }
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			f.mu.Unlock()
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		f.resets = append(f.resets, systemID+"/"+body.ResetType)
+		if body.ResetType == RedfishResetOn {
+			f.powerState[systemID] = RedfishPowerStateOn
+		} else {
+			f.powerState[systemID] = RedfishPowerStateOff
+		}
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		f.mu.Unlock()
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func newRedfishTestNode(name string, ready bool) *corev1.Node {
+	status := corev1.ConditionFalse
+	if ready {
+		status = corev1.ConditionTrue
+	}
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Status: corev1.NodeStatus{
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: status}},
+		},
+	}
+}
+
+// newRedfishTestHosts returns the dgx pool's three hosts, served by one BMC endpoint
+func newRedfishTestHosts(endpoint string) map[string][]RedfishHost {
+	var hosts []RedfishHost
+	for _, systemID := range []string{"1", "2", "3"} {
+		hosts = append(hosts, RedfishHost{
+			NodeName:     "dgx-0" + systemID,
+			Endpoint:     endpoint,
+			SystemID:     systemID,
+			Username:     "admin",
+			Password:     "secret",
+			InstanceType: "dgx-a100",
+			GPUCount:     8,
+			Zone:         "rack-1",
+		})
+	}
+	return map[string][]RedfishHost{"dgx": hosts}
+}
+
+func TestRedfishProviderScaleUp(t *testing.T) {
+	cordoned := newRedfishTestNode("dgx-02", false)
+	cordoned.Spec.Unschedulable = true
+	cordoned.Annotations = map[string]string{RedfishPoweredOffAnnotation: "2024-01-01T12:00:00Z"}
+
+	tests := []struct {
+		name            string
+		powerState      map[string]string
+		nodes           []client.Object
+		maxSize         int
+		count           int
+		expectAtMaxSize bool
+		expectedResets  []string
+	}{
+		{
+			name:           "Scale up is capped at the pool's max size",
+			powerState:     map[string]string{"1": RedfishPowerStateOn, "2": RedfishPowerStateOff, "3": RedfishPowerStateOff},
+			nodes:          []client.Object{newRedfishTestNode("dgx-01", true), cordoned},
+			maxSize:        2,
+			count:          2,
+			expectedResets: []string{"2/" + RedfishResetOn},
+		},
+		{
+			name:           "Scale up of a host that has never joined the cluster",
+			powerState:     map[string]string{"1": RedfishPowerStateOff, "2": RedfishPowerStateOff, "3": RedfishPowerStateOff},
+			count:          1,
+			expectedResets: []string{"1/" + RedfishResetOn},
+		},
+		{
+			name:            "Scale up at max size",
+			powerState:      map[string]string{"1": RedfishPowerStateOn, "2": RedfishPowerStateOn, "3": RedfishPowerStateOff},
+			nodes:           []client.Object{newRedfishTestNode("dgx-01", true), newRedfishTestNode("dgx-02", true)},
+			maxSize:         2,
+			count:           1,
+			expectAtMaxSize: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := newFakeRedfishServer(t, tt.powerState)
+			var objects []client.Object
+			for _, node := range tt.nodes {
+				objects = append(objects, node.DeepCopyObject().(client.Object))
+			}
+			k8sClient := fake.NewClientBuilder().WithObjects(objects...).Build()
+			provider := NewRedfishProvider(k8sClient, RedfishProviderConfig{
+				Inventory:  newRedfishTestHosts(server.URL),
+				HTTPClient: server.Client(),
+			})
+
+			// ScaleUp returns once the hosts are powered on, while their nodes are still NotReady
+			err := provider.ScaleUp(context.Background(), &NodePoolConfig{Name: "dgx", MaxSize: tt.maxSize}, tt.count)
+			if tt.expectAtMaxSize {
+				if !IsPoolAtMaxSize(err) {
+					t.Errorf("Expected a pool-at-max-size error, got %v", err)
+				}
+			} else if err != nil {
+				t.Fatalf("ScaleUp() error = %v", err)
+			}
+
+			if strings.Join(server.resets, ",") != strings.Join(tt.expectedResets, ",") {
+				t.Errorf("Expected resets %v, got %v", tt.expectedResets, server.resets)
+			}
+
+			nodeList := &corev1.NodeList{}
+			if err := k8sClient.List(context.Background(), nodeList); err != nil {
+				t.Fatalf("failed to list nodes: %v", err)
+			}
+			for _, node := range nodeList.Items {
+				if node.Spec.Unschedulable {
+					t.Errorf("Expected the powered-on node %s to be uncordoned", node.Name)
+				}
+				if _, ok := node.Annotations[RedfishPoweredOffAnnotation]; ok {
+					t.Errorf("Expected the powered-off annotation to be removed from %s", node.Name)
+				}
+				poweredOn := node.Name == "dgx-02" && len(tt.expectedResets) > 0
+				if _, ok := node.Annotations[RedfishPoweredOnAnnotation]; ok != poweredOn {
+					t.Errorf("Expected only the node of the powered-on host to record its power-on time, got %s on %s",
+						node.Annotations[RedfishPoweredOnAnnotation], node.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestRedfishProviderScaleDown(t *testing.T) {
+	daemonSetPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "dcgm-exporter-abcde",
+			Namespace:       "gpu-system",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "apps/v1", Kind: "DaemonSet", Name: "dcgm-exporter", UID: "1"}},
+		},
+		Spec: corev1.PodSpec{NodeName: "dgx-01"},
+	}
+	controller := true
+	trainingPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:            "training",
+			Namespace:       "ml",
+			OwnerReferences: []metav1.OwnerReference{{APIVersion: "batch/v1", Kind: "Job", Name: "training", UID: "2", Controller: &controller}},
+		},
+		Spec: corev1.PodSpec{NodeName: "dgx-01"},
+	}
+	unownedPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "debug-shell", Namespace: "ml"},
+		Spec:       corev1.PodSpec{NodeName: "dgx-02"},
+	}
+
+	server := newFakeRedfishServer(t, map[string]string{"1": RedfishPowerStateOn, "2": RedfishPowerStateOn, "3": RedfishPowerStateOff})
+	k8sClient := fake.NewClientBuilder().
+		WithObjects(newRedfishTestNode("dgx-01", true), newRedfishTestNode("dgx-02", true), daemonSetPod, trainingPod, unownedPod).
+		WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+			return []string{obj.(*corev1.Pod).Spec.NodeName}
+		}).
+		Build()
+	provider := NewRedfishProvider(k8sClient, RedfishProviderConfig{
+		Inventory:    newRedfishTestHosts(server.URL),
+		HTTPClient:   server.Client(),
+		DrainTimeout: time.Second,
+		PollInterval: 10 * time.Millisecond,
+	})
+
+	if err := provider.ScaleDown(context.Background(), "dgx-01"); err != nil {
+		t.Fatalf("ScaleDown() error = %v", err)
+	}
+
+	node := &corev1.Node{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "dgx-01"}, node); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if !node.Spec.Unschedulable {
+		t.Error("Expected the node to be cordoned")
+	}
+
+	podList := &corev1.PodList{}
+	if err := k8sClient.List(context.Background(), podList); err != nil {
+		t.Fatalf("failed to list pods: %v", err)
+	}
+	if len(podList.Items) != 2 {
+		t.Errorf("Expected only the DaemonSet pod and the unowned pod to remain, got %d pods", len(podList.Items))
+	}
+
+	if len(server.resets) != 1 || server.resets[0] != "1/"+RedfishResetGracefulShutdown {
+		t.Errorf("Expected host 1 to be shut down, got %v", server.resets)
+	}
+
+	// A pod no controller would recreate keeps its host powered on
+	if err := provider.ScaleDown(context.Background(), "dgx-02"); !IsDrainBlocked(err) {
+		t.Errorf("Expected a blocked drain, got %v", err)
+	}
+	if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: "dgx-02"}, node); err != nil {
+		t.Fatalf("failed to get node: %v", err)
+	}
+	if node.Spec.Unschedulable {
+		t.Error("Expected the node with the unowned pod to stay schedulable")
+	}
+	if len(server.resets) != 1 {
+		t.Errorf("Expected host 2 to stay powered on, got %v", server.resets)
+	}
+
+	if err := provider.ScaleDown(context.Background(), "unknown-node"); err == nil {
+		t.Error("Expected error for a node without a host")
+	}
+}
+
+func TestRedfishProviderGetNodePoolInfo(t *testing.T) {
+	server := newFakeRedfishServer(t, map[string]string{"1": RedfishPowerStateOn, "2": RedfishPowerStateOn, "3": RedfishPowerStateOff})
+	provider := NewRedfishProvider(fake.NewClientBuilder().Build(), RedfishProviderConfig{
+		Inventory:  newRedfishTestHosts(server.URL),
+		HTTPClient: server.Client(),
+	})
+
+	info, err := provider.GetNodePoolInfo(context.Background(), "dgx")
+	if err != nil {
+		t.Fatalf("GetNodePoolInfo() error = %v", err)
+	}
+	if info.CurrentSize != 2 || info.MaxSize != 3 || info.AvailableGPUs != 16 {
+		t.Errorf("Unexpected sizes: current=%d max=%d gpus=%d", info.CurrentSize, info.MaxSize, info.AvailableGPUs)
+	}
+	if info.InstanceType != "dgx-a100" || info.CapacityType != CapacityTypeReserved || info.Cost != 0 {
+		t.Errorf("Unexpected pool info: %+v", info)
+	}
+
+	if _, err := provider.GetNodePoolInfo(context.Background(), "missing"); err == nil {
+		t.Error("Expected error for unknown node pool")
+	}
+}
+
+func TestRedfishProviderGetNodePoolForNode(t *testing.T) {
+	provider := NewRedfishProvider(fake.NewClientBuilder().Build(), RedfishProviderConfig{
+		Inventory: newRedfishTestHosts("https://bmc.example.com"),
+	})
+
+	if pool, err := provider.GetNodePoolForNode(context.Background(), "dgx-02"); err != nil || pool != "dgx" {
+		t.Errorf("Expected dgx-02 in the dgx pool, got %q (error %v)", pool, err)
+	}
+	if pool, err := provider.GetNodePoolForNode(context.Background(), "cpu-01"); err != nil || pool != "" {
+		t.Errorf("Expected cpu-01 in no pool, got %q (error %v)", pool, err)
+	}
+}
+
+func TestLoadRedfishInventory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "inventory.yaml")
+	data := `dgx:
+- nodeName: dgx-01
+  endpoint: https://10.0.10.21
+  username: admin
+  password: secret
+  gpuCount: 8
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write inventory: %v", err)
+	}
+
+	inventory, err := LoadRedfishInventory(path)
+	if err != nil {
+		t.Fatalf("LoadRedfishInventory() error = %v", err)
+	}
+	hosts := inventory["dgx"]
+	if len(hosts) != 1 || hosts[0].NodeName != "dgx-01" || hosts[0].Endpoint != "https://10.0.10.21" || hosts[0].GPUCount != 8 {
+		t.Errorf("Unexpected inventory: %+v", inventory)
+	}
+
+	if err := os.WriteFile(path, []byte("dgx:\n- nodeName: dgx-01\n"), 0o600); err != nil {
+		t.Fatalf("failed to write inventory: %v", err)
+	}
+	if _, err := LoadRedfishInventory(path); err == nil {
+		t.Error("Expected error for a host without an endpoint")
+	}
+}
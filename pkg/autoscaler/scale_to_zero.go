@@ -0,0 +1,184 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+// trackNodePools reports the nodes of each node pool in the policy status. For pools with an idle timeout
+// it also records since when none of the pool's nodes has run a GPU pod, keeping the time from earlier
+// reconciles so the idle timeout survives controller restarts.
+func (r *AutoscalerController) trackNodePools(ctx context.Context, scope *scalingScope) error {
+	var busyNodes map[string]bool
+	for _, pool := range scope.config.NodePools {
+		if pool.IdleTimeout > 0 {
+			var err error
+			if busyNodes, err = r.getBusyGPUNodes(ctx); err != nil {
+				return err
+			}
+			break
+		}
+	}
+
+	idleSince := make(map[string]*metav1.Time, len(scope.policy.Status.NodePools))
+	for _, status := range scope.policy.Status.NodePools {
+		idleSince[status.Name] = status.IdleSince
+	}
+
+	now := metav1.NewTime(r.now().Truncate(time.Second))
+	statuses := make([]v1alpha1.NodePoolStatus, 0, len(scope.config.NodePools))
+	for i := range scope.config.NodePools {
+		pool := &scope.config.NodePools[i]
+		nodes := r.nodePoolNodes(scope, pool)
+		status := v1alpha1.NodePoolStatus{Name: pool.Name, Nodes: int32(len(nodes))}
+
+		if pool.IdleTimeout > 0 && len(nodes) > 0 && scope.provisioning[pool.Name] == 0 {
+			idle := true
+			for _, node := range nodes {
+				if busyNodes[node.Name] {
+					idle = false
+					break
+				}
+			}
+			if idle {
+				status.IdleSince = idleSince[pool.Name]
+				if status.IdleSince == nil {
+					status.IdleSince = now.DeepCopy()
+				}
+			}
+		}
+		statuses = append(statuses, status)
+	}
+
+	scope.policy.Status.NodePools = statuses
+	return nil
+}
+
+// getBusyGPUNodes returns the names of the nodes running GPU pods
+func (r *AutoscalerController) getBusyGPUNodes(ctx context.Context) (map[string]bool, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList); err != nil {
+		return nil, fmt.Errorf("failed to list pods: %w", err)
+	}
+
+	busyNodes := make(map[string]bool)
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Spec.NodeName == "" || pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if r.isGPUPod(pod) {
+			busyNodes[pod.Spec.NodeName] = true
+		}
+	}
+	return busyNodes, nil
+}
+
+// selectIdleNodePool returns a node pool that has run no GPU pods for its idle timeout, together with
+// the number of its nodes to drain. Pools are drained down to their MinSize without taking the policy
+// below MinNodes, and not while pending pods could still land on them.
+func (r *AutoscalerController) selectIdleNodePool(scope *scalingScope) (*NodePoolConfig, int) {
+	now := r.now()
+	if now.Sub(scope.state.lastScaleDownTime) < scope.config.ScaleDownCooldown {
+		return nil, 0
+	}
+
+	for _, status := range scope.policy.Status.NodePools {
+		pool := r.getNodePoolByName(scope, status.Name)
+		if pool == nil || status.IdleSince == nil || now.Sub(status.IdleSince.Time) < pool.IdleTimeout {
+			continue
+		}
+		if r.nodePoolHostsPendingPods(scope, pool) {
+			continue
+		}
+
+		count := int(status.Nodes) - pool.MinSize
+		if spare := len(scope.nodes) - scope.config.MinNodes; count > spare {
+			count = spare
+		}
+		if count > 0 {
+			return pool, count
+		}
+	}
+	return nil, 0
+}
+
+// scaledToZeroPoolFor returns a scale-to-zero node pool with no nodes that can host one of the
+// unschedulable pending pods, so the pool is woken up without waiting for the pending pod timeout
+func (r *AutoscalerController) scaledToZeroPoolFor(scope *scalingScope, pendingPods []corev1.Pod) *NodePoolConfig {
+	for i := range scope.config.NodePools {
+		pool := &scope.config.NodePools[i]
+		if pool.IdleTimeout == 0 || scope.provisioning[pool.Name] > 0 || len(r.nodePoolNodes(scope, pool)) > 0 {
+			continue
+		}
+		if r.nodePoolBackedOff(scope, pool) {
+			continue
+		}
+		for j := range pendingPods {
+			if podUnschedulable(&pendingPods[j]) && podFitsNodePool(&pendingPods[j], pool) {
+				return pool
+			}
+		}
+	}
+	return nil
+}
+
+// reportColdStart records an event on the pending pods a scale-up of a node pool with no nodes will host,
+// telling them they wait for the pool's first nodes to boot
+func (r *AutoscalerController) reportColdStart(scope *scalingScope, decision *ScalingDecision) {
+	pool := r.getNodePoolByName(scope, decision.NodePool)
+	if pool == nil || len(r.nodePoolNodes(scope, pool)) > 0 {
+		return
+	}
+
+	message := fmt.Sprintf("Node pool %s of autoscaling policy %s has no nodes; waiting for a cold start of %d new nodes",
+		pool.Name, scope.policy.Name, scope.provisioning[pool.Name])
+	for i := range scope.pendingPods {
+		pod := &scope.pendingPods[i]
+		if !podFitsNodePool(pod, pool) {
+			continue
+		}
+		r.Log.Info("pending pod waits for a cold start", "pod", client.ObjectKeyFromObject(pod), "nodePool", pool.Name)
+		if r.Recorder != nil {
+			r.Recorder.Event(pod, corev1.EventTypeNormal, ReasonWaitingForColdStart, message)
+		}
+	}
+}
+
+// nodePoolHostsPendingPods reports whether any pending pod could be hosted by the pool
+func (r *AutoscalerController) nodePoolHostsPendingPods(scope *scalingScope, pool *NodePoolConfig) bool {
+	for i := range scope.pendingPods {
+		if podFitsNodePool(&scope.pendingPods[i], pool) {
+			return true
+		}
+	}
+	return false
+}
+
+// nodePoolNodes returns the policy's nodes that belong to the pool
+func (r *AutoscalerController) nodePoolNodes(scope *scalingScope, pool *NodePoolConfig) []*corev1.Node {
+	var nodes []*corev1.Node
+	for i := range scope.nodes {
+		if scope.nodeInNodePool(&scope.nodes[i], pool.Name) {
+			nodes = append(nodes, &scope.nodes[i])
+		}
+	}
+	return nodes
+}
+
+// podUnschedulable reports whether the scheduler found no node for the pod
+func podUnschedulable(pod *corev1.Pod) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == corev1.PodScheduled {
+			return condition.Status == corev1.ConditionFalse && condition.Reason == corev1.PodReasonUnschedulable
+		}
+	}
+	return false
+}
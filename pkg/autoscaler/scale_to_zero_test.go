@@ -0,0 +1,141 @@
+package autoscaler
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+func newScaleToZeroTestPolicy() *v1alpha1.AutoscalingPolicy {
+	return &v1alpha1.AutoscalingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "training"},
+		Spec: v1alpha1.AutoscalingPolicySpec{
+			Enabled:  true,
+			MaxNodes: 10,
+			NodePools: []v1alpha1.NodePoolSpec{
+				{Name: "a100", CapacityType: CapacityTypeOnDemand, IdleTimeoutSeconds: 600},
+			},
+		},
+	}
+}
+
+func TestReconcileScalesIdleNodePoolToZero(t *testing.T) {
+	tests := []struct {
+		name               string
+		busy               bool
+		expectedScaleDowns int
+	}{
+		{name: "idle pool is drained after the idle timeout", expectedScaleDowns: 2},
+		{name: "pool running GPU pods is kept", busy: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().Truncate(time.Second)
+			objects := []client.Object{newScaleToZeroTestPolicy()}
+			for _, name := range []string{"a100-0", "a100-1"} {
+				node := newTestGPUNode(name, CapacityTypeOnDemand)
+				node.Labels[NodePoolLabel] = "a100"
+				objects = append(objects, node)
+			}
+			if tt.busy {
+				pod := newProvisioningTestPod("trainer-0", now.Add(-time.Hour))
+				pod.Spec.NodeName = "a100-1"
+				pod.Status.Phase = corev1.PodRunning
+				objects = append(objects, pod)
+			}
+
+			controller, provider, _, k8sClient := newTestAutoscalerController(t, objects...)
+			controller.Clock = func() time.Time { return now }
+			req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+			if _, err := controller.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if provider.scaleDowns != 0 {
+				t.Fatalf("Expected no scale-down before the idle timeout, got %d nodes removed", provider.scaleDowns)
+			}
+
+			updated := &v1alpha1.AutoscalingPolicy{}
+			if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("failed to get policy: %v", err)
+			}
+			pools := updated.Status.NodePools
+			if len(pools) != 1 || pools[0].Name != "a100" || pools[0].Nodes != 2 {
+				t.Fatalf("Unexpected node pool status: %+v", pools)
+			}
+			if idle := pools[0].IdleSince != nil; idle == tt.busy {
+				t.Errorf("Expected idle %v, got idle since %v", !tt.busy, pools[0].IdleSince)
+			}
+
+			// A restarted controller keeps counting the idle time from the status
+			restarted := NewAutoscalerController(k8sClient, controller.Scheme, nil, nil, provider, controller.Config)
+			restarted.Clock = func() time.Time { return now.Add(10 * time.Minute) }
+			if _, err := restarted.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if provider.scaleDowns != tt.expectedScaleDowns {
+				t.Errorf("Expected %d nodes removed, got %d", tt.expectedScaleDowns, provider.scaleDowns)
+			}
+		})
+	}
+}
+
+func TestReconcileWakesScaledToZeroPool(t *testing.T) {
+	tests := []struct {
+		name             string
+		unschedulable    bool
+		expectedScaleUps int
+	}{
+		{name: "unschedulable pod wakes the pool", unschedulable: true, expectedScaleUps: 1},
+		{name: "pod the scheduler has not looked at yet"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now := time.Now().Truncate(time.Second)
+			pod := newProvisioningTestPod("trainer-0", now)
+			if tt.unschedulable {
+				pod.Status.Conditions = []corev1.PodCondition{{
+					Type:   corev1.PodScheduled,
+					Status: corev1.ConditionFalse,
+					Reason: corev1.PodReasonUnschedulable,
+				}}
+			}
+
+			controller, provider, recorder, _ := newTestAutoscalerController(t, newScaleToZeroTestPolicy(), pod)
+			controller.Clock = func() time.Time { return now }
+
+			// A recent scale-up does not hold back waking the pool
+			scope := controller.newScalingScope(newScaleToZeroTestPolicy())
+			scope.state.lastScaleUpTime = now.Add(-time.Minute)
+
+			if _, err := controller.Reconcile(context.Background(), ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if provider.scaleUps != tt.expectedScaleUps {
+				t.Fatalf("Expected %d nodes requested, got %d", tt.expectedScaleUps, provider.scaleUps)
+			}
+			if tt.expectedScaleUps == 0 {
+				return
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if !strings.Contains(event, ReasonWaitingForColdStart) || !strings.Contains(event, "Node pool a100") {
+					t.Errorf("Unexpected event: %s", event)
+				}
+			default:
+				t.Error("Expected a cold start event on the pending pod")
+			}
+		})
+	}
+}
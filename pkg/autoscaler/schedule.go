@@ -0,0 +1,302 @@
+package autoscaler
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	// Embed the time zone database so schedule time zones resolve in minimal container images
+	_ "time/tzdata"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+var (
+	cronMonthNames   = []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}
+	cronWeekdayNames = []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}
+)
+
+// cronSchedule is a parsed five-field cron expression: minute, hour, day of month, month and day of week.
+// Each field is a bitset of the values it matches.
+type cronSchedule struct {
+	minutes  uint64
+	hours    uint64
+	days     uint64
+	months   uint64
+	weekdays uint64
+
+	// anyDay is set when the day of month or the day of week is "*", in which case a day must
+	// match both fields; otherwise it must match either, as in standard cron
+	anyDay bool
+}
+
+// parseCron parses a cron expression. Fields accept "*", values, ranges, lists and steps
+// (e.g. "*/15", "8-18", "MON-FRI", "1,15"); months and weekdays also accept their names.
+func parseCron(expr string) (*cronSchedule, error) {
+	fields := strings.Fields(expr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("cron expression %q must have 5 fields, got %d", expr, len(fields))
+	}
+
+	schedule := &cronSchedule{
+		anyDay: strings.HasPrefix(fields[2], "*") || strings.HasPrefix(fields[4], "*"),
+	}
+	for _, field := range []struct {
+		value    string
+		min, max int
+		names    []string
+		bits     *uint64
+	}{
+		{fields[0], 0, 59, nil, &schedule.minutes},
+		{fields[1], 0, 23, nil, &schedule.hours},
+		{fields[2], 1, 31, nil, &schedule.days},
+		{fields[3], 1, 12, cronMonthNames, &schedule.months},
+		{fields[4], 0, 7, cronWeekdayNames, &schedule.weekdays},
+	} {
+		bits, err := parseCronField(field.value, field.min, field.max, field.names)
+		if err != nil {
+			return nil, fmt.Errorf("invalid cron expression %q: %w", expr, err)
+		}
+		*field.bits = bits
+	}
+
+	// Both 0 and 7 are Sunday
+	if schedule.weekdays&(1<<7) != 0 {
+		schedule.weekdays |= 1
+	}
+
+	return schedule, nil
+}
+
+// parseCronField parses one field of a cron expression into a bitset
+func parseCronField(field string, min, max int, names []string) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(field, ",") {
+		valueRange, step, stepped := part, 1, false
+		if i := strings.Index(part, "/"); i >= 0 {
+			stepped = true
+			var err error
+			if step, err = strconv.Atoi(part[i+1:]); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step in %q", part)
+			}
+			valueRange = part[:i]
+		}
+
+		low, high := min, max
+		switch {
+		case valueRange == "*":
+		case strings.Contains(valueRange, "-"):
+			bounds := strings.SplitN(valueRange, "-", 2)
+			var err error
+			if low, err = parseCronValue(bounds[0], min, max, names); err != nil {
+				return 0, err
+			}
+			if high, err = parseCronValue(bounds[1], min, max, names); err != nil {
+				return 0, err
+			}
+			if low > high {
+				return 0, fmt.Errorf("invalid range %q", valueRange)
+			}
+		default:
+			var err error
+			if low, err = parseCronValue(valueRange, min, max, names); err != nil {
+				return 0, err
+			}
+			// "5/15" runs from 5 to the end of the range
+			if !stepped {
+				high = low
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << uint(value)
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a number or a name of a cron field
+func parseCronValue(value string, min, max int, names []string) (int, error) {
+	for i, name := range names {
+		if strings.EqualFold(value, name) {
+			return min + i, nil
+		}
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", value)
+	}
+	if number < min || number > max {
+		return 0, fmt.Errorf("value %d out of range %d-%d", number, min, max)
+	}
+	return number, nil
+}
+
+// next returns the first minute after t matching the schedule, in t's location,
+// or the zero time if none occurs within five years
+func (s *cronSchedule) next(t time.Time) time.Time {
+	loc := t.Location()
+	t = t.Truncate(time.Minute).Add(time.Minute)
+
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, loc)
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, loc)
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, loc)
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *cronSchedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekdays&(1<<uint(t.Weekday())) != 0
+	if s.anyDay {
+		return day && weekday
+	}
+	return day || weekday
+}
+
+// capacityWindowAt reports whether a capacity window is open at now, and when it next opens or closes.
+// A window is open when its end comes before its next start.
+func capacityWindowAt(schedule *v1alpha1.CapacitySchedule, now time.Time) (bool, time.Time, error) {
+	location, err := time.LoadLocation(schedule.Timezone)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid timezone %q: %w", schedule.Timezone, err)
+	}
+	start, err := parseCron(schedule.Start)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := parseCron(schedule.End)
+	if err != nil {
+		return false, time.Time{}, fmt.Errorf("invalid end: %w", err)
+	}
+
+	local := now.In(location)
+	nextStart, nextEnd := start.next(local), end.next(local)
+	if !nextEnd.IsZero() && (nextStart.IsZero() || nextEnd.Before(nextStart)) {
+		return true, nextEnd, nil
+	}
+	return false, nextStart, nil
+}
+
+// applySchedules applies the capacity window in effect to the scope's node limits, and reports it in the
+// policy status together with the next time a window opens or closes. When windows overlap, the first
+// one listed applies. Schedules with an invalid cron expression, time zone or node pool are skipped and
+// reported in the Schedules condition.
+func (r *AutoscalerController) applySchedules(scope *scalingScope) {
+	schedules := scope.policy.Spec.Schedules
+	if len(schedules) == 0 {
+		scope.policy.Status.Schedule = nil
+		meta.RemoveStatusCondition(&scope.policy.Status.Conditions, ConditionTypeSchedules)
+		return
+	}
+
+	now := r.now()
+	status := &v1alpha1.ScheduleStatus{}
+	var active *v1alpha1.CapacitySchedule
+	var nextTransition time.Time
+	var invalid []string
+	for i := range schedules {
+		schedule := &schedules[i]
+		open, transition, err := capacityWindowAt(schedule, now)
+		if err == nil {
+			err = checkNodePoolOverrides(&scope.config, schedule)
+		}
+		if err != nil {
+			r.Log.Error(err, "skipping invalid capacity schedule", "policy", scope.policy.Name, "schedule", schedule.Name)
+			invalid = append(invalid, fmt.Sprintf("schedule %s: %v", schedule.Name, err))
+			continue
+		}
+
+		if open && active == nil {
+			active = schedule
+		}
+		if !transition.IsZero() && (nextTransition.IsZero() || transition.Before(nextTransition)) {
+			nextTransition = transition
+			status.NextTransition = schedule.Name + " opens"
+			if open {
+				status.NextTransition = schedule.Name + " closes"
+			}
+		}
+	}
+
+	if len(invalid) > 0 {
+		setPolicyCondition(scope.policy, ConditionTypeSchedules, metav1.ConditionFalse, ReasonInvalidSchedule, strings.Join(invalid, "; "))
+	} else {
+		setPolicyCondition(scope.policy, ConditionTypeSchedules, metav1.ConditionTrue, ReasonSchedulesValid, "")
+	}
+
+	if active != nil {
+		applyCapacitySchedule(&scope.config, active)
+		scope.schedule = active
+		status.ActiveWindow = active.Name
+	}
+	if previous := scope.policy.Status.Schedule; previous == nil || previous.ActiveWindow != status.ActiveWindow {
+		r.Log.Info("capacity window changed", "policy", scope.policy.Name, "window", status.ActiveWindow,
+			"minNodes", scope.config.MinNodes, "maxNodes", scope.config.MaxNodes)
+	}
+
+	status.MinNodes = int32(scope.config.MinNodes)
+	status.MaxNodes = int32(scope.config.MaxNodes)
+	if !nextTransition.IsZero() {
+		status.NextTransitionTime = &metav1.Time{Time: nextTransition}
+	}
+	scope.policy.Status.Schedule = status
+}
+
+// checkNodePoolOverrides returns an error when a capacity window resizes a node pool the config does not have
+func checkNodePoolOverrides(config *AutoscalerConfig, schedule *v1alpha1.CapacitySchedule) error {
+	for _, override := range schedule.NodePools {
+		if configNodePool(config, override.Name) == nil {
+			return fmt.Errorf("node pool %s not found", override.Name)
+		}
+	}
+	return nil
+}
+
+// applyCapacitySchedule overrides the node limits of a config with those of a capacity window
+func applyCapacitySchedule(config *AutoscalerConfig, schedule *v1alpha1.CapacitySchedule) {
+	if schedule.MinNodes != nil {
+		config.MinNodes = int(*schedule.MinNodes)
+	}
+	if schedule.MaxNodes != nil {
+		config.MaxNodes = int(*schedule.MaxNodes)
+	}
+
+	for _, override := range schedule.NodePools {
+		pool := configNodePool(config, override.Name)
+		if pool == nil {
+			continue
+		}
+		if override.MinSize != nil {
+			pool.MinSize = int(*override.MinSize)
+		}
+		if override.MaxSize != nil {
+			pool.MaxSize = int(*override.MaxSize)
+		}
+	}
+}
+
+// configNodePool returns the named node pool of a config, or nil
+func configNodePool(config *AutoscalerConfig, name string) *NodePoolConfig {
+	for i := range config.NodePools {
+		if config.NodePools[i].Name == name {
+			return &config.NodePools[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,345 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+func TestCronScheduleNext(t *testing.T) {
+	// Friday
+	from := time.Date(2026, 10, 16, 9, 7, 30, 0, time.UTC)
+
+	tests := []struct {
+		name      string
+		expr      string
+		expected  time.Time
+		expectErr bool
+	}{
+		{name: "step", expr: "*/15 * * * *", expected: time.Date(2026, 10, 16, 9, 15, 0, 0, time.UTC)},
+		{name: "weekday range", expr: "0 8 * * MON-FRI", expected: time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)},
+		{name: "day of month", expr: "30 2 1 * *", expected: time.Date(2026, 11, 1, 2, 30, 0, 0, time.UTC)},
+		{name: "day of month or weekday", expr: "0 0 1,20 * SUN", expected: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{name: "month list", expr: "0 12 * jan,jul *", expected: time.Date(2027, 1, 1, 12, 0, 0, 0, time.UTC)},
+		{name: "sunday as 7", expr: "0 0 * * 7", expected: time.Date(2026, 10, 18, 0, 0, 0, 0, time.UTC)},
+		{name: "missing field", expr: "0 8 * *", expectErr: true},
+		{name: "out of range", expr: "60 * * * *", expectErr: true},
+		{name: "unknown name", expr: "0 8 * * FOO", expectErr: true},
+		{name: "reversed range", expr: "0 18-8 * * *", expectErr: true},
+		{name: "zero step", expr: "*/0 * * * *", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			schedule, err := parseCron(tt.expr)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error %v, got %v", tt.expectErr, err)
+			}
+			if tt.expectErr {
+				return
+			}
+			if next := schedule.next(from); !next.Equal(tt.expected) {
+				t.Errorf("Expected %s, got %s", tt.expected, next)
+			}
+		})
+	}
+}
+
+func TestCapacityWindowAt(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Fatalf("failed to load time zone: %v", err)
+	}
+	workingHours := &v1alpha1.CapacitySchedule{Name: "working-hours", Start: "0 8 * * MON-FRI", End: "0 19 * * MON-FRI", Timezone: "America/New_York"}
+	nightly := &v1alpha1.CapacitySchedule{Name: "nightly-batch", Start: "0 22 * * *", End: "0 6 * * *"}
+
+	tests := []struct {
+		name               string
+		schedule           *v1alpha1.CapacitySchedule
+		now                time.Time
+		expectedOpen       bool
+		expectedTransition time.Time
+	}{
+		{
+			name:               "during working hours",
+			schedule:           workingHours,
+			now:                time.Date(2026, 10, 12, 10, 0, 0, 0, newYork),
+			expectedOpen:       true,
+			expectedTransition: time.Date(2026, 10, 12, 19, 0, 0, 0, newYork),
+		},
+		{
+			name:               "evening",
+			schedule:           workingHours,
+			now:                time.Date(2026, 10, 12, 19, 0, 0, 0, newYork),
+			expectedTransition: time.Date(2026, 10, 13, 8, 0, 0, 0, newYork),
+		},
+		{
+			// The window reopens after the switch from daylight saving time
+			name:               "weekend",
+			schedule:           workingHours,
+			now:                time.Date(2026, 10, 31, 15, 0, 0, 0, time.UTC),
+			expectedTransition: time.Date(2026, 11, 2, 13, 0, 0, 0, time.UTC),
+		},
+		{
+			name:               "window across midnight",
+			schedule:           nightly,
+			now:                time.Date(2026, 10, 13, 2, 0, 0, 0, time.UTC),
+			expectedOpen:       true,
+			expectedTransition: time.Date(2026, 10, 13, 6, 0, 0, 0, time.UTC),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			open, transition, err := capacityWindowAt(tt.schedule, tt.now)
+			if err != nil {
+				t.Fatalf("capacityWindowAt() error = %v", err)
+			}
+			if open != tt.expectedOpen {
+				t.Errorf("Expected open %v, got %v", tt.expectedOpen, open)
+			}
+			if !transition.Equal(tt.expectedTransition) {
+				t.Errorf("Expected transition at %s, got %s", tt.expectedTransition, transition)
+			}
+		})
+	}
+
+	if _, _, err := capacityWindowAt(&v1alpha1.CapacitySchedule{Start: "0 8 * * *", End: "0 9 * * *", Timezone: "Mars/Olympus"}, time.Now()); err == nil {
+		t.Error("Expected an error for an unknown time zone")
+	}
+}
+
+func TestReconcileAppliesCapacitySchedule(t *testing.T) {
+	minNodes, poolMin := int32(3), int32(2)
+	tests := []struct {
+		name               string
+		now                time.Time
+		expectedWindow     string
+		expectedMinNodes   int32
+		expectedScaleUps   int
+		expectedTransition string
+	}{
+		{
+			name:               "inside the window",
+			now:                time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC),
+			expectedWindow:     "working-hours",
+			expectedMinNodes:   3,
+			expectedScaleUps:   3,
+			expectedTransition: "working-hours closes",
+		},
+		{
+			name:               "outside the window",
+			now:                time.Date(2026, 10, 12, 20, 0, 0, 0, time.UTC),
+			expectedTransition: "working-hours opens",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			policy := &v1alpha1.AutoscalingPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "training"},
+				Spec: v1alpha1.AutoscalingPolicySpec{
+					Enabled:  true,
+					MaxNodes: 10,
+					NodePools: []v1alpha1.NodePoolSpec{
+						{Name: "a100", CapacityType: CapacityTypeOnDemand},
+						{Name: "h100", CapacityType: CapacityTypeOnDemand},
+					},
+					Schedules: []v1alpha1.CapacitySchedule{{
+						Name:      "working-hours",
+						Start:     "0 8 * * MON-FRI",
+						End:       "0 19 * * MON-FRI",
+						MinNodes:  &minNodes,
+						NodePools: []v1alpha1.NodePoolSizeOverride{{Name: "h100", MinSize: &poolMin}},
+					}},
+				},
+			}
+			controller, provider, _, k8sClient := newTestAutoscalerController(t, policy)
+			controller.Clock = func() time.Time { return tt.now }
+			req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+			// The pool minimum is met first, then the policy minimum
+			for i := 0; i < 2; i++ {
+				if _, err := controller.Reconcile(context.Background(), req); err != nil {
+					t.Fatalf("Reconcile() error = %v", err)
+				}
+			}
+			if provider.scaleUps != tt.expectedScaleUps {
+				t.Errorf("Expected %d nodes requested, got %d", tt.expectedScaleUps, provider.scaleUps)
+			}
+
+			updated := &v1alpha1.AutoscalingPolicy{}
+			if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("failed to get policy: %v", err)
+			}
+			schedule := updated.Status.Schedule
+			if schedule == nil {
+				t.Fatal("Expected the schedule in the status")
+			}
+			if schedule.ActiveWindow != tt.expectedWindow || schedule.MinNodes != tt.expectedMinNodes || schedule.MaxNodes != 10 {
+				t.Errorf("Unexpected schedule status: %+v", schedule)
+			}
+			if schedule.NextTransition != tt.expectedTransition || schedule.NextTransitionTime == nil {
+				t.Errorf("Expected next transition %q, got %q at %v", tt.expectedTransition, schedule.NextTransition, schedule.NextTransitionTime)
+			}
+			if tt.expectedScaleUps > 0 {
+				requests := updated.Status.ProvisioningRequests
+				if len(requests) != 2 || requests[0].NodePool != "h100" || requests[0].Count != 2 || requests[1].Count != 1 {
+					t.Errorf("Unexpected provisioning requests: %+v", requests)
+				}
+			}
+		})
+	}
+}
+
+func TestReconcileEnforcesCapacityWindowLimits(t *testing.T) {
+	one, two := int32(1), int32(2)
+	workingHours := time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC)
+	evening := time.Date(2026, 10, 12, 20, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name               string
+		now                time.Time
+		minNodes           int32
+		window             v1alpha1.CapacitySchedule
+		unlabeledNodes     int
+		expectedScaleUps   int
+		expectedScaleDowns int
+	}{
+		{
+			name:               "a window lowering maxNodes removes nodes",
+			now:                workingHours,
+			window:             v1alpha1.CapacitySchedule{MaxNodes: &one},
+			expectedScaleDowns: 2,
+		},
+		{
+			name:               "a window lowering a pool's maxSize removes its nodes",
+			now:                workingHours,
+			window:             v1alpha1.CapacitySchedule{NodePools: []v1alpha1.NodePoolSizeOverride{{Name: "a100", MaxSize: &two}}},
+			expectedScaleDowns: 1,
+		},
+		{
+			name:     "limits are not enforced outside the window",
+			now:      evening,
+			minNodes: 5,
+			window:   v1alpha1.CapacitySchedule{MaxNodes: &one},
+		},
+		{
+			name:           "nodes placed by the provider count toward a pool's minimum",
+			now:            workingHours,
+			window:         v1alpha1.CapacitySchedule{NodePools: []v1alpha1.NodePoolSizeOverride{{Name: "h100", MinSize: &two}}},
+			unlabeledNodes: 2,
+		},
+		{
+			name:             "a window raising a pool's minimum adds nodes",
+			now:              workingHours,
+			window:           v1alpha1.CapacitySchedule{NodePools: []v1alpha1.NodePoolSizeOverride{{Name: "h100", MinSize: &two}}},
+			expectedScaleUps: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			window := tt.window
+			window.Name, window.Start, window.End = "working-hours", "0 8 * * MON-FRI", "0 19 * * MON-FRI"
+			policy := &v1alpha1.AutoscalingPolicy{
+				ObjectMeta: metav1.ObjectMeta{Name: "training"},
+				Spec: v1alpha1.AutoscalingPolicySpec{
+					Enabled:  true,
+					MinNodes: tt.minNodes,
+					MaxNodes: 10,
+					NodePools: []v1alpha1.NodePoolSpec{
+						{Name: "a100", CapacityType: CapacityTypeOnDemand},
+						{Name: "h100", CapacityType: CapacityTypeOnDemand},
+					},
+					Schedules: []v1alpha1.CapacitySchedule{window},
+				},
+			}
+			objects := []client.Object{policy}
+			for i := 0; i < 3; i++ {
+				node := newTestGPUNode(fmt.Sprintf("a100-%d", i), CapacityTypeOnDemand)
+				node.Labels[NodePoolLabel] = "a100"
+				objects = append(objects, node)
+			}
+			nodePools := make(map[string]string)
+			for i := 0; i < tt.unlabeledNodes; i++ {
+				node := newTestGPUNode(fmt.Sprintf("h100-%d", i), CapacityTypeOnDemand)
+				nodePools[node.Name] = "h100"
+				objects = append(objects, node)
+			}
+
+			controller, provider, _, _ := newTestAutoscalerController(t, objects...)
+			controller.Clock = func() time.Time { return tt.now }
+			provider.nodePools = nodePools
+			req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+			if _, err := controller.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			if provider.scaleUps != tt.expectedScaleUps {
+				t.Errorf("Expected %d nodes requested, got %d", tt.expectedScaleUps, provider.scaleUps)
+			}
+			if provider.scaleDowns != tt.expectedScaleDowns {
+				t.Errorf("Expected %d nodes removed, got %d", tt.expectedScaleDowns, provider.scaleDowns)
+			}
+		})
+	}
+}
+
+func TestReconcileSkipsInvalidSchedules(t *testing.T) {
+	minNodes := int32(2)
+	policy := &v1alpha1.AutoscalingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "training"},
+		Spec: v1alpha1.AutoscalingPolicySpec{
+			Enabled:   true,
+			MaxNodes:  10,
+			NodePools: []v1alpha1.NodePoolSpec{{Name: "a100", CapacityType: CapacityTypeOnDemand}},
+			Schedules: []v1alpha1.CapacitySchedule{
+				{Name: "typo", Start: "0 8 * * MON-FRY", End: "0 19 * * MON-FRI"},
+				{Name: "mars", Start: "0 8 * * *", End: "0 19 * * *", Timezone: "Mars/Olympus"},
+				{Name: "unknown-pool", Start: "0 8 * * *", End: "0 19 * * *", NodePools: []v1alpha1.NodePoolSizeOverride{{Name: "h100", MinSize: &minNodes}}},
+				{Name: "working-hours", Start: "0 8 * * MON-FRI", End: "0 19 * * MON-FRI", MinNodes: &minNodes},
+			},
+		},
+	}
+	controller, provider, _, k8sClient := newTestAutoscalerController(t, policy)
+	controller.Clock = func() time.Time { return time.Date(2026, 10, 12, 10, 0, 0, 0, time.UTC) }
+	req := ctrl.Request{NamespacedName: client.ObjectKey{Name: "training"}}
+
+	if _, err := controller.Reconcile(context.Background(), req); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	// The valid window still applies
+	if provider.scaleUps != 2 {
+		t.Errorf("Expected 2 nodes requested for the valid window, got %d", provider.scaleUps)
+	}
+	updated := &v1alpha1.AutoscalingPolicy{}
+	if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	if schedule := updated.Status.Schedule; schedule == nil || schedule.ActiveWindow != "working-hours" {
+		t.Errorf("Expected the working-hours window to be active, got %+v", schedule)
+	}
+	condition := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeSchedules)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != ReasonInvalidSchedule {
+		t.Fatalf("Expected an invalid schedule condition, got %+v", condition)
+	}
+	for _, name := range []string{"typo", "mars", "unknown-pool"} {
+		if !strings.Contains(condition.Message, "schedule "+name+":") {
+			t.Errorf("Expected schedule %s in the condition message, got %s", name, condition.Message)
+		}
+	}
+	if ready := meta.FindStatusCondition(updated.Status.Conditions, ConditionTypeReady); ready == nil || ready.Status != metav1.ConditionTrue {
+		t.Errorf("Expected the policy to stay ready, got %+v", ready)
+	}
+}
@@ -0,0 +1,555 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/cost"
+)
+
+const (
+	// SimulatedNodeLabel marks nodes created by the SimulatedProvider
+	SimulatedNodeLabel = "gpu-autoscaler.io/simulated"
+
+	// Default simulation settings
+	DefaultSimulatedProvisioningLatency = 90 * time.Second
+	DefaultSimulatedSyncInterval        = 5 * time.Second
+	DefaultSimulatedSpotDiscount        = 0.35 // spot costs ~35% of on-demand
+	DefaultSimulatedSpotVolatility      = 0.2  // ±20% daily swing around the spot discount
+)
+
+// SpotPriceCurve returns the simulated spot price for an instance type at a point in time
+type SpotPriceCurve func(instanceType string, onDemandPrice float64, at time.Time) float64
+
+// SimulatedProviderConfig configures the SimulatedProvider
+type SimulatedProviderConfig struct {
+	// ProvisioningLatency is how long a requested node takes to register
+	ProvisioningLatency time.Duration
+
+	// SyncInterval is how often pending nodes and spot notices are processed when running under a manager
+	SyncInterval time.Duration
+
+	// PoolMaxSizes overrides NodePoolConfig.MaxSize per pool, modelling cloud-side quota
+	PoolMaxSizes map[string]int
+
+	// SpotPriceCurve overrides the default daily sinusoidal spot price curve
+	SpotPriceCurve SpotPriceCurve
+
+	// Clock returns the current time; defaults to time.Now and can be replaced with a fake clock
+	Clock func() time.Time
+}
+
+// Simulated nodes renew their lease as often as the kubelet does
+const (
+	simulatedLeaseDurationSeconds = 40
+	simulatedLeaseRenewInterval   = 10 * time.Second
+)
+
+// simulatedPendingNode is a requested node that has not registered yet
+type simulatedPendingNode struct {
+	name      string
+	pool      NodePoolConfig
+	zone      string
+	readyAt   time.Time
+	requested time.Time
+}
+
+// SimulatedProvider implements CloudProvider by creating and deleting Node objects directly.
+// It lets kind or envtest clusters run the full scale-up, scale-down and spot loop without a cloud account.
+type SimulatedProvider struct {
+	client client.Client
+	config SimulatedProviderConfig
+
+	mu           sync.Mutex
+	pools        map[string]NodePoolConfig
+	pending      []simulatedPendingNode
+	spotNotices  map[string]time.Time
+	nodeSequence int
+}
+
+// NewSimulatedProvider creates a new simulated provider
+func NewSimulatedProvider(client client.Client, config SimulatedProviderConfig) *SimulatedProvider {
+	if config.ProvisioningLatency < 0 {
+		config.ProvisioningLatency = 0
+	}
+	if config.SyncInterval <= 0 {
+		config.SyncInterval = DefaultSimulatedSyncInterval
+	}
+	if config.SpotPriceCurve == nil {
+		config.SpotPriceCurve = DailySpotPriceCurve(DefaultSimulatedSpotDiscount, DefaultSimulatedSpotVolatility)
+	}
+	if config.Clock == nil {
+		config.Clock = time.Now
+	}
+
+	return &SimulatedProvider{
+		client:      client,
+		config:      config,
+		pools:       make(map[string]NodePoolConfig),
+		spotNotices: make(map[string]time.Time),
+	}
+}
+
+// DailySpotPriceCurve returns a spot price curve that swings sinusoidally over a day,
+// peaking mid-afternoon UTC when demand is typically highest
+func DailySpotPriceCurve(discount, volatility float64) SpotPriceCurve {
+	return func(instanceType string, onDemandPrice float64, at time.Time) float64 {
+		hour := float64(at.UTC().Hour()) + float64(at.UTC().Minute())/60
+		phase := 2 * math.Pi * (hour - 9) / 24
+		price := onDemandPrice * discount * (1 + volatility*math.Sin(phase))
+		return math.Min(price, onDemandPrice)
+	}
+}
+
+// Start runs the simulation loop until the context is cancelled.
+// It implements manager.Runnable so the provider can be added to a controller-runtime manager.
+func (p *SimulatedProvider) Start(ctx context.Context) error {
+	ticker := time.NewTicker(p.config.SyncInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := p.Sync(ctx); err != nil {
+				log.FromContext(ctx).Error(err, "failed to sync simulated nodes")
+			}
+		}
+	}
+}
+
+// Sync registers pending nodes whose provisioning latency has elapsed, renews the node leases
+// of simulated nodes and reclaims spot nodes whose termination time has passed
+func (p *SimulatedProvider) Sync(ctx context.Context) error {
+	now := p.config.Clock()
+
+	p.mu.Lock()
+	ready := make([]simulatedPendingNode, 0)
+	for _, pendingNode := range p.pending {
+		if !pendingNode.readyAt.After(now) {
+			ready = append(ready, pendingNode)
+		}
+	}
+
+	reclaimed := make([]string, 0)
+	for nodeName, terminationTime := range p.spotNotices {
+		if !terminationTime.After(now) {
+			reclaimed = append(reclaimed, nodeName)
+		}
+	}
+	p.mu.Unlock()
+
+	// Nodes stay pending until they are created, so a failed create is retried on the next sync
+	for _, pendingNode := range ready {
+		if err := p.client.Create(ctx, p.buildNode(pendingNode, now)); err != nil && !errors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create simulated node %s: %w", pendingNode.name, err)
+		}
+		p.registered(pendingNode.name)
+	}
+
+	if err := p.renewNodeLeases(ctx, now); err != nil {
+		return err
+	}
+
+	for _, nodeName := range reclaimed {
+		if err := p.deleteNode(ctx, nodeName); err != nil {
+			return fmt.Errorf("failed to reclaim spot node %s: %w", nodeName, err)
+		}
+	}
+
+	return nil
+}
+
+// registered removes a node that was created from the pending nodes
+func (p *SimulatedProvider) registered(nodeName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	for i, pendingNode := range p.pending {
+		if pendingNode.name == nodeName {
+			p.pending = append(p.pending[:i], p.pending[i+1:]...)
+			return
+		}
+	}
+}
+
+// renewNodeLeases heartbeats simulated nodes the way the kubelet does, by renewing their Lease in
+// kube-node-lease, so the node lifecycle controller doesn't mark them NotReady and taint them
+func (p *SimulatedProvider) renewNodeLeases(ctx context.Context, now time.Time) error {
+	nodeList := &corev1.NodeList{}
+	if err := p.client.List(ctx, nodeList, client.MatchingLabels{SimulatedNodeLabel: "true"}); err != nil {
+		return fmt.Errorf("failed to list simulated nodes: %w", err)
+	}
+
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		lease := &coordinationv1.Lease{}
+		err := p.client.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceNodeLease, Name: node.Name}, lease)
+		if errors.IsNotFound(err) {
+			if err := p.client.Create(ctx, buildNodeLease(node, now)); err != nil && !errors.IsAlreadyExists(err) {
+				return fmt.Errorf("failed to create lease of simulated node %s: %w", node.Name, err)
+			}
+			continue
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get lease of simulated node %s: %w", node.Name, err)
+		}
+
+		if lease.Spec.RenewTime != nil && now.Sub(lease.Spec.RenewTime.Time) < simulatedLeaseRenewInterval {
+			continue
+		}
+		renewTime := metav1.NewMicroTime(now)
+		lease.Spec.RenewTime = &renewTime
+		if err := p.client.Update(ctx, lease); err != nil && !errors.IsConflict(err) {
+			return fmt.Errorf("failed to renew lease of simulated node %s: %w", node.Name, err)
+		}
+	}
+
+	return nil
+}
+
+// ScaleUp requests new simulated nodes; they register once the provisioning latency has elapsed
+func (p *SimulatedProvider) ScaleUp(ctx context.Context, nodePool *NodePoolConfig, count int) error {
+	currentSize, err := p.poolSize(ctx, nodePool.Name)
+	if err != nil {
+		return err
+	}
+
+	maxSize := p.maxSize(nodePool)
+	if currentSize >= maxSize {
+		return NewProviderError(ProviderErrorPoolAtMaxSize, nodePool.Name,
+			fmt.Errorf("simulated pool %s is already at max size %d", nodePool.Name, maxSize))
+	}
+	if currentSize+count > maxSize {
+		count = maxSize - currentSize
+	}
+
+	now := p.config.Clock()
+
+	p.mu.Lock()
+	p.pools[nodePool.Name] = *nodePool
+	for i := 0; i < count; i++ {
+		p.nodeSequence++
+		pendingNode := simulatedPendingNode{
+			name:      fmt.Sprintf("sim-%s-%d", nodePool.Name, p.nodeSequence),
+			pool:      *nodePool,
+			readyAt:   now.Add(p.config.ProvisioningLatency),
+			requested: now,
+		}
+		// Spread nodes across the pool's zones
+		if len(nodePool.AvailabilityZones) > 0 {
+			pendingNode.zone = nodePool.AvailabilityZones[p.nodeSequence%len(nodePool.AvailabilityZones)]
+		}
+		p.pending = append(p.pending, pendingNode)
+	}
+	p.mu.Unlock()
+
+	// Register immediately when there is no latency to model
+	if p.config.ProvisioningLatency == 0 {
+		return p.Sync(ctx)
+	}
+
+	return nil
+}
+
+// GetScaleUpOverrides narrows both: simulated nodes take the pool's first instance type and its zones
+func (p *SimulatedProvider) GetScaleUpOverrides(nodePool *NodePoolConfig) ScaleUpOverrides {
+	return ScaleUpOverrides{InstanceType: true, Zone: true}
+}
+
+// CheckScaleUp returns nil; simulated launches do not fail
+func (p *SimulatedProvider) CheckScaleUp(ctx context.Context, nodePool *NodePoolConfig, since time.Time, missing int) (*ProviderError, error) {
+	return nil, nil
+}
+
+// ScaleDown deletes a simulated node
+func (p *SimulatedProvider) ScaleDown(ctx context.Context, nodeName string) error {
+	return p.deleteNode(ctx, nodeName)
+}
+
+// InjectSpotTerminationNotice schedules a spot interruption for a node.
+// The notice is reported by GetSpotTerminationNotice and the node is deleted at terminationTime.
+func (p *SimulatedProvider) InjectSpotTerminationNotice(nodeName string, terminationTime time.Time) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.spotNotices[nodeName] = terminationTime
+}
+
+// GetSpotTerminationNotice returns an injected spot termination notice for a node
+func (p *SimulatedProvider) GetSpotTerminationNotice(ctx context.Context, nodeName string) (time.Time, bool, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	terminationTime, ok := p.spotNotices[nodeName]
+	return terminationTime, ok, nil
+}
+
+// GetSpotPrice returns the spot price for an instance type from the configured price curve
+func (p *SimulatedProvider) GetSpotPrice(ctx context.Context, instanceType string) (float64, error) {
+	onDemandPrice, err := p.GetOnDemandPrice(ctx, instanceType)
+	if err != nil {
+		return 0, err
+	}
+	return p.config.SpotPriceCurve(instanceType, onDemandPrice, p.config.Clock()), nil
+}
+
+// GetOnDemandPrice returns the on-demand price for an instance type.
+// Simulated prices follow the AWS list prices.
+func (p *SimulatedProvider) GetOnDemandPrice(ctx context.Context, instanceType string) (float64, error) {
+	return (&AWSProvider{}).GetOnDemandPrice(ctx, instanceType)
+}
+
+// GetRecommendedSpotInstanceTypes returns instance types suitable for spot
+func (p *SimulatedProvider) GetRecommendedSpotInstanceTypes(ctx context.Context) ([]string, error) {
+	return (&AWSProvider{}).GetRecommendedSpotInstanceTypes(ctx)
+}
+
+// GetAvailabilityZones returns the simulated availability zones
+func (p *SimulatedProvider) GetAvailabilityZones(ctx context.Context) ([]string, error) {
+	return []string{"sim-a", "sim-b", "sim-c"}, nil
+}
+
+// GetNodePoolInfo returns information about a simulated node pool, counting nodes still provisioning
+func (p *SimulatedProvider) GetNodePoolInfo(ctx context.Context, nodePoolName string) (*NodePoolInfo, error) {
+	nodes, err := p.listPoolNodes(ctx, nodePoolName)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	pool, known := p.pools[nodePoolName]
+	pendingCount := 0
+	for _, pendingNode := range p.pending {
+		if pendingNode.pool.Name == nodePoolName {
+			pendingCount++
+		}
+	}
+	p.mu.Unlock()
+
+	info := &NodePoolInfo{
+		Name:        nodePoolName,
+		CurrentSize: len(nodes) + pendingCount,
+		MaxSize:     p.config.PoolMaxSizes[nodePoolName],
+	}
+	if known {
+		info.MinSize = pool.MinSize
+		info.MaxSize = p.maxSize(&pool)
+		info.InstanceType = simulatedInstanceType(&pool)
+		info.CapacityType = simulatedCapacityType(&pool)
+		info.GPUsPerNode = simulatedGPUCount(&pool)
+	}
+
+	for _, node := range nodes {
+		info.InstanceType = node.Labels[InstanceTypeLabel]
+		info.CapacityType = node.Labels[CapacityTypeLabel]
+		if gpus, ok := node.Status.Allocatable["nvidia.com/gpu"]; ok {
+			info.AvailableGPUs += int(gpus.Value())
+			info.GPUsPerNode = int(gpus.Value())
+		}
+	}
+
+	if info.InstanceType != "" {
+		price, err := p.GetOnDemandPrice(ctx, info.InstanceType)
+		if info.CapacityType == CapacityTypeSpot {
+			price, err = p.GetSpotPrice(ctx, info.InstanceType)
+		}
+		if err == nil {
+			info.Cost = price * float64(info.CurrentSize)
+		}
+	}
+
+	return info, nil
+}
+
+// GetNodePoolForNode returns the pool a simulated node was launched for
+func (p *SimulatedProvider) GetNodePoolForNode(ctx context.Context, nodeName string) (string, error) {
+	node := &corev1.Node{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return "", client.IgnoreNotFound(err)
+	}
+	return node.Labels[NodePoolLabel], nil
+}
+
+// Helper methods
+
+func (p *SimulatedProvider) maxSize(nodePool *NodePoolConfig) int {
+	if maxSize, ok := p.config.PoolMaxSizes[nodePool.Name]; ok {
+		return maxSize
+	}
+	if nodePool.MaxSize > 0 {
+		return nodePool.MaxSize
+	}
+	return DefaultMaxNodes
+}
+
+func (p *SimulatedProvider) poolSize(ctx context.Context, nodePoolName string) (int, error) {
+	nodes, err := p.listPoolNodes(ctx, nodePoolName)
+	if err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	size := len(nodes)
+	for _, pendingNode := range p.pending {
+		if pendingNode.pool.Name == nodePoolName {
+			size++
+		}
+	}
+	return size, nil
+}
+
+func (p *SimulatedProvider) listPoolNodes(ctx context.Context, nodePoolName string) ([]corev1.Node, error) {
+	nodeList := &corev1.NodeList{}
+	if err := p.client.List(ctx, nodeList, client.MatchingLabels{
+		NodePoolLabel:      nodePoolName,
+		SimulatedNodeLabel: "true",
+	}); err != nil {
+		return nil, fmt.Errorf("failed to list simulated nodes: %w", err)
+	}
+	return nodeList.Items, nil
+}
+
+func (p *SimulatedProvider) deleteNode(ctx context.Context, nodeName string) error {
+	node := &corev1.Node{}
+	if err := p.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			p.forgetNode(nodeName)
+			return nil
+		}
+		return err
+	}
+
+	if node.Labels[SimulatedNodeLabel] != "true" {
+		return fmt.Errorf("node %s was not created by the simulated provider", nodeName)
+	}
+
+	if err := p.client.Delete(ctx, node); err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+
+	p.forgetNode(nodeName)
+	return nil
+}
+
+func (p *SimulatedProvider) forgetNode(nodeName string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	delete(p.spotNotices, nodeName)
+}
+
+// buildNode creates a Ready GPU node for a pool
+func (p *SimulatedProvider) buildNode(pendingNode simulatedPendingNode, now time.Time) *corev1.Node {
+	pool := pendingNode.pool
+	instanceType := simulatedInstanceType(&pool)
+	gpus := resource.MustParse(fmt.Sprintf("%d", simulatedGPUCount(&pool)))
+
+	nodeLabels := map[string]string{
+		"kubernetes.io/hostname": pendingNode.name,
+		NodePoolLabel:            pool.Name,
+		InstanceTypeLabel:        instanceType,
+		CapacityTypeLabel:        simulatedCapacityType(&pool),
+		GPUTypeLabel:             pool.GPUType,
+		SimulatedNodeLabel:       "true",
+	}
+	if pendingNode.zone != "" {
+		nodeLabels[corev1.LabelTopologyZone] = pendingNode.zone
+	}
+	for k, v := range pool.Labels {
+		nodeLabels[k] = v
+	}
+
+	capacity := corev1.ResourceList{
+		corev1.ResourceCPU:    resource.MustParse("96"),
+		corev1.ResourceMemory: resource.MustParse("768Gi"),
+		corev1.ResourcePods:   resource.MustParse("110"),
+		"nvidia.com/gpu":      gpus,
+	}
+
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              pendingNode.name,
+			Labels:            nodeLabels,
+			CreationTimestamp: metav1.NewTime(now),
+			Annotations: map[string]string{
+				"gpu-autoscaler.io/requested-at": pendingNode.requested.UTC().Format(time.RFC3339),
+			},
+		},
+		Spec: corev1.NodeSpec{
+			ProviderID: fmt.Sprintf("simulated:///%s/%s", pool.Name, pendingNode.name),
+			Taints:     append([]corev1.Taint(nil), pool.Taints...),
+		},
+		Status: corev1.NodeStatus{
+			Capacity:    capacity,
+			Allocatable: capacity.DeepCopy(),
+			Conditions: []corev1.NodeCondition{
+				{
+					Type:               corev1.NodeReady,
+					Status:             corev1.ConditionTrue,
+					Reason:             "SimulatedNodeReady",
+					LastHeartbeatTime:  metav1.NewTime(now),
+					LastTransitionTime: metav1.NewTime(now),
+				},
+			},
+		},
+	}
+}
+
+// buildNodeLease creates the heartbeat Lease of a node, owned by the node so it is deleted with it
+func buildNodeLease(node *corev1.Node, now time.Time) *coordinationv1.Lease {
+	holder := node.Name
+	duration := int32(simulatedLeaseDurationSeconds)
+	renewTime := metav1.NewMicroTime(now)
+	return &coordinationv1.Lease{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      node.Name,
+			Namespace: corev1.NamespaceNodeLease,
+			OwnerReferences: []metav1.OwnerReference{{
+				APIVersion: "v1",
+				Kind:       "Node",
+				Name:       node.Name,
+				UID:        node.UID,
+			}},
+		},
+		Spec: coordinationv1.LeaseSpec{
+			HolderIdentity:       &holder,
+			LeaseDurationSeconds: &duration,
+			RenewTime:            &renewTime,
+		},
+	}
+}
+
+func simulatedInstanceType(pool *NodePoolConfig) string {
+	if len(pool.InstanceTypes) > 0 {
+		return pool.InstanceTypes[0]
+	}
+	return "p3.2xlarge"
+}
+
+// simulatedGPUCount returns the GPUs on a simulated node of the pool
+func simulatedGPUCount(pool *NodePoolConfig) int {
+	if pool.GPUsPerNode > 0 {
+		return pool.GPUsPerNode
+	}
+	return cost.GetGPUCountForInstanceType(simulatedInstanceType(pool))
+}
+
+func simulatedCapacityType(pool *NodePoolConfig) string {
+	if pool.CapacityType != "" {
+		return pool.CapacityType
+	}
+	return CapacityTypeOnDemand
+}
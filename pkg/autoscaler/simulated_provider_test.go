@@ -0,0 +1,226 @@
+package autoscaler
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+)
+
+func newTestSimulatedProvider(latency time.Duration) (*SimulatedProvider, client.Client, *time.Time) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	k8sClient := fake.NewClientBuilder().Build()
+	provider := NewSimulatedProvider(k8sClient, SimulatedProviderConfig{
+		ProvisioningLatency: latency,
+		Clock:               func() time.Time { return now },
+	})
+	return provider, k8sClient, &now
+}
+
+func listSimulatedNodes(t *testing.T, k8sClient client.Client) []corev1.Node {
+	t.Helper()
+	nodeList := &corev1.NodeList{}
+	if err := k8sClient.List(context.Background(), nodeList); err != nil {
+		t.Fatalf("failed to list nodes: %v", err)
+	}
+	return nodeList.Items
+}
+
+func TestSimulatedProviderProvisioningLatency(t *testing.T) {
+	ctx := context.Background()
+	provider, k8sClient, now := newTestSimulatedProvider(time.Minute)
+	pool := &NodePoolConfig{
+		Name:          "a100-spot",
+		MaxSize:       4,
+		GPUType:       "nvidia-tesla-a100",
+		InstanceTypes: []string{"p4d.24xlarge"},
+		CapacityType:  CapacityTypeSpot,
+	}
+
+	if err := provider.ScaleUp(ctx, pool, 2); err != nil {
+		t.Fatalf("ScaleUp() error = %v", err)
+	}
+
+	// Nodes are not registered before the provisioning latency elapses
+	if err := provider.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	if nodes := listSimulatedNodes(t, k8sClient); len(nodes) != 0 {
+		t.Fatalf("Expected no nodes before provisioning latency, got %d", len(nodes))
+	}
+
+	info, err := provider.GetNodePoolInfo(ctx, pool.Name)
+	if err != nil {
+		t.Fatalf("GetNodePoolInfo() error = %v", err)
+	}
+	if info.CurrentSize != 2 {
+		t.Errorf("Expected pending nodes to count toward pool size, got %d", info.CurrentSize)
+	}
+
+	*now = now.Add(time.Minute)
+	if err := provider.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	nodes := listSimulatedNodes(t, k8sClient)
+	if len(nodes) != 2 {
+		t.Fatalf("Expected 2 nodes after provisioning latency, got %d", len(nodes))
+	}
+
+	node := nodes[0]
+	gpus := node.Status.Capacity["nvidia.com/gpu"]
+	if gpus.Value() != 8 {
+		t.Errorf("Expected 8 GPUs for p4d.24xlarge, got %d", gpus.Value())
+	}
+	if node.Labels[CapacityTypeLabel] != CapacityTypeSpot || node.Labels[GPUTypeLabel] != "nvidia-tesla-a100" {
+		t.Errorf("Unexpected node labels: %v", node.Labels)
+	}
+}
+
+func TestSimulatedProviderMaxSize(t *testing.T) {
+	ctx := context.Background()
+	provider, k8sClient, _ := newTestSimulatedProvider(0)
+	pool := &NodePoolConfig{Name: "t4", MaxSize: 3, InstanceTypes: []string{"g4dn.xlarge"}}
+
+	if err := provider.ScaleUp(ctx, pool, 5); err != nil {
+		t.Fatalf("ScaleUp() error = %v", err)
+	}
+	if nodes := listSimulatedNodes(t, k8sClient); len(nodes) != 3 {
+		t.Errorf("Expected scale-up to be capped at 3 nodes, got %d", len(nodes))
+	}
+
+	if err := provider.ScaleUp(ctx, pool, 1); err == nil {
+		t.Error("Expected error when pool is at max size")
+	}
+}
+
+func TestSimulatedProviderSpotTermination(t *testing.T) {
+	ctx := context.Background()
+	provider, k8sClient, now := newTestSimulatedProvider(0)
+	pool := &NodePoolConfig{Name: "v100-spot", MaxSize: 2, CapacityType: CapacityTypeSpot}
+
+	if err := provider.ScaleUp(ctx, pool, 2); err != nil {
+		t.Fatalf("ScaleUp() error = %v", err)
+	}
+	nodes := listSimulatedNodes(t, k8sClient)
+	target := nodes[0].Name
+
+	terminationTime := now.Add(2 * time.Minute)
+	provider.InjectSpotTerminationNotice(target, terminationTime)
+
+	noticeTime, hasNotice, err := provider.GetSpotTerminationNotice(ctx, target)
+	if err != nil || !hasNotice || !noticeTime.Equal(terminationTime) {
+		t.Fatalf("Expected termination notice at %s, got %s (notice=%v, err=%v)", terminationTime, noticeTime, hasNotice, err)
+	}
+
+	*now = terminationTime
+	if err := provider.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+
+	nodes = listSimulatedNodes(t, k8sClient)
+	if len(nodes) != 1 || nodes[0].Name == target {
+		t.Errorf("Expected %s to be reclaimed, remaining nodes: %d", target, len(nodes))
+	}
+
+	if err := provider.ScaleDown(ctx, nodes[0].Name); err != nil {
+		t.Fatalf("ScaleDown() error = %v", err)
+	}
+	if nodes := listSimulatedNodes(t, k8sClient); len(nodes) != 0 {
+		t.Errorf("Expected all nodes removed, got %d", len(nodes))
+	}
+}
+
+func TestSimulatedProviderSync(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	failCreate := true
+	k8sClient := fake.NewClientBuilder().WithInterceptorFuncs(interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*corev1.Node); ok && failCreate {
+				return fmt.Errorf("apiserver unavailable")
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	}).Build()
+	provider := NewSimulatedProvider(k8sClient, SimulatedProviderConfig{
+		ProvisioningLatency: time.Minute,
+		Clock:               func() time.Time { return now },
+	})
+	pool := &NodePoolConfig{Name: "a100", MaxSize: 2, InstanceTypes: []string{"p4d.24xlarge"}}
+
+	if err := provider.ScaleUp(ctx, pool, 1); err != nil {
+		t.Fatalf("ScaleUp() error = %v", err)
+	}
+	now = now.Add(time.Minute)
+
+	// A node that failed to register stays pending and is registered on the next sync
+	if err := provider.Sync(ctx); err == nil {
+		t.Fatal("Expected Sync() to fail while nodes cannot be created")
+	}
+	if info, _ := provider.GetNodePoolInfo(ctx, pool.Name); info.CurrentSize != 1 {
+		t.Fatalf("Expected the node to stay pending, got pool size %d", info.CurrentSize)
+	}
+	failCreate = false
+	if err := provider.Sync(ctx); err != nil {
+		t.Fatalf("Sync() error = %v", err)
+	}
+	nodes := listSimulatedNodes(t, k8sClient)
+	if len(nodes) != 1 {
+		t.Fatalf("Expected 1 node, got %d", len(nodes))
+	}
+	if info, _ := provider.GetNodePoolInfo(ctx, pool.Name); info.CurrentSize != 1 {
+		t.Errorf("Expected a pool size of 1 once registered, got %d", info.CurrentSize)
+	}
+
+	// The node's lease is renewed like a kubelet heartbeat
+	tests := []struct {
+		name              string
+		after             time.Duration
+		expectedRenewTime time.Time
+	}{
+		{name: "lease created on registration", expectedRenewTime: now},
+		{name: "lease not renewed within the renew interval", after: 5 * time.Second, expectedRenewTime: now},
+		{name: "lease renewed after the renew interval", after: 15 * time.Second, expectedRenewTime: now.Add(15 * time.Second)},
+	}
+	registeredAt := now
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = registeredAt.Add(tt.after)
+			if err := provider.Sync(ctx); err != nil {
+				t.Fatalf("Sync() error = %v", err)
+			}
+
+			lease := &coordinationv1.Lease{}
+			if err := k8sClient.Get(ctx, client.ObjectKey{Namespace: corev1.NamespaceNodeLease, Name: nodes[0].Name}, lease); err != nil {
+				t.Fatalf("failed to get node lease: %v", err)
+			}
+			if lease.Spec.RenewTime == nil || !lease.Spec.RenewTime.Time.Equal(tt.expectedRenewTime) {
+				t.Errorf("Expected lease renewed at %s, got %v", tt.expectedRenewTime, lease.Spec.RenewTime)
+			}
+			if len(lease.OwnerReferences) != 1 || lease.OwnerReferences[0].Name != nodes[0].Name {
+				t.Errorf("Expected the lease to be owned by its node, got %+v", lease.OwnerReferences)
+			}
+		})
+	}
+}
+
+func TestDailySpotPriceCurve(t *testing.T) {
+	curve := DailySpotPriceCurve(0.3, 0.2)
+
+	peak := curve("p3.2xlarge", 10, time.Date(2024, 1, 1, 15, 0, 0, 0, time.UTC))
+	trough := curve("p3.2xlarge", 10, time.Date(2024, 1, 1, 3, 0, 0, 0, time.UTC))
+
+	if peak <= trough {
+		t.Errorf("Expected afternoon price %.2f to exceed night price %.2f", peak, trough)
+	}
+	if peak > 10 || trough <= 0 {
+		t.Errorf("Spot prices should stay between 0 and on-demand, got peak=%.2f trough=%.2f", peak, trough)
+	}
+}
@@ -3,6 +3,7 @@ package autoscaler
 import (
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -29,6 +30,7 @@ type SpotOrchestrator struct {
 	client        client.Client
 	cloudProvider CloudProvider
 	logger        logr.Logger
+	drainer       *Drainer
 
 	// Active spot termination warnings
 	terminationWarnings map[string]time.Time
@@ -36,10 +38,12 @@ type SpotOrchestrator struct {
 
 // NewSpotOrchestrator creates a new spot orchestrator
 func NewSpotOrchestrator(client client.Client, cloudProvider CloudProvider, logger logr.Logger) *SpotOrchestrator {
+	logger = logger.WithName("spot-orchestrator")
 	return &SpotOrchestrator{
 		client:              client,
 		cloudProvider:       cloudProvider,
-		logger:              logger.WithName("spot-orchestrator"),
+		logger:              logger,
+		drainer:             NewDrainer(client, logger),
 		terminationWarnings: make(map[string]time.Time),
 	}
 }
@@ -122,8 +126,17 @@ func (s *SpotOrchestrator) handleSpotTermination(ctx context.Context, node *core
 		return fmt.Errorf("failed to mark node unschedulable: %w", err)
 	}
 
-	// Start graceful eviction of pods
-	go s.gracefullyEvictPods(context.Background(), node)
+	// Start graceful eviction of pods, giving up on evictions blocked by disruption budgets
+	// once the node is reclaimed
+	deadline := terminationTime
+	if !deadline.After(time.Now()) {
+		deadline = time.Now().Add(SpotTerminationGracePeriod)
+	}
+	go func() {
+		ctx, cancel := context.WithDeadline(context.Background(), deadline)
+		defer cancel()
+		s.gracefullyEvictPods(ctx, node)
+	}()
 
 	return nil
 }
@@ -132,9 +145,9 @@ func (s *SpotOrchestrator) handleSpotTermination(ctx context.Context, node *core
 func (s *SpotOrchestrator) gracefullyEvictPods(ctx context.Context, node *corev1.Node) {
 	s.logger.Info("starting graceful pod eviction", "node", node.Name)
 
-	// Get all pods on the node
-	podList := &corev1.PodList{}
-	if err := s.client.List(ctx, podList, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
+	// Get the pods on the node, skipping DaemonSet and mirror pods
+	pods, err := s.drainer.PodsToEvict(ctx, node.Name)
+	if err != nil {
 		s.logger.Error(err, "failed to list pods on node", "node", node.Name)
 		return
 	}
@@ -144,7 +157,12 @@ func (s *SpotOrchestrator) gracefullyEvictPods(ctx context.Context, node *corev1
 	mediumPriorityPods := make([]corev1.Pod, 0)
 	lowPriorityPods := make([]corev1.Pod, 0)
 
-	for _, pod := range podList.Items {
+	for _, pod := range pods {
+		if !isSafeToEvict(&pod) {
+			s.logger.Info("not evicting pod annotated as unsafe to evict", "pod", pod.Name, "namespace", pod.Namespace)
+			continue
+		}
+
 		priority := s.getPodEvictionPriority(&pod)
 		switch priority {
 		case EvictionPriorityHigh:
@@ -172,9 +190,12 @@ func (s *SpotOrchestrator) gracefullyEvictPods(ctx context.Context, node *corev1
 	delete(s.terminationWarnings, node.Name)
 }
 
-// evictPods evicts a list of pods with specified grace period
+// evictPods evicts a list of pods with specified grace period through the Eviction API.
+// Evictions refused by a PodDisruptionBudget are retried until the context is done.
 func (s *SpotOrchestrator) evictPods(ctx context.Context, pods []corev1.Pod, gracePeriodSeconds int64) {
-	for _, pod := range pods {
+	var wg sync.WaitGroup
+	for i := range pods {
+		pod := &pods[i]
 		s.logger.Info("evicting pod",
 			"pod", pod.Name,
 			"namespace", pod.Namespace,
@@ -182,10 +203,15 @@ func (s *SpotOrchestrator) evictPods(ctx context.Context, pods []corev1.Pod, gra
 			"gracePeriod", gracePeriodSeconds,
 		)
 
-		if err := s.client.Delete(ctx, &pod, client.GracePeriodSeconds(gracePeriodSeconds)); err != nil {
-			s.logger.Error(err, "failed to evict pod", "pod", pod.Name)
-		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if err := s.drainer.EvictPod(ctx, pod, gracePeriodSeconds); err != nil {
+				s.logger.Error(err, "failed to evict pod", "pod", pod.Name)
+			}
+		}()
 	}
+	wg.Wait()
 }
 
 // getPodEvictionPriority determines the eviction priority for a pod
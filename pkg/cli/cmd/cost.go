@@ -12,6 +12,7 @@ import (
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/client/config"
@@ -79,31 +80,14 @@ func (o *CostOptions) Run() error {
 	ctx := context.Background()
 
 	// Create controller-runtime client
-	cfg, err := config.GetConfig()
+	cfg, err := loadRESTConfig()
 	if err != nil {
-		// Fallback to default kubeconfig
-		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
-		configOverrides := &clientcmd.ConfigOverrides{}
-		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
-		cfg, err = kubeConfig.ClientConfig()
-		if err != nil {
-			return fmt.Errorf("failed to load kubeconfig: %w", err)
-		}
+		return err
 	}
 
-	scheme, err := v1alpha1.SchemeBuilder.Build()
+	k8sClient, err := newKubeClient(cfg)
 	if err != nil {
-		return fmt.Errorf("failed to build scheme: %w", err)
-	}
-
-	// Register core Kubernetes types
-	if err := clientgoscheme.AddToScheme(scheme); err != nil {
-		return fmt.Errorf("failed to add core types to scheme: %w", err)
-	}
-
-	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
-	if err != nil {
-		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+		return err
 	}
 
 	fmt.Fprintf(o.streams.Out, "\n")
@@ -450,6 +434,41 @@ func (o *CostOptions) showROIReport(ctx context.Context, k8sClient client.Client
 	return nil
 }
 
+// loadRESTConfig loads the cluster configuration, falling back to the default kubeconfig
+func loadRESTConfig() (*rest.Config, error) {
+	cfg, err := config.GetConfig()
+	if err != nil {
+		// Fallback to default kubeconfig
+		loadingRules := clientcmd.NewDefaultClientConfigLoadingRules()
+		configOverrides := &clientcmd.ConfigOverrides{}
+		kubeConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(loadingRules, configOverrides)
+		cfg, err = kubeConfig.ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
+		}
+	}
+	return cfg, nil
+}
+
+// newKubeClient creates a controller-runtime client that knows the gpuautoscaler.io types
+func newKubeClient(cfg *rest.Config) (client.Client, error) {
+	scheme, err := v1alpha1.SchemeBuilder.Build()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build scheme: %w", err)
+	}
+
+	// Register core Kubernetes types
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add core types to scheme: %w", err)
+	}
+
+	k8sClient, err := client.New(cfg, client.Options{Scheme: scheme})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+	return k8sClient, nil
+}
+
 func parseDuration(s string) (time.Duration, error) {
 	// Parse duration strings like "1h", "7d", "30d"
 	if strings.HasSuffix(s, "d") {
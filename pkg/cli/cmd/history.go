@@ -0,0 +1,181 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/autoscaler"
+)
+
+type HistoryOptions struct {
+	Namespace string
+	Policy    string
+	NodePool  string
+	Action    string
+	Since     string
+	Until     string
+	Limit     int
+	Format    string
+	streams   genericclioptions.IOStreams
+}
+
+// NewHistoryCmd creates the history command
+func NewHistoryCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	o := &HistoryOptions{
+		streams:   streams,
+		Namespace: autoscaler.DefaultHistoryNamespace,
+		Format:    "text",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "history",
+		Short: "Show the autoscaler's scaling history",
+		Long: `Show the scaling actions the autoscaler has taken, with the inputs behind each
+decision: GPU utilization, pending pods, underutilized nodes, the chosen node
+pool and any provider error.
+
+The history is read from the ConfigMap the controller persists it to and keeps
+the most recent 1000 events.
+
+Examples:
+  # Show all recorded scaling actions
+  gpu-autoscaler history
+
+  # Show failed and successful scale-ups of one node pool over the last day
+  gpu-autoscaler history --pool gpu-spot --action scale-up --since 24h
+
+  # Export a policy's history for a fixed window as JSON
+  gpu-autoscaler history --policy training --since 2024-01-01 --until 2024-01-08 --format json`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run()
+		},
+	}
+
+	cmd.Flags().StringVarP(&o.Namespace, "namespace", "n", o.Namespace, "Namespace of the scaling history ConfigMap")
+	cmd.Flags().StringVar(&o.Policy, "policy", "", "Only show events of this AutoscalingPolicy")
+	cmd.Flags().StringVar(&o.NodePool, "pool", "", "Only show events of this node pool")
+	cmd.Flags().StringVar(&o.Action, "action", "", "Only show events of this action (scale-up, scale-down)")
+	cmd.Flags().StringVar(&o.Since, "since", "", "Only show events after this time, as a duration ago (e.g., 24h, 7d), a date or an RFC3339 time")
+	cmd.Flags().StringVar(&o.Until, "until", "", "Only show events before this time, in the same forms as --since")
+	cmd.Flags().IntVar(&o.Limit, "limit", 0, "Show at most this many of the most recent events (0 shows all)")
+	cmd.Flags().StringVar(&o.Format, "format", "text", "Output format (text, json)")
+
+	return cmd
+}
+
+func (o *HistoryOptions) Run() error {
+	if o.Format != "text" && o.Format != "json" {
+		return fmt.Errorf("unsupported format %q: must be text or json", o.Format)
+	}
+
+	filter, err := o.filter(time.Now())
+	if err != nil {
+		return err
+	}
+
+	cfg, err := loadRESTConfig()
+	if err != nil {
+		return err
+	}
+	k8sClient, err := newKubeClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	store := autoscaler.NewConfigMapHistoryStore(k8sClient, o.Namespace)
+	events, err := store.List(context.Background(), filter)
+	if err != nil {
+		return err
+	}
+	if o.Limit > 0 && len(events) > o.Limit {
+		events = events[len(events)-o.Limit:]
+	}
+
+	if o.Format == "json" {
+		encoder := json.NewEncoder(o.streams.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(events)
+	}
+	return writeScalingHistory(o.streams.Out, events)
+}
+
+// filter builds the history filter from the command-line flags
+func (o *HistoryOptions) filter(now time.Time) (autoscaler.HistoryFilter, error) {
+	filter := autoscaler.HistoryFilter{
+		Policy:   o.Policy,
+		NodePool: o.NodePool,
+		Action:   autoscaler.ScalingAction(o.Action),
+	}
+
+	switch filter.Action {
+	case "", autoscaler.ScaleUp, autoscaler.ScaleDown:
+	default:
+		return filter, fmt.Errorf("unsupported action %q: must be %s or %s", o.Action, autoscaler.ScaleUp, autoscaler.ScaleDown)
+	}
+
+	var err error
+	if o.Since != "" {
+		if filter.Since, err = parseReportTime(o.Since, now); err != nil {
+			return filter, fmt.Errorf("invalid --since: %w", err)
+		}
+	}
+	if o.Until != "" {
+		if filter.Until, err = parseReportTime(o.Until, now); err != nil {
+			return filter, fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !filter.Since.IsZero() && !filter.Until.IsZero() && !filter.Since.Before(filter.Until) {
+		return filter, fmt.Errorf("--since (%s) must be before --until (%s)", filter.Since.Format(time.RFC3339), filter.Until.Format(time.RFC3339))
+	}
+
+	return filter, nil
+}
+
+// writeScalingHistory prints the scaling events as a table
+func writeScalingHistory(out io.Writer, events []autoscaler.ScalingEvent) error {
+	if len(events) == 0 {
+		fmt.Fprintln(out, "No scaling events found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "TIME\tPOLICY\tACTION\tPOOL\tNODES\tUTILIZATION\tPENDING\tUNDERUTILIZED\tRESULT\tREASON")
+	for _, event := range events {
+		action := string(event.Action)
+		if event.DryRun {
+			action += " (dry-run)"
+		}
+		result := "ok"
+		if !event.Success {
+			result = "failed: " + event.Error
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%d -> %d\t%.1f%%\t%d\t%d\t%s\t%s\n",
+			event.Timestamp.Local().Format("2006-01-02 15:04:05"),
+			event.Policy,
+			action,
+			valueOrDash(event.NodePool),
+			event.CurrentNodeCount,
+			event.NodeCount,
+			event.GPUUtilization*100,
+			event.PendingPods,
+			event.UnderutilizedNodes,
+			result,
+			event.Reason,
+		)
+	}
+	return w.Flush()
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/autoscaler"
+)
+
+func TestHistoryFilter(t *testing.T) {
+	now := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	o := &HistoryOptions{Policy: "training", NodePool: "gpu-spot", Action: "scale-up", Since: "24h", Until: "2024-01-08T11:00:00Z"}
+	filter, err := o.filter(now)
+	if err != nil {
+		t.Fatalf("filter() error = %v", err)
+	}
+	if filter.Policy != "training" || filter.NodePool != "gpu-spot" || filter.Action != autoscaler.ScaleUp {
+		t.Errorf("Unexpected filter: %+v", filter)
+	}
+	if !filter.Since.Equal(now.Add(-24*time.Hour)) || !filter.Until.Equal(now.Add(-time.Hour)) {
+		t.Errorf("Unexpected time range: %s to %s", filter.Since, filter.Until)
+	}
+
+	for _, invalid := range []*HistoryOptions{
+		{Action: "resize"},
+		{Since: "yesterday"},
+		{Since: "1h", Until: "2h"},
+	} {
+		if _, err := invalid.filter(now); err == nil {
+			t.Errorf("Expected error for %+v", invalid)
+		}
+	}
+}
+
+func TestWriteScalingHistory(t *testing.T) {
+	events := []autoscaler.ScalingEvent{
+		{
+			Policy: "training", Action: autoscaler.ScaleUp, NodePool: "gpu-spot", Reason: "3 pending GPU pods waiting",
+			CurrentNodeCount: 2, NodeCount: 4, GPUUtilization: 0.85, PendingPods: 3, Success: true,
+		},
+		{
+			Policy: "training", Action: autoscaler.ScaleUp, Reason: "3 pending GPU pods waiting",
+			CurrentNodeCount: 2, Error: "insufficient capacity", DryRun: true,
+		},
+	}
+
+	var out bytes.Buffer
+	if err := writeScalingHistory(&out, events); err != nil {
+		t.Fatalf("writeScalingHistory() error = %v", err)
+	}
+	for _, expected := range []string{"gpu-spot", "2 -> 4", "85.0%", "scale-up (dry-run)", "failed: insufficient capacity"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected output to contain %q:\n%s", expected, out.String())
+		}
+	}
+
+	out.Reset()
+	if err := writeScalingHistory(&out, nil); err != nil || !strings.Contains(out.String(), "No scaling events") {
+		t.Errorf("Expected a no-events message, got %q (error %v)", out.String(), err)
+	}
+}
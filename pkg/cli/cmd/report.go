@@ -1,73 +1,381 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
+	"os"
+	"sort"
+	"time"
 
 	"github.com/spf13/cobra"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/kubernetes"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/cost"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/metrics"
+)
+
+const (
+	// sharingWasteThreshold is the waste score above which a workload is a sharing candidate
+	sharingWasteThreshold = 50.0
+
+	// sharingSavingsRatio is the fraction of a GPU's cost recovered by sharing it
+	sharingSavingsRatio = 0.4
+
+	// spotDiscount is the typical spot discount over on-demand pricing
+	spotDiscount = 0.65
+
+	// defaultSpotTarget mirrors the AutoscalingPolicy default for SpotInstancePercentage
+	defaultSpotTarget = 0.6
 )
 
 type ReportOptions struct {
-	Format  string
-	streams genericclioptions.IOStreams
+	Format         string
+	Since          string
+	Until          string
+	CloudProvider  string
+	Region         string
+	TimescaleDBDSN string
+	streams        genericclioptions.IOStreams
+}
+
+// Report is the data behind every report format
+type Report struct {
+	GeneratedAt          time.Time                      `json:"generatedAt"`
+	Since                time.Time                      `json:"since"`
+	Until                time.Time                      `json:"until"`
+	Utilization          []metrics.NamespaceUtilization `json:"utilization"`
+	Costs                []ReportCost                   `json:"costs"`
+	TotalCost            float64                        `json:"totalCost"`
+	Savings              v1alpha1.SavingsData           `json:"savings"`
+	ROI                  *cost.ROIReport                `json:"roi,omitempty"`
+	SharingOpportunities []SharingOpportunity           `json:"sharingOpportunities"`
+	SpotOpportunities    []SpotOpportunity              `json:"spotOpportunities"`
+	Warnings             []string                       `json:"warnings,omitempty"`
+}
+
+// ReportCost is the spend of one CostAttribution over the report period
+type ReportCost struct {
+	Name        string  `json:"name"`
+	Namespace   string  `json:"namespace,omitempty"`
+	Team        string  `json:"team,omitempty"`
+	PeriodCost  float64 `json:"periodCost"`
+	Estimated   bool    `json:"estimated"` // PeriodCost was extrapolated from the current rate
+	HourlyCost  float64 `json:"hourlyCost"`
+	MonthlyCost float64 `json:"monthlyCost"`
+	ActiveGPUs  int     `json:"activeGPUs"`
+}
+
+// SharingOpportunity is an underutilized workload that could share its GPU
+type SharingOpportunity struct {
+	Pod                     string  `json:"pod"`
+	Namespace               string  `json:"namespace"`
+	GPUs                    int     `json:"gpus"`
+	AvgUtilization          float64 `json:"avgUtilization"`
+	AvgMemoryUtil           float64 `json:"avgMemoryUtil"`
+	Recommendation          string  `json:"recommendation"`
+	EstimatedMonthlySavings float64 `json:"estimatedMonthlySavings"`
+}
+
+// SpotOpportunity is a node pool running fewer spot nodes than its policy allows
+type SpotOpportunity struct {
+	Policy                  string  `json:"policy"`
+	CurrentNodes            int32   `json:"currentNodes"`
+	SpotNodes               int32   `json:"spotNodes"`
+	TargetSpotNodes         int32   `json:"targetSpotNodes"`
+	EstimatedMonthlySavings float64 `json:"estimatedMonthlySavings"`
 }
 
 // NewReportCmd creates the report command
 func NewReportCmd(streams genericclioptions.IOStreams) *cobra.Command {
 	o := &ReportOptions{
-		streams: streams,
-		Format:  "text",
+		streams:        streams,
+		Format:         "text",
+		Since:          "7d",
+		CloudProvider:  "aws",
+		Region:         "us-east-1",
+		TimescaleDBDSN: os.Getenv("TIMESCALEDB_DSN"),
 	}
 
 	cmd := &cobra.Command{
 		Use:   "report",
 		Short: "Generate comprehensive GPU utilization and cost reports",
 		Long: `Generate detailed reports on:
-- GPU utilization trends
+- GPU utilization by namespace
 - Cost analysis and savings
-- Optimization recommendations
-- Cluster health metrics
+- GPU sharing and spot instance opportunities
+
+Reports can be exported in multiple formats for sharing with stakeholders.
 
-Reports can be exported in multiple formats for sharing with stakeholders.`,
+Examples:
+  # Report on the last 7 days
+  gpu-autoscaler report
+
+  # Report on the last 30 days as markdown
+  gpu-autoscaler report --since 30d --format markdown
+
+  # Report on a fixed window as HTML
+  gpu-autoscaler report --since 2024-01-01 --until 2024-02-01 --format html > report.html`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			return o.Run()
 		},
 	}
 
-	cmd.Flags().StringVar(&o.Format, "format", "text", "Output format (text, json, csv)")
+	cmd.Flags().StringVar(&o.Format, "format", "text", "Output format (text, json, csv, markdown, html)")
+	cmd.Flags().StringVar(&o.Since, "since", "7d", "Start of the report period, as a duration ago (e.g., 24h, 7d), a date or an RFC3339 time")
+	cmd.Flags().StringVar(&o.Until, "until", "", "End of the report period, in the same forms as --since (default now)")
+	cmd.Flags().StringVar(&o.CloudProvider, "cloud-provider", "aws", "Cloud provider used for GPU pricing")
+	cmd.Flags().StringVar(&o.Region, "region", "us-east-1", "Cloud region used for GPU pricing")
+	cmd.Flags().StringVar(&o.TimescaleDBDSN, "timescaledb-dsn", o.TimescaleDBDSN, "TimescaleDB connection string for historical savings (defaults to $TIMESCALEDB_DSN)")
 
 	return cmd
 }
 
 func (o *ReportOptions) Run() error {
-	fmt.Fprintf(o.streams.Out, "\n=== GPU Autoscaler Report ===\n\n")
+	ctx := context.Background()
+
+	if !isReportFormat(o.Format) {
+		return fmt.Errorf("unsupported format %q (expected one of %v)", o.Format, reportFormats)
+	}
+
+	now := time.Now()
+	since, err := parseReportTime(o.Since, now)
+	if err != nil {
+		return fmt.Errorf("invalid --since: %w", err)
+	}
+	until := now
+	if o.Until != "" {
+		until, err = parseReportTime(o.Until, now)
+		if err != nil {
+			return fmt.Errorf("invalid --until: %w", err)
+		}
+	}
+	if !since.Before(until) {
+		return fmt.Errorf("--since (%s) must be before --until (%s)", since.Format(time.RFC3339), until.Format(time.RFC3339))
+	}
+
+	cfg, err := loadRESTConfig()
+	if err != nil {
+		return err
+	}
+
+	k8sClient, err := newKubeClient(cfg)
+	if err != nil {
+		return err
+	}
+
+	clientset, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		return fmt.Errorf("failed to create clientset: %w", err)
+	}
+
+	promURL := os.Getenv("PROMETHEUS_URL")
+	if promURL == "" {
+		promURL = "http://prometheus-operated.gpu-autoscaler-system.svc:9090"
+	}
+	collector := metrics.NewCollector(promURL)
+	if err := collector.Start(k8sClient); err != nil {
+		return fmt.Errorf("failed to start metrics collector: %w", err)
+	}
+
+	report := &Report{
+		GeneratedAt: now,
+		Since:       since,
+		Until:       until,
+	}
+
+	// Each section is best effort so one missing data source does not hide the rest
+	report.Utilization, err = collector.GetNamespaceUtilization(ctx, since, until)
+	if err != nil {
+		report.warn("GPU utilization unavailable: %v", err)
+	}
+
+	if err := o.addCosts(ctx, k8sClient, report); err != nil {
+		report.warn("Cost attribution unavailable: %v", err)
+	}
+
+	if err := o.addROI(ctx, k8sClient, clientset, report); err != nil {
+		report.warn("ROI report unavailable: %v", err)
+	}
+
+	wasteMetrics, err := collector.GetWasteMetricsForPeriod(ctx, since, until)
+	if err != nil {
+		report.warn("GPU sharing opportunities unavailable: %v", err)
+	} else {
+		report.SharingOpportunities = findSharingOpportunities(wasteMetrics)
+	}
+
+	policies := &v1alpha1.AutoscalingPolicyList{}
+	if err := k8sClient.List(ctx, policies); err != nil {
+		report.warn("Spot instance opportunities unavailable: %v", err)
+	} else {
+		report.SpotOpportunities = findSpotOpportunities(policies.Items)
+	}
 
-	fmt.Fprintf(o.streams.Out, "⚠️  Full reporting requires historical data collection.\n")
-	fmt.Fprintf(o.streams.Out, "Ensure the system has been running for at least 24 hours.\n\n")
+	return writeReport(o.streams.Out, o.Format, report)
+}
+
+// addCosts fills in per-attribution spend and savings for the report period
+func (o *ReportOptions) addCosts(ctx context.Context, k8sClient client.Client, report *Report) error {
+	attributions := &v1alpha1.CostAttributionList{}
+	if err := k8sClient.List(ctx, attributions); err != nil {
+		return fmt.Errorf("failed to list cost attributions: %w", err)
+	}
 
-	fmt.Fprintf(o.streams.Out, "Report will include:\n")
-	fmt.Fprintf(o.streams.Out, "1. Executive Summary\n")
-	fmt.Fprintf(o.streams.Out, "   - Cluster-wide GPU utilization\n")
-	fmt.Fprintf(o.streams.Out, "   - Cost trends\n")
-	fmt.Fprintf(o.streams.Out, "   - Key recommendations\n\n")
+	for _, attr := range attributions.Items {
+		row := attributionPeriodCost(attr, report.Since, report.Until)
+		report.Costs = append(report.Costs, row)
+		report.TotalCost += row.PeriodCost
 
-	fmt.Fprintf(o.streams.Out, "2. Utilization Analysis\n")
-	fmt.Fprintf(o.streams.Out, "   - Per-namespace breakdown\n")
-	fmt.Fprintf(o.streams.Out, "   - Top consumers\n")
-	fmt.Fprintf(o.streams.Out, "   - Underutilized resources\n\n")
+		report.Savings.TotalSavings += attr.Status.Savings.TotalSavings
+		report.Savings.SpotSavings += attr.Status.Savings.SpotSavings
+		report.Savings.SharingSavings += attr.Status.Savings.SharingSavings
+		report.Savings.AutoscalingSavings += attr.Status.Savings.AutoscalingSavings
+		report.Savings.WasteEliminated += attr.Status.Savings.WasteEliminated
+		report.Savings.BaselineCost += attr.Status.Savings.BaselineCost
+	}
+	if report.Savings.BaselineCost > 0 {
+		report.Savings.SavingsPercentage = report.Savings.TotalSavings / report.Savings.BaselineCost * 100
+	}
 
-	fmt.Fprintf(o.streams.Out, "3. Cost Analysis\n")
-	fmt.Fprintf(o.streams.Out, "   - Total GPU spend\n")
-	fmt.Fprintf(o.streams.Out, "   - Cost attribution\n")
-	fmt.Fprintf(o.streams.Out, "   - Savings from optimization\n\n")
+	sort.Slice(report.Costs, func(i, j int) bool {
+		return report.Costs[i].PeriodCost > report.Costs[j].PeriodCost
+	})
+	return nil
+}
 
-	fmt.Fprintf(o.streams.Out, "4. Optimization Opportunities\n")
-	fmt.Fprintf(o.streams.Out, "   - GPU sharing candidates\n")
-	fmt.Fprintf(o.streams.Out, "   - Spot instance opportunities\n")
-	fmt.Fprintf(o.streams.Out, "   - Autoscaling recommendations\n\n")
+// addROI runs the ROI reporter over the report period
+func (o *ReportOptions) addROI(ctx context.Context, k8sClient client.Client, clientset kubernetes.Interface, report *Report) error {
+	var db *cost.TimescaleDBClient
+	if o.TimescaleDBDSN != "" {
+		var err error
+		db, err = cost.NewTimescaleDBClient(o.TimescaleDBDSN)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+	}
 
-	fmt.Fprintf(o.streams.Out, "To generate this report, use:\n")
-	fmt.Fprintf(o.streams.Out, "  gpu-autoscaler report --format=%s\n", o.Format)
+	// The tracker only reads current pod costs here; it must not write data points
+	tracker := cost.NewCostTracker(clientset, cost.NewPricingClient(o.CloudProvider, o.Region), nil)
+	if err := tracker.Refresh(ctx); err != nil {
+		return err
+	}
 
+	period := cost.ReportPeriod{
+		StartDate: report.Since,
+		EndDate:   report.Until,
+		Duration:  report.Until.Sub(report.Since),
+		Label:     fmt.Sprintf("%s to %s", report.Since.Format("2006-01-02"), report.Until.Format("2006-01-02")),
+	}
+	roi, err := cost.NewROIReporter(k8sClient, clientset, tracker, db).GenerateReport(ctx, period)
+	if err != nil {
+		return err
+	}
+	report.ROI = roi
 	return nil
 }
+
+// attributionPeriodCost sums an attribution's history within the period, falling back to
+// extrapolating the current hourly rate when no history covers it
+func attributionPeriodCost(attr v1alpha1.CostAttribution, since, until time.Time) ReportCost {
+	row := ReportCost{
+		Name:        attr.Name,
+		Namespace:   attr.Spec.Namespace,
+		Team:        attr.Spec.Team,
+		HourlyCost:  attr.Status.HourlyCost,
+		MonthlyCost: attr.Status.MonthlyCost,
+		ActiveGPUs:  attr.Status.ActiveGPUs,
+	}
+
+	covered := false
+	for _, point := range attr.Status.HistoricalData {
+		ts := point.Timestamp.Time
+		if ts.Before(since) || ts.After(until) {
+			continue
+		}
+		row.PeriodCost += point.Cost
+		covered = true
+	}
+	if !covered {
+		row.PeriodCost = attr.Status.HourlyCost * until.Sub(since).Hours()
+		row.Estimated = true
+	}
+
+	return row
+}
+
+// findSharingOpportunities lists wasteful workloads, most wasteful first
+func findSharingOpportunities(wasteMetrics []metrics.WasteMetrics) []SharingOpportunity {
+	sort.Slice(wasteMetrics, func(i, j int) bool {
+		return wasteMetrics[i].WasteScore > wasteMetrics[j].WasteScore
+	})
+
+	var opportunities []SharingOpportunity
+	for _, waste := range wasteMetrics {
+		if waste.WasteScore < sharingWasteThreshold {
+			continue
+		}
+		opportunities = append(opportunities, SharingOpportunity{
+			Pod:                     waste.PodName,
+			Namespace:               waste.PodNamespace,
+			GPUs:                    waste.AllocatedGPUs,
+			AvgUtilization:          waste.AvgUtilization,
+			AvgMemoryUtil:           waste.AvgMemoryUtil,
+			Recommendation:          waste.Recommendation,
+			EstimatedMonthlySavings: waste.EstimatedMonthlyCost * sharingSavingsRatio,
+		})
+	}
+	return opportunities
+}
+
+// findSpotOpportunities lists autoscaling policies running below their spot target
+func findSpotOpportunities(policies []v1alpha1.AutoscalingPolicy) []SpotOpportunity {
+	var opportunities []SpotOpportunity
+	for _, policy := range policies {
+		if !policy.Spec.EnableSpotInstances || policy.Status.CurrentNodes == 0 {
+			continue
+		}
+
+		target := policy.Spec.SpotInstancePercentage
+		if target == 0 {
+			target = defaultSpotTarget
+		}
+		targetSpotNodes := int32(float64(policy.Status.CurrentNodes) * target)
+		extraSpotNodes := targetSpotNodes - policy.Status.SpotNodes
+		if extraSpotNodes <= 0 {
+			continue
+		}
+
+		nodeMonthlyCost := policy.Status.EstimatedMonthlyCost / float64(policy.Status.CurrentNodes)
+		opportunities = append(opportunities, SpotOpportunity{
+			Policy:                  policy.Name,
+			CurrentNodes:            policy.Status.CurrentNodes,
+			SpotNodes:               policy.Status.SpotNodes,
+			TargetSpotNodes:         targetSpotNodes,
+			EstimatedMonthlySavings: nodeMonthlyCost * spotDiscount * float64(extraSpotNodes),
+		})
+	}
+	return opportunities
+}
+
+// parseReportTime accepts an RFC3339 time, a YYYY-MM-DD date or a duration before now
+func parseReportTime(value string, now time.Time) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	duration, err := parseDuration(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%q is not a time, date or duration", value)
+	}
+	return now.Add(-duration), nil
+}
+
+func (r *Report) warn(format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
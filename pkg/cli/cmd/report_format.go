@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var reportFormats = []string{"text", "json", "csv", "markdown", "html"}
+
+// reportTable is one section of a report, shared by the tabular formats
+type reportTable struct {
+	Title   string
+	Headers []string
+	Rows    [][]string
+}
+
+func isReportFormat(format string) bool {
+	for _, f := range reportFormats {
+		if f == format {
+			return true
+		}
+	}
+	return false
+}
+
+// writeReport renders the report in the requested format
+func writeReport(w io.Writer, format string, report *Report) error {
+	switch format {
+	case "json":
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	case "csv":
+		return writeReportCSV(w, report)
+	case "markdown":
+		return writeReportMarkdown(w, report)
+	case "html":
+		return writeReportHTML(w, report)
+	default:
+		return writeReportText(w, report)
+	}
+}
+
+// tables lays out the report sections as tables
+func (r *Report) tables() []reportTable {
+	tables := []reportTable{{
+		Title:   "Summary",
+		Headers: []string{"Metric", "Value"},
+		Rows: [][]string{
+			{"Period", fmt.Sprintf("%s to %s", r.Since.Format(time.RFC3339), r.Until.Format(time.RFC3339))},
+			{"Total Cost", formatUSD(r.TotalCost)},
+			{"Total Savings", formatUSD(r.Savings.TotalSavings)},
+			{"Savings Percentage", fmt.Sprintf("%.1f%%", r.Savings.SavingsPercentage)},
+		},
+	}}
+
+	utilization := reportTable{
+		Title:   "GPU Utilization by Namespace",
+		Headers: []string{"Namespace", "GPUs", "Avg Utilization", "Avg Memory", "Wasted GPUs"},
+	}
+	for _, u := range r.Utilization {
+		utilization.Rows = append(utilization.Rows, []string{
+			u.Namespace,
+			fmt.Sprintf("%d", u.GPUs),
+			fmt.Sprintf("%.1f%%", u.AvgUtilization),
+			fmt.Sprintf("%.1f%%", u.AvgMemoryUtil),
+			fmt.Sprintf("%.1f", u.EstimatedWastedGPUs),
+		})
+	}
+	tables = append(tables, utilization)
+
+	costs := reportTable{
+		Title:   "Cost by Attribution",
+		Headers: []string{"Attribution", "Namespace", "Team", "Period Cost", "Hourly Rate", "Monthly Cost", "GPUs"},
+	}
+	for _, c := range r.Costs {
+		periodCost := formatUSD(c.PeriodCost)
+		if c.Estimated {
+			periodCost += " (est.)"
+		}
+		costs.Rows = append(costs.Rows, []string{
+			c.Name,
+			c.Namespace,
+			c.Team,
+			periodCost,
+			formatUSD(c.HourlyCost),
+			formatUSD(c.MonthlyCost),
+			fmt.Sprintf("%d", c.ActiveGPUs),
+		})
+	}
+	tables = append(tables, costs)
+
+	savings := reportTable{
+		Title:   "Savings",
+		Headers: []string{"Source", "Amount"},
+		Rows: [][]string{
+			{"Spot Instances", formatUSD(r.Savings.SpotSavings)},
+			{"GPU Sharing", formatUSD(r.Savings.SharingSavings)},
+			{"Autoscaling", formatUSD(r.Savings.AutoscalingSavings)},
+			{"Waste Elimination", formatUSD(r.Savings.WasteEliminated)},
+		},
+	}
+	if r.ROI != nil {
+		savings.Rows = append(savings.Rows,
+			[]string{"Monthly Savings (ROI)", formatUSD(r.ROI.ROIMetrics.MonthlySavings)},
+			[]string{"ROI", fmt.Sprintf("%.0f%%", r.ROI.ROIMetrics.ROIPercentage)},
+			[]string{"Payback Period", fmt.Sprintf("%d days", r.ROI.ROIMetrics.PaybackPeriodDays)},
+		)
+	}
+	tables = append(tables, savings)
+
+	sharing := reportTable{
+		Title:   "GPU Sharing Opportunities",
+		Headers: []string{"Pod", "Namespace", "GPUs", "Avg Utilization", "Avg Memory", "Recommendation", "Est. Monthly Savings"},
+	}
+	for _, s := range r.SharingOpportunities {
+		sharing.Rows = append(sharing.Rows, []string{
+			s.Pod,
+			s.Namespace,
+			fmt.Sprintf("%d", s.GPUs),
+			fmt.Sprintf("%.1f%%", s.AvgUtilization),
+			fmt.Sprintf("%.1f%%", s.AvgMemoryUtil),
+			s.Recommendation,
+			formatUSD(s.EstimatedMonthlySavings),
+		})
+	}
+	tables = append(tables, sharing)
+
+	spot := reportTable{
+		Title:   "Spot Instance Opportunities",
+		Headers: []string{"Policy", "Nodes", "Spot Nodes", "Target Spot Nodes", "Est. Monthly Savings"},
+	}
+	for _, s := range r.SpotOpportunities {
+		spot.Rows = append(spot.Rows, []string{
+			s.Policy,
+			fmt.Sprintf("%d", s.CurrentNodes),
+			fmt.Sprintf("%d", s.SpotNodes),
+			fmt.Sprintf("%d", s.TargetSpotNodes),
+			formatUSD(s.EstimatedMonthlySavings),
+		})
+	}
+	tables = append(tables, spot)
+
+	if r.ROI != nil && len(r.ROI.Recommendations) > 0 {
+		recommendations := reportTable{
+			Title:   "Recommendations",
+			Headers: []string{"Priority", "Type", "Description", "Est. Savings"},
+		}
+		for _, rec := range r.ROI.Recommendations {
+			recommendations.Rows = append(recommendations.Rows, []string{
+				rec.Priority,
+				rec.Type,
+				rec.Description,
+				formatUSD(rec.EstimatedSavings),
+			})
+		}
+		tables = append(tables, recommendations)
+	}
+
+	return tables
+}
+
+func writeReportText(w io.Writer, report *Report) error {
+	fmt.Fprintf(w, "\n=== GPU Autoscaler Report ===\n")
+	for _, table := range report.tables() {
+		fmt.Fprintf(w, "\n%s\n%s\n", table.Title, strings.Repeat("-", len(table.Title)))
+		if len(table.Rows) == 0 {
+			fmt.Fprintf(w, "None\n")
+			continue
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(tw, strings.Join(table.Headers, "\t"))
+		for _, row := range table.Rows {
+			fmt.Fprintln(tw, strings.Join(row, "\t"))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	for _, warning := range report.Warnings {
+		fmt.Fprintf(w, "\n⚠️  %s", warning)
+	}
+	fmt.Fprintf(w, "\n")
+	return nil
+}
+
+// writeReportCSV writes every table into a single CSV, keyed by a leading section column
+func writeReportCSV(w io.Writer, report *Report) error {
+	writer := csv.NewWriter(w)
+	for i, table := range report.tables() {
+		if i > 0 {
+			if err := writer.Write(nil); err != nil {
+				return err
+			}
+		}
+		if err := writer.Write(append([]string{"Section"}, table.Headers...)); err != nil {
+			return err
+		}
+		for _, row := range table.Rows {
+			if err := writer.Write(append([]string{table.Title}, row...)); err != nil {
+				return err
+			}
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func writeReportMarkdown(w io.Writer, report *Report) error {
+	fmt.Fprintf(w, "# GPU Autoscaler Report\n\n")
+	fmt.Fprintf(w, "Generated %s\n", report.GeneratedAt.Format(time.RFC3339))
+	for _, table := range report.tables() {
+		fmt.Fprintf(w, "\n## %s\n\n", table.Title)
+		if len(table.Rows) == 0 {
+			fmt.Fprintf(w, "None\n")
+			continue
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(table.Headers, " | "))
+		fmt.Fprintf(w, "|%s\n", strings.Repeat(" --- |", len(table.Headers)))
+		for _, row := range table.Rows {
+			cells := make([]string, len(row))
+			for i, cell := range row {
+				cells[i] = strings.ReplaceAll(cell, "|", "\\|")
+			}
+			fmt.Fprintf(w, "| %s |\n", strings.Join(cells, " | "))
+		}
+	}
+	if len(report.Warnings) > 0 {
+		fmt.Fprintf(w, "\n## Warnings\n\n")
+		for _, warning := range report.Warnings {
+			fmt.Fprintf(w, "- %s\n", warning)
+		}
+	}
+	return nil
+}
+
+var reportHTMLTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GPU Autoscaler Report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+table { border-collapse: collapse; margin-bottom: 1.5em; }
+th, td { border: 1px solid #ccc; padding: 4px 8px; text-align: left; }
+th { background: #f0f0f0; }
+.warning { color: #a60; }
+</style>
+</head>
+<body>
+<h1>GPU Autoscaler Report</h1>
+<p>Generated {{.GeneratedAt}}</p>
+{{range .Tables}}<h2>{{.Title}}</h2>
+{{if .Rows}}<table>
+<tr>{{range .Headers}}<th>{{.}}</th>{{end}}</tr>
+{{range .Rows}}<tr>{{range .}}<td>{{.}}</td>{{end}}</tr>
+{{end}}</table>
+{{else}}<p>None</p>
+{{end}}{{end}}{{range .Warnings}}<p class="warning">{{.}}</p>
+{{end}}</body>
+</html>
+`))
+
+func writeReportHTML(w io.Writer, report *Report) error {
+	return reportHTMLTemplate.Execute(w, struct {
+		GeneratedAt string
+		Tables      []reportTable
+		Warnings    []string
+	}{
+		GeneratedAt: report.GeneratedAt.Format(time.RFC3339),
+		Tables:      report.tables(),
+		Warnings:    report.Warnings,
+	})
+}
+
+func formatUSD(amount float64) string {
+	return fmt.Sprintf("$%.2f", amount)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/metrics"
+)
+
+func newTestReport() *Report {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	return &Report{
+		GeneratedAt: since.Add(7 * 24 * time.Hour),
+		Since:       since,
+		Until:       since.Add(7 * 24 * time.Hour),
+		Utilization: []metrics.NamespaceUtilization{
+			{Namespace: "ml", GPUs: 4, AvgUtilization: 35, AvgMemoryUtil: 20, EstimatedWastedGPUs: 2.6},
+		},
+		Costs:     []ReportCost{{Name: "ml-team", Namespace: "ml", Team: "research", PeriodCost: 420, HourlyCost: 2.5}},
+		TotalCost: 420,
+		Savings:   v1alpha1.SavingsData{TotalSavings: 100, SpotSavings: 100},
+		SharingOpportunities: []SharingOpportunity{
+			{Pod: "notebook", Namespace: "ml", GPUs: 1, AvgUtilization: 5, Recommendation: "Use MIG | MPS", EstimatedMonthlySavings: 730},
+		},
+	}
+}
+
+func TestWriteReport(t *testing.T) {
+	tests := []struct {
+		format   string
+		contains []string
+	}{
+		{format: "text", contains: []string{"GPU Utilization by Namespace", "ml-team", "$420.00"}},
+		{format: "markdown", contains: []string{"## Cost by Attribution", "| ml-team | ml | research |", `Use MIG \| MPS`}},
+		{format: "html", contains: []string{"<h2>GPU Sharing Opportunities</h2>", "<td>notebook</td>", "<p>None</p>"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.format, func(t *testing.T) {
+			var out bytes.Buffer
+			if err := writeReport(&out, tt.format, newTestReport()); err != nil {
+				t.Fatalf("writeReport() error = %v", err)
+			}
+			for _, s := range tt.contains {
+				if !strings.Contains(out.String(), s) {
+					t.Errorf("Expected %s output to contain %q, got:\n%s", tt.format, s, out.String())
+				}
+			}
+		})
+	}
+}
+
+func TestWriteReportStructuredFormats(t *testing.T) {
+	var jsonOut bytes.Buffer
+	if err := writeReport(&jsonOut, "json", newTestReport()); err != nil {
+		t.Fatalf("writeReport(json) error = %v", err)
+	}
+	decoded := &Report{}
+	if err := json.Unmarshal(jsonOut.Bytes(), decoded); err != nil {
+		t.Fatalf("Expected valid JSON, got error %v", err)
+	}
+	if decoded.TotalCost != 420 || len(decoded.Utilization) != 1 {
+		t.Errorf("Expected JSON to round-trip the report, got %+v", decoded)
+	}
+
+	var csvOut bytes.Buffer
+	if err := writeReport(&csvOut, "csv", newTestReport()); err != nil {
+		t.Fatalf("writeReport(csv) error = %v", err)
+	}
+	reader := csv.NewReader(&csvOut)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("Expected valid CSV, got error %v", err)
+	}
+	found := false
+	for _, record := range records {
+		if record[0] == "GPU Utilization by Namespace" && record[1] == "ml" {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("Expected a utilization row keyed by section, got %v", records)
+	}
+}
+
+func TestAttributionPeriodCost(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := since.Add(24 * time.Hour)
+	point := func(offset time.Duration, cost float64) v1alpha1.CostDataPoint {
+		return v1alpha1.CostDataPoint{Timestamp: metav1.Time{Time: since.Add(offset)}, Cost: cost}
+	}
+
+	tests := []struct {
+		name            string
+		history         []v1alpha1.CostDataPoint
+		expectCost      float64
+		expectEstimated bool
+	}{
+		{
+			name:       "Sums history within the period",
+			history:    []v1alpha1.CostDataPoint{point(-time.Hour, 100), point(time.Hour, 10), point(2*time.Hour, 15)},
+			expectCost: 25,
+		},
+		{
+			name:            "Extrapolates the hourly rate without history",
+			history:         []v1alpha1.CostDataPoint{point(-time.Hour, 100)},
+			expectCost:      48,
+			expectEstimated: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			attr := v1alpha1.CostAttribution{
+				ObjectMeta: metav1.ObjectMeta{Name: "ml-team"},
+				Status:     v1alpha1.CostAttributionStatus{HourlyCost: 2, HistoricalData: tt.history},
+			}
+			row := attributionPeriodCost(attr, since, until)
+			if row.PeriodCost != tt.expectCost {
+				t.Errorf("Expected period cost %.2f, got %.2f", tt.expectCost, row.PeriodCost)
+			}
+			if row.Estimated != tt.expectEstimated {
+				t.Errorf("Expected estimated=%v, got %v", tt.expectEstimated, row.Estimated)
+			}
+		})
+	}
+}
+
+func TestParseReportTime(t *testing.T) {
+	now := time.Date(2024, 1, 8, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value     string
+		expected  time.Time
+		expectErr bool
+	}{
+		{value: "7d", expected: now.Add(-7 * 24 * time.Hour)},
+		{value: "90m", expected: now.Add(-90 * time.Minute)},
+		{value: "2024-01-01T00:00:00Z", expected: time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{value: "last week", expectErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, err := parseReportTime(tt.value, now)
+			if (err != nil) != tt.expectErr {
+				t.Fatalf("Expected error=%v, got %v", tt.expectErr, err)
+			}
+			if !tt.expectErr && !got.Equal(tt.expected) {
+				t.Errorf("Expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -24,6 +24,8 @@ GPU workload placement.`,
 	rootCmd.AddCommand(NewOptimizeCmd(streams))
 	rootCmd.AddCommand(NewCostCmd(streams))
 	rootCmd.AddCommand(NewReportCmd(streams))
+	rootCmd.AddCommand(NewSimulateCmd(streams))
+	rootCmd.AddCommand(NewHistoryCmd(streams))
 	rootCmd.AddCommand(NewVersionCmd(streams))
 
 	return rootCmd
@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"sigs.k8s.io/yaml"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/scheduler"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/simulator"
+)
+
+type SimulateOptions struct {
+	TracePath            string
+	PolicyPath           string
+	ScaleUpThreshold     float64
+	ScaleDownThreshold   float64
+	ScaleUpCooldown      time.Duration
+	ScaleDownCooldown    time.Duration
+	SpotPercentage       float64
+	ProvisioningLatency  time.Duration
+	SpotInterruptionRate float64
+	PackStrategy         string
+	Step                 time.Duration
+	Seed                 int64
+	Format               string
+	streams              genericclioptions.IOStreams
+}
+
+// NewSimulateCmd creates the simulate command
+func NewSimulateCmd(streams genericclioptions.IOStreams) *cobra.Command {
+	o := &SimulateOptions{
+		streams: streams,
+		Format:  "text",
+	}
+
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Replay a workload trace through the autoscaler offline",
+		Long: `Replay a recorded trace of GPU pod arrivals, durations and utilization samples
+through the autoscaler's scaling decisions, the bin-packing scheduler and a
+simulated cloud provider, without touching a cluster.
+
+The simulation reports total cost, wasted GPU-hours, pending-time percentiles
+and spot interruptions, so policy settings can be tuned before they reach
+production.
+
+Examples:
+  # Replay a trace against the default policy
+  gpu-autoscaler simulate --trace trace.yaml
+
+  # Replay against an existing AutoscalingPolicy with a lower scale-up threshold
+  gpu-autoscaler simulate --trace trace.yaml --policy policy.yaml --scale-up-threshold 0.6
+
+  # Compare spot mixes with a 5% hourly interruption rate
+  gpu-autoscaler simulate --trace trace.yaml --spot-percentage 0.8 --spot-interruption-rate 0.05`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return o.Run(cmd)
+		},
+	}
+
+	cmd.Flags().StringVar(&o.TracePath, "trace", "", "Path to the workload trace (YAML or JSON)")
+	cmd.Flags().StringVar(&o.PolicyPath, "policy", "", "Path to an AutoscalingPolicy manifest (defaults to a spot and an on-demand pool)")
+	cmd.Flags().Float64Var(&o.ScaleUpThreshold, "scale-up-threshold", 0, "Override the policy's scaleUpThreshold (0-1)")
+	cmd.Flags().Float64Var(&o.ScaleDownThreshold, "scale-down-threshold", 0, "Override the policy's scaleDownThreshold (0-1)")
+	cmd.Flags().DurationVar(&o.ScaleUpCooldown, "scale-up-cooldown", 0, "Override the policy's scale-up cooldown")
+	cmd.Flags().DurationVar(&o.ScaleDownCooldown, "scale-down-cooldown", 0, "Override the policy's scale-down cooldown")
+	cmd.Flags().Float64Var(&o.SpotPercentage, "spot-percentage", 0, "Override the policy's spotInstancePercentage (0-1)")
+	cmd.Flags().DurationVar(&o.ProvisioningLatency, "provisioning-latency", 0, "How long new nodes take to register (default 1m30s)")
+	cmd.Flags().Float64Var(&o.SpotInterruptionRate, "spot-interruption-rate", 0, "Probability that a spot node is reclaimed in any hour")
+	cmd.Flags().StringVar(&o.PackStrategy, "pack-strategy", string(scheduler.BestFit), "Bin-packing strategy (bestfit, firstfit, worstfit)")
+	cmd.Flags().DurationVar(&o.Step, "step", simulator.DefaultStep, "Simulated time between reconciles")
+	cmd.Flags().Int64Var(&o.Seed, "seed", 0, "Seed for random spot interruptions")
+	cmd.Flags().StringVar(&o.Format, "format", "text", "Output format (text, json)")
+	_ = cmd.MarkFlagRequired("trace")
+
+	return cmd
+}
+
+func (o *SimulateOptions) Run(cmd *cobra.Command) error {
+	if o.Format != "text" && o.Format != "json" {
+		return fmt.Errorf("unsupported format %q: must be text or json", o.Format)
+	}
+
+	trace, err := simulator.LoadTrace(o.TracePath)
+	if err != nil {
+		return err
+	}
+
+	policy := simulator.DefaultPolicy()
+	if o.PolicyPath != "" {
+		if policy, err = loadPolicy(o.PolicyPath); err != nil {
+			return err
+		}
+	}
+
+	// Only flags set on the command line override the policy
+	flags := cmd.Flags()
+	if flags.Changed("scale-up-threshold") {
+		policy.Spec.ScaleUpThreshold = o.ScaleUpThreshold
+	}
+	if flags.Changed("scale-down-threshold") {
+		policy.Spec.ScaleDownThreshold = o.ScaleDownThreshold
+	}
+	if flags.Changed("scale-up-cooldown") {
+		policy.Spec.ScaleUpCooldownSeconds = int32(o.ScaleUpCooldown.Seconds())
+	}
+	if flags.Changed("scale-down-cooldown") {
+		policy.Spec.ScaleDownCooldownSeconds = int32(o.ScaleDownCooldown.Seconds())
+	}
+	if flags.Changed("spot-percentage") {
+		policy.Spec.SpotInstancePercentage = o.SpotPercentage
+	}
+
+	sim, err := simulator.NewSimulator(trace, simulator.Options{
+		Policy:               policy,
+		Step:                 o.Step,
+		ProvisioningLatency:  o.ProvisioningLatency,
+		PackStrategy:         scheduler.PackStrategy(o.PackStrategy),
+		SpotInterruptionRate: o.SpotInterruptionRate,
+		Seed:                 o.Seed,
+	})
+	if err != nil {
+		return err
+	}
+
+	report, err := sim.Run(context.Background())
+	if err != nil {
+		return err
+	}
+
+	if o.Format == "json" {
+		encoder := json.NewEncoder(o.streams.Out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(report)
+	}
+	return writeSimulationReport(o.streams.Out, policy, report)
+}
+
+// loadPolicy reads an AutoscalingPolicy manifest
+func loadPolicy(path string) (*v1alpha1.AutoscalingPolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy: %w", err)
+	}
+
+	policy := &v1alpha1.AutoscalingPolicy{}
+	if err := yaml.Unmarshal(data, policy); err != nil {
+		return nil, fmt.Errorf("failed to parse policy: %w", err)
+	}
+	if policy.Name == "" {
+		policy.Name = simulator.DefaultPolicyName
+	}
+	if len(policy.Spec.NodePools) == 0 {
+		return nil, fmt.Errorf("policy %s has no node pools", policy.Name)
+	}
+
+	return policy, nil
+}
+
+// writeSimulationReport prints the settings under test and the simulation outcome
+func writeSimulationReport(out io.Writer, policy *v1alpha1.AutoscalingPolicy, report *simulator.Report) error {
+	spec := policy.Spec
+
+	fmt.Fprintf(out, "\n=== Autoscaling Simulation ===\n\n")
+	fmt.Fprintf(out, "Policy: %s\n", policy.Name)
+	fmt.Fprintf(out, "Scale-up threshold: %.0f%%   Scale-down threshold: %.0f%%\n", spec.ScaleUpThreshold*100, spec.ScaleDownThreshold*100)
+	fmt.Fprintf(out, "Cooldowns: scale-up %s, scale-down %s\n",
+		time.Duration(spec.ScaleUpCooldownSeconds)*time.Second, time.Duration(spec.ScaleDownCooldownSeconds)*time.Second)
+	fmt.Fprintf(out, "Spot target: %.0f%%\n", spec.SpotInstancePercentage*100)
+	fmt.Fprintf(out, "Simulated time: %s\n\n", report.Duration.Duration)
+
+	w := tabwriter.NewWriter(out, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(w, "METRIC\tVALUE")
+	fmt.Fprintln(w, "------\t-----")
+	fmt.Fprintf(w, "Total cost\t$%.2f\n", report.TotalCost)
+	fmt.Fprintf(w, "GPU-hours provisioned\t%.1f\n", report.GPUHours)
+	fmt.Fprintf(w, "GPU-hours wasted\t%.1f\n", report.WastedGPUHours)
+	fmt.Fprintf(w, "Pending time p50\t%s\n", report.PendingP50.Duration)
+	fmt.Fprintf(w, "Pending time p95\t%s\n", report.PendingP95.Duration)
+	fmt.Fprintf(w, "Pending time max\t%s\n", report.PendingMax.Duration)
+	fmt.Fprintf(w, "Spot interruptions\t%d\n", report.SpotInterruptions)
+	fmt.Fprintf(w, "Pods completed\t%d/%d\n", report.CompletedPods, report.Pods)
+	fmt.Fprintf(w, "Pods unscheduled\t%d\n", report.UnscheduledPods)
+	fmt.Fprintf(w, "Pods restarted\t%d\n", report.RestartedPods)
+	fmt.Fprintf(w, "Nodes launched\t%d\n", report.NodesLaunched)
+	fmt.Fprintf(w, "Peak nodes\t%d\n", report.PeakNodes)
+	fmt.Fprintf(w, "Scaling errors\t%d\n", report.ScalingErrors)
+	return w.Flush()
+}
@@ -101,16 +101,7 @@ func (r *BudgetController) updateBudgetStatus(ctx context.Context, budget *v1alp
 	logger := log.FromContext(ctx)
 
 	// Determine budget period
-	startDate := time.Now()
-	if budget.Spec.StartDate != nil {
-		startDate = budget.Spec.StartDate.Time
-	} else {
-		// Default to start of current month
-		now := time.Now()
-		startDate = time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
-	}
-
-	endDate := startDate.AddDate(0, 1, 0) // One month from start
+	startDate, endDate := BudgetPeriod(budget, time.Now())
 
 	// Calculate current spend for this budget scope
 	currentSpend, err := r.calculateScopeSpend(ctx, budget.Spec.Scope, startDate, time.Now())
@@ -125,11 +116,11 @@ func (r *BudgetController) updateBudgetStatus(ctx context.Context, budget *v1alp
 	}
 
 	// Determine budget status
-	budgetStatus := "ok"
+	budgetStatus := BudgetStatusOK
 	if percentageUsed >= 100 {
-		budgetStatus = "exceeded"
+		budgetStatus = BudgetStatusExceeded
 	} else if percentageUsed >= 80 {
-		budgetStatus = "warning"
+		budgetStatus = BudgetStatusWarning
 	}
 
 	// Calculate projected monthly spend
@@ -153,10 +144,10 @@ func (r *BudgetController) updateBudgetStatus(ctx context.Context, budget *v1alp
 
 	// Track when budget first exceeded 100% (for grace period)
 	exceededSince := budget.Status.ExceededSince
-	if budgetStatus == "exceeded" && exceededSince == nil {
+	if budgetStatus == BudgetStatusExceeded && exceededSince == nil {
 		// First time exceeding budget
 		exceededSince = &metav1.Time{Time: now}
-	} else if budgetStatus != "exceeded" {
+	} else if budgetStatus != BudgetStatusExceeded {
 		// No longer exceeded, reset
 		exceededSince = nil
 	}
@@ -287,26 +278,11 @@ func (r *BudgetController) getScopePods(ctx context.Context, scope v1alpha1.Budg
 			continue
 		}
 
-		// Filter by experiment ID
-		if scope.ExperimentID != "" && pod.Labels["experiment-id"] != scope.ExperimentID {
+		// Filter by experiment ID and team
+		if !PodInBudgetScope(&pod, scope) {
 			continue
 		}
 
-		// Filter by team
-		if len(scope.Teams) > 0 {
-			teamLabel := pod.Labels["team"]
-			found := false
-			for _, team := range scope.Teams {
-				if teamLabel == team {
-					found = true
-					break
-				}
-			}
-			if !found {
-				continue
-			}
-		}
-
 		// Only GPU pods
 		if !isGPUPod(&pod) {
 			continue
@@ -422,7 +398,7 @@ func (r *BudgetController) enforceBudget(ctx context.Context, budget *v1alpha1.C
 	logger := log.FromContext(ctx)
 
 	// Only enforce if budget is exceeded
-	if budget.Status.BudgetStatus != "exceeded" {
+	if budget.Status.BudgetStatus != BudgetStatusExceeded {
 		if budget.Status.EnforcementActive {
 			logger.Info("Budget no longer exceeded, lifting enforcement", "budget", budget.Name)
 			budget.Status.EnforcementActive = false
@@ -455,12 +431,12 @@ func (r *BudgetController) enforceBudget(ctx context.Context, budget *v1alpha1.C
 		)
 
 		switch budget.Spec.Enforcement.Action {
-		case "alert":
+		case EnforcementActionAlert:
 			// Only alerting - already handled in checkAlerts
 			r.Recorder.Event(budget, corev1.EventTypeWarning, "BudgetExceeded",
 				"Budget has been exceeded")
 
-		case "throttle":
+		case EnforcementActionThrottle:
 			// Implement throttling logic
 			if err := r.throttleResources(ctx, budget); err != nil {
 				return fmt.Errorf("failed to throttle resources: %w", err)
@@ -468,7 +444,7 @@ func (r *BudgetController) enforceBudget(ctx context.Context, budget *v1alpha1.C
 			r.Recorder.Event(budget, corev1.EventTypeWarning, "BudgetThrottled",
 				"GPU resources are being throttled due to budget limit")
 
-		case "block":
+		case EnforcementActionBlock:
 			// Implement blocking logic
 			if err := r.blockNewPods(ctx, budget); err != nil {
 				logger.Error(err, "Failed to block new pods", "budget", budget.Name)
@@ -540,22 +516,16 @@ func (r *BudgetController) throttleResources(ctx context.Context, budget *v1alph
 	return nil
 }
 
-// blockNewPods prevents new GPU pod creation (NOT YET IMPLEMENTED)
+// blockNewPods prevents new GPU pod creation. Pods are rejected by the budget
+// validating webhook, which reads the budget status written by this controller.
 func (r *BudgetController) blockNewPods(ctx context.Context, budget *v1alpha1.CostBudget) error {
 	logger := log.FromContext(ctx)
-	logger.Info("Budget blocking requested but not yet implemented",
-		"budget", budget.Name)
-
-	// TODO: Full implementation requires:
-	// 1. Create/update a ConfigMap with budget enforcement state
-	// 2. Integrate with GPUOptimizationWebhook to read the ConfigMap
-	// 3. Webhook should validate pod creation against budget limits
-	// 4. Reject pods that would cause budget to exceed limits
-	//
-	// For now, this enforcement mode only logs the action.
-	// Use "throttle" mode for actual resource limitation.
-
-	return fmt.Errorf("block enforcement mode not yet implemented - use 'throttle' or 'alert' mode instead")
+	logger.Info("Blocking new GPU pods in budget scope",
+		"budget", budget.Name,
+		"currentSpend", budget.Status.CurrentSpend,
+		"monthlyLimit", budget.Spec.MonthlyLimit)
+
+	return nil
 }
 
 // Helper functions
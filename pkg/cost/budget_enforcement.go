@@ -0,0 +1,139 @@
+package cost
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	v1alpha1 "github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// Budget status values
+	BudgetStatusOK       = "ok"
+	BudgetStatusWarning  = "warning"
+	BudgetStatusExceeded = "exceeded"
+
+	// Budget enforcement actions
+	EnforcementActionAlert    = "alert"
+	EnforcementActionThrottle = "throttle"
+	EnforcementActionBlock    = "block"
+
+	// Pod labels used for budget scoping
+	TeamLabel         = "team"
+	ExperimentIDLabel = "experiment-id"
+)
+
+// PodInBudgetScope checks if a pod is covered by a budget scope
+func PodInBudgetScope(pod *corev1.Pod, scope v1alpha1.BudgetScope) bool {
+	if len(scope.Namespaces) > 0 && !containsString(scope.Namespaces, pod.Namespace) {
+		return false
+	}
+
+	for key, value := range scope.Labels {
+		if pod.Labels[key] != value {
+			return false
+		}
+	}
+
+	if scope.ExperimentID != "" && pod.Labels[ExperimentIDLabel] != scope.ExperimentID {
+		return false
+	}
+
+	if len(scope.Teams) > 0 && !containsString(scope.Teams, pod.Labels[TeamLabel]) {
+		return false
+	}
+
+	return true
+}
+
+// IsBudgetBlocking checks if a budget blocks new GPU pods at the given time.
+// A budget blocks once it is exceeded with the block action and its grace period has elapsed.
+func IsBudgetBlocking(budget *v1alpha1.CostBudget, now time.Time) bool {
+	if !budget.Spec.Enabled || budget.Spec.Enforcement == nil {
+		return false
+	}
+	if budget.Spec.Enforcement.Action != EnforcementActionBlock {
+		return false
+	}
+	if budget.Status.BudgetStatus != BudgetStatusExceeded {
+		return false
+	}
+
+	// Matches enforceBudget: without a recorded exceeded time the grace period is not applied
+	exceededTime := getExceededTime(budget)
+	gracePeriod := time.Duration(budget.Spec.Enforcement.GracePeriodMinutes) * time.Minute
+	return exceededTime.IsZero() || now.Sub(exceededTime) >= gracePeriod
+}
+
+// BudgetPeriod returns the start and end of the budget period containing now
+func BudgetPeriod(budget *v1alpha1.CostBudget, now time.Time) (time.Time, time.Time) {
+	// Default to start of current month
+	startDate := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, now.Location())
+	if budget.Spec.StartDate != nil {
+		startDate = budget.Spec.StartDate.Time
+	}
+	return startDate, startDate.AddDate(0, 1, 0)
+}
+
+// EstimatePodHourlyCost estimates the hourly cost of a pod before it is scheduled.
+// GPU type and capacity type are taken from the pod's node selector.
+func EstimatePodHourlyCost(ctx context.Context, pricingClient *PricingClient, pod *corev1.Pod) (float64, error) {
+	gpuCount := getGPUCount(pod)
+	if gpuCount == 0 {
+		return 0, nil
+	}
+
+	// The node selector describes the node the pod will land on
+	target := &corev1.Node{}
+	target.Labels = pod.Spec.NodeSelector
+
+	gpuType := getGPUType(target)
+	if gpuType == "" {
+		gpuType = "unknown"
+	}
+
+	pricing, err := pricingClient.GetGPUPricing(ctx, GPUPricingRequest{
+		GPUType:      gpuType,
+		CapacityType: getCapacityType(target),
+		Region:       pod.Spec.NodeSelector["topology.kubernetes.io/region"],
+		Zone:         pod.Spec.NodeSelector["topology.kubernetes.io/zone"],
+	})
+	if err != nil {
+		return 0, fmt.Errorf("failed to get pricing: %w", err)
+	}
+
+	hourlyRate := pricing.PricePerGPUHour * float64(gpuCount)
+	if sharingMode := getSharingMode(pod); sharingMode != "exclusive" {
+		hourlyRate *= getSharingFactor(pod, sharingMode)
+	}
+
+	return hourlyRate, nil
+}
+
+// ProjectedSpendWithPod projects the budget's spend at period end if a pod with the
+// given hourly cost is admitted now and runs until the period ends
+func ProjectedSpendWithPod(budget *v1alpha1.CostBudget, podHourlyCost float64, now time.Time) float64 {
+	_, endDate := BudgetPeriod(budget, now)
+
+	projected := budget.Status.ProjectedMonthlySpend
+	if projected < budget.Status.CurrentSpend {
+		projected = budget.Status.CurrentSpend
+	}
+
+	if remaining := endDate.Sub(now); remaining > 0 {
+		projected += podHourlyCost * remaining.Hours()
+	}
+
+	return projected
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -137,7 +137,7 @@ func (pc *PricingClient) fetchAWSPricing(ctx context.Context, req GPUPricingRequ
 	key := fmt.Sprintf("%s:%s", region, instanceType)
 
 	if price, ok := priceMap[key]; ok {
-		gpuCount := getGPUCountForInstanceType(instanceType)
+		gpuCount := GetGPUCountForInstanceType(instanceType)
 		pricePerGPU := price / float64(gpuCount)
 
 		return &GPUPricing{
@@ -164,7 +164,7 @@ func (pc *PricingClient) fetchAWSSpotPricing(ctx context.Context, instanceType,
 	key := fmt.Sprintf("%s:%s", region, instanceType)
 
 	if price, ok := spotPrices[key]; ok {
-		gpuCount := getGPUCountForInstanceType(instanceType)
+		gpuCount := GetGPUCountForInstanceType(instanceType)
 		pricePerGPU := price / float64(gpuCount)
 
 		return &GPUPricing{
@@ -403,7 +403,8 @@ func mapGPUTypeToAzureVM(gpuType string) string {
 	return "Standard_ND96asr_v4" // default
 }
 
-func getGPUCountForInstanceType(instanceType string) int {
+// GetGPUCountForInstanceType returns the number of GPUs in an AWS instance type, defaulting to 1
+func GetGPUCountForInstanceType(instanceType string) int {
 	counts := map[string]int{
 		"p4d.24xlarge":   8,
 		"p4de.24xlarge":  8,
@@ -98,6 +98,11 @@ func (ct *CostTracker) Start(ctx context.Context, interval time.Duration) {
 	}
 }
 
+// Refresh recalculates costs for all GPU pods once, for callers that do not run the tracking loop
+func (ct *CostTracker) Refresh(ctx context.Context) error {
+	return ct.updateCosts(ctx)
+}
+
 // updateCosts recalculates costs for all GPU pods
 func (ct *CostTracker) updateCosts(ctx context.Context) error {
 	logger := log.FromContext(ctx)
@@ -3,6 +3,7 @@ package metrics
 import (
 	"context"
 	"fmt"
+	"sort"
 	"time"
 
 	promapi "github.com/prometheus/client_golang/api"
@@ -43,6 +44,15 @@ type WasteMetrics struct {
 	EstimatedMonthlyCost float64
 }
 
+// NamespaceUtilization summarizes GPU utilization for a namespace over a period
+type NamespaceUtilization struct {
+	Namespace           string
+	GPUs                int
+	AvgUtilization      float64 // Percentage (0-100)
+	AvgMemoryUtil       float64 // Percentage (0-100)
+	EstimatedWastedGPUs float64 // GPUs' worth of unused capacity
+}
+
 // Collector collects GPU metrics from Prometheus and enriches with Kubernetes metadata
 type Collector struct {
 	promURL    string
@@ -123,10 +133,17 @@ func (c *Collector) GetGPUMetrics(ctx context.Context) ([]GPUMetrics, error) {
 
 // GetWasteMetrics analyzes GPU metrics to identify waste and optimization opportunities
 func (c *Collector) GetWasteMetrics(ctx context.Context, lookbackMinutes int) ([]WasteMetrics, error) {
-	// Query average GPU utilization over the lookback period
-	query := fmt.Sprintf(`avg_over_time(DCGM_FI_DEV_GPU_UTIL[%dm])`, lookbackMinutes)
+	end := time.Now()
+	return c.GetWasteMetricsForPeriod(ctx, end.Add(-time.Duration(lookbackMinutes)*time.Minute), end)
+}
 
-	result, warnings, err := c.promClient.Query(ctx, query, time.Now())
+// GetWasteMetricsForPeriod analyzes average GPU utilization between start and end
+func (c *Collector) GetWasteMetricsForPeriod(ctx context.Context, start, end time.Time) ([]WasteMetrics, error) {
+	// Query average GPU utilization over the period
+	window := model.Duration(end.Sub(start)).String()
+	query := fmt.Sprintf(`avg_over_time(DCGM_FI_DEV_GPU_UTIL[%s])`, window)
+
+	result, warnings, err := c.promClient.Query(ctx, query, end)
 	if err != nil {
 		return nil, fmt.Errorf("failed to query Prometheus: %w", err)
 	}
@@ -142,7 +159,7 @@ func (c *Collector) GetWasteMetrics(ctx context.Context, lookbackMinutes int) ([
 			avgUtil := float64(sample.Value)
 
 			// Get memory utilization
-			memUtil, err := c.getAvgMemoryUtil(ctx, sample.Metric, lookbackMinutes)
+			memUtil, err := c.getAvgMemoryUtil(ctx, sample.Metric, window, end)
 			if err != nil {
 				klog.Warningf("Failed to get memory utilization: %v", err)
 				memUtil = 0
@@ -182,6 +199,46 @@ func (c *Collector) GetWasteMetrics(ctx context.Context, lookbackMinutes int) ([
 	return wasteMetrics, nil
 }
 
+// GetNamespaceUtilization summarizes average GPU utilization per namespace between start and end
+func (c *Collector) GetNamespaceUtilization(ctx context.Context, start, end time.Time) ([]NamespaceUtilization, error) {
+	wasteMetrics, err := c.GetWasteMetricsForPeriod(ctx, start, end)
+	if err != nil {
+		return nil, err
+	}
+	return AggregateByNamespace(wasteMetrics), nil
+}
+
+// AggregateByNamespace averages per-GPU utilization into per-namespace totals, sorted by namespace
+func AggregateByNamespace(wasteMetrics []WasteMetrics) []NamespaceUtilization {
+	byNamespace := make(map[string]*NamespaceUtilization)
+	var namespaces []string
+
+	for _, waste := range wasteMetrics {
+		usage, ok := byNamespace[waste.PodNamespace]
+		if !ok {
+			usage = &NamespaceUtilization{Namespace: waste.PodNamespace}
+			byNamespace[waste.PodNamespace] = usage
+			namespaces = append(namespaces, waste.PodNamespace)
+		}
+		// Each sample is one physical GPU
+		usage.GPUs++
+		usage.AvgUtilization += waste.AvgUtilization
+		usage.AvgMemoryUtil += waste.AvgMemoryUtil
+		usage.EstimatedWastedGPUs += (100 - waste.AvgUtilization) / 100
+	}
+
+	sort.Strings(namespaces)
+	result := make([]NamespaceUtilization, 0, len(namespaces))
+	for _, namespace := range namespaces {
+		usage := byNamespace[namespace]
+		usage.AvgUtilization /= float64(usage.GPUs)
+		usage.AvgMemoryUtil /= float64(usage.GPUs)
+		result = append(result, *usage)
+	}
+
+	return result
+}
+
 // enrichGPUMetrics adds memory, power, and temperature metrics
 func (c *Collector) enrichGPUMetrics(ctx context.Context, metric *GPUMetrics) error {
 	// Query GPU memory used
@@ -230,12 +287,13 @@ func (c *Collector) enrichGPUMetrics(ctx context.Context, metric *GPUMetrics) er
 	return nil
 }
 
-// getAvgMemoryUtil gets average memory utilization over the lookback period
-func (c *Collector) getAvgMemoryUtil(ctx context.Context, labels model.Metric, lookbackMinutes int) (float64, error) {
-	query := fmt.Sprintf(`avg_over_time((DCGM_FI_DEV_FB_USED / DCGM_FI_DEV_FB_TOTAL * 100){kubernetes_node="%s",gpu="%s"}[%dm])`,
-		labels["kubernetes_node"], labels["gpu"], lookbackMinutes)
+// getAvgMemoryUtil gets average memory utilization over the window ending at the given time
+func (c *Collector) getAvgMemoryUtil(ctx context.Context, labels model.Metric, window string, at time.Time) (float64, error) {
+	selector := fmt.Sprintf(`{kubernetes_node="%s",gpu="%s"}`, labels["kubernetes_node"], labels["gpu"])
+	query := fmt.Sprintf(`avg_over_time((DCGM_FI_DEV_FB_USED%s / DCGM_FI_DEV_FB_TOTAL%s * 100)[%s:])`,
+		selector, selector, window)
 
-	result, _, err := c.promClient.Query(ctx, query, time.Now())
+	result, _, err := c.promClient.Query(ctx, query, at)
 	if err != nil {
 		return 0, err
 	}
@@ -180,50 +180,24 @@ func (s *BinPackingScheduler) getGPUNodes(ctx context.Context) ([]*GPUNode, erro
 	var gpuNodes []*GPUNode
 	for i := range nodeList.Items {
 		node := &nodeList.Items[i]
-		gpuCapacity, hasGPU := node.Status.Capacity["nvidia.com/gpu"]
-		if !hasGPU {
+		if _, hasGPU := node.Status.Capacity["nvidia.com/gpu"]; !hasGPU {
 			continue
 		}
 
-		totalGPUs := int(gpuCapacity.Value())
-
 		// Get allocated GPUs from running pods
 		podList := &corev1.PodList{}
 		if err := s.client.List(ctx, podList, client.MatchingFields{"spec.nodeName": node.Name}); err != nil {
 			return nil, err
 		}
 
-		allocatedGPUs := 0
-		var allocatedPods []*corev1.Pod
+		var runningPods []*corev1.Pod
 		for j := range podList.Items {
-			pod := &podList.Items[j]
-			if pod.Status.Phase == corev1.PodRunning {
-				for _, container := range pod.Spec.Containers {
-					if gpuReq, ok := container.Resources.Requests["nvidia.com/gpu"]; ok {
-						allocatedGPUs += int(gpuReq.Value())
-						allocatedPods = append(allocatedPods, pod)
-					}
-				}
+			if podList.Items[j].Status.Phase == corev1.PodRunning {
+				runningPods = append(runningPods, &podList.Items[j])
 			}
 		}
 
-		// Check GPU capabilities from node labels
-		supportsMIG := node.Labels["nvidia.com/mig.capable"] == "true"
-		supportsMPS := node.Labels["nvidia.com/mps.capable"] == "true"
-		supportsTimeSlice := node.Labels["nvidia.com/time-slicing.capable"] == "true"
-		gpuType := node.Labels["nvidia.com/gpu.product"]
-
-		gpuNode := &GPUNode{
-			Name:              node.Name,
-			TotalGPUs:         totalGPUs,
-			AvailableGPUs:     totalGPUs - allocatedGPUs,
-			GPUType:           gpuType,
-			AllocatedPods:     allocatedPods,
-			SupportsMIG:       supportsMIG,
-			SupportsMPS:       supportsMPS,
-			SupportsTimeSlice: supportsTimeSlice,
-		}
-
+		gpuNode := NewGPUNode(node, runningPods)
 		gpuNodes = append(gpuNodes, gpuNode)
 	}
 
@@ -246,46 +220,11 @@ func (s *BinPackingScheduler) getPendingGPUWorkloads(ctx context.Context) ([]*GP
 			continue
 		}
 
-		// Calculate total GPU request
-		totalGPURequest := 0
-		totalMemoryRequest := int64(0)
-		hasGPURequest := false
-
-		for _, container := range pod.Spec.Containers {
-			if gpuReq, ok := container.Resources.Requests["nvidia.com/gpu"]; ok {
-				totalGPURequest += int(gpuReq.Value())
-				hasGPURequest = true
-			}
-			if memReq, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
-				totalMemoryRequest += memReq.Value()
-			}
-		}
-
-		if !hasGPURequest {
+		workload := NewGPUWorkload(pod)
+		if workload.GPURequest == 0 {
 			continue
 		}
 
-		// Get workload preferences from annotations
-		sharingEnabled := pod.Annotations["gpu-autoscaler.io/sharing"] == "enabled"
-		preferredMode := pod.Annotations["gpu-autoscaler.io/sharing-mode"]
-		if preferredMode == "" {
-			preferredMode = "exclusive"
-		}
-
-		priority := int32(0)
-		if pod.Spec.Priority != nil {
-			priority = *pod.Spec.Priority
-		}
-
-		workload := &GPUWorkload{
-			Pod:            pod,
-			GPURequest:     totalGPURequest,
-			MemoryRequest:  totalMemoryRequest,
-			Priority:       priority,
-			SharingEnabled: sharingEnabled,
-			PreferredMode:  preferredMode,
-		}
-
 		workloads = append(workloads, workload)
 	}
 
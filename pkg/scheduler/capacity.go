@@ -0,0 +1,206 @@
+package scheduler
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/sharing"
+)
+
+const (
+	// MIGSlicesPerGPU is the number of compute slices a MIG-capable GPU is partitioned into
+	MIGSlicesPerGPU = 7
+
+	// SharedGPUResource is the resource the NVIDIA device plugin advertises for time-sliced
+	// replicas when it renames shared GPUs
+	SharedGPUResource = "nvidia.com/gpu.shared"
+)
+
+// GPUShape breaks a pod's GPU request down by how the GPUs are shared
+type GPUShape struct {
+	WholeGPUs          int   // exclusive nvidia.com/gpu
+	MIGInstances       []int // compute slices of each MIG instance, 1/7th of a GPU each
+	TimeSlicedReplicas int   // time-sliced replicas of a GPU
+}
+
+// GetGPUShapeFromPod returns the whole, MIG and time-sliced GPUs a pod requests.
+// nvidia.com/gpu requests count as time-sliced replicas on pods converted to time-slicing.
+func GetGPUShapeFromPod(pod *corev1.Pod) GPUShape {
+	timeSliced := pod.Annotations["gpu-autoscaler.io/time-slicing-enabled"] == "true" ||
+		pod.Annotations["nvidia.com/time-slicing"] == "enabled"
+
+	var shape GPUShape
+	for _, container := range pod.Spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			count := int(quantity.Value())
+			switch {
+			case name == "nvidia.com/gpu" && timeSliced:
+				shape.TimeSlicedReplicas += count
+			case name == "nvidia.com/gpu":
+				shape.WholeGPUs += count
+			case name == SharedGPUResource:
+				shape.TimeSlicedReplicas += count
+			case strings.HasPrefix(string(name), "nvidia.com/mig-"):
+				for i := 0; i < count; i++ {
+					shape.MIGInstances = append(shape.MIGInstances, migSliceCount(string(name)))
+				}
+			}
+		}
+	}
+	// Largest instances first, so they claim GPUs before smaller ones fragment them
+	sort.Sort(sort.Reverse(sort.IntSlice(shape.MIGInstances)))
+	return shape
+}
+
+// MIGSlices returns the compute slices of all the shape's MIG instances
+func (s GPUShape) MIGSlices() int {
+	slices := 0
+	for _, instance := range s.MIGInstances {
+		slices += instance
+	}
+	return slices
+}
+
+// Units returns the shape in packing units, where one GPU is MIGSlicesPerGPU*replicasPerGPU units,
+// so MIG slices and time-sliced replicas pack exactly alongside whole GPUs
+func (s GPUShape) Units(replicasPerGPU int) int {
+	return s.WholeGPUs*MIGSlicesPerGPU*replicasPerGPU + s.MIGSlices()*replicasPerGPU + s.TimeSlicedReplicas*MIGSlicesPerGPU
+}
+
+// NodePlan is the result of a what-if packing of pods onto new nodes
+type NodePlan struct {
+	Nodes       int
+	PlacedPods  int
+	Unplaceable []*corev1.Pod // pods that do not fit on a single new node
+}
+
+// PlanNodes packs pods onto hypothetical empty nodes with gpusPerNode GPUs each, using the
+// scheduler's fit logic, and returns how many nodes they need. Time-sliced requests are packed
+// replicasPerGPU to a GPU, and each MIG instance must fit in the free slices of a single GPU.
+func (s *BinPackingScheduler) PlanNodes(pods []*corev1.Pod, gpusPerNode, replicasPerGPU int) *NodePlan {
+	if replicasPerGPU <= 0 {
+		replicasPerGPU = sharing.DefaultTimeSlicingConfig().ReplicasPerGPU
+	}
+	unitsPerGPU := MIGSlicesPerGPU * replicasPerGPU
+
+	workloads := make([]*GPUWorkload, 0, len(pods))
+	shapes := make(map[*GPUWorkload]GPUShape, len(pods))
+	for _, pod := range pods {
+		workload := NewGPUWorkload(pod)
+		shape := GetGPUShapeFromPod(pod)
+		workload.GPURequest = shape.Units(replicasPerGPU)
+		if workload.GPURequest > 0 {
+			workloads = append(workloads, workload)
+			shapes[workload] = shape
+		}
+	}
+
+	// Largest requests first, so smaller ones fill the gaps they leave
+	sort.SliceStable(workloads, func(i, j int) bool {
+		return workloads[i].GPURequest > workloads[j].GPURequest
+	})
+
+	plan := &NodePlan{}
+	var nodes []*GPUNode
+	gpus := make(map[*GPUNode][]int) // free units of each GPU on a planned node
+	for _, workload := range workloads {
+		shape := shapes[workload]
+
+		// Only nodes with room on individual GPUs are candidates, the strategy picks among them
+		var candidates []*GPUNode
+		for _, node := range nodes {
+			if allocateGPUs(gpus[node], shape, unitsPerGPU, replicasPerGPU) != nil {
+				candidates = append(candidates, node)
+			}
+		}
+
+		node := s.selectNode(candidates, workload)
+		if node == nil {
+			node = &GPUNode{
+				Name:          fmt.Sprintf("planned-node-%d", len(nodes)+1),
+				TotalGPUs:     gpusPerNode * unitsPerGPU,
+				AvailableGPUs: gpusPerNode * unitsPerGPU,
+			}
+			gpus[node] = make([]int, gpusPerNode)
+			for i := range gpus[node] {
+				gpus[node][i] = unitsPerGPU
+			}
+			if fits, _ := Fits(node, workload); !fits || allocateGPUs(gpus[node], shape, unitsPerGPU, replicasPerGPU) == nil {
+				plan.Unplaceable = append(plan.Unplaceable, workload.Pod)
+				continue
+			}
+			nodes = append(nodes, node)
+		}
+
+		gpus[node] = allocateGPUs(gpus[node], shape, unitsPerGPU, replicasPerGPU)
+		node.AvailableGPUs -= workload.GPURequest
+		node.AllocatedPods = append(node.AllocatedPods, workload.Pod)
+		plan.PlacedPods++
+	}
+
+	plan.Nodes = len(nodes)
+	return plan
+}
+
+// allocateGPUs places a shape on a node's GPUs, given the free units of each, and returns the
+// GPUs' free units afterwards, or nil when it does not fit. Whole GPUs take unused GPUs, while
+// MIG instances and time-sliced replicas each go on the fullest GPU with room for them.
+func allocateGPUs(free []int, shape GPUShape, unitsPerGPU, replicasPerGPU int) []int {
+	remaining := append([]int(nil), free...)
+
+	for i := 0; i < shape.WholeGPUs; i++ {
+		gpu := bestFitGPU(remaining, unitsPerGPU)
+		if gpu < 0 {
+			return nil
+		}
+		remaining[gpu] = 0
+	}
+
+	units := make([]int, 0, len(shape.MIGInstances)+shape.TimeSlicedReplicas)
+	for _, slices := range shape.MIGInstances {
+		units = append(units, slices*replicasPerGPU)
+	}
+	for i := 0; i < shape.TimeSlicedReplicas; i++ {
+		units = append(units, MIGSlicesPerGPU)
+	}
+	for _, request := range units {
+		gpu := bestFitGPU(remaining, request)
+		if gpu < 0 {
+			return nil
+		}
+		remaining[gpu] -= request
+	}
+
+	return remaining
+}
+
+// bestFitGPU returns the GPU with the fewest free units that still has request units free,
+// or -1 when none does
+func bestFitGPU(free []int, request int) int {
+	best := -1
+	for i, units := range free {
+		if units >= request && (best < 0 || units < free[best]) {
+			best = i
+		}
+	}
+	return best
+}
+
+// migSliceCount returns the compute slices of a MIG resource such as nvidia.com/mig-3g.20gb,
+// treating unrecognised profiles as a whole GPU
+func migSliceCount(resourceName string) int {
+	profile := strings.TrimPrefix(resourceName, "nvidia.com/mig-")
+	slices, _, found := strings.Cut(profile, "g.")
+	if !found {
+		return MIGSlicesPerGPU
+	}
+	count, err := strconv.Atoi(slices)
+	if err != nil || count <= 0 || count > MIGSlicesPerGPU {
+		return MIGSlicesPerGPU
+	}
+	return count
+}
@@ -0,0 +1,141 @@
+package scheduler
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func newShapedPod(name string, requests corev1.ResourceList, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Annotations: annotations},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name:      "main",
+				Resources: corev1.ResourceRequirements{Requests: requests},
+			}},
+		},
+	}
+}
+
+func TestGetGPUShapeFromPod(t *testing.T) {
+	tests := []struct {
+		name     string
+		pod      *corev1.Pod
+		expected GPUShape
+	}{
+		{
+			name:     "whole GPUs",
+			pod:      newPlacementPod("trainer", "2"),
+			expected: GPUShape{WholeGPUs: 2},
+		},
+		{
+			name:     "MIG profile",
+			pod:      newShapedPod("notebook", corev1.ResourceList{"nvidia.com/mig-3g.20gb": resource.MustParse("2")}, nil),
+			expected: GPUShape{MIGInstances: []int{3, 3}},
+		},
+		{
+			name:     "unrecognised MIG profile",
+			pod:      newShapedPod("notebook", corev1.ResourceList{"nvidia.com/mig-custom": resource.MustParse("1")}, nil),
+			expected: GPUShape{MIGInstances: []int{7}},
+		},
+		{
+			name: "time-sliced",
+			pod: newShapedPod("inference", corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				map[string]string{"gpu-autoscaler.io/time-slicing-enabled": "true"}),
+			expected: GPUShape{TimeSlicedReplicas: 1},
+		},
+		{
+			name:     "shared GPU resource",
+			pod:      newShapedPod("inference", corev1.ResourceList{SharedGPUResource: resource.MustParse("3")}, nil),
+			expected: GPUShape{TimeSlicedReplicas: 3},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := GetGPUShapeFromPod(tt.pod); !reflect.DeepEqual(got, tt.expected) {
+				t.Errorf("Expected %+v, got %+v", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestPlanNodes(t *testing.T) {
+	pods := func(count int, newPod func(name string) *corev1.Pod) []*corev1.Pod {
+		result := make([]*corev1.Pod, count)
+		for i := range result {
+			result[i] = newPod(fmt.Sprintf("pod-%d", i))
+		}
+		return result
+	}
+	wholeGPUs := func(gpus string) func(string) *corev1.Pod {
+		return func(name string) *corev1.Pod { return newPlacementPod(name, gpus) }
+	}
+	migSlice := func(name string) *corev1.Pod {
+		return newShapedPod(name, corev1.ResourceList{"nvidia.com/mig-1g.5gb": resource.MustParse("1")}, nil)
+	}
+	migInstance := func(profile string) func(string) *corev1.Pod {
+		return func(name string) *corev1.Pod {
+			return newShapedPod(name, corev1.ResourceList{corev1.ResourceName("nvidia.com/mig-" + profile): resource.MustParse("1")}, nil)
+		}
+	}
+	timeSliced := func(name string) *corev1.Pod {
+		return newShapedPod(name, corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+			map[string]string{"nvidia.com/time-slicing": "enabled"})
+	}
+
+	tests := []struct {
+		name                string
+		pods                []*corev1.Pod
+		gpusPerNode         int
+		replicasPerGPU      int
+		expectedNodes       int
+		expectedPlaced      int
+		expectedUnplaceable int
+	}{
+		{name: "two 2-GPU pods per 4-GPU node", pods: pods(3, wholeGPUs("2")), gpusPerNode: 4, expectedNodes: 2, expectedPlaced: 3},
+		{name: "8-GPU pods on 8-GPU nodes", pods: pods(3, wholeGPUs("8")), gpusPerNode: 8, expectedNodes: 3, expectedPlaced: 3},
+		{name: "pods larger than a node", pods: pods(2, wholeGPUs("8")), gpusPerNode: 4, expectedUnplaceable: 2},
+		{name: "MIG slices share a GPU", pods: pods(7, migSlice), gpusPerNode: 1, expectedNodes: 1, expectedPlaced: 7},
+		{name: "MIG slices overflow a GPU", pods: pods(8, migSlice), gpusPerNode: 1, expectedNodes: 2, expectedPlaced: 8},
+		{name: "MIG instances do not span GPUs", pods: pods(3, migInstance("4g.20gb")), gpusPerNode: 2, expectedNodes: 2, expectedPlaced: 3},
+		{
+			name:           "MIG instances fill a GPU",
+			pods:           append(pods(2, migInstance("4g.20gb")), pods(2, migInstance("3g.20gb"))...),
+			gpusPerNode:    2,
+			expectedNodes:  1,
+			expectedPlaced: 4,
+		},
+		{name: "time-sliced replicas", pods: pods(8, timeSliced), gpusPerNode: 1, replicasPerGPU: 4, expectedNodes: 2, expectedPlaced: 8},
+		{name: "default replicas", pods: pods(4, timeSliced), gpusPerNode: 1, expectedNodes: 1, expectedPlaced: 4},
+		{
+			name:           "mixed shapes fill gaps",
+			pods:           append(pods(1, wholeGPUs("3")), append(pods(7, migSlice), pods(4, timeSliced)...)...),
+			gpusPerNode:    4,
+			replicasPerGPU: 4,
+			expectedNodes:  2,
+			expectedPlaced: 12,
+		},
+	}
+
+	s := NewBinPackingScheduler(nil, BestFit)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan := s.PlanNodes(tt.pods, tt.gpusPerNode, tt.replicasPerGPU)
+			if plan.Nodes != tt.expectedNodes {
+				t.Errorf("Expected %d nodes, got %d", tt.expectedNodes, plan.Nodes)
+			}
+			if plan.PlacedPods != tt.expectedPlaced {
+				t.Errorf("Expected %d placed pods, got %d", tt.expectedPlaced, plan.PlacedPods)
+			}
+			if len(plan.Unplaceable) != tt.expectedUnplaceable {
+				t.Errorf("Expected %d unplaceable pods, got %d", tt.expectedUnplaceable, len(plan.Unplaceable))
+			}
+		})
+	}
+}
@@ -0,0 +1,167 @@
+package scheduler
+
+import (
+	"fmt"
+	"sync"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// NewGPUNode builds GPU accounting for a node from the pods assigned to it
+func NewGPUNode(node *corev1.Node, pods []*corev1.Pod) *GPUNode {
+	gpuCapacity := node.Status.Capacity["nvidia.com/gpu"]
+	totalGPUs := int(gpuCapacity.Value())
+
+	allocatedGPUs := 0
+	var allocatedPods []*corev1.Pod
+	for _, pod := range pods {
+		if gpus := GetGPURequestFromPod(pod); gpus > 0 {
+			allocatedGPUs += gpus
+			allocatedPods = append(allocatedPods, pod)
+		}
+	}
+
+	return &GPUNode{
+		Name:              node.Name,
+		TotalGPUs:         totalGPUs,
+		AvailableGPUs:     totalGPUs - allocatedGPUs,
+		GPUType:           node.Labels["nvidia.com/gpu.product"],
+		AllocatedPods:     allocatedPods,
+		SupportsMIG:       node.Labels["nvidia.com/mig.capable"] == "true",
+		SupportsMPS:       node.Labels["nvidia.com/mps.capable"] == "true",
+		SupportsTimeSlice: node.Labels["nvidia.com/time-slicing.capable"] == "true",
+	}
+}
+
+// NewGPUWorkload describes a pod's GPU request for placement
+func NewGPUWorkload(pod *corev1.Pod) *GPUWorkload {
+	memoryRequest := int64(0)
+	for _, container := range pod.Spec.Containers {
+		if memReq, ok := container.Resources.Requests[corev1.ResourceMemory]; ok {
+			memoryRequest += memReq.Value()
+		}
+	}
+
+	priority := int32(0)
+	if pod.Spec.Priority != nil {
+		priority = *pod.Spec.Priority
+	}
+
+	preferredMode := pod.Annotations["gpu-autoscaler.io/sharing-mode"]
+	if preferredMode == "" {
+		preferredMode = "exclusive"
+	}
+
+	return &GPUWorkload{
+		Pod:            pod,
+		GPURequest:     GetGPURequestFromPod(pod),
+		MemoryRequest:  memoryRequest,
+		Priority:       priority,
+		SharingEnabled: pod.Annotations["gpu-autoscaler.io/sharing"] == "enabled",
+		PreferredMode:  preferredMode,
+	}
+}
+
+// Fits reports whether the node has enough free GPUs for the workload, with a reason when it does not
+func Fits(node *GPUNode, workload *GPUWorkload) (bool, string) {
+	if node.TotalGPUs == 0 {
+		return false, fmt.Sprintf("node %s has no GPUs", node.Name)
+	}
+	if node.AvailableGPUs < workload.GPURequest {
+		return false, fmt.Sprintf("node %s has %d free GPUs, pod needs %d", node.Name, node.AvailableGPUs, workload.GPURequest)
+	}
+	return true, ""
+}
+
+// RankNodes orders the nodes that fit a workload by the packing strategy, most preferred first
+func (s *BinPackingScheduler) RankNodes(nodes []*GPUNode, workload *GPUWorkload) []*GPUNode {
+	remaining := make([]*GPUNode, len(nodes))
+	copy(remaining, nodes)
+
+	var ranked []*GPUNode
+	for {
+		node := s.selectNode(remaining, workload)
+		if node == nil {
+			return ranked
+		}
+		ranked = append(ranked, node)
+		for i := range remaining {
+			if remaining[i] == node {
+				remaining = append(remaining[:i], remaining[i+1:]...)
+				break
+			}
+		}
+	}
+}
+
+// ScoreNodes scores the nodes that fit a workload between 0 and maxScore, following RankNodes.
+// Nodes that cannot fit the workload are left out.
+func (s *BinPackingScheduler) ScoreNodes(nodes []*GPUNode, workload *GPUWorkload, maxScore int64) map[string]int64 {
+	ranked := s.RankNodes(nodes, workload)
+	scores := make(map[string]int64, len(ranked))
+	for i, node := range ranked {
+		if len(ranked) == 1 {
+			scores[node.Name] = maxScore
+			continue
+		}
+		scores[node.Name] = maxScore - int64(i)*maxScore/int64(len(ranked)-1)
+	}
+	return scores
+}
+
+// GPUReservations tracks GPUs reserved for pods that have been placed but are not yet
+// visible in a node's pod list. Reservations for pods that show up on the node are ignored.
+type GPUReservations struct {
+	mu     sync.Mutex
+	byNode map[string]map[types.UID]int
+}
+
+// NewGPUReservations creates an empty reservation ledger
+func NewGPUReservations() *GPUReservations {
+	return &GPUReservations{byNode: make(map[string]map[types.UID]int)}
+}
+
+// Reserve records GPUs reserved for a pod on a node
+func (r *GPUReservations) Reserve(nodeName string, pod *corev1.Pod, gpus int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.byNode[nodeName] == nil {
+		r.byNode[nodeName] = make(map[types.UID]int)
+	}
+	r.byNode[nodeName][pod.UID] = gpus
+}
+
+// Unreserve drops a pod's reservation on a node
+func (r *GPUReservations) Unreserve(nodeName string, pod *corev1.Pod) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.byNode[nodeName], pod.UID)
+	if len(r.byNode[nodeName]) == 0 {
+		delete(r.byNode, nodeName)
+	}
+}
+
+// Apply subtracts reservations from the node's available GPUs. Reservations for pods already
+// counted in the node's allocated pods are released, since the node accounts for them.
+func (r *GPUReservations) Apply(node *GPUNode) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reservations := r.byNode[node.Name]
+	if len(reservations) == 0 {
+		return
+	}
+
+	for _, pod := range node.AllocatedPods {
+		delete(reservations, pod.UID)
+	}
+	for _, gpus := range reservations {
+		node.AvailableGPUs -= gpus
+	}
+	if len(reservations) == 0 {
+		delete(r.byNode, node.Name)
+	}
+}
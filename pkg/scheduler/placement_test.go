@@ -0,0 +1,138 @@
+package scheduler
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func newPlacementPod(name string, gpus string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, UID: types.UID(name)},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "main",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse(gpus)},
+				},
+			}},
+		},
+	}
+}
+
+func TestNewGPUNode(t *testing.T) {
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "gpu-node-01",
+			Labels: map[string]string{"nvidia.com/gpu.product": "NVIDIA-A100-SXM4-40GB", "nvidia.com/mig.capable": "true"},
+		},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("8")},
+		},
+	}
+
+	multiContainer := newPlacementPod("multi", "1")
+	multiContainer.Spec.Containers = append(multiContainer.Spec.Containers, multiContainer.Spec.Containers[0])
+	cpuOnly := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "cpu-only"}}
+
+	gpuNode := NewGPUNode(node, []*corev1.Pod{newPlacementPod("trainer", "4"), multiContainer, cpuOnly})
+
+	if gpuNode.AvailableGPUs != 2 {
+		t.Errorf("Expected 2 available GPUs, got %d", gpuNode.AvailableGPUs)
+	}
+	if len(gpuNode.AllocatedPods) != 2 {
+		t.Errorf("Expected 2 allocated pods, got %d", len(gpuNode.AllocatedPods))
+	}
+	if !gpuNode.SupportsMIG || gpuNode.GPUType != "NVIDIA-A100-SXM4-40GB" {
+		t.Errorf("Expected MIG-capable A100 node, got %+v", gpuNode)
+	}
+}
+
+func TestScoreNodes(t *testing.T) {
+	nodes := []*GPUNode{
+		{Name: "node1", TotalGPUs: 8, AvailableGPUs: 6},
+		{Name: "node2", TotalGPUs: 8, AvailableGPUs: 3},
+		{Name: "node3", TotalGPUs: 8, AvailableGPUs: 8},
+		{Name: "node4", TotalGPUs: 8, AvailableGPUs: 1},
+	}
+
+	tests := []struct {
+		name         string
+		strategy     PackStrategy
+		gpuRequest   int
+		expectScores map[string]int64
+	}{
+		{
+			name:         "Best fit prefers the tightest node",
+			strategy:     BestFit,
+			gpuRequest:   2,
+			expectScores: map[string]int64{"node2": 100, "node1": 50, "node3": 0},
+		},
+		{
+			name:         "Worst fit prefers the emptiest node",
+			strategy:     WorstFit,
+			gpuRequest:   2,
+			expectScores: map[string]int64{"node3": 100, "node1": 50, "node2": 0},
+		},
+		{
+			name:         "Single fitting node gets the max score",
+			strategy:     BestFit,
+			gpuRequest:   7,
+			expectScores: map[string]int64{"node3": 100},
+		},
+		{
+			name:         "No fitting node",
+			strategy:     BestFit,
+			gpuRequest:   9,
+			expectScores: map[string]int64{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheduler := &BinPackingScheduler{packStrategy: tt.strategy}
+			scores := scheduler.ScoreNodes(nodes, &GPUWorkload{GPURequest: tt.gpuRequest}, 100)
+
+			if len(scores) != len(tt.expectScores) {
+				t.Errorf("Expected %d scored nodes, got %d (%v)", len(tt.expectScores), len(scores), scores)
+			}
+			for name, expected := range tt.expectScores {
+				if got, ok := scores[name]; !ok || got != expected {
+					t.Errorf("Expected %s to score %d, got %d", name, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestGPUReservations(t *testing.T) {
+	reservations := NewGPUReservations()
+	first := newPlacementPod("first", "2")
+	second := newPlacementPod("second", "1")
+
+	reservations.Reserve("node1", first, 2)
+	reservations.Reserve("node1", second, 1)
+
+	node := &GPUNode{Name: "node1", TotalGPUs: 8, AvailableGPUs: 8}
+	reservations.Apply(node)
+	if node.AvailableGPUs != 5 {
+		t.Errorf("Expected 5 available GPUs after reservations, got %d", node.AvailableGPUs)
+	}
+
+	// Once the first pod is bound it is counted by the node, not the ledger
+	bound := &GPUNode{Name: "node1", TotalGPUs: 8, AvailableGPUs: 6, AllocatedPods: []*corev1.Pod{first}}
+	reservations.Apply(bound)
+	if bound.AvailableGPUs != 5 {
+		t.Errorf("Expected 5 available GPUs after binding, got %d", bound.AvailableGPUs)
+	}
+
+	reservations.Unreserve("node1", second)
+	empty := &GPUNode{Name: "node1", TotalGPUs: 8, AvailableGPUs: 8}
+	reservations.Apply(empty)
+	if empty.AvailableGPUs != 8 {
+		t.Errorf("Expected 8 available GPUs after unreserve, got %d", empty.AvailableGPUs)
+	}
+}
@@ -185,13 +185,14 @@ func (m *MIGManager) ApplyMIGConfiguration(ctx context.Context, nodeName string,
 		node.Annotations = make(map[string]string)
 	}
 	node.Annotations["nvidia.com/mig.config"] = profile.DeviceID
-	node.Annotations["nvidia.com/mig.config.state"] = "pending"
 
-	// Update node labels for device plugin discovery
+	// Update node labels for device plugin discovery. The MIG manager reports its progress in the
+	// state label; resetting it keeps a success from the previous layout from being read as done.
 	if node.Labels == nil {
 		node.Labels = make(map[string]string)
 	}
 	node.Labels["nvidia.com/mig.config"] = strings.ReplaceAll(profile.DeviceID, ".", "-")
+	node.Labels["nvidia.com/mig.config.state"] = "pending"
 
 	if err := m.client.Update(ctx, node); err != nil {
 		return fmt.Errorf("failed to update node: %w", err)
@@ -204,6 +205,71 @@ func (m *MIGManager) ApplyMIGConfiguration(ctx context.Context, nodeName string,
 	return nil
 }
 
+// MaxMIGSlices is the number of compute slices an A100 or H100 GPU can be partitioned into
+const MaxMIGSlices = 7
+
+// GetProfileByName returns the supported MIG profile with the given name
+func GetProfileByName(name string) (MIGProfile, bool) {
+	for _, profile := range GetSupportedProfiles() {
+		if profile.Name == name {
+			return profile, true
+		}
+	}
+	return MIGProfile{}, false
+}
+
+// CombineMIGProfiles merges profiles into one mixed layout so a GPU can be partitioned
+// into differently sized instances. A single profile is returned unchanged.
+func CombineMIGProfiles(profiles []MIGProfile) (MIGProfile, error) {
+	if len(profiles) == 0 {
+		return MIGProfile{}, fmt.Errorf("no MIG profiles given")
+	}
+	if len(profiles) == 1 {
+		return profiles[0], nil
+	}
+
+	combined := MIGProfile{}
+	names := make([]string, 0, len(profiles))
+	deviceIDs := make([]string, 0, len(profiles))
+	for _, profile := range profiles {
+		names = append(names, profile.Name)
+		deviceIDs = append(deviceIDs, profile.DeviceID)
+		combined.SliceCount += profile.SliceCount
+		combined.Memory += profile.Memory
+		combined.Compute += profile.Compute
+	}
+	if combined.SliceCount > MaxMIGSlices {
+		return MIGProfile{}, fmt.Errorf("MIG profiles %s need %d slices, a GPU has %d",
+			strings.Join(names, ", "), combined.SliceCount, MaxMIGSlices)
+	}
+
+	combined.Name = strings.Join(names, ",")
+	combined.DeviceID = strings.Join(deviceIDs, "_")
+	combined.Description = fmt.Sprintf("Mixed layout: %s", strings.Join(names, ", "))
+	return combined, nil
+}
+
+// RemoveMIGConfiguration removes the MIG configuration from a node
+func (m *MIGManager) RemoveMIGConfiguration(ctx context.Context, nodeName string) error {
+	log := log.FromContext(ctx)
+	log.Info("Removing MIG configuration", "node", nodeName)
+
+	node := &corev1.Node{}
+	if err := m.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node: %w", err)
+	}
+
+	delete(node.Annotations, "nvidia.com/mig.config")
+	delete(node.Labels, "nvidia.com/mig.config")
+	delete(node.Labels, "nvidia.com/mig.config.state")
+
+	if err := m.client.Update(ctx, node); err != nil {
+		return fmt.Errorf("failed to update node: %w", err)
+	}
+
+	return nil
+}
+
 // GetMIGDeviceResourceName returns the resource name for MIG device
 func GetMIGDeviceResourceName(profile MIGProfile) string {
 	// NVIDIA device plugin exposes MIG devices as nvidia.com/mig-<profile>
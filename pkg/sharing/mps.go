@@ -99,6 +99,29 @@ func (m *MPSManager) EnableMPSOnNode(ctx context.Context, nodeName string, confi
 	return nil
 }
 
+// DisableMPSOnNode removes the MPS configuration from a node
+func (m *MPSManager) DisableMPSOnNode(ctx context.Context, nodeName string) error {
+	log := log.FromContext(ctx)
+	log.Info("Disabling MPS on node", "node", nodeName)
+
+	node := &corev1.Node{}
+	if err := m.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node: %w", err)
+	}
+
+	delete(node.Annotations, "nvidia.com/mps.enabled")
+	delete(node.Annotations, "nvidia.com/mps.max-clients")
+	delete(node.Annotations, "nvidia.com/mps.active-threads")
+	delete(node.Annotations, "nvidia.com/mps.memory-limit")
+	delete(node.Labels, "nvidia.com/mps.enabled")
+
+	if err := m.client.Update(ctx, node); err != nil {
+		return fmt.Errorf("failed to update node: %w", err)
+	}
+
+	return nil
+}
+
 // ConvertPodToMPS converts a pod to use MPS for GPU sharing
 func (m *MPSManager) ConvertPodToMPS(ctx context.Context, pod *corev1.Pod, config MPSConfig) error {
 	log := log.FromContext(ctx)
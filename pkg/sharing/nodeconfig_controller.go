@@ -0,0 +1,429 @@
+package sharing
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+const (
+	// GPUNodeConfig phases
+	NodeConfigPhasePending     = "Pending"
+	NodeConfigPhaseConfiguring = "Configuring"
+	NodeConfigPhaseReady       = "Ready"
+	NodeConfigPhaseFailed      = "Failed"
+
+	// NodeConfigConditionReady reports whether the node matches its GPUNodeConfig
+	NodeConfigConditionReady = "Ready"
+
+	// Condition reasons
+	ReasonConfigured          = "Configured"
+	ReasonConfiguring         = "Configuring"
+	ReasonGPUPodsRunning      = "GPUPodsRunning"
+	ReasonNodeNotFound        = "NodeNotFound"
+	ReasonNotCapable          = "NotCapable"
+	ReasonInvalidSpec         = "InvalidSpec"
+	ReasonConfigurationFailed = "ConfigurationFailed"
+
+	// migConfigStateLabel is the node label the NVIDIA MIG manager reports the state of a MIG reconfiguration in
+	migConfigStateLabel = "nvidia.com/mig.config.state"
+
+	// MIG config states written by the NVIDIA MIG manager
+	migConfigStateSuccess = "success"
+	migConfigStateFailed  = "failed"
+
+	// nodeConfigRequeueInterval is how often blocked or in-progress configurations are retried
+	nodeConfigRequeueInterval = 30 * time.Second
+)
+
+// NodeConfigController reconciles GPUNodeConfig objects by configuring MIG, MPS and
+// time-slicing on the target node. Nodes are only reconfigured once no GPU pods run on them.
+type NodeConfigController struct {
+	client.Client
+	Scheme     *runtime.Scheme
+	Recorder   record.EventRecorder
+	MIGManager *MIGManager
+	MPSManager *MPSManager
+	TSManager  *TimeSlicingManager
+}
+
+// NewNodeConfigController creates a new GPUNodeConfig controller
+func NewNodeConfigController(client client.Client, scheme *runtime.Scheme, recorder record.EventRecorder) *NodeConfigController {
+	return &NodeConfigController{
+		Client:     client,
+		Scheme:     scheme,
+		Recorder:   recorder,
+		MIGManager: NewMIGManager(client),
+		MPSManager: NewMPSManager(client),
+		TSManager:  NewTimeSlicingManager(client),
+	}
+}
+
+// Reconcile applies a GPUNodeConfig to its node and reports progress in its status
+func (r *NodeConfigController) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+
+	config := &v1alpha1.GPUNodeConfig{}
+	if err := r.Get(ctx, req.NamespacedName, config); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	logger.Info("Reconciling GPUNodeConfig", "name", config.Name, "node", config.Spec.NodeName)
+
+	result, reconcileErr := r.reconcileNode(ctx, config)
+
+	now := metav1.Now()
+	config.Status.LastUpdateTime = &now
+	if err := r.Status().Update(ctx, config); err != nil {
+		logger.Error(err, "Failed to update GPUNodeConfig status")
+		return ctrl.Result{}, err
+	}
+
+	return result, reconcileErr
+}
+
+// reconcileNode drives the node towards the spec and records the outcome on the config status
+func (r *NodeConfigController) reconcileNode(ctx context.Context, config *v1alpha1.GPUNodeConfig) (ctrl.Result, error) {
+	logger := log.FromContext(ctx)
+	spec := config.Spec
+
+	var migProfile MIGProfile
+	if spec.MIGEnabled {
+		profile, err := desiredMIGProfile(spec.MIGProfiles)
+		if err != nil {
+			setNodeConfigPhase(config, NodeConfigPhaseFailed, ReasonInvalidSpec, err.Error())
+			return ctrl.Result{}, nil
+		}
+		migProfile = profile
+	}
+
+	node := &corev1.Node{}
+	if err := r.Get(ctx, client.ObjectKey{Name: spec.NodeName}, node); err != nil {
+		if errors.IsNotFound(err) {
+			setNodeConfigPhase(config, NodeConfigPhasePending, ReasonNodeNotFound,
+				fmt.Sprintf("node %s not found", spec.NodeName))
+			return ctrl.Result{RequeueAfter: time.Minute}, nil
+		}
+		return ctrl.Result{}, fmt.Errorf("failed to get node: %w", err)
+	}
+
+	if changes := pendingNodeChanges(node, spec, migProfile); len(changes) > 0 {
+		if err := r.checkCapabilities(ctx, spec); err != nil {
+			setNodeConfigPhase(config, NodeConfigPhaseFailed, ReasonNotCapable, err.Error())
+			return ctrl.Result{}, nil
+		}
+
+		gpuPods, err := r.gpuPodsOnNode(ctx, spec.NodeName)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if len(gpuPods) > 0 {
+			message := fmt.Sprintf("node %s still runs %d GPU pod(s) (%s); drain them before it can be reconfigured to %s",
+				spec.NodeName, len(gpuPods), strings.Join(gpuPods, ", "), strings.Join(changes, ", "))
+			setNodeConfigPhase(config, NodeConfigPhasePending, ReasonGPUPodsRunning, message)
+			r.Recorder.Event(config, corev1.EventTypeWarning, ReasonGPUPodsRunning, message)
+			return ctrl.Result{RequeueAfter: nodeConfigRequeueInterval}, nil
+		}
+
+		logger.Info("Reconfiguring node", "node", spec.NodeName, "changes", changes)
+		if err := r.applyNodeChanges(ctx, node, spec, migProfile); err != nil {
+			setNodeConfigPhase(config, NodeConfigPhaseFailed, ReasonConfigurationFailed, err.Error())
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Eventf(config, corev1.EventTypeNormal, "Reconfigured",
+			"Reconfigured node %s to %s", spec.NodeName, strings.Join(changes, ", "))
+
+		if err := r.Get(ctx, client.ObjectKey{Name: spec.NodeName}, node); err != nil {
+			return ctrl.Result{}, fmt.Errorf("failed to get node: %w", err)
+		}
+	}
+
+	r.updateSharingStatus(ctx, config, node)
+
+	// MIG repartitioning happens asynchronously on the node
+	if spec.MIGEnabled {
+		switch state := node.Labels[migConfigStateLabel]; state {
+		case migConfigStateSuccess:
+		case migConfigStateFailed:
+			setNodeConfigPhase(config, NodeConfigPhaseFailed, ReasonConfigurationFailed,
+				fmt.Sprintf("MIG manager failed to apply %s on node %s", migProfile.Name, spec.NodeName))
+			return ctrl.Result{}, nil
+		default:
+			setNodeConfigPhase(config, NodeConfigPhaseConfiguring, ReasonConfiguring,
+				fmt.Sprintf("waiting for MIG profile %s to be applied on node %s (state %q)", migProfile.Name, spec.NodeName, state))
+			return ctrl.Result{RequeueAfter: nodeConfigRequeueInterval}, nil
+		}
+	}
+
+	setNodeConfigPhase(config, NodeConfigPhaseReady, ReasonConfigured,
+		fmt.Sprintf("node %s matches the requested GPU configuration", spec.NodeName))
+	return ctrl.Result{}, nil
+}
+
+// desiredMIGProfile resolves the MIG profile names in the spec into a single node layout
+func desiredMIGProfile(names []string) (MIGProfile, error) {
+	if len(names) == 0 {
+		return MIGProfile{}, fmt.Errorf("migEnabled requires at least one entry in migProfiles")
+	}
+
+	profiles := make([]MIGProfile, 0, len(names))
+	for _, name := range names {
+		profile, ok := GetProfileByName(name)
+		if !ok {
+			return MIGProfile{}, fmt.Errorf("unsupported MIG profile %q", name)
+		}
+		profiles = append(profiles, profile)
+	}
+
+	return CombineMIGProfiles(profiles)
+}
+
+// pendingNodeChanges describes how the node's current configuration differs from the spec
+func pendingNodeChanges(node *corev1.Node, spec v1alpha1.GPUNodeConfigSpec, migProfile MIGProfile) []string {
+	var changes []string
+
+	currentMIG := node.Annotations["nvidia.com/mig.config"]
+	if spec.MIGEnabled && currentMIG != migProfile.DeviceID {
+		changes = append(changes, fmt.Sprintf("enable MIG %s", migProfile.Name))
+	} else if !spec.MIGEnabled && currentMIG != "" {
+		changes = append(changes, "disable MIG")
+	}
+
+	mpsEnabled := node.Annotations["nvidia.com/mps.enabled"] == "true"
+	maxClients := mpsConfigForSpec(spec).MaxClients
+	if spec.MPSEnabled && (!mpsEnabled || node.Annotations["nvidia.com/mps.max-clients"] != fmt.Sprintf("%d", maxClients)) {
+		changes = append(changes, fmt.Sprintf("enable MPS with %d clients", maxClients))
+	} else if !spec.MPSEnabled && mpsEnabled {
+		changes = append(changes, "disable MPS")
+	}
+
+	tsEnabled := node.Annotations["nvidia.com/time-slicing.enabled"] == "true"
+	replicas := timeSlicingConfigForSpec(spec).ReplicasPerGPU
+	if spec.TimeSlicingEnabled && (!tsEnabled || node.Labels["nvidia.com/time-slicing.replicas"] != fmt.Sprintf("%d", replicas)) {
+		changes = append(changes, fmt.Sprintf("enable time-slicing with %d replicas", replicas))
+	} else if !spec.TimeSlicingEnabled && tsEnabled {
+		changes = append(changes, "disable time-slicing")
+	}
+
+	return changes
+}
+
+// checkCapabilities verifies the node supports every sharing mode the spec enables
+func (r *NodeConfigController) checkCapabilities(ctx context.Context, spec v1alpha1.GPUNodeConfigSpec) error {
+	if spec.MIGEnabled {
+		capable, err := r.MIGManager.IsMIGCapable(ctx, spec.NodeName)
+		if err != nil {
+			return err
+		}
+		if !capable {
+			return fmt.Errorf("node %s is not MIG capable", spec.NodeName)
+		}
+	}
+	if spec.MPSEnabled {
+		capable, err := r.MPSManager.IsMPSCapable(ctx, spec.NodeName)
+		if err != nil {
+			return err
+		}
+		if !capable {
+			return fmt.Errorf("node %s is not MPS capable", spec.NodeName)
+		}
+	}
+	if spec.TimeSlicingEnabled {
+		capable, err := r.TSManager.IsTimeSlicingCapable(ctx, spec.NodeName)
+		if err != nil {
+			return err
+		}
+		if !capable {
+			return fmt.Errorf("node %s does not support time-slicing", spec.NodeName)
+		}
+	}
+	return nil
+}
+
+// applyNodeChanges enables or disables each sharing mode to match the spec
+func (r *NodeConfigController) applyNodeChanges(ctx context.Context, node *corev1.Node, spec v1alpha1.GPUNodeConfigSpec, migProfile MIGProfile) error {
+	currentMIG := node.Annotations["nvidia.com/mig.config"]
+	if spec.MIGEnabled && currentMIG != migProfile.DeviceID {
+		if err := r.MIGManager.ApplyMIGConfiguration(ctx, spec.NodeName, migProfile); err != nil {
+			return fmt.Errorf("failed to apply MIG configuration: %w", err)
+		}
+	} else if !spec.MIGEnabled && currentMIG != "" {
+		if err := r.MIGManager.RemoveMIGConfiguration(ctx, spec.NodeName); err != nil {
+			return fmt.Errorf("failed to remove MIG configuration: %w", err)
+		}
+	}
+
+	if spec.MPSEnabled {
+		if err := r.MPSManager.EnableMPSOnNode(ctx, spec.NodeName, mpsConfigForSpec(spec)); err != nil {
+			return fmt.Errorf("failed to enable MPS: %w", err)
+		}
+	} else if node.Annotations["nvidia.com/mps.enabled"] == "true" {
+		if err := r.MPSManager.DisableMPSOnNode(ctx, spec.NodeName); err != nil {
+			return fmt.Errorf("failed to disable MPS: %w", err)
+		}
+	}
+
+	if spec.TimeSlicingEnabled {
+		if err := r.TSManager.EnableTimeSlicingOnNode(ctx, spec.NodeName, timeSlicingConfigForSpec(spec)); err != nil {
+			return fmt.Errorf("failed to enable time-slicing: %w", err)
+		}
+	} else if node.Annotations["nvidia.com/time-slicing.enabled"] == "true" {
+		if err := r.TSManager.DisableTimeSlicingOnNode(ctx, spec.NodeName); err != nil {
+			return fmt.Errorf("failed to disable time-slicing: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// updateSharingStatus reports the node's observed MIG, MPS and time-slicing state
+func (r *NodeConfigController) updateSharingStatus(ctx context.Context, config *v1alpha1.GPUNodeConfig, node *corev1.Node) {
+	logger := log.FromContext(ctx)
+
+	migStatus := &v1alpha1.MIGStatus{Enabled: node.Annotations["nvidia.com/mig.config"] != ""}
+	if migStatus.Enabled {
+		migStatus.ConfiguredProfiles = config.Spec.MIGProfiles
+	}
+	for name, quantity := range node.Status.Allocatable {
+		if strings.HasPrefix(string(name), "nvidia.com/mig-") {
+			migStatus.AvailableDevices += int(quantity.Value())
+		}
+	}
+	config.Status.MIGStatus = migStatus
+
+	if mpsStatus, err := r.MPSManager.GetMPSStatus(ctx, node.Name); err != nil {
+		logger.Error(err, "Failed to get MPS status", "node", node.Name)
+	} else {
+		config.Status.MPSStatus = &v1alpha1.MPSStatusInfo{
+			Enabled:       mpsStatus.Enabled,
+			ActiveClients: mpsStatus.ActiveClients,
+			MaxClients:    mpsStatus.MaxClients,
+		}
+	}
+
+	if tsStatus, err := r.TSManager.GetTimeSlicingStatus(ctx, node.Name); err != nil {
+		logger.Error(err, "Failed to get time-slicing status", "node", node.Name)
+	} else {
+		config.Status.TimeSlicingStatus = &v1alpha1.TimeSlicingStatusInfo{
+			Enabled:        tsStatus.Enabled,
+			PhysicalGPUs:   tsStatus.PhysicalGPUs,
+			VirtualGPUs:    tsStatus.VirtualGPUs,
+			ReplicasPerGPU: tsStatus.ReplicasPerGPU,
+		}
+	}
+}
+
+// gpuPodsOnNode returns the names of pods on the node that hold GPU resources
+func (r *NodeConfigController) gpuPodsOnNode(ctx context.Context, nodeName string) ([]string, error) {
+	podList := &corev1.PodList{}
+	if err := r.List(ctx, podList, client.MatchingFields{"spec.nodeName": nodeName}); err != nil {
+		return nil, fmt.Errorf("failed to list pods on node: %w", err)
+	}
+
+	var names []string
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase == corev1.PodSucceeded || pod.Status.Phase == corev1.PodFailed {
+			continue
+		}
+		if usesGPU(pod) {
+			names = append(names, fmt.Sprintf("%s/%s", pod.Namespace, pod.Name))
+		}
+	}
+	return names, nil
+}
+
+// usesGPU reports whether any container requests a whole, shared or MIG GPU
+func usesGPU(pod *corev1.Pod) bool {
+	for _, container := range pod.Spec.Containers {
+		for name := range container.Resources.Requests {
+			if name == "nvidia.com/gpu" || strings.HasPrefix(string(name), "nvidia.com/gpu.") ||
+				strings.HasPrefix(string(name), "nvidia.com/mig-") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// mpsConfigForSpec builds the MPS configuration requested by a GPUNodeConfig
+func mpsConfigForSpec(spec v1alpha1.GPUNodeConfigSpec) MPSConfig {
+	config := DefaultMPSConfig()
+	if spec.MPSMaxClients > 0 {
+		config.MaxClients = spec.MPSMaxClients
+	}
+	return config
+}
+
+// timeSlicingConfigForSpec builds the time-slicing configuration requested by a GPUNodeConfig
+func timeSlicingConfigForSpec(spec v1alpha1.GPUNodeConfigSpec) TimeSlicingConfig {
+	config := DefaultTimeSlicingConfig()
+	if spec.TimeSlicingReplicas > 0 {
+		config.ReplicasPerGPU = spec.TimeSlicingReplicas
+	}
+	return config
+}
+
+// setNodeConfigPhase sets the phase, message and Ready condition on the config status
+func setNodeConfigPhase(config *v1alpha1.GPUNodeConfig, phase, reason, message string) {
+	config.Status.Phase = phase
+	config.Status.Message = message
+
+	status := metav1.ConditionFalse
+	if phase == NodeConfigPhaseReady {
+		status = metav1.ConditionTrue
+	}
+	meta.SetStatusCondition(&config.Status.Conditions, metav1.Condition{
+		Type:               NodeConfigConditionReady,
+		Status:             status,
+		ObservedGeneration: config.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+}
+
+// configsForNode maps a node event to the GPUNodeConfigs that target the node
+func (r *NodeConfigController) configsForNode(ctx context.Context, obj client.Object) []reconcile.Request {
+	configs := &v1alpha1.GPUNodeConfigList{}
+	if err := r.List(ctx, configs); err != nil {
+		log.FromContext(ctx).Error(err, "Failed to list GPUNodeConfigs")
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for _, config := range configs.Items {
+		if config.Spec.NodeName == obj.GetName() {
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: config.Name}})
+		}
+	}
+	return requests
+}
+
+// SetupWithManager sets up the controller with the Manager
+func (r *NodeConfigController) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		For(&v1alpha1.GPUNodeConfig{}).
+		// Node label and annotation changes carry MIG manager progress and capability updates
+		Watches(&corev1.Node{},
+			handler.EnqueueRequestsFromMapFunc(r.configsForNode),
+			builder.WithPredicates(predicate.Or(predicate.LabelChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
+		Complete(r)
+}
@@ -0,0 +1,241 @@
+package sharing
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+func newTestGPUNode(annotations map[string]string) *corev1.Node {
+	return &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "gpu-node-01",
+			Labels: map[string]string{
+				"nvidia.com/mig.capable": "true",
+				"nvidia.com/mps.capable": "true",
+			},
+			Annotations: annotations,
+		},
+		Status: corev1.NodeStatus{
+			Capacity: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("4")},
+		},
+	}
+}
+
+// newTestMIGNode returns a node partitioned with a MIG layout, in the state the MIG manager reports in its label
+func newTestMIGNode(deviceID, state string) *corev1.Node {
+	node := newTestGPUNode(map[string]string{"nvidia.com/mig.config": deviceID})
+	node.Labels["nvidia.com/mig.config"] = strings.ReplaceAll(deviceID, ".", "-")
+	node.Labels[migConfigStateLabel] = state
+	return node
+}
+
+func TestNodeConfigControllerReconcile(t *testing.T) {
+	gpuPod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "trainer", Namespace: "ml"},
+		Spec: corev1.PodSpec{
+			NodeName: "gpu-node-01",
+			Containers: []corev1.Container{{
+				Name: "trainer",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodRunning},
+	}
+
+	tests := []struct {
+		name            string
+		spec            v1alpha1.GPUNodeConfigSpec
+		node            *corev1.Node
+		pods            []client.Object
+		expectPhase     string
+		expectReason    string
+		expectNodeAnnos map[string]string
+		// expectNodeLabels are checked in addition to expectNodeAnnos
+		expectNodeLabels map[string]string
+	}{
+		{
+			name:            "Enables MPS on an idle node",
+			spec:            v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-01", MPSEnabled: true, MPSMaxClients: 8},
+			node:            newTestGPUNode(nil),
+			expectPhase:     NodeConfigPhaseReady,
+			expectReason:    ReasonConfigured,
+			expectNodeAnnos: map[string]string{"nvidia.com/mps.enabled": "true", "nvidia.com/mps.max-clients": "8"},
+		},
+		{
+			name:            "Refuses to reconfigure a node with GPU pods",
+			spec:            v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-01", MPSEnabled: true},
+			node:            newTestGPUNode(nil),
+			pods:            []client.Object{gpuPod},
+			expectPhase:     NodeConfigPhasePending,
+			expectReason:    ReasonGPUPodsRunning,
+			expectNodeAnnos: map[string]string{"nvidia.com/mps.enabled": ""},
+		},
+		{
+			name:             "Applies mixed MIG profiles and waits for the MIG manager",
+			spec:             v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-01", MIGEnabled: true, MIGProfiles: []string{"1g.5gb", "2g.10gb"}},
+			node:             newTestMIGNode("3g.20gb", migConfigStateSuccess),
+			expectPhase:      NodeConfigPhaseConfiguring,
+			expectReason:     ReasonConfiguring,
+			expectNodeAnnos:  map[string]string{"nvidia.com/mig.config": "1g.5gb_2g.10gb"},
+			expectNodeLabels: map[string]string{"nvidia.com/mig.config": "1g-5gb_2g-10gb", migConfigStateLabel: "pending"},
+		},
+		{
+			name:         "MIG layout applied by the MIG manager is ready",
+			spec:         v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-01", MIGEnabled: true, MIGProfiles: []string{"1g.5gb"}},
+			node:         newTestMIGNode("1g.5gb", migConfigStateSuccess),
+			pods:         []client.Object{gpuPod},
+			expectPhase:  NodeConfigPhaseReady,
+			expectReason: ReasonConfigured,
+		},
+		{
+			name:         "MIG layout the MIG manager failed to apply",
+			spec:         v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-01", MIGEnabled: true, MIGProfiles: []string{"1g.5gb"}},
+			node:         newTestMIGNode("1g.5gb", migConfigStateFailed),
+			expectPhase:  NodeConfigPhaseFailed,
+			expectReason: ReasonConfigurationFailed,
+		},
+		{
+			name:         "Unknown MIG profile fails",
+			spec:         v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-01", MIGEnabled: true, MIGProfiles: []string{"9g.99gb"}},
+			node:         newTestGPUNode(nil),
+			expectPhase:  NodeConfigPhaseFailed,
+			expectReason: ReasonInvalidSpec,
+		},
+		{
+			name: "Disables time-slicing that is no longer requested",
+			spec: v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-01"},
+			node: newTestGPUNode(map[string]string{
+				"nvidia.com/time-slicing.enabled":  "true",
+				"nvidia.com/time-slicing.replicas": "4",
+			}),
+			expectPhase:     NodeConfigPhaseReady,
+			expectReason:    ReasonConfigured,
+			expectNodeAnnos: map[string]string{"nvidia.com/time-slicing.enabled": ""},
+		},
+		{
+			name:         "Missing node is pending",
+			spec:         v1alpha1.GPUNodeConfigSpec{NodeName: "gpu-node-02", MPSEnabled: true},
+			node:         newTestGPUNode(nil),
+			expectPhase:  NodeConfigPhasePending,
+			expectReason: ReasonNodeNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := runtime.NewScheme()
+			if err := clientgoscheme.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to add client-go scheme: %v", err)
+			}
+			if err := v1alpha1.AddToScheme(scheme); err != nil {
+				t.Fatalf("failed to add v1alpha1 scheme: %v", err)
+			}
+
+			config := &v1alpha1.GPUNodeConfig{
+				ObjectMeta: metav1.ObjectMeta{Name: "gpu-node-01-config"},
+				Spec:       tt.spec,
+			}
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append([]client.Object{config, tt.node}, tt.pods...)...).
+				WithStatusSubresource(&v1alpha1.GPUNodeConfig{}).
+				WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+					return []string{obj.(*corev1.Pod).Spec.NodeName}
+				}).
+				Build()
+
+			controller := NewNodeConfigController(k8sClient, scheme, record.NewFakeRecorder(10))
+			req := ctrl.Request{NamespacedName: types.NamespacedName{Name: config.Name}}
+			if _, err := controller.Reconcile(context.Background(), req); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+
+			updated := &v1alpha1.GPUNodeConfig{}
+			if err := k8sClient.Get(context.Background(), req.NamespacedName, updated); err != nil {
+				t.Fatalf("failed to get GPUNodeConfig: %v", err)
+			}
+			if updated.Status.Phase != tt.expectPhase {
+				t.Errorf("Expected phase %s, got %s (%s)", tt.expectPhase, updated.Status.Phase, updated.Status.Message)
+			}
+			condition := meta.FindStatusCondition(updated.Status.Conditions, NodeConfigConditionReady)
+			if condition == nil || condition.Reason != tt.expectReason {
+				t.Errorf("Expected Ready condition with reason %s, got %+v", tt.expectReason, condition)
+			}
+
+			node := &corev1.Node{}
+			if err := k8sClient.Get(context.Background(), client.ObjectKey{Name: tt.node.Name}, node); err != nil {
+				t.Fatalf("failed to get node: %v", err)
+			}
+			for key, expected := range tt.expectNodeAnnos {
+				if got := node.Annotations[key]; got != expected {
+					t.Errorf("Expected node annotation %s=%q, got %q", key, expected, got)
+				}
+			}
+			for key, expected := range tt.expectNodeLabels {
+				if got := node.Labels[key]; got != expected {
+					t.Errorf("Expected node label %s=%q, got %q", key, expected, got)
+				}
+			}
+		})
+	}
+}
+
+func TestCombineMIGProfiles(t *testing.T) {
+	tests := []struct {
+		name           string
+		profiles       []MIGProfile
+		expectDeviceID string
+		shouldError    bool
+	}{
+		{
+			name:           "Single profile is unchanged",
+			profiles:       []MIGProfile{MIGProfile3g20gb},
+			expectDeviceID: "3g.20gb",
+		},
+		{
+			name:           "Mixed profiles that fit one GPU",
+			profiles:       []MIGProfile{MIGProfile1g5gb, MIGProfile2g10gb, MIGProfile4g20gb},
+			expectDeviceID: "1g.5gb_2g.10gb_4g.20gb",
+		},
+		{
+			name:        "Mixed profiles that exceed one GPU",
+			profiles:    []MIGProfile{MIGProfile4g20gb, MIGProfile4g20gb},
+			shouldError: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			profile, err := CombineMIGProfiles(tt.profiles)
+			if tt.shouldError {
+				if err == nil {
+					t.Error("Expected error, got nil")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Unexpected error: %v", err)
+			}
+			if profile.DeviceID != tt.expectDeviceID {
+				t.Errorf("Expected device ID %s, got %s", tt.expectDeviceID, profile.DeviceID)
+			}
+		})
+	}
+}
@@ -110,6 +110,31 @@ func (t *TimeSlicingManager) EnableTimeSlicingOnNode(ctx context.Context, nodeNa
 	return nil
 }
 
+// DisableTimeSlicingOnNode removes the time-slicing configuration from a node
+func (t *TimeSlicingManager) DisableTimeSlicingOnNode(ctx context.Context, nodeName string) error {
+	log := log.FromContext(ctx)
+	log.Info("Disabling time-slicing on node", "node", nodeName)
+
+	node := &corev1.Node{}
+	if err := t.client.Get(ctx, client.ObjectKey{Name: nodeName}, node); err != nil {
+		return fmt.Errorf("failed to get node: %w", err)
+	}
+
+	delete(node.Annotations, "nvidia.com/time-slicing.enabled")
+	delete(node.Annotations, "nvidia.com/time-slicing.replicas")
+	delete(node.Annotations, "nvidia.com/time-slicing.slice-ms")
+	delete(node.Annotations, "nvidia.com/time-slicing.fairness")
+	delete(node.Annotations, "nvidia.com/gpu.original-capacity")
+	delete(node.Labels, "nvidia.com/time-slicing.enabled")
+	delete(node.Labels, "nvidia.com/time-slicing.replicas")
+
+	if err := t.client.Update(ctx, node); err != nil {
+		return fmt.Errorf("failed to update node: %w", err)
+	}
+
+	return nil
+}
+
 // ConvertPodToTimeSlicing converts a pod to use time-sliced GPU
 func (t *TimeSlicingManager) ConvertPodToTimeSlicing(ctx context.Context, pod *corev1.Pod, replicasPerGPU int) error {
 	log := log.FromContext(ctx)
@@ -0,0 +1,654 @@
+package simulator
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+	"time"
+
+	"github.com/go-logr/logr"
+	coordinationv1 "k8s.io/api/coordination/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/autoscaler"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/cost"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/metrics"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/scheduler"
+)
+
+const (
+	// Default simulation settings
+	DefaultStep           = autoscaler.DefaultReconcileInterval
+	DefaultHorizon        = 24 * time.Hour // how long pods may keep running after the last trace event
+	DefaultSpotNoticeLead = 2 * time.Minute
+	DefaultPolicyName     = "simulated"
+	DefaultInstanceType   = "p3.8xlarge"
+
+	// TracePodLabel carries the trace name of a simulated pod across restarts
+	TracePodLabel = "gpu-autoscaler.io/trace-pod"
+)
+
+// Options configures a simulation run
+type Options struct {
+	// Policy is the AutoscalingPolicy under test; DefaultPolicy is used when nil
+	Policy *v1alpha1.AutoscalingPolicy
+
+	// Step is the simulated time between reconciles
+	Step time.Duration
+
+	// Horizon bounds how long the simulation runs past the last trace event
+	Horizon time.Duration
+
+	// ProvisioningLatency is how long new nodes take to register.
+	// Zero uses the simulated provider's default; a negative value registers nodes immediately.
+	ProvisioningLatency time.Duration
+
+	// PackStrategy is the bin-packing strategy used to place pending pods
+	PackStrategy scheduler.PackStrategy
+
+	// SpotInterruptionRate is the probability that a spot node is reclaimed in any hour,
+	// in addition to the interruptions recorded in the trace
+	SpotInterruptionRate float64
+
+	// SpotNoticeLead is the warning given before a spot node is reclaimed
+	SpotNoticeLead time.Duration
+
+	// Seed seeds random spot interruptions so runs are reproducible
+	Seed int64
+}
+
+// Report summarizes a simulation run
+type Report struct {
+	Duration          metav1.Duration `json:"duration"`
+	Pods              int             `json:"pods"`
+	CompletedPods     int             `json:"completedPods"`
+	UnscheduledPods   int             `json:"unscheduledPods"`
+	RestartedPods     int             `json:"restartedPods"`
+	TotalCost         float64         `json:"totalCost"`
+	GPUHours          float64         `json:"gpuHours"`
+	WastedGPUHours    float64         `json:"wastedGPUHours"`
+	PendingP50        metav1.Duration `json:"pendingP50"`
+	PendingP95        metav1.Duration `json:"pendingP95"`
+	PendingMax        metav1.Duration `json:"pendingMax"`
+	SpotInterruptions int             `json:"spotInterruptions"`
+	NodesLaunched     int             `json:"nodesLaunched"`
+	PeakNodes         int             `json:"peakNodes"`
+	ScalingErrors     int             `json:"scalingErrors"`
+}
+
+// simulatedPod tracks a trace pod through its attempts
+type simulatedPod struct {
+	arrival     PodArrival
+	name        string
+	namespace   string
+	attempts    int
+	objectName  string // name of the current attempt's Pod object
+	submittedAt time.Time
+	startedAt   time.Time
+	nodeName    string
+	submitted   bool
+	running     bool
+	done        bool
+}
+
+// Simulator replays a workload trace through the autoscaler controller, the bin-packing
+// scheduler and the simulated provider against a fake client and a fake clock
+type Simulator struct {
+	trace   *Trace
+	options Options
+	policy  *v1alpha1.AutoscalingPolicy
+
+	client     client.Client
+	provider   *autoscaler.SimulatedProvider
+	controller *autoscaler.AutoscalerController
+	scheduler  *scheduler.BinPackingScheduler
+	random     *rand.Rand
+
+	start         time.Time
+	currentTime   time.Time
+	pods          []*simulatedPod
+	podsByObject  map[string]*simulatedPod
+	interruptions map[int]bool         // trace interruptions already applied
+	noticed       map[string]bool      // spot nodes with a termination notice
+	launched      map[string]time.Time // first time each node was seen
+	pendingTimes  []time.Duration
+	report        *Report
+}
+
+// DefaultPolicy returns an AutoscalingPolicy with the CRD defaults and a spot and an on-demand pool
+func DefaultPolicy() *v1alpha1.AutoscalingPolicy {
+	return &v1alpha1.AutoscalingPolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: DefaultPolicyName},
+		Spec: v1alpha1.AutoscalingPolicySpec{
+			Enabled:                  true,
+			Provider:                 "custom",
+			ScaleUpThreshold:         autoscaler.DefaultScaleUpThreshold,
+			ScaleDownThreshold:       autoscaler.DefaultScaleDownThreshold,
+			ScaleUpCooldownSeconds:   int32(autoscaler.DefaultScaleUpCooldown.Seconds()),
+			ScaleDownCooldownSeconds: int32(autoscaler.DefaultScaleDownCooldown.Seconds()),
+			PendingPodTimeoutSeconds: int32(autoscaler.DefaultPendingPodTimeout.Seconds()),
+			MinNodes:                 autoscaler.DefaultMinNodes,
+			MaxNodes:                 autoscaler.DefaultMaxNodes,
+			SpotInstancePercentage:   autoscaler.DefaultSpotInstancePercentage,
+			EnableSpotInstances:      true,
+			NodePools: []v1alpha1.NodePoolSpec{
+				{Name: "gpu-spot", InstanceTypes: []string{DefaultInstanceType}, CapacityType: autoscaler.CapacityTypeSpot},
+				{Name: "gpu-on-demand", InstanceTypes: []string{DefaultInstanceType}, CapacityType: autoscaler.CapacityTypeOnDemand},
+			},
+		},
+	}
+}
+
+// NewSimulator creates a simulator for a trace
+func NewSimulator(trace *Trace, options Options) (*Simulator, error) {
+	if err := trace.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trace: %w", err)
+	}
+
+	if options.Policy == nil {
+		options.Policy = DefaultPolicy()
+	}
+	if options.Step <= 0 {
+		options.Step = DefaultStep
+	}
+	if options.Horizon <= 0 {
+		options.Horizon = DefaultHorizon
+	}
+	if options.ProvisioningLatency == 0 {
+		options.ProvisioningLatency = autoscaler.DefaultSimulatedProvisioningLatency
+	}
+	if options.PackStrategy == "" {
+		options.PackStrategy = scheduler.BestFit
+	}
+	if options.SpotNoticeLead <= 0 {
+		options.SpotNoticeLead = DefaultSpotNoticeLead
+	}
+
+	scheme := runtime.NewScheme()
+	if err := corev1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add core scheme: %w", err)
+	}
+	if err := coordinationv1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add coordination scheme: %w", err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		return nil, fmt.Errorf("failed to add v1alpha1 scheme: %w", err)
+	}
+
+	policy := options.Policy.DeepCopy()
+	policy.Spec.Enabled = true
+	policy.ResourceVersion = ""
+
+	// Simulated nodes carry the GPUs of their pool's first instance type; plan scale-ups with the same count
+	for i := range policy.Spec.NodePools {
+		pool := &policy.Spec.NodePools[i]
+		if pool.GPUsPerNode == 0 && len(pool.InstanceTypes) > 0 {
+			pool.GPUsPerNode = int32(cost.GetGPUCountForInstanceType(pool.InstanceTypes[0]))
+		}
+	}
+
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(policy).
+		WithStatusSubresource(&v1alpha1.AutoscalingPolicy{}).
+		WithIndex(&corev1.Pod{}, "spec.nodeName", func(obj client.Object) []string {
+			return []string{obj.(*corev1.Pod).Spec.NodeName}
+		}).
+		Build()
+
+	s := &Simulator{
+		trace:         trace,
+		options:       options,
+		policy:        policy,
+		client:        k8sClient,
+		scheduler:     scheduler.NewBinPackingScheduler(k8sClient, options.PackStrategy),
+		random:        rand.New(rand.NewSource(options.Seed)),
+		start:         trace.StartTime(),
+		currentTime:   trace.StartTime(),
+		podsByObject:  make(map[string]*simulatedPod),
+		interruptions: make(map[int]bool),
+		noticed:       make(map[string]bool),
+		launched:      make(map[string]time.Time),
+		report:        &Report{Pods: len(trace.Pods)},
+	}
+
+	for i, arrival := range trace.Pods {
+		namespace := arrival.Namespace
+		if namespace == "" {
+			namespace = "default"
+		}
+		s.pods = append(s.pods, &simulatedPod{arrival: arrival, name: podName(i, arrival), namespace: namespace})
+	}
+
+	s.provider = autoscaler.NewSimulatedProvider(k8sClient, autoscaler.SimulatedProviderConfig{
+		ProvisioningLatency: options.ProvisioningLatency,
+		Clock:               s.now,
+	})
+
+	s.controller = autoscaler.NewAutoscalerController(k8sClient, scheme, nil, s, s.provider, autoscaler.AutoscalerConfig{
+		ReconcileInterval: options.Step,
+	})
+	s.controller.Log = logr.Discard()
+	s.controller.Clock = s.now
+	if s.controller.PredictiveScaler != nil {
+		s.controller.PredictiveScaler.Clock = s.now
+	}
+
+	return s, nil
+}
+
+// Run replays the trace until every pod has finished or the horizon is reached
+func (s *Simulator) Run(ctx context.Context) (*Report, error) {
+	ctx = log.IntoContext(ctx, logr.Discard())
+	end := s.start.Add(s.trace.End() + s.options.Horizon)
+
+	for {
+		if err := s.step(ctx); err != nil {
+			return nil, fmt.Errorf("simulation failed at %s: %w", s.currentTime.Sub(s.start), err)
+		}
+		if s.finished() || !s.currentTime.Before(end) {
+			break
+		}
+		if err := s.accumulate(ctx); err != nil {
+			return nil, err
+		}
+		s.currentTime = s.currentTime.Add(s.options.Step)
+	}
+
+	return s.finalize(), nil
+}
+
+// step advances the cluster to the current simulated time
+func (s *Simulator) step(ctx context.Context) error {
+	if err := s.provider.Sync(ctx); err != nil {
+		return err
+	}
+	if err := s.requeueLostPods(ctx); err != nil {
+		return err
+	}
+	if err := s.completePods(ctx); err != nil {
+		return err
+	}
+	if err := s.submitPods(ctx); err != nil {
+		return err
+	}
+	if err := s.interruptSpotNodes(ctx); err != nil {
+		return err
+	}
+	if err := s.schedulePods(ctx); err != nil {
+		return err
+	}
+
+	// Scaling failures such as a pool at max size are part of the outcome being measured
+	if _, err := s.controller.Reconcile(ctx, ctrl.Request{NamespacedName: client.ObjectKey{Name: s.policy.Name}}); err != nil {
+		s.report.ScalingErrors++
+	}
+
+	return nil
+}
+
+// requeueLostPods resubmits running pods that were evicted by a drain or lost with a reclaimed node
+func (s *Simulator) requeueLostPods(ctx context.Context) error {
+	for _, pod := range s.pods {
+		if !pod.running {
+			continue
+		}
+
+		lost := false
+		object := &corev1.Pod{}
+		if err := s.client.Get(ctx, client.ObjectKey{Namespace: pod.namespace, Name: pod.objectName}, object); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			lost = true
+		} else if err := s.client.Get(ctx, client.ObjectKey{Name: pod.nodeName}, &corev1.Node{}); err != nil {
+			if !errors.IsNotFound(err) {
+				return err
+			}
+			lost = true
+			if err := s.client.Delete(ctx, object); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+
+		if lost {
+			pod.running = false
+			pod.nodeName = ""
+			s.report.RestartedPods++
+			if err := s.createPod(ctx, pod); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// completePods removes running pods whose duration has elapsed
+func (s *Simulator) completePods(ctx context.Context) error {
+	for _, pod := range s.pods {
+		if !pod.running || pod.startedAt.Add(pod.arrival.Duration.Duration).After(s.currentTime) {
+			continue
+		}
+
+		object := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: pod.namespace, Name: pod.objectName}}
+		if err := s.client.Delete(ctx, object); err != nil && !errors.IsNotFound(err) {
+			return fmt.Errorf("failed to complete pod %s: %w", pod.name, err)
+		}
+		pod.running = false
+		pod.done = true
+		s.report.CompletedPods++
+	}
+	return nil
+}
+
+// submitPods creates the pods that have arrived by the current time
+func (s *Simulator) submitPods(ctx context.Context) error {
+	for _, pod := range s.pods {
+		if pod.submitted || s.start.Add(pod.arrival.Arrival.Duration).After(s.currentTime) {
+			continue
+		}
+		pod.submitted = true
+		if err := s.createPod(ctx, pod); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// createPod creates a pending Pod object for the next attempt of a trace pod
+func (s *Simulator) createPod(ctx context.Context, pod *simulatedPod) error {
+	pod.objectName = pod.name
+	if pod.attempts > 0 {
+		pod.objectName = fmt.Sprintf("%s-retry-%d", pod.name, pod.attempts)
+	}
+	pod.attempts++
+
+	// The first attempt is created at its recorded arrival time, even between steps
+	pod.submittedAt = s.currentTime
+	if pod.attempts == 1 {
+		pod.submittedAt = s.start.Add(pod.arrival.Arrival.Duration)
+	}
+
+	priority := pod.arrival.Priority
+	object := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:              pod.objectName,
+			Namespace:         pod.namespace,
+			Labels:            map[string]string{TracePodLabel: pod.name},
+			CreationTimestamp: metav1.NewTime(pod.submittedAt),
+		},
+		Spec: corev1.PodSpec{
+			Priority: &priority,
+			Containers: []corev1.Container{{
+				Name:  "workload",
+				Image: "simulated",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"nvidia.com/gpu": *resource.NewQuantity(int64(pod.arrival.GPUs), resource.DecimalSI)},
+					Limits:   corev1.ResourceList{"nvidia.com/gpu": *resource.NewQuantity(int64(pod.arrival.GPUs), resource.DecimalSI)},
+				},
+			}},
+		},
+		Status: corev1.PodStatus{Phase: corev1.PodPending},
+	}
+
+	if err := s.client.Create(ctx, object); err != nil {
+		return fmt.Errorf("failed to create pod %s: %w", pod.objectName, err)
+	}
+	s.podsByObject[pod.objectName] = pod
+	return nil
+}
+
+// interruptSpotNodes issues termination notices for recorded and randomly drawn spot interruptions
+func (s *Simulator) interruptSpotNodes(ctx context.Context) error {
+	nodes, err := s.listNodes(ctx)
+	if err != nil {
+		return err
+	}
+
+	spotNodes := make([]corev1.Node, 0)
+	for _, node := range nodes {
+		if node.Labels[autoscaler.CapacityTypeLabel] == autoscaler.CapacityTypeSpot && !s.noticed[node.Name] {
+			spotNodes = append(spotNodes, node)
+		}
+	}
+	// Longest-running nodes first, so recorded interruptions without a node are deterministic
+	sort.SliceStable(spotNodes, func(i, j int) bool {
+		return s.launched[spotNodes[i].Name].Before(s.launched[spotNodes[j].Name])
+	})
+
+	for i, interruption := range s.trace.SpotInterruptions {
+		if s.interruptions[i] || s.start.Add(interruption.At.Duration).After(s.currentTime) {
+			continue
+		}
+		s.interruptions[i] = true
+
+		for _, node := range spotNodes {
+			if s.noticed[node.Name] || (interruption.Node != "" && interruption.Node != node.Name) {
+				continue
+			}
+			s.interrupt(node.Name)
+			break
+		}
+	}
+
+	if s.options.SpotInterruptionRate > 0 {
+		probability := s.options.SpotInterruptionRate * s.options.Step.Hours()
+		for _, node := range spotNodes {
+			if !s.noticed[node.Name] && s.random.Float64() < probability {
+				s.interrupt(node.Name)
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Simulator) interrupt(nodeName string) {
+	s.noticed[nodeName] = true
+	s.provider.InjectSpotTerminationNotice(nodeName, s.currentTime.Add(s.options.SpotNoticeLead))
+	s.report.SpotInterruptions++
+}
+
+// schedulePods binds pending pods to the nodes chosen by the bin-packing scheduler
+func (s *Simulator) schedulePods(ctx context.Context) error {
+	result, err := s.scheduler.PackWorkloads(ctx)
+	if err != nil {
+		return err
+	}
+
+	for objectName, nodeName := range result.Placements {
+		pod, ok := s.podsByObject[objectName]
+		if !ok {
+			continue
+		}
+
+		object := &corev1.Pod{}
+		if err := s.client.Get(ctx, client.ObjectKey{Namespace: pod.namespace, Name: objectName}, object); err != nil {
+			return fmt.Errorf("failed to get pod %s: %w", objectName, err)
+		}
+		object.Spec.NodeName = nodeName
+		if err := s.client.Update(ctx, object); err != nil {
+			return fmt.Errorf("failed to bind pod %s: %w", objectName, err)
+		}
+		startTime := metav1.NewTime(s.currentTime)
+		object.Status.Phase = corev1.PodRunning
+		object.Status.StartTime = &startTime
+		if err := s.client.Status().Update(ctx, object); err != nil {
+			return fmt.Errorf("failed to start pod %s: %w", objectName, err)
+		}
+
+		pod.running = true
+		pod.nodeName = nodeName
+		pod.startedAt = s.currentTime
+		s.pendingTimes = append(s.pendingTimes, s.currentTime.Sub(pod.submittedAt))
+	}
+
+	return nil
+}
+
+// accumulate charges the nodes that exist for one step
+func (s *Simulator) accumulate(ctx context.Context) error {
+	nodes, err := s.listNodes(ctx)
+	if err != nil {
+		return err
+	}
+	if len(nodes) > s.report.PeakNodes {
+		s.report.PeakNodes = len(nodes)
+	}
+
+	allocated := make(map[string]int)
+	for _, pod := range s.pods {
+		if pod.running {
+			allocated[pod.nodeName] += int(pod.arrival.GPUs)
+		}
+	}
+
+	hours := s.options.Step.Hours()
+	for _, node := range nodes {
+		instanceType := node.Labels[autoscaler.InstanceTypeLabel]
+		price, err := s.provider.GetOnDemandPrice(ctx, instanceType)
+		if node.Labels[autoscaler.CapacityTypeLabel] == autoscaler.CapacityTypeSpot {
+			price, err = s.provider.GetSpotPrice(ctx, instanceType)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to price node %s: %w", node.Name, err)
+		}
+
+		gpus := nodeGPUs(&node)
+		s.report.TotalCost += price * hours
+		s.report.GPUHours += float64(gpus) * hours
+		if idle := gpus - allocated[node.Name]; idle > 0 {
+			s.report.WastedGPUHours += float64(idle) * hours
+		}
+	}
+
+	return nil
+}
+
+// GetGPUMetrics reports per-GPU utilization the way DCGM would: allocated GPUs run at
+// the trace's sampled utilization and idle GPUs report zero
+func (s *Simulator) GetGPUMetrics(ctx context.Context) ([]metrics.GPUMetrics, error) {
+	nodes, err := s.listNodes(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	utilization := s.trace.UtilizationAt(s.currentTime.Sub(s.start))
+	gpuMetrics := make([]metrics.GPUMetrics, 0)
+	for _, node := range nodes {
+		gpuIndex := 0
+		for _, pod := range s.pods {
+			if !pod.running || pod.nodeName != node.Name {
+				continue
+			}
+			for i := 0; i < int(pod.arrival.GPUs); i++ {
+				gpuMetrics = append(gpuMetrics, metrics.GPUMetrics{
+					PodName:        pod.objectName,
+					PodNamespace:   pod.namespace,
+					NodeName:       node.Name,
+					GPUIndex:       gpuIndex,
+					GPUUtilization: utilization,
+					Timestamp:      s.currentTime,
+				})
+				gpuIndex++
+			}
+		}
+		for ; gpuIndex < nodeGPUs(&node); gpuIndex++ {
+			gpuMetrics = append(gpuMetrics, metrics.GPUMetrics{
+				NodeName:  node.Name,
+				GPUIndex:  gpuIndex,
+				Timestamp: s.currentTime,
+			})
+		}
+	}
+
+	return gpuMetrics, nil
+}
+
+// finished reports whether the whole trace has been replayed and every pod has completed
+func (s *Simulator) finished() bool {
+	if s.currentTime.Before(s.start.Add(s.trace.End())) {
+		return false
+	}
+	for _, pod := range s.pods {
+		if !pod.done {
+			return false
+		}
+	}
+	return true
+}
+
+// finalize counts pods that never got scheduled and computes the pending-time percentiles
+func (s *Simulator) finalize() *Report {
+	report := s.report
+	report.Duration = metav1.Duration{Duration: s.currentTime.Sub(s.start)}
+	report.NodesLaunched = len(s.launched)
+
+	// Pods still waiting count with the time they have waited so far
+	pendingTimes := append([]time.Duration(nil), s.pendingTimes...)
+	for _, pod := range s.pods {
+		if pod.submitted && !pod.running && !pod.done {
+			report.UnscheduledPods++
+			pendingTimes = append(pendingTimes, s.currentTime.Sub(pod.submittedAt))
+		}
+	}
+
+	sort.Slice(pendingTimes, func(i, j int) bool { return pendingTimes[i] < pendingTimes[j] })
+	report.PendingP50 = metav1.Duration{Duration: percentile(pendingTimes, 0.50)}
+	report.PendingP95 = metav1.Duration{Duration: percentile(pendingTimes, 0.95)}
+	if len(pendingTimes) > 0 {
+		report.PendingMax = metav1.Duration{Duration: pendingTimes[len(pendingTimes)-1]}
+	}
+
+	return report
+}
+
+func (s *Simulator) listNodes(ctx context.Context) ([]corev1.Node, error) {
+	nodeList := &corev1.NodeList{}
+	if err := s.client.List(ctx, nodeList); err != nil {
+		return nil, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	for _, node := range nodeList.Items {
+		if _, seen := s.launched[node.Name]; !seen {
+			s.launched[node.Name] = s.currentTime
+		}
+	}
+	return nodeList.Items, nil
+}
+
+func (s *Simulator) now() time.Time {
+	return s.currentTime
+}
+
+func nodeGPUs(node *corev1.Node) int {
+	if gpus, ok := node.Status.Allocatable["nvidia.com/gpu"]; ok {
+		return int(gpus.Value())
+	}
+	return 0
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	rank := int(math.Ceil(p*float64(len(sorted)))) - 1
+	if rank < 0 {
+		rank = 0
+	}
+	if rank >= len(sorted) {
+		rank = len(sorted) - 1
+	}
+	return sorted[rank]
+}
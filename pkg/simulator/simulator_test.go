@@ -0,0 +1,175 @@
+package simulator
+
+import (
+	"context"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/autoscaler"
+)
+
+func newTestTrace() *Trace {
+	pods := make([]PodArrival, 0)
+	for i := 0; i < 6; i++ {
+		pods = append(pods, PodArrival{
+			Namespace: "ml",
+			Arrival:   metav1.Duration{Duration: time.Duration(i) * time.Minute},
+			Duration:  metav1.Duration{Duration: time.Hour},
+			GPUs:      2,
+		})
+	}
+	return &Trace{
+		Pods: pods,
+		Utilization: []UtilizationSample{
+			{At: metav1.Duration{Duration: 0}, Utilization: 90},
+		},
+	}
+}
+
+func TestSimulatorRun(t *testing.T) {
+	policy := DefaultPolicy()
+	policy.Spec.EnableSpotInstances = false
+	policy.Spec.NodePools = policy.Spec.NodePools[1:]
+
+	simulator, err := NewSimulator(newTestTrace(), Options{Policy: policy})
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+
+	report, err := simulator.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.CompletedPods != 6 || report.UnscheduledPods != 0 {
+		t.Errorf("Expected all 6 pods to complete, got %d completed and %d unscheduled", report.CompletedPods, report.UnscheduledPods)
+	}
+	if report.SpotInterruptions != 0 || report.RestartedPods != 0 {
+		t.Errorf("Expected no interruptions, got %d interruptions and %d restarts", report.SpotInterruptions, report.RestartedPods)
+	}
+
+	// Pods wait out the pending timeout and the provisioning latency before the first node registers
+	minimumWait := autoscaler.DefaultPendingPodTimeout + autoscaler.DefaultSimulatedProvisioningLatency
+	if report.PendingMax.Duration < minimumWait {
+		t.Errorf("Expected the longest wait to be at least %s, got %s", minimumWait, report.PendingMax.Duration)
+	}
+	if report.PendingP50.Duration > report.PendingP95.Duration || report.PendingP95.Duration > report.PendingMax.Duration {
+		t.Errorf("Expected ordered percentiles, got p50=%s p95=%s max=%s", report.PendingP50.Duration, report.PendingP95.Duration, report.PendingMax.Duration)
+	}
+
+	// 12 GPUs of work for an hour on 4-GPU p3.8xlarge nodes
+	if report.NodesLaunched < 3 {
+		t.Errorf("Expected at least 3 nodes to be launched, got %d", report.NodesLaunched)
+	}
+	if used := report.GPUHours - report.WastedGPUHours; math.Abs(used-12) > 0.01 {
+		t.Errorf("Expected 12 allocated GPU-hours, got total=%.2f wasted=%.2f", report.GPUHours, report.WastedGPUHours)
+	}
+	if report.WastedGPUHours <= 0 {
+		t.Error("Expected the idle GPUs of the extra node to count as waste")
+	}
+	if report.TotalCost <= 0 {
+		t.Errorf("Expected a positive cost, got %.2f", report.TotalCost)
+	}
+}
+
+func TestSimulatorSpotInterruption(t *testing.T) {
+	trace := newTestTrace()
+	trace.SpotInterruptions = []SpotInterruption{{At: metav1.Duration{Duration: 30 * time.Minute}}}
+
+	policy := DefaultPolicy()
+	policy.Spec.SpotInstancePercentage = 1
+
+	simulator, err := NewSimulator(trace, Options{Policy: policy, ProvisioningLatency: -1})
+	if err != nil {
+		t.Fatalf("NewSimulator() error = %v", err)
+	}
+
+	report, err := simulator.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+
+	if report.SpotInterruptions != 1 {
+		t.Errorf("Expected 1 spot interruption, got %d", report.SpotInterruptions)
+	}
+	if report.RestartedPods == 0 {
+		t.Error("Expected pods on the reclaimed node to be restarted")
+	}
+	if report.CompletedPods != 6 {
+		t.Errorf("Expected restarted pods to complete, got %d completed", report.CompletedPods)
+	}
+}
+
+func TestPercentile(t *testing.T) {
+	durations := []time.Duration{time.Second, 2 * time.Second, 3 * time.Second, 4 * time.Second, 10 * time.Second}
+
+	tests := []struct {
+		p        float64
+		expected time.Duration
+	}{
+		{p: 0.50, expected: 3 * time.Second},
+		{p: 0.95, expected: 10 * time.Second},
+		{p: 0, expected: time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := percentile(durations, tt.p); got != tt.expected {
+			t.Errorf("Expected p%.0f %s, got %s", tt.p*100, tt.expected, got)
+		}
+	}
+
+	if got := percentile(nil, 0.5); got != 0 {
+		t.Errorf("Expected 0 for no samples, got %s", got)
+	}
+}
+
+func TestLoadTrace(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "trace.yaml")
+	data := `start: "2024-03-04T09:00:00Z"
+pods:
+- name: train-resnet
+  namespace: ml
+  arrival: 5m
+  duration: 2h
+  gpus: 4
+utilization:
+- at: 0s
+  utilization: 35
+- at: 1h
+  utilization: 80
+spotInterruptions:
+- at: 90m
+`
+	if err := os.WriteFile(path, []byte(data), 0o600); err != nil {
+		t.Fatalf("failed to write trace: %v", err)
+	}
+
+	trace, err := LoadTrace(path)
+	if err != nil {
+		t.Fatalf("LoadTrace() error = %v", err)
+	}
+	if len(trace.Pods) != 1 || trace.Pods[0].Arrival.Duration != 5*time.Minute || trace.Pods[0].Duration.Duration != 2*time.Hour || trace.Pods[0].GPUs != 4 {
+		t.Errorf("Unexpected pods: %+v", trace.Pods)
+	}
+	if !trace.StartTime().Equal(time.Date(2024, 3, 4, 9, 0, 0, 0, time.UTC)) {
+		t.Errorf("Unexpected start time: %s", trace.StartTime())
+	}
+	if got := trace.UtilizationAt(90 * time.Minute); got != 80 {
+		t.Errorf("Expected utilization 80 after the second sample, got %.0f", got)
+	}
+	if trace.End() != 90*time.Minute {
+		t.Errorf("Expected trace end 90m, got %s", trace.End())
+	}
+
+	if err := os.WriteFile(path, []byte("pods:\n- name: notebook\n  duration: 1h\n"), 0o600); err != nil {
+		t.Fatalf("failed to write trace: %v", err)
+	}
+	if _, err := LoadTrace(path); err == nil {
+		t.Error("Expected error for a pod without GPUs")
+	}
+}
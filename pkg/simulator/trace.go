@@ -0,0 +1,158 @@
+package simulator
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/yaml"
+)
+
+// DefaultTraceStart is the simulated wall-clock time of a trace that does not set one
+var DefaultTraceStart = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// Trace is a recorded GPU workload replayed by the simulator.
+// All times are offsets from Start.
+type Trace struct {
+	// Start is the wall-clock time the trace begins at, which drives spot prices and predictions
+	Start *metav1.Time `json:"start,omitempty"`
+
+	// Pods are the GPU pods submitted during the trace
+	Pods []PodArrival `json:"pods"`
+
+	// Utilization samples the GPU utilization of allocated GPUs over time
+	Utilization []UtilizationSample `json:"utilization,omitempty"`
+
+	// SpotInterruptions are spot reclaims observed while the trace was recorded
+	SpotInterruptions []SpotInterruption `json:"spotInterruptions,omitempty"`
+}
+
+// PodArrival is a GPU pod submitted during the trace
+type PodArrival struct {
+	// Name identifies the pod; generated from its position in the trace when empty
+	Name string `json:"name,omitempty"`
+
+	// Namespace defaults to "default"
+	Namespace string `json:"namespace,omitempty"`
+
+	// Arrival is when the pod is created
+	Arrival metav1.Duration `json:"arrival"`
+
+	// Duration is how long the pod runs once scheduled
+	Duration metav1.Duration `json:"duration"`
+
+	// GPUs is the number of nvidia.com/gpu requested
+	GPUs int32 `json:"gpus"`
+
+	// Priority is the pod priority used when packing pending pods
+	Priority int32 `json:"priority,omitempty"`
+}
+
+// UtilizationSample is the average utilization (0-100) of allocated GPUs from At until the next sample
+type UtilizationSample struct {
+	At          metav1.Duration `json:"at"`
+	Utilization float64         `json:"utilization"`
+}
+
+// SpotInterruption reclaims a spot node at a point in the trace
+type SpotInterruption struct {
+	At metav1.Duration `json:"at"`
+
+	// Node is the spot node to reclaim; the longest-running spot node is reclaimed when empty
+	Node string `json:"node,omitempty"`
+}
+
+// LoadTrace reads a YAML or JSON trace file
+func LoadTrace(path string) (*Trace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read trace: %w", err)
+	}
+
+	trace := &Trace{}
+	if err := yaml.Unmarshal(data, trace); err != nil {
+		return nil, fmt.Errorf("failed to parse trace: %w", err)
+	}
+
+	if err := trace.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid trace %s: %w", path, err)
+	}
+
+	return trace, nil
+}
+
+// Validate checks the trace for pods the simulator cannot replay
+func (t *Trace) Validate() error {
+	names := make(map[string]bool, len(t.Pods))
+	for i, pod := range t.Pods {
+		name := podName(i, pod)
+		if names[name] {
+			return fmt.Errorf("duplicate pod name %s", name)
+		}
+		names[name] = true
+
+		if pod.GPUs <= 0 {
+			return fmt.Errorf("pod %s must request at least one GPU", name)
+		}
+		if pod.Duration.Duration <= 0 {
+			return fmt.Errorf("pod %s must have a positive duration", name)
+		}
+		if pod.Arrival.Duration < 0 {
+			return fmt.Errorf("pod %s has a negative arrival time", name)
+		}
+	}
+
+	for _, sample := range t.Utilization {
+		if sample.Utilization < 0 || sample.Utilization > 100 {
+			return fmt.Errorf("utilization sample at %s must be between 0 and 100", sample.At.Duration)
+		}
+	}
+
+	return nil
+}
+
+// StartTime returns the wall-clock time the trace begins at
+func (t *Trace) StartTime() time.Time {
+	if t.Start != nil {
+		return t.Start.Time
+	}
+	return DefaultTraceStart
+}
+
+// UtilizationAt returns the sampled utilization (0-100) in effect at an offset into the trace.
+// Allocated GPUs are assumed fully busy before the first sample.
+func (t *Trace) UtilizationAt(offset time.Duration) float64 {
+	utilization := 100.0
+	latest := time.Duration(-1)
+	for _, sample := range t.Utilization {
+		if sample.At.Duration <= offset && sample.At.Duration > latest {
+			latest = sample.At.Duration
+			utilization = sample.Utilization
+		}
+	}
+	return utilization
+}
+
+// End returns the offset of the last pod arrival or interruption in the trace
+func (t *Trace) End() time.Duration {
+	var end time.Duration
+	for _, pod := range t.Pods {
+		if pod.Arrival.Duration > end {
+			end = pod.Arrival.Duration
+		}
+	}
+	for _, interruption := range t.SpotInterruptions {
+		if interruption.At.Duration > end {
+			end = interruption.At.Duration
+		}
+	}
+	return end
+}
+
+func podName(index int, pod PodArrival) string {
+	if pod.Name != "" {
+		return pod.Name
+	}
+	return fmt.Sprintf("trace-pod-%d", index)
+}
@@ -0,0 +1,166 @@
+package webhook
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/cost"
+)
+
+const (
+	// BudgetOverrideAnnotation lets a pod bypass budget blocking. The value should
+	// explain why the override is needed; every use is recorded as an Event.
+	BudgetOverrideAnnotation = "gpu-autoscaler.io/budget-override"
+)
+
+// BudgetValidationWebhook is a validating webhook that rejects GPU pods covered by a blocking
+// CostBudget, or whose projected cost would push a budget over its monthly limit
+type BudgetValidationWebhook struct {
+	client        client.Client
+	decoder       *admission.Decoder
+	recorder      record.EventRecorder
+	pricingClient *cost.PricingClient
+	now           func() time.Time
+}
+
+// NewBudgetValidationWebhook creates a new budget validation webhook.
+// Projected spend checks are skipped when pricingClient is nil.
+func NewBudgetValidationWebhook(client client.Client, recorder record.EventRecorder, pricingClient *cost.PricingClient) *BudgetValidationWebhook {
+	return &BudgetValidationWebhook{
+		client:        client,
+		recorder:      recorder,
+		pricingClient: pricingClient,
+		now:           time.Now,
+	}
+}
+
+// Handle processes admission requests for pod creation
+func (w *BudgetValidationWebhook) Handle(ctx context.Context, req admission.Request) admission.Response {
+	log := log.FromContext(ctx)
+
+	if req.Operation != admissionv1.Create {
+		return admission.Allowed("budget checks only apply to pod creation")
+	}
+
+	pod := &corev1.Pod{}
+	if err := w.decoder.Decode(req, pod); err != nil {
+		log.Error(err, "Failed to decode pod")
+		return admission.Errored(http.StatusBadRequest, err)
+	}
+	// The namespace is not always set on the object for create requests
+	if pod.Namespace == "" {
+		pod.Namespace = req.Namespace
+	}
+
+	if !hasGPURequest(pod) {
+		return admission.Allowed("no GPU requests")
+	}
+
+	budgets := &v1alpha1.CostBudgetList{}
+	if err := w.client.List(ctx, budgets); err != nil {
+		log.Error(err, "Failed to list cost budgets")
+		return admission.Errored(http.StatusInternalServerError, err)
+	}
+
+	// Estimate the pod's cost once for the projected spend checks
+	podHourlyCost := 0.0
+	if w.pricingClient != nil {
+		hourlyCost, err := cost.EstimatePodHourlyCost(ctx, w.pricingClient, pod)
+		if err != nil {
+			log.Error(err, "Failed to estimate pod cost, skipping projected spend checks")
+		}
+		podHourlyCost = hourlyCost
+	}
+
+	now := w.now()
+	var warnings []string
+	for i := range budgets.Items {
+		budget := &budgets.Items[i]
+		if !budget.Spec.Enabled || !cost.PodInBudgetScope(pod, budget.Spec.Scope) {
+			continue
+		}
+
+		var reason string
+		if cost.IsBudgetBlocking(budget, now) {
+			reason = budgetDeniedMessage(budget)
+		} else if podHourlyCost > 0 {
+			projected := cost.ProjectedSpendWithPod(budget, podHourlyCost, now)
+			if projected > budget.Spec.MonthlyLimit {
+				message := projectedSpendMessage(budget, podHourlyCost, projected)
+				// Only budgets that block pods reject them; others warn the submitter
+				if budget.Spec.Enforcement != nil && budget.Spec.Enforcement.Action == cost.EnforcementActionBlock {
+					reason = message
+				} else {
+					warnings = append(warnings, message)
+				}
+			}
+		}
+		if reason == "" {
+			continue
+		}
+
+		if override, ok := pod.Annotations[BudgetOverrideAnnotation]; ok {
+			log.Info("Budget block overridden",
+				"budget", budget.Name,
+				"pod", podDisplayName(pod),
+				"user", req.UserInfo.Username)
+			w.recorder.Eventf(budget, corev1.EventTypeWarning, "BudgetOverride",
+				"GPU pod %s/%s admitted past budget by %s: %s",
+				pod.Namespace, podDisplayName(pod), req.UserInfo.Username, override)
+			continue
+		}
+
+		log.Info("Rejecting GPU pod due to budget",
+			"budget", budget.Name,
+			"pod", podDisplayName(pod))
+		return admission.Denied(reason)
+	}
+
+	return admission.Allowed("within budget").WithWarnings(warnings...)
+}
+
+// InjectDecoder injects the decoder
+func (w *BudgetValidationWebhook) InjectDecoder(d *admission.Decoder) error {
+	w.decoder = d
+	return nil
+}
+
+// budgetDeniedMessage explains why a pod was rejected by a budget
+func budgetDeniedMessage(budget *v1alpha1.CostBudget) string {
+	return fmt.Sprintf("GPU pod blocked by CostBudget %q: spend $%.2f of $%.2f monthly limit (%.0f%%). "+
+		"Set the %s annotation to override",
+		budget.Name,
+		budget.Status.CurrentSpend,
+		budget.Spec.MonthlyLimit,
+		budget.Status.PercentageUsed,
+		BudgetOverrideAnnotation)
+}
+
+// projectedSpendMessage explains how a pod would push a budget over its limit
+func projectedSpendMessage(budget *v1alpha1.CostBudget, podHourlyCost, projected float64) string {
+	return fmt.Sprintf("GPU pod estimated at $%.2f/hour would bring CostBudget %q to a projected $%.2f, "+
+		"over its $%.2f monthly limit (current spend $%.2f)",
+		podHourlyCost,
+		budget.Name,
+		projected,
+		budget.Spec.MonthlyLimit,
+		budget.Status.CurrentSpend)
+}
+
+// podDisplayName returns the pod name, falling back to its generateName prefix
+func podDisplayName(pod *corev1.Pod) string {
+	if pod.Name != "" {
+		return pod.Name
+	}
+	return pod.GenerateName
+}
@@ -0,0 +1,240 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	admissionv1 "k8s.io/api/admission/v1"
+	authenticationv1 "k8s.io/api/authentication/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/cost"
+)
+
+func newTestScheme(t *testing.T) *runtime.Scheme {
+	t.Helper()
+	scheme := runtime.NewScheme()
+	if err := clientgoscheme.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add client-go scheme: %v", err)
+	}
+	if err := v1alpha1.AddToScheme(scheme); err != nil {
+		t.Fatalf("failed to add v1alpha1 scheme: %v", err)
+	}
+	return scheme
+}
+
+func newPodAdmissionRequest(t *testing.T, pod *corev1.Pod) admission.Request {
+	t.Helper()
+	raw, err := json.Marshal(pod)
+	if err != nil {
+		t.Fatalf("failed to marshal pod: %v", err)
+	}
+	return admission.Request{AdmissionRequest: admissionv1.AdmissionRequest{
+		Operation: admissionv1.Create,
+		Namespace: pod.Namespace,
+		Object:    runtime.RawExtension{Raw: raw},
+		UserInfo:  authenticationv1.UserInfo{Username: "alice"},
+	}}
+}
+
+func newGPUPod(namespace string, labels, annotations map[string]string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        "trainer",
+			Namespace:   namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: corev1.PodSpec{
+			Containers: []corev1.Container{{
+				Name: "trainer",
+				Resources: corev1.ResourceRequirements{
+					Requests: corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")},
+				},
+			}},
+		},
+	}
+}
+
+func TestBudgetValidationWebhook(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+	exceededBudget := func(name string, scope v1alpha1.BudgetScope, exceededFor time.Duration) *v1alpha1.CostBudget {
+		return &v1alpha1.CostBudget{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1alpha1.CostBudgetSpec{
+				MonthlyLimit: 1000,
+				Scope:        scope,
+				Enabled:      true,
+				Enforcement:  &v1alpha1.BudgetEnforcement{Action: "block", GracePeriodMinutes: 60},
+			},
+			Status: v1alpha1.CostBudgetStatus{
+				CurrentSpend:   1250,
+				PercentageUsed: 125,
+				BudgetStatus:   "exceeded",
+				ExceededSince:  &metav1.Time{Time: now.Add(-exceededFor)},
+			},
+		}
+	}
+
+	tests := []struct {
+		name          string
+		budget        *v1alpha1.CostBudget
+		pod           *corev1.Pod
+		expectAllowed bool
+		expectEvent   bool
+	}{
+		{
+			name:          "Namespace budget past grace period blocks pod",
+			budget:        exceededBudget("ml-team", v1alpha1.BudgetScope{Namespaces: []string{"ml"}}, 2*time.Hour),
+			pod:           newGPUPod("ml", nil, nil),
+			expectAllowed: false,
+		},
+		{
+			name:          "Budget within grace period allows pod",
+			budget:        exceededBudget("ml-team", v1alpha1.BudgetScope{Namespaces: []string{"ml"}}, 30*time.Minute),
+			pod:           newGPUPod("ml", nil, nil),
+			expectAllowed: true,
+		},
+		{
+			name:          "Team budget blocks pod with team label",
+			budget:        exceededBudget("research", v1alpha1.BudgetScope{Teams: []string{"research"}}, 2*time.Hour),
+			pod:           newGPUPod("default", map[string]string{"team": "research"}, nil),
+			expectAllowed: false,
+		},
+		{
+			name:          "Experiment budget ignores other experiments",
+			budget:        exceededBudget("exp-42", v1alpha1.BudgetScope{ExperimentID: "exp-42"}, 2*time.Hour),
+			pod:           newGPUPod("default", map[string]string{"experiment-id": "exp-7"}, nil),
+			expectAllowed: true,
+		},
+		{
+			name:          "Override annotation admits pod and records an event",
+			budget:        exceededBudget("ml-team", v1alpha1.BudgetScope{Namespaces: []string{"ml"}}, 2*time.Hour),
+			pod:           newGPUPod("ml", nil, map[string]string{BudgetOverrideAnnotation: "incident 1234"}),
+			expectAllowed: true,
+			expectEvent:   true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newTestScheme(t)
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(tt.budget).Build()
+			recorder := record.NewFakeRecorder(10)
+
+			webhook := NewBudgetValidationWebhook(k8sClient, recorder, nil)
+			webhook.now = func() time.Time { return now }
+			if err := webhook.InjectDecoder(admission.NewDecoder(scheme)); err != nil {
+				t.Fatalf("InjectDecoder() error = %v", err)
+			}
+
+			resp := webhook.Handle(context.Background(), newPodAdmissionRequest(t, tt.pod))
+			if resp.Allowed != tt.expectAllowed {
+				t.Fatalf("Expected allowed=%v, got %v (%s)", tt.expectAllowed, resp.Allowed, resp.Result.Message)
+			}
+			if !resp.Allowed {
+				message := resp.Result.Message
+				if !strings.Contains(message, tt.budget.Name) || !strings.Contains(message, "$1250.00") {
+					t.Errorf("Expected denial to name budget and spend, got %q", message)
+				}
+			}
+
+			select {
+			case event := <-recorder.Events:
+				if !tt.expectEvent {
+					t.Errorf("Unexpected event: %s", event)
+				} else if !strings.Contains(event, "BudgetOverride") || !strings.Contains(event, "alice") {
+					t.Errorf("Expected override event naming the user, got %q", event)
+				}
+			default:
+				if tt.expectEvent {
+					t.Error("Expected an override event")
+				}
+			}
+		})
+	}
+}
+
+func TestBudgetValidationWebhookProjectedSpend(t *testing.T) {
+	now := time.Date(2024, 1, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name           string
+		action         string
+		projectedSpend float64
+		expectAllowed  bool
+		expectWarning  bool
+	}{
+		{
+			name:           "Block budget rejects pod that would exceed the limit",
+			action:         "block",
+			projectedSpend: 900,
+			expectAllowed:  false,
+		},
+		{
+			name:           "Alert budget warns about pod that would exceed the limit",
+			action:         "alert",
+			projectedSpend: 900,
+			expectAllowed:  true,
+			expectWarning:  true,
+		},
+		{
+			name:           "Pod that fits within the limit is admitted",
+			action:         "block",
+			projectedSpend: 100,
+			expectAllowed:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// The limit leaves room for roughly 16 days of a $2.50/hour V100
+			budget := &v1alpha1.CostBudget{
+				ObjectMeta: metav1.ObjectMeta{Name: "ml-team"},
+				Spec: v1alpha1.CostBudgetSpec{
+					MonthlyLimit: 1100,
+					Scope:        v1alpha1.BudgetScope{Namespaces: []string{"ml"}},
+					Enabled:      true,
+					Enforcement:  &v1alpha1.BudgetEnforcement{Action: tt.action, GracePeriodMinutes: 60},
+				},
+				Status: v1alpha1.CostBudgetStatus{
+					CurrentSpend:          tt.projectedSpend / 2,
+					BudgetStatus:          "ok",
+					ProjectedMonthlySpend: tt.projectedSpend,
+				},
+			}
+			pod := newGPUPod("ml", nil, nil)
+			pod.Spec.NodeSelector = map[string]string{"nvidia.com/gpu.product": "nvidia-tesla-v100"}
+
+			scheme := newTestScheme(t)
+			k8sClient := fake.NewClientBuilder().WithScheme(scheme).WithObjects(budget).Build()
+			webhook := NewBudgetValidationWebhook(k8sClient, record.NewFakeRecorder(10), cost.NewPricingClient("", "us-east-1"))
+			webhook.now = func() time.Time { return now }
+			if err := webhook.InjectDecoder(admission.NewDecoder(scheme)); err != nil {
+				t.Fatalf("InjectDecoder() error = %v", err)
+			}
+
+			resp := webhook.Handle(context.Background(), newPodAdmissionRequest(t, pod))
+			if resp.Allowed != tt.expectAllowed {
+				t.Fatalf("Expected allowed=%v, got %v (%s)", tt.expectAllowed, resp.Allowed, resp.Result.Message)
+			}
+			if !resp.Allowed && !strings.Contains(resp.Result.Message, "$2.50/hour") {
+				t.Errorf("Expected denial to include the pod's hourly estimate, got %q", resp.Result.Message)
+			}
+			if hasWarning := len(resp.Warnings) > 0; hasWarning != tt.expectWarning {
+				t.Errorf("Expected warning=%v, got %v", tt.expectWarning, resp.Warnings)
+			}
+		})
+	}
+}
@@ -5,15 +5,28 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"sort"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
 	"github.com/gpuautoscaler/gpuautoscaler/pkg/sharing"
 )
 
+const (
+	// SharingPolicyAnnotation records the GPUSharingPolicy that chose a pod's strategy
+	SharingPolicyAnnotation = "gpu-autoscaler.io/sharing-policy"
+
+	// StrategyAuto lets the built-in heuristics pick the strategy for pods matching a policy
+	StrategyAuto = "auto"
+)
+
 // GPUOptimizationWebhook is a mutating webhook that automatically optimizes GPU requests
 type GPUOptimizationWebhook struct {
 	client    client.Client
@@ -24,6 +37,9 @@ type GPUOptimizationWebhook struct {
 	enableMIG bool
 	enableMPS bool
 	enableTS  bool
+
+	// policyCounts batches the AppliedPods status updates of matched sharing policies
+	policyCounts *policyCounter
 }
 
 // NewGPUOptimizationWebhook creates a new GPU optimization webhook
@@ -36,6 +52,8 @@ func NewGPUOptimizationWebhook(client client.Client, enableMIG, enableMPS, enabl
 		enableMIG: enableMIG,
 		enableMPS: enableMPS,
 		enableTS:  enableTS,
+
+		policyCounts: newPolicyCounter(client, DefaultPolicyCountFlushInterval),
 	}
 }
 
@@ -72,21 +90,31 @@ func (w *GPUOptimizationWebhook) Handle(ctx context.Context, req admission.Reque
 	modified := false
 	var err error
 
+	// The namespace is not always set on the object for create requests
+	if optimizedPod.Namespace == "" {
+		optimizedPod.Namespace = req.Namespace
+	}
+
 	// Determine the best sharing strategy
-	strategy, err := w.selectOptimizationStrategy(ctx, optimizedPod)
+	strategy, policy, err := w.selectOptimizationStrategy(ctx, optimizedPod)
 	if err != nil {
 		log.Error(err, "Failed to select optimization strategy")
 		return admission.Errored(http.StatusInternalServerError, err)
 	}
 
+	policyName := ""
+	if policy != nil {
+		policyName = policy.Name
+	}
 	log.Info("Selected optimization strategy",
 		"pod", pod.Name,
-		"strategy", strategy)
+		"strategy", strategy,
+		"policy", policyName)
 
 	switch strategy {
 	case "mig":
 		if w.enableMIG {
-			if err := w.applyMIGOptimization(ctx, optimizedPod); err != nil {
+			if err := w.applyMIGOptimization(ctx, optimizedPod, policy); err != nil {
 				log.Error(err, "Failed to apply MIG optimization")
 			} else {
 				modified = true
@@ -94,7 +122,7 @@ func (w *GPUOptimizationWebhook) Handle(ctx context.Context, req admission.Reque
 		}
 	case "mps":
 		if w.enableMPS {
-			if err := w.applyMPSOptimization(ctx, optimizedPod); err != nil {
+			if err := w.applyMPSOptimization(ctx, optimizedPod, policy); err != nil {
 				log.Error(err, "Failed to apply MPS optimization")
 			} else {
 				modified = true
@@ -102,7 +130,7 @@ func (w *GPUOptimizationWebhook) Handle(ctx context.Context, req admission.Reque
 		}
 	case "timeslicing":
 		if w.enableTS {
-			if err := w.applyTimeSlicingOptimization(ctx, optimizedPod); err != nil {
+			if err := w.applyTimeSlicingOptimization(ctx, optimizedPod, policy); err != nil {
 				log.Error(err, "Failed to apply time-slicing optimization")
 			} else {
 				modified = true
@@ -114,6 +142,11 @@ func (w *GPUOptimizationWebhook) Handle(ctx context.Context, req admission.Reque
 		log.Info("No optimization strategy selected")
 	}
 
+	// A policy counts as applied when it kept the pod exclusive or its strategy was applied
+	if policy != nil && (modified || strategy == "exclusive") && (req.DryRun == nil || !*req.DryRun) {
+		w.policyCounts.add(policy.Name, 1)
+	}
+
 	if !modified {
 		return admission.Allowed("no optimizations applied")
 	}
@@ -132,16 +165,109 @@ func (w *GPUOptimizationWebhook) Handle(ctx context.Context, req admission.Reque
 	return admission.PatchResponseFromRaw(req.Object.Raw, marshaledPod)
 }
 
-// selectOptimizationStrategy selects the best GPU sharing strategy for a pod
-func (w *GPUOptimizationWebhook) selectOptimizationStrategy(ctx context.Context, pod *corev1.Pod) (string, error) {
+// selectOptimizationStrategy selects the best GPU sharing strategy for a pod.
+// An explicit annotation wins, then the highest-priority matching GPUSharingPolicy,
+// then the built-in heuristics. The matching policy, if any, is returned with the strategy.
+func (w *GPUOptimizationWebhook) selectOptimizationStrategy(ctx context.Context, pod *corev1.Pod) (string, *v1alpha1.GPUSharingPolicy, error) {
 	log := log.FromContext(ctx)
 
 	// Check for explicit strategy in annotations
 	if strategy, ok := pod.Annotations["gpu-autoscaler.io/sharing-mode"]; ok {
 		log.Info("Using explicit sharing mode from annotation", "strategy", strategy)
-		return strategy, nil
+		return strategy, nil, nil
+	}
+
+	policy, err := w.findSharingPolicy(ctx, pod)
+	if err != nil {
+		return "", nil, err
+	}
+	if policy != nil && policy.Spec.Strategy != StrategyAuto {
+		log.Info("Using strategy from GPUSharingPolicy", "policy", policy.Name, "strategy", policy.Spec.Strategy)
+		return policy.Spec.Strategy, policy, nil
+	}
+
+	return w.selectHeuristicStrategy(ctx, pod), policy, nil
+}
+
+// findSharingPolicy returns the highest-priority GPUSharingPolicy matching the pod, or nil
+func (w *GPUOptimizationWebhook) findSharingPolicy(ctx context.Context, pod *corev1.Pod) (*v1alpha1.GPUSharingPolicy, error) {
+	policies := &v1alpha1.GPUSharingPolicyList{}
+	if err := w.client.List(ctx, policies); err != nil {
+		return nil, fmt.Errorf("failed to list GPU sharing policies: %w", err)
+	}
+	if len(policies.Items) == 0 {
+		return nil, nil
+	}
+
+	// Highest priority first, ties broken by name so the choice is stable
+	sort.Slice(policies.Items, func(i, j int) bool {
+		if policies.Items[i].Spec.Priority != policies.Items[j].Spec.Priority {
+			return policies.Items[i].Spec.Priority > policies.Items[j].Spec.Priority
+		}
+		return policies.Items[i].Name < policies.Items[j].Name
+	})
+
+	var namespaceLabels labels.Set
+	for i := range policies.Items {
+		policy := &policies.Items[i]
+
+		if policy.Spec.NamespaceSelector != nil && namespaceLabels == nil {
+			namespace := &corev1.Namespace{}
+			if err := w.client.Get(ctx, client.ObjectKey{Name: pod.Namespace}, namespace); err != nil {
+				return nil, fmt.Errorf("failed to get namespace %s: %w", pod.Namespace, err)
+			}
+			namespaceLabels = labels.Set(namespace.Labels)
+		}
+
+		matches, err := sharingPolicyMatches(policy, pod, namespaceLabels)
+		if err != nil {
+			log.FromContext(ctx).Error(err, "Skipping GPU sharing policy with invalid selector", "policy", policy.Name)
+			continue
+		}
+		if matches {
+			return policy, nil
+		}
+	}
+
+	return nil, nil
+}
+
+// sharingPolicyMatches reports whether a policy's selectors match the pod. A pod matches the
+// NodeSelector unless its own nodeSelector pins a key to a different value.
+func sharingPolicyMatches(policy *v1alpha1.GPUSharingPolicy, pod *corev1.Pod, namespaceLabels labels.Set) (bool, error) {
+	if policy.Spec.NamespaceSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.Spec.NamespaceSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid namespaceSelector: %w", err)
+		}
+		if !selector.Matches(namespaceLabels) {
+			return false, nil
+		}
 	}
 
+	if policy.Spec.PodSelector != nil {
+		selector, err := metav1.LabelSelectorAsSelector(policy.Spec.PodSelector)
+		if err != nil {
+			return false, fmt.Errorf("invalid podSelector: %w", err)
+		}
+		if !selector.Matches(labels.Set(pod.Labels)) {
+			return false, nil
+		}
+	}
+
+	for key, value := range policy.Spec.NodeSelector {
+		if podValue, ok := pod.Spec.NodeSelector[key]; ok && podValue != value {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+// selectHeuristicStrategy picks a strategy from workload characteristics when no policy decides
+func (w *GPUOptimizationWebhook) selectHeuristicStrategy(ctx context.Context, pod *corev1.Pod) string {
+	log := log.FromContext(ctx)
+
 	// Extract workload characteristics
 	gpuRequest := getTotalGPURequest(pod)
 	memoryRequest := getTotalMemoryRequest(pod)
@@ -160,52 +286,62 @@ func (w *GPUOptimizationWebhook) selectOptimizationStrategy(ctx context.Context,
 
 	// Check for training workloads - typically need exclusive access
 	if workloadType == "training" {
-		return "exclusive", nil
+		return "exclusive"
 	}
 
 	// Small workloads are good candidates for MIG
 	if w.enableMIG && gpuRequest == 1 && memoryRequest < 20*1024*1024*1024 {
-		return "mig", nil
+		return "mig"
 	}
 
 	// Inference workloads benefit from MPS
 	if w.enableMPS && workloadType == "inference" {
-		return "mps", nil
+		return "mps"
 	}
 
 	// Development and batch workloads work well with time-slicing
 	if w.enableTS && (workloadType == "development" || workloadType == "batch") {
-		return "timeslicing", nil
+		return "timeslicing"
 	}
 
 	// Check if sharing is explicitly requested
 	if pod.Annotations["gpu-autoscaler.io/sharing"] == "enabled" {
 		// Default to MPS for inference-like workloads
 		if w.enableMPS {
-			return "mps", nil
+			return "mps"
 		}
 		// Fall back to time-slicing
 		if w.enableTS {
-			return "timeslicing", nil
+			return "timeslicing"
 		}
 	}
 
 	// Default to exclusive access
-	return "exclusive", nil
+	return "exclusive"
 }
 
 // applyMIGOptimization applies MIG-based optimization to a pod
-func (w *GPUOptimizationWebhook) applyMIGOptimization(ctx context.Context, pod *corev1.Pod) error {
+func (w *GPUOptimizationWebhook) applyMIGOptimization(ctx context.Context, pod *corev1.Pod, policy *v1alpha1.GPUSharingPolicy) error {
 	log := log.FromContext(ctx)
 	log.Info("Applying MIG optimization", "pod", pod.Name)
 
 	gpuRequest := getTotalGPURequest(pod)
 	memoryRequest := getTotalMemoryRequest(pod)
 
-	// Select appropriate MIG profile
-	profile, err := w.migMgr.GetMIGProfile(gpuRequest, memoryRequest)
-	if err != nil {
-		return fmt.Errorf("failed to get MIG profile: %w", err)
+	// Use the policy's profile when it names one, otherwise select one for the workload
+	var profile *sharing.MIGProfile
+	if policy != nil && policy.Spec.MIGConfig != nil && policy.Spec.MIGConfig.Profile != "" {
+		fixed, ok := sharing.GetProfileByName(policy.Spec.MIGConfig.Profile)
+		if !ok {
+			return fmt.Errorf("policy %s uses unsupported MIG profile %q", policy.Name, policy.Spec.MIGConfig.Profile)
+		}
+		profile = &fixed
+	} else {
+		selected, err := w.migMgr.GetMIGProfile(gpuRequest, memoryRequest)
+		if err != nil {
+			return fmt.Errorf("failed to get MIG profile: %w", err)
+		}
+		profile = selected
 	}
 
 	log.Info("Selected MIG profile",
@@ -219,22 +355,28 @@ func (w *GPUOptimizationWebhook) applyMIGOptimization(ctx context.Context, pod *
 	}
 
 	// Add optimization metadata
-	if pod.Annotations == nil {
-		pod.Annotations = make(map[string]string)
-	}
-	pod.Annotations["gpu-autoscaler.io/optimized"] = "true"
-	pod.Annotations["gpu-autoscaler.io/optimization-strategy"] = "mig"
-	pod.Annotations["gpu-autoscaler.io/optimization-timestamp"] = ctx.Value("timestamp").(string)
+	annotateOptimization(pod, "mig", policy)
 
 	return nil
 }
 
 // applyMPSOptimization applies MPS-based optimization to a pod
-func (w *GPUOptimizationWebhook) applyMPSOptimization(ctx context.Context, pod *corev1.Pod) error {
+func (w *GPUOptimizationWebhook) applyMPSOptimization(ctx context.Context, pod *corev1.Pod, policy *v1alpha1.GPUSharingPolicy) error {
 	log := log.FromContext(ctx)
 	log.Info("Applying MPS optimization", "pod", pod.Name)
 
 	config := sharing.DefaultMPSConfig()
+	if policy != nil && policy.Spec.MPSConfig != nil {
+		if policy.Spec.MPSConfig.MaxClients > 0 {
+			config.MaxClients = policy.Spec.MPSConfig.MaxClients
+		}
+		if policy.Spec.MPSConfig.DefaultActiveThreads > 0 {
+			config.DefaultActiveThreads = policy.Spec.MPSConfig.DefaultActiveThreads
+		}
+		if policy.Spec.MPSConfig.MemoryLimitMB > 0 {
+			config.MemoryLimit = policy.Spec.MPSConfig.MemoryLimitMB * 1024 * 1024
+		}
+	}
 
 	// Convert pod to use MPS
 	if err := w.mpsMgr.ConvertPodToMPS(ctx, pod, config); err != nil {
@@ -242,22 +384,20 @@ func (w *GPUOptimizationWebhook) applyMPSOptimization(ctx context.Context, pod *
 	}
 
 	// Add optimization metadata
-	if pod.Annotations == nil {
-		pod.Annotations = make(map[string]string)
-	}
-	pod.Annotations["gpu-autoscaler.io/optimized"] = "true"
-	pod.Annotations["gpu-autoscaler.io/optimization-strategy"] = "mps"
-	pod.Annotations["gpu-autoscaler.io/optimization-timestamp"] = ctx.Value("timestamp").(string)
+	annotateOptimization(pod, "mps", policy)
 
 	return nil
 }
 
 // applyTimeSlicingOptimization applies time-slicing optimization to a pod
-func (w *GPUOptimizationWebhook) applyTimeSlicingOptimization(ctx context.Context, pod *corev1.Pod) error {
+func (w *GPUOptimizationWebhook) applyTimeSlicingOptimization(ctx context.Context, pod *corev1.Pod, policy *v1alpha1.GPUSharingPolicy) error {
 	log := log.FromContext(ctx)
 	log.Info("Applying time-slicing optimization", "pod", pod.Name)
 
-	replicasPerGPU := 4 // Default replicas
+	replicasPerGPU := sharing.DefaultTimeSlicingConfig().ReplicasPerGPU
+	if policy != nil && policy.Spec.TimeSlicingConfig != nil && policy.Spec.TimeSlicingConfig.ReplicasPerGPU > 0 {
+		replicasPerGPU = policy.Spec.TimeSlicingConfig.ReplicasPerGPU
+	}
 
 	// Convert pod to use time-slicing
 	if err := w.tsMgr.ConvertPodToTimeSlicing(ctx, pod, replicasPerGPU); err != nil {
@@ -265,14 +405,31 @@ func (w *GPUOptimizationWebhook) applyTimeSlicingOptimization(ctx context.Contex
 	}
 
 	// Add optimization metadata
+	annotateOptimization(pod, "timeslicing", policy)
+
+	return nil
+}
+
+// annotateOptimization records the applied strategy, and the policy that chose it, on the pod.
+// The policy's NodeSelector is added so the pod lands on nodes configured for the strategy.
+func annotateOptimization(pod *corev1.Pod, strategy string, policy *v1alpha1.GPUSharingPolicy) {
 	if pod.Annotations == nil {
 		pod.Annotations = make(map[string]string)
 	}
 	pod.Annotations["gpu-autoscaler.io/optimized"] = "true"
-	pod.Annotations["gpu-autoscaler.io/optimization-strategy"] = "timeslicing"
-	pod.Annotations["gpu-autoscaler.io/optimization-timestamp"] = ctx.Value("timestamp").(string)
+	pod.Annotations["gpu-autoscaler.io/optimization-strategy"] = strategy
+	pod.Annotations["gpu-autoscaler.io/optimization-timestamp"] = time.Now().UTC().Format(time.RFC3339)
 
-	return nil
+	if policy == nil {
+		return
+	}
+	pod.Annotations[SharingPolicyAnnotation] = policy.Name
+	if len(policy.Spec.NodeSelector) > 0 && pod.Spec.NodeSelector == nil {
+		pod.Spec.NodeSelector = make(map[string]string)
+	}
+	for key, value := range policy.Spec.NodeSelector {
+		pod.Spec.NodeSelector[key] = value
+	}
 }
 
 // InjectDecoder injects the decoder
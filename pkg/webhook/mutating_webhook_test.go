@@ -0,0 +1,221 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+// patchedAnnotations collects the pod annotations set by a patch response
+func patchedAnnotations(resp admission.Response) map[string]string {
+	annotations := map[string]string{}
+	for _, patch := range resp.Patches {
+		switch {
+		case patch.Path == "/metadata/annotations":
+			if values, ok := patch.Value.(map[string]interface{}); ok {
+				for key, value := range values {
+					annotations[key], _ = value.(string)
+				}
+			}
+		case strings.HasPrefix(patch.Path, "/metadata/annotations/"):
+			key := strings.TrimPrefix(patch.Path, "/metadata/annotations/")
+			key = strings.ReplaceAll(strings.ReplaceAll(key, "~1", "/"), "~0", "~")
+			annotations[key], _ = patch.Value.(string)
+		}
+	}
+	return annotations
+}
+
+func TestGPUOptimizationWebhookSharingPolicies(t *testing.T) {
+	newPolicy := func(name, strategy string, priority int32, podSelector *metav1.LabelSelector) *v1alpha1.GPUSharingPolicy {
+		return &v1alpha1.GPUSharingPolicy{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: v1alpha1.GPUSharingPolicySpec{
+				Strategy:    strategy,
+				Priority:    priority,
+				PodSelector: podSelector,
+			},
+		}
+	}
+	inferenceSelector := &metav1.LabelSelector{MatchLabels: map[string]string{"gpu-autoscaler.io/workload-type": "inference"}}
+
+	mpsPolicy := newPolicy("inference-mps", "mps", 10, inferenceSelector)
+	mpsPolicy.Spec.MPSConfig = &v1alpha1.MPSConfig{MaxClients: 8, DefaultActiveThreads: 50}
+
+	timeSlicingPolicy := newPolicy("dev-timeslicing", "timeslicing", 20, inferenceSelector)
+	timeSlicingPolicy.Spec.TimeSlicingConfig = &v1alpha1.TimeSlicingConfig{ReplicasPerGPU: 8}
+
+	namespacedPolicy := newPolicy("research-mig", "mig", 100, nil)
+	namespacedPolicy.Spec.NamespaceSelector = &metav1.LabelSelector{MatchLabels: map[string]string{"team": "research"}}
+	namespacedPolicy.Spec.MIGConfig = &v1alpha1.MIGConfig{Profile: "3g.20gb"}
+
+	exclusivePolicy := newPolicy("training-exclusive", "exclusive", 0, nil)
+
+	tests := []struct {
+		name              string
+		policies          []client.Object
+		namespaceLabels   map[string]string
+		podLabels         map[string]string
+		expectStrategy    string
+		expectPolicy      string
+		expectAnnotations map[string]string
+	}{
+		{
+			name:           "Matching policy overrides heuristics",
+			policies:       []client.Object{mpsPolicy},
+			podLabels:      map[string]string{"gpu-autoscaler.io/workload-type": "inference"},
+			expectStrategy: "mps",
+			expectPolicy:   "inference-mps",
+			expectAnnotations: map[string]string{
+				"nvidia.com/mps.active-threads": "50",
+			},
+		},
+		{
+			name:           "Highest priority matching policy wins",
+			policies:       []client.Object{mpsPolicy, timeSlicingPolicy},
+			podLabels:      map[string]string{"gpu-autoscaler.io/workload-type": "inference"},
+			expectStrategy: "timeslicing",
+			expectPolicy:   "dev-timeslicing",
+			expectAnnotations: map[string]string{
+				"nvidia.com/time-slicing": "enabled",
+			},
+		},
+		{
+			name:            "Namespace selector picks the policy's MIG profile",
+			policies:        []client.Object{namespacedPolicy, mpsPolicy},
+			namespaceLabels: map[string]string{"team": "research"},
+			podLabels:       map[string]string{"gpu-autoscaler.io/workload-type": "inference"},
+			expectStrategy:  "mig",
+			expectPolicy:    "research-mig",
+			expectAnnotations: map[string]string{
+				"gpu-autoscaler.io/mig-profile": "3g.20gb",
+			},
+		},
+		{
+			name:            "Non-matching namespace falls back to heuristics",
+			policies:        []client.Object{namespacedPolicy},
+			namespaceLabels: map[string]string{"team": "platform"},
+			podLabels:       map[string]string{"gpu-autoscaler.io/workload-type": "development"},
+			expectStrategy:  "timeslicing",
+		},
+		{
+			name:           "Exclusive policy keeps the pod unchanged",
+			policies:       []client.Object{exclusivePolicy},
+			podLabels:      map[string]string{"gpu-autoscaler.io/workload-type": "development"},
+			expectStrategy: "",
+			expectPolicy:   "training-exclusive",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme := newTestScheme(t)
+			namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: "ml", Labels: tt.namespaceLabels}}
+			k8sClient := fake.NewClientBuilder().
+				WithScheme(scheme).
+				WithObjects(append([]client.Object{namespace}, tt.policies...)...).
+				WithStatusSubresource(&v1alpha1.GPUSharingPolicy{}).
+				Build()
+
+			webhook := NewGPUOptimizationWebhook(k8sClient, true, true, true)
+			if err := webhook.InjectDecoder(admission.NewDecoder(scheme)); err != nil {
+				t.Fatalf("InjectDecoder() error = %v", err)
+			}
+
+			pod := newGPUPod("ml", tt.podLabels, map[string]string{"owner": "ml-team"})
+			pod.Spec.Containers[0].Resources.Requests[corev1.ResourceMemory] = resource.MustParse("32Gi")
+			pod.Spec.Containers[0].Resources.Limits = corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("1")}
+
+			resp := webhook.Handle(context.Background(), newPodAdmissionRequest(t, pod))
+			if !resp.Allowed {
+				t.Fatalf("Expected pod to be allowed, got %s", resp.Result.Message)
+			}
+
+			annotations := patchedAnnotations(resp)
+			if got := annotations["gpu-autoscaler.io/optimization-strategy"]; got != tt.expectStrategy {
+				t.Errorf("Expected strategy %q, got %q", tt.expectStrategy, got)
+			}
+			if tt.expectStrategy != "" {
+				if got := annotations[SharingPolicyAnnotation]; got != tt.expectPolicy {
+					t.Errorf("Expected policy annotation %q, got %q", tt.expectPolicy, got)
+				}
+			}
+			for key, expected := range tt.expectAnnotations {
+				if got := annotations[key]; got != expected {
+					t.Errorf("Expected annotation %s=%q, got %q", key, expected, got)
+				}
+			}
+
+			// Applied-pod counts reach the status on the next flush
+			webhook.policyCounts.flush(context.Background())
+			for _, obj := range tt.policies {
+				policy := &v1alpha1.GPUSharingPolicy{}
+				if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(obj), policy); err != nil {
+					t.Fatalf("failed to get policy: %v", err)
+				}
+				expectApplied := int32(0)
+				if policy.Name == tt.expectPolicy {
+					expectApplied = 1
+				}
+				if policy.Status.AppliedPods != expectApplied {
+					t.Errorf("Expected policy %s to have %d applied pods, got %d", policy.Name, expectApplied, policy.Status.AppliedPods)
+				}
+			}
+		})
+	}
+}
+
+func TestPolicyCounterFlush(t *testing.T) {
+	scheme := newTestScheme(t)
+	policy := &v1alpha1.GPUSharingPolicy{ObjectMeta: metav1.ObjectMeta{Name: "inference-mps"}}
+	failUpdates := true
+	k8sClient := fake.NewClientBuilder().
+		WithScheme(scheme).
+		WithObjects(policy).
+		WithStatusSubresource(&v1alpha1.GPUSharingPolicy{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			SubResourceUpdate: func(ctx context.Context, c client.Client, subResourceName string, obj client.Object, opts ...client.SubResourceUpdateOption) error {
+				if failUpdates {
+					return errors.New("apiserver unavailable")
+				}
+				return c.SubResource(subResourceName).Update(ctx, obj, opts...)
+			},
+		}).
+		Build()
+
+	counter := newPolicyCounter(k8sClient, time.Minute)
+	counter.add("inference-mps", 1)
+	counter.add("inference-mps", 1)
+	counter.add("deleted-policy", 1)
+
+	// A failed write keeps the count for the next flush, a deleted policy drops it
+	counter.flush(context.Background())
+	if counter.pending["inference-mps"] != 2 || len(counter.pending) != 1 {
+		t.Errorf("Expected the failed count to be kept, got %v", counter.pending)
+	}
+
+	failUpdates = false
+	counter.flush(context.Background())
+	updated := &v1alpha1.GPUSharingPolicy{}
+	if err := k8sClient.Get(context.Background(), client.ObjectKeyFromObject(policy), updated); err != nil {
+		t.Fatalf("failed to get policy: %v", err)
+	}
+	if updated.Status.AppliedPods != 2 || updated.Status.LastUpdateTime == nil {
+		t.Errorf("Expected 2 applied pods, got %+v", updated.Status)
+	}
+	if len(counter.pending) != 0 {
+		t.Errorf("Expected no pending counts, got %v", counter.pending)
+	}
+}
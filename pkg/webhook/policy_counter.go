@@ -0,0 +1,91 @@
+package webhook
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/util/retry"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/apis/v1alpha1"
+)
+
+// DefaultPolicyCountFlushInterval is how often applied-pod counts are written to GPUSharingPolicy status
+const DefaultPolicyCountFlushInterval = 10 * time.Second
+
+// policyCounter batches GPUSharingPolicy AppliedPods increments so admission never waits on a status write
+type policyCounter struct {
+	client   client.Client
+	interval time.Duration
+
+	mu      sync.Mutex
+	pending map[string]int32
+}
+
+// newPolicyCounter creates a counter that flushes every interval once started
+func newPolicyCounter(client client.Client, interval time.Duration) *policyCounter {
+	return &policyCounter{
+		client:   client,
+		interval: interval,
+		pending:  make(map[string]int32),
+	}
+}
+
+// add records pods admitted under the policy
+func (c *policyCounter) add(policyName string, count int32) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.pending[policyName] += count
+}
+
+// Start flushes pending counts until the context is cancelled, then flushes once more
+func (c *policyCounter) Start(ctx context.Context) error {
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			c.flush(ctx)
+		case <-ctx.Done():
+			// Write what was counted since the last tick before the webhook shuts down
+			flushCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+			c.flush(flushCtx)
+			cancel()
+			return nil
+		}
+	}
+}
+
+// NeedLeaderElection lets every webhook replica write the counts for the pods it admitted
+func (c *policyCounter) NeedLeaderElection() bool {
+	return false
+}
+
+// flush adds the pending counts to each policy's status, keeping counts that fail to write for the next flush
+func (c *policyCounter) flush(ctx context.Context) {
+	c.mu.Lock()
+	pending := c.pending
+	c.pending = make(map[string]int32)
+	c.mu.Unlock()
+
+	for policyName, count := range pending {
+		err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			policy := &v1alpha1.GPUSharingPolicy{}
+			if err := c.client.Get(ctx, client.ObjectKey{Name: policyName}, policy); err != nil {
+				return err
+			}
+			now := metav1.Now()
+			policy.Status.AppliedPods += count
+			policy.Status.LastUpdateTime = &now
+			return c.client.Status().Update(ctx, policy)
+		})
+		if client.IgnoreNotFound(err) != nil {
+			log.FromContext(ctx).Error(err, "Failed to update GPU sharing policy status", "policy", policyName)
+			c.add(policyName, count)
+		}
+	}
+}
@@ -9,6 +9,9 @@ import (
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
+	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
+
+	"github.com/gpuautoscaler/gpuautoscaler/pkg/cost"
 )
 
 // SetupWebhookServer configures and starts the webhook server
@@ -21,6 +24,16 @@ func SetupWebhookServer(mgr ctrl.Manager, enableMIG, enableMPS, enableTS bool) e
 		enableTS,
 	)
 
+	// controller-runtime no longer injects decoders, so set it explicitly
+	if err := webhookHandler.InjectDecoder(admission.NewDecoder(mgr.GetScheme())); err != nil {
+		return fmt.Errorf("failed to inject decoder: %w", err)
+	}
+
+	// Write applied-pod counts in the background instead of on each admission request
+	if err := mgr.Add(webhookHandler.policyCounts); err != nil {
+		return fmt.Errorf("failed to add sharing policy counter: %w", err)
+	}
+
 	// Register the webhook with the manager
 	mgr.GetWebhookServer().Register(
 		"/mutate-v1-pod",
@@ -30,6 +43,26 @@ func SetupWebhookServer(mgr ctrl.Manager, enableMIG, enableMPS, enableTS bool) e
 	return nil
 }
 
+// SetupBudgetWebhook registers the validating webhook that enforces CostBudget limits
+func SetupBudgetWebhook(mgr ctrl.Manager, pricingClient *cost.PricingClient) error {
+	budgetHandler := NewBudgetValidationWebhook(
+		mgr.GetClient(),
+		mgr.GetEventRecorderFor("budget-webhook"),
+		pricingClient,
+	)
+
+	if err := budgetHandler.InjectDecoder(admission.NewDecoder(mgr.GetScheme())); err != nil {
+		return fmt.Errorf("failed to inject decoder: %w", err)
+	}
+
+	mgr.GetWebhookServer().Register(
+		"/validate-v1-pod-budget",
+		&webhook.Admission{Handler: budgetHandler},
+	)
+
+	return nil
+}
+
 // ValidateWebhookConfiguration validates the webhook configuration
 func ValidateWebhookConfiguration(ctx context.Context, client client.Client) error {
 	// Check if webhook service exists